@@ -0,0 +1,162 @@
+// Package calendar format dates in calendar systems of users.
+// IDs of calendars are the seeded rows of calendar_systems table.
+package calendar
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// IDs of calendar systems, seeded in calendar_systems table
+const (
+	Jalali    uint = 1
+	Gregorian uint = 2
+)
+
+// DefaultDateLayout is used when user has no date format
+const DefaultDateLayout = "2006-01-02"
+
+// DefaultTimeLayout is used when user has no time format
+const DefaultTimeLayout = "15:04"
+
+var jalaliMonths = []string{
+	"Farvardin", "Ordibehesht", "Khordad", "Tir", "Mordad", "Shahrivar",
+	"Mehr", "Aban", "Azar", "Dey", "Bahman", "Esfand",
+}
+
+// Date is a day in a calendar system
+type Date struct {
+	Year  int
+	Month int
+	Day   int
+}
+
+// ToJalali convert a gregorian date to jalali (solar hijri) calendar
+func ToJalali(t time.Time) Date {
+	gy, gm, gd := t.Date()
+	jdn := gregorianToJDN(gy, int(gm), gd)
+
+	jy := gy - 621
+	leap, march := jalaliYear(jy)
+	k := jdn - gregorianToJDN(gy, 3, march)
+	if k >= 0 {
+		if k <= 185 {
+			return Date{Year: jy, Month: 1 + k/31, Day: k%31 + 1}
+		}
+		k -= 186
+	} else {
+		jy--
+		k += 179
+		if leap == 1 {
+			k++
+		}
+	}
+
+	return Date{Year: jy, Month: 7 + k/30, Day: k%30 + 1}
+}
+
+// breaks are the jalali years which start a new leap cycle
+var breaks = []int{-61, 9, 38, 199, 426, 686, 756, 818, 1111, 1181, 1210,
+	1635, 2060, 2097, 2192, 2262, 2324, 2394, 2456, 3178}
+
+// jalaliYear return the number of years since the last leap year (0 for leap years)
+// of the jalali year jy and the day in March of gregorian year jy+621 which Farvardin 1st falls on
+func jalaliYear(jy int) (leap int, march int) {
+	gy := jy + 621
+	leapJ := -14
+	jp := breaks[0]
+	jump := 0
+	for i := 1; i < len(breaks); i++ {
+		jm := breaks[i]
+		jump = jm - jp
+		if jy < jm {
+			break
+		}
+		leapJ += jump/33*8 + (jump%33)/4
+		jp = jm
+	}
+
+	n := jy - jp
+	leapJ += n/33*8 + (n%33+3)/4
+	if jump%33 == 4 && jump-n == 4 {
+		leapJ++
+	}
+	leapG := gy/4 - (gy/100+1)*3/4 - 150
+	march = 20 + leapJ - leapG
+
+	if jump-n < 6 {
+		n = n - jump + (jump+4)/33*33
+	}
+	leap = ((n+1)%33 - 1) % 4
+	if leap == -1 {
+		leap = 4
+	}
+
+	return
+}
+
+// gregorianToJDN return the julian day number of a gregorian date
+func gregorianToJDN(gy, gm, gd int) int {
+	d := (gy+(gm-8)/6+100100)*1461/4 + (153*((gm+9)%12)+2)/5 + gd - 34840408
+
+	return d - (gy+100100+(gm-8)/6)/100*3/4 + 752
+}
+
+// FormatDate format date part of t in the calendar using a go layout like 2006-01-02.
+// Year, month and day elements are converted, other elements are kept as go formats them.
+func FormatDate(t time.Time, calendarSystemID uint, layout string) string {
+	if layout == "" {
+		layout = DefaultDateLayout
+	}
+	if calendarSystemID != Jalali {
+		return t.Format(layout)
+	}
+
+	d := ToJalali(t)
+	elements := []struct {
+		layout string
+		value  string
+	}{
+		// Longer elements first, "2006" contains "2" and "January" contains "Jan"
+		{"2006", fmt.Sprintf("%04d", d.Year)},
+		{"January", jalaliMonths[d.Month-1]},
+		{"Jan", jalaliMonths[d.Month-1][:3]},
+		{"Monday", t.Weekday().String()},
+		{"Mon", t.Weekday().String()[:3]},
+		{"01", fmt.Sprintf("%02d", d.Month)},
+		{"02", fmt.Sprintf("%02d", d.Day)},
+		{"06", fmt.Sprintf("%02d", d.Year%100)},
+		{"_2", fmt.Sprintf("%2d", d.Day)},
+		{"1", fmt.Sprint(d.Month)},
+		{"2", fmt.Sprint(d.Day)},
+	}
+
+	var out []string
+	for i := 0; i < len(layout); {
+		matched := false
+		for _, element := range elements {
+			if strings.HasPrefix(layout[i:], element.layout) {
+				out = append(out, element.value)
+				i += len(element.layout)
+				matched = true
+				break
+			}
+		}
+		if matched == false {
+			out = append(out, layout[i:i+1])
+			i++
+		}
+	}
+
+	return strings.Join(out, "")
+}
+
+// FormatDateTime format t in the calendar, date and time layouts are joined by a space
+func FormatDateTime(t time.Time, calendarSystemID uint, dateLayout, timeLayout string) string {
+	if timeLayout == "" {
+		timeLayout = DefaultTimeLayout
+	}
+
+	return FormatDate(t, calendarSystemID, dateLayout) + " " + t.Format(timeLayout)
+}
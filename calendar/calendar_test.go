@@ -0,0 +1,49 @@
+package calendar
+
+import (
+	"testing"
+	"time"
+)
+
+func date(y int, m time.Month, d int) time.Time {
+	return time.Date(y, m, d, 10, 30, 0, 0, time.UTC)
+}
+
+func TestToJalali(t *testing.T) {
+	cases := map[time.Time]Date{
+		date(2018, time.June, 18):      {1397, 3, 28},
+		date(2018, time.March, 21):     {1397, 1, 1},
+		date(2018, time.March, 20):     {1396, 12, 29},
+		date(2017, time.March, 20):     {1395, 12, 30},
+		date(2016, time.March, 20):     {1395, 1, 1},
+		date(2018, time.September, 22): {1397, 6, 31},
+		date(2018, time.September, 23): {1397, 7, 1},
+		date(1979, time.February, 11):  {1357, 11, 22},
+	}
+
+	for gregorian, expected := range cases {
+		if d := ToJalali(gregorian); d != expected {
+			t.Fatalf("%s must be %v, got %v", gregorian.Format("2006-01-02"), expected, d)
+		}
+	}
+}
+
+func TestFormatDate(t *testing.T) {
+	d := date(2018, time.June, 18)
+
+	if s := FormatDate(d, Gregorian, "2006/01/02"); s != "2018/06/18" {
+		t.Fatal("Unexpected gregorian date", s)
+	}
+	if s := FormatDate(d, Jalali, "2006/01/02"); s != "1397/03/28" {
+		t.Fatal("Unexpected jalali date", s)
+	}
+	if s := FormatDate(d, Jalali, "Mon 2 January 2006"); s != "Mon 28 Khordad 1397" {
+		t.Fatal("Unexpected jalali date", s)
+	}
+	if s := FormatDate(d, Jalali, ""); s != "1397-03-28" {
+		t.Fatal("Default layout must be used", s)
+	}
+	if s := FormatDateTime(d, Jalali, "2006/01/02", "15:04:05"); s != "1397/03/28 10:30:00" {
+		t.Fatal("Unexpected date time", s)
+	}
+}
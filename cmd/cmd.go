@@ -5,8 +5,10 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	"devin/cmd/helpers"
+	"devin/crypto"
 )
 
 func main() {
@@ -47,6 +49,71 @@ func main() {
 			}
 			helpers.MakeMigration(&name)
 		}
+	case "reminders:run":
+		{
+			set := flag.NewFlagSet("reminders:run", flag.ExitOnError)
+			once := set.Bool("once", false, "Deliver due reminders and exit")
+			interval := set.Duration("interval", time.Minute, "Time between runs of the scheduler")
+			set.Parse(os.Args[2:])
+
+			helpers.RunReminders(*once, *interval)
+		}
+	case "mail:run":
+		{
+			set := flag.NewFlagSet("mail:run", flag.ExitOnError)
+			once := set.Bool("once", false, "Send pending emails and exit")
+			interval := set.Duration("interval", 30*time.Second, "Time between runs of the worker")
+			set.Parse(os.Args[2:])
+
+			helpers.RunMailWorker(*once, *interval)
+		}
+	case "notifications:run":
+		{
+			set := flag.NewFlagSet("notifications:run", flag.ExitOnError)
+			once := set.Bool("once", false, "Send due notifications and digests and exit")
+			interval := set.Duration("interval", time.Minute, "Time between runs of the worker")
+			set.Parse(os.Args[2:])
+
+			helpers.RunNotificationsWorker(*once, *interval)
+		}
+	case "keys:generate":
+		{
+			set := flag.NewFlagSet("keys:generate", flag.ExitOnError)
+			dir := set.String("dir", crypto.KeysDir(), "Directory of keys")
+			set.Parse(os.Args[2:])
+
+			helpers.GenerateKeys(*dir)
+		}
+	case "keys:rotate":
+		{
+			set := flag.NewFlagSet("keys:rotate", flag.ExitOnError)
+			dir := set.String("dir", crypto.KeysDir(), "Directory of keys")
+			jwt := set.Bool("jwt", true, "Rotate JWT signing key")
+			aes := set.Bool("aes", false, "Rotate AES encryption key")
+			set.Parse(os.Args[2:])
+
+			helpers.RotateKeys(*dir, *jwt, *aes)
+		}
+	case "keys:import":
+		{
+			set := flag.NewFlagSet("keys:import", flag.ExitOnError)
+			dir := set.String("dir", crypto.KeysDir(), "Directory of keys")
+			id := set.String("id", crypto.LegacyKeyID, "Id of imported keys")
+			jwtFile := set.String("jwt", "", "PEM file of RSA private key")
+			aesKey := set.String("aes", "", "Hex encoded AES-256 key")
+			set.Parse(os.Args[2:])
+
+			helpers.ImportKeys(*dir, *id, *jwtFile, *aesKey)
+		}
+	case "keys:prune":
+		{
+			set := flag.NewFlagSet("keys:prune", flag.ExitOnError)
+			dir := set.String("dir", crypto.KeysDir(), "Directory of keys")
+			olderThan := set.Duration("older-than", 24*time.Hour, "Remove JWT keys retired before this duration")
+			set.Parse(os.Args[2:])
+
+			helpers.PruneKeys(*dir, *olderThan)
+		}
 	default:
 		{
 			fmt.Println("Command not found :( ")
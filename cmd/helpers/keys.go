@@ -0,0 +1,95 @@
+package helpers
+
+import (
+	"io/ioutil"
+	"os"
+	"time"
+
+	"devin/crypto"
+)
+
+// GenerateKeys create JWT and AES keys in the directory when it has no current key
+func GenerateKeys(dir string) {
+	k, e := crypto.ReadKeyring(dir)
+	if e != nil {
+		Printer{}.Error(e.Error())
+		os.Exit(1)
+	}
+
+	if k.JWTKeyID == "" {
+		rotateJWTKey(dir)
+	} else {
+		Printer{}.Info("JWT key exists: ", k.JWTKeyID)
+	}
+
+	if k.AESKeyID == "" {
+		rotateAESKey(dir)
+	} else {
+		Printer{}.Info("AES key exists: ", k.AESKeyID)
+	}
+}
+
+// RotateKeys create new current keys in the directory. Retired JWT keys still verify issued
+// tokens and old AES keys still decrypt, so nobody is logged out.
+// Running servers load new keys on SIGHUP or restart.
+func RotateKeys(dir string, jwt, aes bool) {
+	if jwt {
+		rotateJWTKey(dir)
+	}
+	if aes {
+		rotateAESKey(dir)
+	}
+	Printer{}.Warning("Send SIGHUP to running servers or restart them to use new keys")
+}
+
+// ImportKeys save existing keys, e.g keys of builds with compiled keys, as current keys of the directory
+func ImportKeys(dir, id, jwtFile, aesKey string) {
+	if jwtFile != "" {
+		bts, e := ioutil.ReadFile(jwtFile)
+		if e == nil {
+			e = crypto.ImportJWTKey(dir, id, bts)
+		}
+		if e != nil {
+			Printer{}.Error(e.Error())
+			os.Exit(1)
+		}
+		Printer{}.Success("JWT key imported: ", id)
+	}
+
+	if aesKey != "" {
+		e := crypto.ImportAESKey(dir, id, aesKey)
+		if e != nil {
+			Printer{}.Error(e.Error())
+			os.Exit(1)
+		}
+		Printer{}.Success("AES key imported: ", id)
+	}
+}
+
+// PruneKeys remove JWT public keys retired before the duration
+func PruneKeys(dir string, olderThan time.Duration) {
+	removed, e := crypto.PruneJWTKeys(dir, olderThan)
+	if e != nil {
+		Printer{}.Error(e.Error())
+		os.Exit(1)
+	}
+	Printer{}.Success(len(removed), " retired JWT keys removed ", removed)
+}
+
+func rotateJWTKey(dir string) {
+	kid, e := crypto.GenerateJWTKey(dir)
+	if e != nil {
+		Printer{}.Error(e.Error())
+		os.Exit(1)
+	}
+	Printer{}.Success("New JWT key: ", kid)
+}
+
+func rotateAESKey(dir string) {
+	id, e := crypto.GenerateAESKey(dir)
+	if e != nil {
+		Printer{}.Error(e.Error())
+		os.Exit(1)
+	}
+	Printer{}.Success("New AES key: ", id)
+}
@@ -0,0 +1,37 @@
+package helpers
+
+import (
+	"os"
+	"time"
+
+	"devin/mailer"
+	"devin/mailer/outbox"
+)
+
+// RunMailWorker send queued emails of the outbox using the transport configured by MAIL_* variables.
+// If once is true, pending emails are sent and function returns,
+// otherwise worker runs every interval until the process is interrupted.
+func RunMailWorker(once bool, interval time.Duration) {
+	config := mailer.ConfigFromEnv()
+	transport, e := mailer.New(config)
+	if e != nil {
+		Printer{}.Error(e.Error())
+		os.Exit(1)
+	}
+	w := outbox.NewWorker(transport)
+	w.Interval = interval
+
+	if once {
+		sent, e := w.RunOnce(time.Now())
+		if e != nil {
+			Printer{}.Error(e.Error())
+			os.Exit(1)
+		}
+		Printer{}.Success(sent, " emails sent")
+		return
+	}
+
+	Printer{}.Info("Mail worker started, driver: ", config.Driver, ", interval: ", interval)
+	w.Run(interrupted())
+	Printer{}.Info("Mail worker stopped")
+}
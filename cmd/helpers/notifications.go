@@ -0,0 +1,31 @@
+package helpers
+
+import (
+	"os"
+	"time"
+
+	"devin/mailer"
+	"devin/modules/notification/worker"
+)
+
+// RunNotificationsWorker send queued email and webhook notifications and daily digests.
+// If once is true, due notifications are sent and function returns,
+// otherwise worker runs every interval until the process is interrupted.
+func RunNotificationsWorker(once bool, interval time.Duration) {
+	w := worker.New(mailer.ConfigFromEnv())
+	w.Interval = interval
+
+	if once {
+		sent, e := w.RunOnce(time.Now())
+		if e != nil {
+			Printer{}.Error(e.Error())
+			os.Exit(1)
+		}
+		Printer{}.Success(sent, " notifications sent")
+		return
+	}
+
+	Printer{}.Info("Notifications worker started, interval: ", interval)
+	w.Run(interrupted())
+	Printer{}.Info("Notifications worker stopped")
+}
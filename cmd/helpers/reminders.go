@@ -0,0 +1,64 @@
+package helpers
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"devin/database"
+	"devin/models"
+	"devin/modules/notification/notify"
+	"devin/modules/reminder/notifier"
+	"devin/modules/reminder/scheduler"
+)
+
+// NewReminderNotifier create the notifier used by reminders scheduler.
+// In-app reminders are delivered through notification preferences of receivers,
+// other notification types without a registered notifier are written to the log.
+func NewReminderNotifier() *notifier.Dispatcher {
+	d := notifier.NewDispatcher(notifier.LogNotifier{})
+	d.Register(notifier.TypeInApp, notifier.NotifierFunc(func(reminder models.TaskReminder, receiver models.TaskReminderReceiver) error {
+		db := database.NewGORMInstance()
+		defer db.Close()
+
+		return notify.Reminder(db, reminder, receiver)
+	}))
+
+	return d
+}
+
+// RunReminders deliver due task reminders.
+// If once is true, due reminders are delivered and function returns,
+// otherwise scheduler runs every interval until the process is interrupted.
+func RunReminders(once bool, interval time.Duration) {
+	s := scheduler.New(NewReminderNotifier())
+	s.Interval = interval
+
+	if once {
+		processed, e := s.RunOnce(time.Now())
+		if e != nil {
+			Printer{}.Error(e.Error())
+			os.Exit(1)
+		}
+		Printer{}.Success(processed, " reminders processed")
+		return
+	}
+
+	Printer{}.Info("Reminders scheduler started, interval: ", interval)
+	s.Run(interrupted())
+	Printer{}.Info("Reminders scheduler stopped")
+}
+
+// interrupted return a channel which is closed when the process receives SIGINT or SIGTERM
+func interrupted() <-chan struct{} {
+	stop := make(chan struct{})
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-signals
+		close(stop)
+	}()
+
+	return stop
+}
@@ -0,0 +1,137 @@
+package crypto
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// Purposes of encrypted data. The purpose is authenticated with the ciphertext,
+// so data encrypted for one purpose can not be decrypted as another.
+const (
+	PurposeClaimPayload  = "jwt.payload"
+	PurposeWebhookSecret = "webhook.secret"
+	PurposeTOTPSecret    = "totp.secret"
+)
+
+// versionGCM is the prefix of AES-256-GCM ciphertexts: v1.<key id>.<base64url(nonce|ciphertext|tag)>
+const versionGCM = "v1"
+
+// ErrDecrypt returned for tampered, truncated or unknown ciphertexts, details are hidden on purpose
+var ErrDecrypt = errors.New("Fail to decrypt")
+
+// Encrypt encrypt and authenticate the plaintext for the purpose with the current AES key
+func Encrypt(plaintext, purpose string) (string, error) {
+	k, e := currentKeyring()
+	if e != nil {
+		return "", e
+	}
+	key, e := k.AESKey(k.AESKeyID)
+	if e != nil {
+		return "", ErrKeyNotConfigured
+	}
+
+	aead, e := newGCM(key)
+	if e != nil {
+		return "", e
+	}
+
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, e = io.ReadFull(rand.Reader, nonce); e != nil {
+		return "", e
+	}
+	prefix := versionGCM + "." + k.AESKeyID
+	sealed := aead.Seal(nonce, nonce, []byte(plaintext), additionalData(prefix, purpose))
+
+	return prefix + "." + base64.RawURLEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt decrypt the ciphertext of Encrypt for the purpose.
+// Any change of the ciphertext, its key id or purpose fails with ErrDecrypt.
+func Decrypt(ciphertext, purpose string) (string, error) {
+	parts := strings.SplitN(ciphertext, ".", 3)
+	if len(parts) != 3 || parts[0] != versionGCM {
+		return "", ErrDecrypt
+	}
+
+	k, e := currentKeyring()
+	if e != nil {
+		return "", e
+	}
+	key, e := k.AESKey(parts[1])
+	if e != nil {
+		return "", ErrDecrypt
+	}
+
+	aead, e := newGCM(key)
+	if e != nil {
+		return "", e
+	}
+
+	sealed, e := base64.RawURLEncoding.DecodeString(parts[2])
+	if e != nil || len(sealed) < aead.NonceSize()+aead.Overhead() {
+		return "", ErrDecrypt
+	}
+
+	nonce := sealed[:aead.NonceSize()]
+	plaintext, e := aead.Open(nil, nonce, sealed[aead.NonceSize():], additionalData(parts[0]+"."+parts[1], purpose))
+	if e != nil {
+		return "", ErrDecrypt
+	}
+
+	return string(plaintext), nil
+}
+
+// DecryptWithLegacy decrypt versioned ciphertexts like Decrypt, and unversioned ciphertexts
+// of CBCEncrypter while the legacy transition window is open.
+// It is only meant for payloads of signed tokens issued before the versioned format.
+func DecryptWithLegacy(ciphertext, purpose string) (string, error) {
+	if strings.HasPrefix(ciphertext, versionGCM+".") {
+		return Decrypt(ciphertext, purpose)
+	}
+
+	if IsLegacyCBCAllowed(time.Now()) == false {
+		return "", ErrDecrypt
+	}
+
+	return CBCDecrypter(ciphertext)
+}
+
+// IsLegacyCBCAllowed check the transition window of CBC ciphertexts to be open.
+// The window is opt-in: LEGACY_CBC_UNTIL environment variable (RFC 3339) keeps it open until
+// all tokens with CBC payloads are expired, i.e 13 hours after deployment, the lifetime of
+// tokens without session which User.TokenLifetime had before sessions were added.
+// CBC ciphertexts are rejected when it is not set or not valid, which logs out users of those tokens.
+func IsLegacyCBCAllowed(now time.Time) bool {
+	until := os.Getenv("LEGACY_CBC_UNTIL")
+	if until == "" {
+		return false
+	}
+
+	t, e := time.Parse(time.RFC3339, until)
+	if e != nil {
+		return false
+	}
+
+	return now.Before(t)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, e := aes.NewCipher(key)
+	if e != nil {
+		return nil, e
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// additionalData bind the ciphertext to its version, key id and purpose
+func additionalData(prefix, purpose string) []byte {
+	return []byte(prefix + "|" + purpose)
+}
@@ -0,0 +1,112 @@
+package crypto
+
+import (
+	"encoding/base64"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEncrypt(t *testing.T) {
+	if e := LoadKeys(); e != nil {
+		t.Fatal(e)
+	}
+	input := `{"id":103,"username":"success_token","email":"success_token@gmail.com"}`
+
+	str, e := Encrypt(input, PurposeClaimPayload)
+	if e != nil {
+		t.Fatal(e)
+	}
+	if strings.HasPrefix(str, "v1.") == false || strings.Contains(str, "success_token") {
+		t.Fatal("Invalid ciphertext", str)
+	}
+	if other, _ := Encrypt(input, PurposeClaimPayload); other == str {
+		t.Fatal("Nonce must be random")
+	}
+
+	ret, e := Decrypt(str, PurposeClaimPayload)
+	if e != nil || ret != input {
+		t.Fatal("Decrypted string not match", ret, e)
+	}
+
+	t.Run("Wrong purpose", func(t *testing.T) {
+		if _, e := Decrypt(str, PurposeTOTPSecret); e != ErrDecrypt {
+			t.Fatal("Ciphertext of another purpose must be rejected", e)
+		}
+	})
+
+	t.Run("Tampered", func(t *testing.T) {
+		parts := strings.SplitN(str, ".", 3)
+		sealed, _ := base64.RawURLEncoding.DecodeString(parts[2])
+		sealed[len(sealed)/2] ^= 1
+		tampered := parts[0] + "." + parts[1] + "." + base64.RawURLEncoding.EncodeToString(sealed)
+		if _, e := Decrypt(tampered, PurposeClaimPayload); e != ErrDecrypt {
+			t.Fatal("Tampered ciphertext must be rejected", e)
+		}
+		for _, bad := range []string{"", str[:len(str)-4], "v2" + str[2:], "v1.unknown." + parts[2], str + "6d"} {
+			if _, e := Decrypt(bad, PurposeClaimPayload); e != ErrDecrypt {
+				t.Fatal("Invalid ciphertext must be rejected", bad)
+			}
+		}
+	})
+
+	t.Run("Rotated key", func(t *testing.T) {
+		if _, e := GenerateAESKey(KeysDir()); e != nil {
+			t.Fatal(e)
+		}
+		LoadKeys()
+		if ret, e := Decrypt(str, PurposeClaimPayload); e != nil || ret != input {
+			t.Fatal("Ciphertext of old key must be decrypted", e)
+		}
+	})
+}
+
+func TestDecryptWithLegacy(t *testing.T) {
+	if e := LoadKeys(); e != nil {
+		t.Fatal(e)
+	}
+	input := `{"id":103,"username":"success_token","email":"success"}`
+	legacy, _ := CBCEncrypter(input)
+	str, _ := Encrypt(input, PurposeClaimPayload)
+
+	if _, e := DecryptWithLegacy(legacy, PurposeClaimPayload); e != ErrDecrypt {
+		t.Fatal("CBC ciphertexts must be rejected without a transition window")
+	}
+
+	os.Setenv("LEGACY_CBC_UNTIL", time.Now().Add(time.Minute).Format(time.RFC3339))
+	defer os.Unsetenv("LEGACY_CBC_UNTIL")
+	for _, ciphertext := range []string{legacy, str} {
+		if ret, e := DecryptWithLegacy(ciphertext, PurposeClaimPayload); e != nil || ret != input {
+			t.Fatal("Decrypted string not match", ret, e)
+		}
+	}
+	if _, e := Decrypt(legacy, PurposeClaimPayload); e != ErrDecrypt {
+		t.Fatal("Decrypt must not accept CBC ciphertexts")
+	}
+
+	os.Setenv("LEGACY_CBC_UNTIL", time.Now().Add(-time.Minute).Format(time.RFC3339))
+	if _, e := DecryptWithLegacy(legacy, PurposeClaimPayload); e != ErrDecrypt {
+		t.Fatal("CBC ciphertexts must be rejected after the transition window")
+	}
+	if ret, e := DecryptWithLegacy(str, PurposeClaimPayload); e != nil || ret != input {
+		t.Fatal("Versioned ciphertexts must be accepted", e)
+	}
+}
+
+func TestIsLegacyCBCAllowed(t *testing.T) {
+	now := time.Now()
+	cases := map[string]bool{
+		"":                                       false,
+		now.Add(time.Hour).Format(time.RFC3339):  true,
+		now.Add(-time.Hour).Format(time.RFC3339): false,
+		"tomorrow":                               false,
+	}
+	defer os.Unsetenv("LEGACY_CBC_UNTIL")
+	for until, expected := range cases {
+		os.Setenv("LEGACY_CBC_UNTIL", until)
+		if IsLegacyCBCAllowed(now) != expected {
+			t.Fatal("Unexpected window for", until)
+		}
+	}
+}
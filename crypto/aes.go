@@ -11,16 +11,24 @@ import (
 	"io"
 	"log"
 	"strings"
-
-	"devin/crypto/keys"
 )
 
 func Init() {
 	log.SetFlags(log.Lshortfile)
 }
 
+// CBCEncrypter encrypt the string with the current AES key.
+//
+// Deprecated: ciphertexts are not authenticated, use Encrypt.
 func CBCEncrypter(str string) (string, error) {
-	key, _ := hex.DecodeString(keys.AES_KEY)
+	k, e := currentKeyring()
+	if e != nil {
+		return "", e
+	}
+	key, e := k.AESKey(k.AESKeyID)
+	if e != nil {
+		return "", ErrKeyNotConfigured
+	}
 	plainBytes := []byte(str)
 	b64 := base64.RawStdEncoding.WithPadding('=').EncodeToString(plainBytes)
 	b64Bytes := []byte(b64)
@@ -48,8 +56,29 @@ func CBCEncrypter(str string) (string, error) {
 	return fmt.Sprintf("%x", ciphertext), nil
 }
 
-func CBCDecrypter(encodedString string) (string, error) {
-	key, _ := hex.DecodeString(keys.AES_KEY)
+// CBCDecrypter decrypt the string encrypted by CBCEncrypter.
+// Ciphertexts have no key id, keys are tried from the current one until the plaintext is valid base64.
+//
+// Deprecated: only used for tokens issued before versioned ciphertexts, see DecryptWithLegacy.
+func CBCDecrypter(encodedString string) (str string, e error) {
+	k, e := currentKeyring()
+	if e != nil {
+		return "", e
+	}
+
+	e = ErrKeyNotConfigured
+	for _, id := range k.AESKeyIDs() {
+		key, _ := k.AESKey(id)
+		str, e = cbcDecrypt(key, encodedString)
+		if e == nil {
+			return
+		}
+	}
+
+	return "", e
+}
+
+func cbcDecrypt(key []byte, encodedString string) (string, error) {
 	ciphertext, e := hex.DecodeString(encodedString)
 	if e != nil {
 		return "", e
@@ -2,18 +2,33 @@ package crypto
 
 import (
 	"crypto/rsa"
+	"errors"
 
 	jwt "github.com/dgrijalva/jwt-go"
-
-	"devin/crypto/keys"
 )
 
-// GetJWTSignKey return private key for RSA sign.
-func GetJWTSignKey() (*rsa.PrivateKey, error) {
-	return jwt.ParseRSAPrivateKeyFromPEM([]byte(keys.JWT_RSA_PRIVATE))
+// GetJWTSignKey return the current private key for RSA sign and its id for `kid` header.
+func GetJWTSignKey() (kid string, key *rsa.PrivateKey, e error) {
+	k, e := currentKeyring()
+	if e != nil {
+		return
+	}
+
+	return k.JWTSignKey()
 }
 
-// GetJWTVerifyKey return public key for RSA verification.
-func GetJWTVerifyKey() (*rsa.PublicKey, error) {
-	return jwt.ParseRSAPublicKeyFromPEM([]byte(keys.JWT_RSA_PUBLIC))
+// GetJWTVerifyKey return public key for RSA verification of the token, selected by its `kid` header.
+// It is used as jwt.Keyfunc of token parsers.
+func GetJWTVerifyKey(token *jwt.Token) (interface{}, error) {
+	if _, ok := token.Method.(*jwt.SigningMethodRSA); ok == false {
+		return nil, errors.New("Unexpected signing method")
+	}
+
+	k, e := currentKeyring()
+	if e != nil {
+		return nil, e
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	return k.JWTVerifyKey(kid)
 }
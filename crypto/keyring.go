@@ -0,0 +1,443 @@
+package crypto
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+)
+
+// Keys are loaded at startup from files of KEYS_DIR and the environment:
+//
+//	<KEYS_DIR>/jwt/<kid>.pem      RSA private key, the current one signs tokens
+//	<KEYS_DIR>/jwt/<kid>.pub.pem  public key of a retired key, still verifies issued tokens
+//	<KEYS_DIR>/jwt/current        kid of the signing key
+//	<KEYS_DIR>/aes/<id>.key       hex encoded AES-256 key, old keys still decrypt
+//	<KEYS_DIR>/aes/current        id of the encryption key
+//
+// JWT_KEY_ID and JWT_RSA_PRIVATE (PEM), AES_KEY_ID and AES_KEY (hex) environment variables
+// override the current keys of files.
+
+// LegacyKeyID is the id of keys imported from builds with compiled keys.
+// Tokens without `kid` header are verified with it.
+const LegacyKeyID = "legacy"
+
+// rsaKeyBits is the size of generated JWT keys
+const rsaKeyBits = 4096
+
+// ErrKeyNotConfigured returned when no current key is loaded
+var ErrKeyNotConfigured = errors.New("Key is not configured, run `keys:generate` command")
+
+// ErrUnknownKeyID returned for tokens signed with a key which is not loaded
+var ErrUnknownKeyID = errors.New("Unknown key id")
+
+var validKeyID = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// Keyring is the set of loaded keys
+type Keyring struct {
+	JWTKeyID      string
+	jwtSignKey    *rsa.PrivateKey
+	jwtVerifyKeys map[string]*rsa.PublicKey
+
+	AESKeyID string
+	aesKeys  map[string][]byte
+}
+
+var (
+	keyring   *Keyring
+	keyringMu sync.RWMutex
+)
+
+// KeysDir return directory of keys from KEYS_DIR environment variable
+func KeysDir() string {
+	if dir := os.Getenv("KEYS_DIR"); dir != "" {
+		return dir
+	}
+
+	return "storage/keys"
+}
+
+// LoadKeys read keys of KEYS_DIR and the environment and replace the loaded keys.
+// It is called at startup and when keys are rotated.
+func LoadKeys() error {
+	k, e := ReadKeyring(KeysDir())
+	if e != nil {
+		return e
+	}
+
+	e = k.applyEnv()
+	if e != nil {
+		return e
+	}
+
+	keyringMu.Lock()
+	keyring = k
+	keyringMu.Unlock()
+
+	return nil
+}
+
+// currentKeyring return loaded keys, loading them on first use
+func currentKeyring() (*Keyring, error) {
+	keyringMu.RLock()
+	k := keyring
+	keyringMu.RUnlock()
+	if k != nil {
+		return k, nil
+	}
+
+	e := LoadKeys()
+	if e != nil {
+		return nil, e
+	}
+
+	keyringMu.RLock()
+	defer keyringMu.RUnlock()
+	return keyring, nil
+}
+
+// ReadKeyring read keys of the directory, missing directories result in an empty keyring
+func ReadKeyring(dir string) (*Keyring, error) {
+	k := &Keyring{
+		jwtVerifyKeys: make(map[string]*rsa.PublicKey),
+		aesKeys:       make(map[string][]byte),
+	}
+
+	jwtDir := filepath.Join(dir, "jwt")
+	files, e := filepath.Glob(filepath.Join(jwtDir, "*.pem"))
+	if e != nil {
+		return nil, e
+	}
+	k.JWTKeyID = readCurrent(jwtDir)
+	for _, file := range files {
+		bts, e := ioutil.ReadFile(file)
+		if e != nil {
+			return nil, e
+		}
+
+		name := filepath.Base(file)
+		if strings.HasSuffix(name, ".pub.pem") {
+			publicKey, e := jwt.ParseRSAPublicKeyFromPEM(bts)
+			if e != nil {
+				return nil, fmt.Errorf("%v: %v", file, e)
+			}
+			k.jwtVerifyKeys[strings.TrimSuffix(name, ".pub.pem")] = publicKey
+			continue
+		}
+
+		privateKey, e := jwt.ParseRSAPrivateKeyFromPEM(bts)
+		if e != nil {
+			return nil, fmt.Errorf("%v: %v", file, e)
+		}
+		kid := strings.TrimSuffix(name, ".pem")
+		k.jwtVerifyKeys[kid] = &privateKey.PublicKey
+		if kid == k.JWTKeyID {
+			k.jwtSignKey = privateKey
+		}
+	}
+	if k.jwtSignKey == nil {
+		k.JWTKeyID = ""
+	}
+
+	aesDir := filepath.Join(dir, "aes")
+	files, e = filepath.Glob(filepath.Join(aesDir, "*.key"))
+	if e != nil {
+		return nil, e
+	}
+	k.AESKeyID = readCurrent(aesDir)
+	for _, file := range files {
+		bts, e := ioutil.ReadFile(file)
+		if e != nil {
+			return nil, e
+		}
+		key, e := parseAESKey(string(bts))
+		if e != nil {
+			return nil, fmt.Errorf("%v: %v", file, e)
+		}
+		k.aesKeys[strings.TrimSuffix(filepath.Base(file), ".key")] = key
+	}
+	if _, ok := k.aesKeys[k.AESKeyID]; ok == false {
+		k.AESKeyID = ""
+	}
+
+	return k, nil
+}
+
+// applyEnv set current keys from environment variables
+func (k *Keyring) applyEnv() error {
+	if pemKey := os.Getenv("JWT_RSA_PRIVATE"); pemKey != "" {
+		privateKey, e := jwt.ParseRSAPrivateKeyFromPEM([]byte(pemKey))
+		if e != nil {
+			return fmt.Errorf("JWT_RSA_PRIVATE: %v", e)
+		}
+		kid := os.Getenv("JWT_KEY_ID")
+		if validKeyID.MatchString(kid) == false {
+			return errors.New("JWT_KEY_ID is required with JWT_RSA_PRIVATE")
+		}
+		k.JWTKeyID = kid
+		k.jwtSignKey = privateKey
+		k.jwtVerifyKeys[kid] = &privateKey.PublicKey
+	}
+
+	if hexKey := os.Getenv("AES_KEY"); hexKey != "" {
+		key, e := parseAESKey(hexKey)
+		if e != nil {
+			return fmt.Errorf("AES_KEY: %v", e)
+		}
+		id := os.Getenv("AES_KEY_ID")
+		if validKeyID.MatchString(id) == false {
+			return errors.New("AES_KEY_ID is required with AES_KEY")
+		}
+		k.AESKeyID = id
+		k.aesKeys[id] = key
+	}
+
+	return nil
+}
+
+// JWTSignKey return the current key for RSA sign and its id
+func (k *Keyring) JWTSignKey() (kid string, key *rsa.PrivateKey, e error) {
+	if k.jwtSignKey == nil {
+		return "", nil, ErrKeyNotConfigured
+	}
+
+	return k.JWTKeyID, k.jwtSignKey, nil
+}
+
+// JWTVerifyKey return public key of the kid for RSA verification.
+// Tokens without kid are verified with the legacy key if it exists, otherwise with the current key.
+func (k *Keyring) JWTVerifyKey(kid string) (*rsa.PublicKey, error) {
+	if kid == "" {
+		kid = LegacyKeyID
+		if _, ok := k.jwtVerifyKeys[kid]; ok == false {
+			kid = k.JWTKeyID
+		}
+	}
+
+	key, ok := k.jwtVerifyKeys[kid]
+	if ok == false {
+		return nil, ErrUnknownKeyID
+	}
+
+	return key, nil
+}
+
+// AESKey return the AES key of the id
+func (k *Keyring) AESKey(id string) ([]byte, error) {
+	key, ok := k.aesKeys[id]
+	if ok == false {
+		return nil, ErrUnknownKeyID
+	}
+
+	return key, nil
+}
+
+// AESKeyIDs return ids of AES keys, the current key first
+func (k *Keyring) AESKeyIDs() []string {
+	ids := []string{}
+	for id := range k.aesKeys {
+		if id != k.AESKeyID {
+			ids = append(ids, id)
+		}
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(ids)))
+	if k.AESKeyID != "" {
+		ids = append([]string{k.AESKeyID}, ids...)
+	}
+
+	return ids
+}
+
+// GenerateJWTKey create a new RSA key in the directory and make it the signing key.
+// The previous signing key is retired, only its public key is kept to verify issued tokens.
+func GenerateJWTKey(dir string) (kid string, e error) {
+	privateKey, e := rsa.GenerateKey(rand.Reader, rsaKeyBits)
+	if e != nil {
+		return
+	}
+
+	kid = newKeyID()
+	e = writeJWTKey(dir, kid, privateKey)
+
+	return
+}
+
+// ImportJWTKey save the PEM encoded RSA private key with the kid and make it the signing key
+func ImportJWTKey(dir, kid string, pemKey []byte) error {
+	if validKeyID.MatchString(kid) == false {
+		return errors.New("Invalid key id")
+	}
+
+	privateKey, e := jwt.ParseRSAPrivateKeyFromPEM(pemKey)
+	if e != nil {
+		return e
+	}
+
+	return writeJWTKey(dir, kid, privateKey)
+}
+
+// GenerateAESKey create a new AES-256 key in the directory and make it the encryption key.
+// Previous keys are kept to decrypt existing data.
+func GenerateAESKey(dir string) (id string, e error) {
+	key := make([]byte, 32)
+	_, e = rand.Read(key)
+	if e != nil {
+		return
+	}
+
+	id = newKeyID()
+	e = writeAESKey(dir, id, key)
+
+	return
+}
+
+// ImportAESKey save the hex encoded AES-256 key with the id and make it the encryption key
+func ImportAESKey(dir, id, hexKey string) error {
+	if validKeyID.MatchString(id) == false {
+		return errors.New("Invalid key id")
+	}
+
+	key, e := parseAESKey(hexKey)
+	if e != nil {
+		return e
+	}
+
+	return writeAESKey(dir, id, key)
+}
+
+// PruneJWTKeys remove public keys retired before the duration, tokens signed by them are expired
+func PruneJWTKeys(dir string, olderThan time.Duration) (removed []string, e error) {
+	files, e := filepath.Glob(filepath.Join(dir, "jwt", "*.pub.pem"))
+	if e != nil {
+		return
+	}
+
+	for _, file := range files {
+		info, e := os.Stat(file)
+		if e != nil {
+			return removed, e
+		}
+		if time.Since(info.ModTime()) < olderThan {
+			continue
+		}
+
+		e = os.Remove(file)
+		if e != nil {
+			return removed, e
+		}
+		removed = append(removed, strings.TrimSuffix(filepath.Base(file), ".pub.pem"))
+	}
+
+	return
+}
+
+// writeJWTKey write the private key, retire the current key and make the new key current
+func writeJWTKey(dir, kid string, privateKey *rsa.PrivateKey) error {
+	jwtDir := filepath.Join(dir, "jwt")
+	e := os.MkdirAll(jwtDir, 0700)
+	if e != nil {
+		return e
+	}
+
+	bts := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(privateKey)})
+	e = ioutil.WriteFile(filepath.Join(jwtDir, kid+".pem"), bts, 0600)
+	if e != nil {
+		return e
+	}
+
+	previous := readCurrent(jwtDir)
+	e = writeCurrent(jwtDir, kid)
+	if e != nil || previous == "" || previous == kid {
+		return e
+	}
+
+	return retireJWTKey(jwtDir, previous)
+}
+
+// retireJWTKey replace the private key of the kid with its public key
+func retireJWTKey(jwtDir, kid string) error {
+	file := filepath.Join(jwtDir, kid+".pem")
+	bts, e := ioutil.ReadFile(file)
+	if os.IsNotExist(e) {
+		return nil
+	}
+	if e != nil {
+		return e
+	}
+
+	privateKey, e := jwt.ParseRSAPrivateKeyFromPEM(bts)
+	if e != nil {
+		return e
+	}
+	publicBytes, e := x509.MarshalPKIXPublicKey(&privateKey.PublicKey)
+	if e != nil {
+		return e
+	}
+
+	bts = pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicBytes})
+	e = ioutil.WriteFile(filepath.Join(jwtDir, kid+".pub.pem"), bts, 0644)
+	if e != nil {
+		return e
+	}
+
+	return os.Remove(file)
+}
+
+// writeAESKey write the key and make it current
+func writeAESKey(dir, id string, key []byte) error {
+	aesDir := filepath.Join(dir, "aes")
+	e := os.MkdirAll(aesDir, 0700)
+	if e != nil {
+		return e
+	}
+
+	e = ioutil.WriteFile(filepath.Join(aesDir, id+".key"), []byte(hex.EncodeToString(key)), 0600)
+	if e != nil {
+		return e
+	}
+
+	return writeCurrent(aesDir, id)
+}
+
+func readCurrent(dir string) string {
+	bts, e := ioutil.ReadFile(filepath.Join(dir, "current"))
+	if e != nil {
+		return ""
+	}
+
+	return strings.TrimSpace(string(bts))
+}
+
+func writeCurrent(dir, id string) error {
+	return ioutil.WriteFile(filepath.Join(dir, "current"), []byte(id+"\n"), 0600)
+}
+
+func parseAESKey(hexKey string) ([]byte, error) {
+	key, e := hex.DecodeString(strings.TrimSpace(hexKey))
+	if e != nil {
+		return nil, e
+	}
+	if len(key) != 32 {
+		return nil, errors.New("AES key must be 32 bytes")
+	}
+
+	return key, nil
+}
+
+// newKeyID return a sortable id from current time
+func newKeyID() string {
+	return fmt.Sprintf("%d", time.Now().UnixNano())
+}
@@ -0,0 +1,163 @@
+package crypto
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+)
+
+// TestMain generate keys of tests in a temporary directory
+func TestMain(m *testing.M) {
+	dir, e := ioutil.TempDir("", "devin_keys")
+	if e != nil {
+		panic(e)
+	}
+	if _, e = GenerateJWTKey(dir); e == nil {
+		_, e = GenerateAESKey(dir)
+	}
+	if e != nil {
+		panic(e)
+	}
+	os.Setenv("KEYS_DIR", dir)
+	os.Unsetenv("JWT_RSA_PRIVATE")
+	os.Unsetenv("AES_KEY")
+
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+func signedToken(t *testing.T) string {
+	kid, key, e := GetJWTSignKey()
+	if e != nil {
+		t.Fatal(e)
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS512, jwt.StandardClaims{Issuer: "devin"})
+	token.Header["kid"] = kid
+	str, e := token.SignedString(key)
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	return str
+}
+
+func TestRotateJWTKey(t *testing.T) {
+	if e := LoadKeys(); e != nil {
+		t.Fatal(e)
+	}
+	oldToken := signedToken(t)
+	oldKid, _, _ := GetJWTSignKey()
+
+	newKid, e := GenerateJWTKey(KeysDir())
+	if e != nil {
+		t.Fatal(e)
+	}
+	if e = LoadKeys(); e != nil {
+		t.Fatal(e)
+	}
+	if kid, _, _ := GetJWTSignKey(); kid != newKid || kid == oldKid {
+		t.Fatal("New key must sign tokens", kid)
+	}
+
+	if _, e = os.Stat(filepath.Join(KeysDir(), "jwt", oldKid+".pem")); os.IsNotExist(e) == false {
+		t.Fatal("Private part of retired key must be removed")
+	}
+	if _, e = jwt.Parse(oldToken, GetJWTVerifyKey); e != nil {
+		t.Fatal("Tokens of retired key must be valid", e)
+	}
+	if _, e = jwt.Parse(signedToken(t), GetJWTVerifyKey); e != nil {
+		t.Fatal("Tokens of new key must be valid", e)
+	}
+
+	removed, e := PruneJWTKeys(KeysDir(), time.Hour)
+	if e != nil || len(removed) != 0 {
+		t.Fatal("Recently retired keys must be kept", removed, e)
+	}
+	removed, e = PruneJWTKeys(KeysDir(), 0)
+	if e != nil || len(removed) != 1 || removed[0] != oldKid {
+		t.Fatal("Retired key must be removed", removed, e)
+	}
+	LoadKeys()
+	if _, e = jwt.Parse(oldToken, GetJWTVerifyKey); e == nil {
+		t.Fatal("Tokens of removed key must be rejected")
+	}
+}
+
+func TestRotateAESKey(t *testing.T) {
+	if e := LoadKeys(); e != nil {
+		t.Fatal(e)
+	}
+	input := `{"id":103,"username":"success_token","email":"success_token@gmail.com"}`
+	old, e := CBCEncrypter(input)
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	if _, e = GenerateAESKey(KeysDir()); e != nil {
+		t.Fatal(e)
+	}
+	if e = LoadKeys(); e != nil {
+		t.Fatal(e)
+	}
+
+	if ret, e := CBCDecrypter(old); e != nil || ret != input {
+		t.Fatal("Data of old key must be decrypted", ret, e)
+	}
+	str, _ := CBCEncrypter(input)
+	if ret, e := CBCDecrypter(str); e != nil || ret != input {
+		t.Fatal("Data of new key must be decrypted", ret, e)
+	}
+}
+
+func TestKeysFromEnv(t *testing.T) {
+	k, e := ReadKeyring(KeysDir())
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	os.Setenv("AES_KEY", "000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f")
+	defer os.Unsetenv("AES_KEY")
+	if e = k.applyEnv(); e == nil {
+		t.Fatal("AES_KEY_ID must be required")
+	}
+
+	os.Setenv("AES_KEY_ID", "env")
+	defer os.Unsetenv("AES_KEY_ID")
+	if e = k.applyEnv(); e != nil || k.AESKeyID != "env" {
+		t.Fatal("Environment key must be current", k.AESKeyID, e)
+	}
+	if ids := k.AESKeyIDs(); ids[0] != "env" || len(ids) < 2 {
+		t.Fatal("Keys of files must be kept", ids)
+	}
+}
+
+func TestImportKeys(t *testing.T) {
+	dir, e := ioutil.TempDir("", "devin_keys_import")
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer os.RemoveAll(dir)
+
+	if e = ImportAESKey(dir, "../x", "00"); e == nil {
+		t.Fatal("Invalid key id must be rejected")
+	}
+	if e = ImportAESKey(dir, LegacyKeyID, "00"); e == nil {
+		t.Fatal("Short key must be rejected")
+	}
+	if e = ImportAESKey(dir, LegacyKeyID, "000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f"); e != nil {
+		t.Fatal(e)
+	}
+
+	k, e := ReadKeyring(dir)
+	if e != nil || k.AESKeyID != LegacyKeyID {
+		t.Fatal("Imported key must be current", k, e)
+	}
+	if _, e = k.JWTVerifyKey(""); e != ErrUnknownKeyID {
+		t.Fatal("Missing JWT keys must be reported", e)
+	}
+}
@@ -0,0 +1,33 @@
+package migrations
+
+import "devin/database"
+
+// Migrate the database to a new version
+func (Migration) MigrateAddProjectIdToTasksTable() (e error) {
+	db := database.NewGORMInstance()
+	defer db.Close()
+	e = db.Exec(`ALTER TABLE public.tasks
+    ADD COLUMN IF NOT EXISTS project_id bigint,
+    ALTER COLUMN estimated_time TYPE bigint USING (extract(epoch from estimated_time) * 1000000000)::bigint,
+    ADD CONSTRAINT tasks_project_id_projects_id FOREIGN KEY (project_id)
+        REFERENCES public.projects (id) MATCH SIMPLE
+        ON DELETE CASCADE
+        ON UPDATE CASCADE;
+
+    CREATE INDEX IF NOT EXISTS tasks_project_id_index ON public.tasks (project_id);`).Error
+
+	return
+}
+
+// Rollback the database to previous version
+func (Migration) RollbackAddProjectIdToTasksTable() (e error) {
+	db := database.NewGORMInstance()
+	defer db.Close()
+	e = db.Exec(`DROP INDEX IF EXISTS public.tasks_project_id_index;
+    ALTER TABLE public.tasks
+    DROP CONSTRAINT IF EXISTS tasks_project_id_projects_id,
+    DROP COLUMN IF EXISTS project_id,
+    ALTER COLUMN estimated_time TYPE interval USING (estimated_time / 1000000000) * interval '1 second';`).Error
+
+	return
+}
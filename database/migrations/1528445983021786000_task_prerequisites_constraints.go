@@ -0,0 +1,35 @@
+package migrations
+
+import "devin/database"
+
+// Migrate the database to a new version
+func (Migration) MigrateTaskPrerequisitesConstraints() (e error) {
+	db := database.NewGORMInstance()
+	defer db.Close()
+	e = db.Exec(`ALTER TABLE public.task_prerequisites
+    ADD CONSTRAINT task_prerequisites_task_id_tasks_id FOREIGN KEY (task_id)
+        REFERENCES public.tasks (id) MATCH SIMPLE
+        ON DELETE CASCADE
+        ON UPDATE CASCADE,
+    ADD CONSTRAINT task_prerequisites_prerequisite_id_tasks_id FOREIGN KEY (prerequisite_id)
+        REFERENCES public.tasks (id) MATCH SIMPLE
+        ON DELETE CASCADE
+        ON UPDATE CASCADE,
+    ADD CONSTRAINT task_prerequisites_task_id_prerequisite_id_unique UNIQUE (task_id, prerequisite_id),
+    ADD CONSTRAINT task_prerequisites_not_self CHECK (task_id <> prerequisite_id);`).Error
+
+	return
+}
+
+// Rollback the database to previous version
+func (Migration) RollbackTaskPrerequisitesConstraints() (e error) {
+	db := database.NewGORMInstance()
+	defer db.Close()
+	e = db.Exec(`ALTER TABLE public.task_prerequisites
+    DROP CONSTRAINT IF EXISTS task_prerequisites_task_id_tasks_id,
+    DROP CONSTRAINT IF EXISTS task_prerequisites_prerequisite_id_tasks_id,
+    DROP CONSTRAINT IF EXISTS task_prerequisites_task_id_prerequisite_id_unique,
+    DROP CONSTRAINT IF EXISTS task_prerequisites_not_self;`).Error
+
+	return
+}
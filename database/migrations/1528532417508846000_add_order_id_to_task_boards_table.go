@@ -0,0 +1,25 @@
+package migrations
+
+import "devin/database"
+
+// Migrate the database to a new version
+func (Migration) MigrateAddOrderIdToTaskBoardsTable() (e error) {
+	db := database.NewGORMInstance()
+	defer db.Close()
+	e = db.Exec(`ALTER TABLE public.task_boards
+    ADD COLUMN IF NOT EXISTS order_id integer DEFAULT 1;
+
+    CREATE INDEX IF NOT EXISTS tasks_task_board_id_order_id_index ON public.tasks (task_board_id, order_id);`).Error
+
+	return
+}
+
+// Rollback the database to previous version
+func (Migration) RollbackAddOrderIdToTaskBoardsTable() (e error) {
+	db := database.NewGORMInstance()
+	defer db.Close()
+	e = db.Exec(`DROP INDEX IF EXISTS public.tasks_task_board_id_order_id_index;
+    ALTER TABLE public.task_boards DROP COLUMN IF EXISTS order_id;`).Error
+
+	return
+}
@@ -0,0 +1,26 @@
+package migrations
+
+import "devin/database"
+
+// Migrate the database to a new version
+func (Migration) MigrateAddRunningTimerIndexToTaskSpentTimesTable() (e error) {
+	db := database.NewGORMInstance()
+	defer db.Close()
+	e = db.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS task_spent_times_running_timer_unique
+    ON public.task_spent_times (spent_by_id)
+    WHERE end_date IS NULL AND deleted_at IS NULL;
+
+    CREATE INDEX IF NOT EXISTS task_spent_times_task_id_start_date_index ON public.task_spent_times (task_id, start_date);`).Error
+
+	return
+}
+
+// Rollback the database to previous version
+func (Migration) RollbackAddRunningTimerIndexToTaskSpentTimesTable() (e error) {
+	db := database.NewGORMInstance()
+	defer db.Close()
+	e = db.Exec(`DROP INDEX IF EXISTS public.task_spent_times_running_timer_unique;
+    DROP INDEX IF EXISTS public.task_spent_times_task_id_start_date_index;`).Error
+
+	return
+}
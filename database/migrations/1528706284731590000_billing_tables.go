@@ -0,0 +1,110 @@
+package migrations
+
+import "devin/database"
+
+// Migrate the database to a new version
+func (Migration) MigrateBillingTables() (e error) {
+	db := database.NewGORMInstance()
+	defer db.Close()
+	e = db.Exec(`CREATE TABLE IF NOT EXISTS public.project_hourly_rates (
+    id bigserial NOT NULL,
+    project_id bigint NOT NULL,
+    user_id bigint,
+    hourly_rate bigint NOT NULL DEFAULT 0,
+    currency varchar(3) NOT NULL,
+    created_by_id bigint NOT NULL,
+    created_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP,
+    updated_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP,
+    deleted_at timestamp with time zone,
+
+    CONSTRAINT project_hourly_rates_pkey PRIMARY KEY (id),
+    CONSTRAINT project_hourly_rates_project_id_projects_id FOREIGN KEY (project_id)
+        REFERENCES public.projects (id) MATCH SIMPLE
+        ON DELETE CASCADE
+        ON UPDATE CASCADE,
+    CONSTRAINT project_hourly_rates_user_id_users_id FOREIGN KEY (user_id)
+        REFERENCES public.users (id) MATCH SIMPLE
+        ON DELETE CASCADE
+        ON UPDATE CASCADE,
+    CONSTRAINT project_hourly_rates_created_by_id_users_id FOREIGN KEY (created_by_id)
+        REFERENCES public.users (id) MATCH SIMPLE
+        ON DELETE CASCADE
+        ON UPDATE CASCADE,
+    CONSTRAINT project_hourly_rates_hourly_rate_positive CHECK (hourly_rate >= 0)
+    );
+
+    CREATE UNIQUE INDEX IF NOT EXISTS project_hourly_rates_default_unique
+        ON public.project_hourly_rates (project_id) WHERE user_id IS NULL AND deleted_at IS NULL;
+    CREATE UNIQUE INDEX IF NOT EXISTS project_hourly_rates_user_unique
+        ON public.project_hourly_rates (project_id, user_id) WHERE user_id IS NOT NULL AND deleted_at IS NULL;
+
+    CREATE TABLE IF NOT EXISTS public.invoices (
+    id bigserial NOT NULL,
+    project_id bigint NOT NULL,
+    status_id smallint NOT NULL DEFAULT 1,
+    from_date timestamp with time zone NOT NULL,
+    to_date timestamp with time zone NOT NULL,
+    currency varchar(3) NOT NULL,
+    total_duration bigint NOT NULL DEFAULT 0,
+    total_amount bigint NOT NULL DEFAULT 0,
+    finalized_at timestamp with time zone,
+    finalized_by_id bigint,
+    created_by_id bigint NOT NULL,
+    created_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP,
+    updated_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP,
+    deleted_at timestamp with time zone,
+
+    CONSTRAINT invoices_pkey PRIMARY KEY (id),
+    CONSTRAINT invoices_project_id_projects_id FOREIGN KEY (project_id)
+        REFERENCES public.projects (id) MATCH SIMPLE
+        ON DELETE CASCADE
+        ON UPDATE CASCADE,
+    CONSTRAINT invoices_finalized_by_id_users_id FOREIGN KEY (finalized_by_id)
+        REFERENCES public.users (id) MATCH SIMPLE
+        ON DELETE SET NULL
+        ON UPDATE CASCADE,
+    CONSTRAINT invoices_created_by_id_users_id FOREIGN KEY (created_by_id)
+        REFERENCES public.users (id) MATCH SIMPLE
+        ON DELETE CASCADE
+        ON UPDATE CASCADE
+    );
+
+    CREATE TABLE IF NOT EXISTS public.invoice_items (
+    id bigserial NOT NULL,
+    invoice_id bigint NOT NULL,
+    task_spent_time_id bigint NOT NULL,
+    user_id bigint NOT NULL,
+    task_id bigint NOT NULL,
+    description text,
+    start_date timestamp with time zone NOT NULL,
+    duration bigint NOT NULL,
+    hourly_rate bigint NOT NULL,
+    amount bigint NOT NULL,
+    created_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP,
+    updated_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT invoice_items_pkey PRIMARY KEY (id),
+    CONSTRAINT invoice_items_invoice_id_invoices_id FOREIGN KEY (invoice_id)
+        REFERENCES public.invoices (id) MATCH SIMPLE
+        ON DELETE CASCADE
+        ON UPDATE CASCADE,
+    CONSTRAINT invoice_items_task_spent_time_id_task_spent_times_id FOREIGN KEY (task_spent_time_id)
+        REFERENCES public.task_spent_times (id) MATCH SIMPLE
+        ON DELETE RESTRICT
+        ON UPDATE CASCADE,
+    CONSTRAINT invoice_items_invoice_id_task_spent_time_id_unique UNIQUE (invoice_id, task_spent_time_id)
+    )`).Error
+
+	return
+}
+
+// Rollback the database to previous version
+func (Migration) RollbackBillingTables() (e error) {
+	db := database.NewGORMInstance()
+	defer db.Close()
+	e = db.Exec(`DROP TABLE IF EXISTS public.invoice_items;
+    DROP TABLE IF EXISTS public.invoices;
+    DROP TABLE IF EXISTS public.project_hourly_rates;`).Error
+
+	return
+}
@@ -0,0 +1,69 @@
+package migrations
+
+import "devin/database"
+
+// Migrate the database to a new version
+func (Migration) MigrateAddProjectIdToMilestonesTable() (e error) {
+	db := database.NewGORMInstance()
+	defer db.Close()
+	e = db.Exec(`ALTER TABLE public.milestones
+    ADD COLUMN IF NOT EXISTS project_id bigint,
+    ADD CONSTRAINT milestones_project_id_projects_id FOREIGN KEY (project_id)
+        REFERENCES public.projects (id) MATCH SIMPLE
+        ON DELETE CASCADE
+        ON UPDATE CASCADE;
+    CREATE INDEX IF NOT EXISTS milestones_project_id_index ON public.milestones (project_id);
+
+    ALTER TABLE public.task_lists
+    ADD COLUMN IF NOT EXISTS project_id bigint,
+    ADD CONSTRAINT task_lists_project_id_projects_id FOREIGN KEY (project_id)
+        REFERENCES public.projects (id) MATCH SIMPLE
+        ON DELETE CASCADE
+        ON UPDATE CASCADE;
+    CREATE INDEX IF NOT EXISTS task_lists_project_id_index ON public.task_lists (project_id);
+
+    ALTER TABLE public.tasks
+    ADD COLUMN IF NOT EXISTS task_list_id bigint,
+    ADD CONSTRAINT tasks_task_list_id_task_lists_id FOREIGN KEY (task_list_id)
+        REFERENCES public.task_lists (id) MATCH SIMPLE
+        ON DELETE SET NULL
+        ON UPDATE CASCADE;
+    CREATE INDEX IF NOT EXISTS tasks_task_list_id_index ON public.tasks (task_list_id);
+
+    ALTER TABLE public.milestone_responsible_users
+    ADD CONSTRAINT milestone_responsible_users_milestone_id_user_id_unique UNIQUE (milestone_id, user_id);
+
+    ALTER TABLE public.milestone_followers
+    ADD CONSTRAINT milestone_followers_milestone_id_user_id_unique UNIQUE (milestone_id, user_id);
+
+    ALTER TABLE public.milestone_task_lists
+    ADD CONSTRAINT milestone_task_lists_milestone_id_task_list_id_unique UNIQUE (milestone_id, task_list_id);
+
+    ALTER TABLE public.milestone_comments
+    DROP CONSTRAINT IF EXISTS milestone_comments_reply_to_id_milestones_id,
+    ADD CONSTRAINT milestone_comments_reply_to_id_milestone_comments_id FOREIGN KEY (reply_to_id)
+        REFERENCES public.milestone_comments (id) MATCH SIMPLE
+        ON DELETE SET NULL
+        ON UPDATE CASCADE;`).Error
+
+	return
+}
+
+// Rollback the database to previous version
+func (Migration) RollbackAddProjectIdToMilestonesTable() (e error) {
+	db := database.NewGORMInstance()
+	defer db.Close()
+	e = db.Exec(`ALTER TABLE public.milestone_comments
+    DROP CONSTRAINT IF EXISTS milestone_comments_reply_to_id_milestone_comments_id;
+    ALTER TABLE public.milestone_task_lists
+    DROP CONSTRAINT IF EXISTS milestone_task_lists_milestone_id_task_list_id_unique;
+    ALTER TABLE public.milestone_followers
+    DROP CONSTRAINT IF EXISTS milestone_followers_milestone_id_user_id_unique;
+    ALTER TABLE public.milestone_responsible_users
+    DROP CONSTRAINT IF EXISTS milestone_responsible_users_milestone_id_user_id_unique;
+    ALTER TABLE public.tasks DROP COLUMN IF EXISTS task_list_id;
+    ALTER TABLE public.task_lists DROP COLUMN IF EXISTS project_id;
+    ALTER TABLE public.milestones DROP COLUMN IF EXISTS project_id;`).Error
+
+	return
+}
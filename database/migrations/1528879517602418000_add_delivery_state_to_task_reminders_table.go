@@ -0,0 +1,42 @@
+package migrations
+
+import "devin/database"
+
+// Migrate the database to a new version
+func (Migration) MigrateAddDeliveryStateToTaskRemindersTable() (e error) {
+	db := database.NewGORMInstance()
+	defer db.Close()
+	e = db.Exec(`ALTER TABLE public.task_reminders
+    ADD COLUMN IF NOT EXISTS processed_at timestamp with time zone;
+    CREATE INDEX IF NOT EXISTS task_reminders_due_index
+        ON public.task_reminders (reminde_on) WHERE processed_at IS NULL;
+
+    ALTER TABLE public.task_reminder_receivers
+    ADD COLUMN IF NOT EXISTS delivered_at timestamp with time zone,
+    ADD COLUMN IF NOT EXISTS attempts integer NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS last_error text,
+    ADD CONSTRAINT task_reminder_receivers_reminder_id_task_reminders_id FOREIGN KEY (reminder_id)
+        REFERENCES public.task_reminders (id) MATCH SIMPLE
+        ON DELETE CASCADE
+        ON UPDATE CASCADE,
+    ADD CONSTRAINT task_reminder_receivers_reminder_id_user_id_unique UNIQUE (reminder_id, user_id);`).Error
+
+	return
+}
+
+// Rollback the database to previous version
+func (Migration) RollbackAddDeliveryStateToTaskRemindersTable() (e error) {
+	db := database.NewGORMInstance()
+	defer db.Close()
+	e = db.Exec(`ALTER TABLE public.task_reminder_receivers
+    DROP CONSTRAINT IF EXISTS task_reminder_receivers_reminder_id_user_id_unique,
+    DROP CONSTRAINT IF EXISTS task_reminder_receivers_reminder_id_task_reminders_id,
+    DROP COLUMN IF EXISTS last_error,
+    DROP COLUMN IF EXISTS attempts,
+    DROP COLUMN IF EXISTS delivered_at;
+
+    DROP INDEX IF EXISTS public.task_reminders_due_index;
+    ALTER TABLE public.task_reminders DROP COLUMN IF EXISTS processed_at;`).Error
+
+	return
+}
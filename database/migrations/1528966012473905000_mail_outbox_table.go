@@ -0,0 +1,39 @@
+package migrations
+
+import "devin/database"
+
+// Migrate the database to a new version
+func (Migration) MigrateMailOutboxTable() (e error) {
+	db := database.NewGORMInstance()
+	defer db.Close()
+	e = db.Exec(`CREATE TABLE IF NOT EXISTS public.mail_outbox (
+    id bigserial NOT NULL,
+    sender character varying(255) NOT NULL DEFAULT '',
+    recipients jsonb NOT NULL,
+    subject character varying(255) NOT NULL,
+    text_body text NOT NULL DEFAULT '',
+    html_body text NOT NULL DEFAULT '',
+    attempts integer NOT NULL DEFAULT 0,
+    last_error text,
+    next_attempt_at timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    sent_at timestamp with time zone,
+    created_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP,
+    updated_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT mail_outbox_pkey PRIMARY KEY (id)
+    );
+
+    CREATE INDEX IF NOT EXISTS mail_outbox_pending_index
+        ON public.mail_outbox (next_attempt_at) WHERE sent_at IS NULL;`).Error
+
+	return
+}
+
+// Rollback the database to previous version
+func (Migration) RollbackMailOutboxTable() (e error) {
+	db := database.NewGORMInstance()
+	defer db.Close()
+	e = db.Exec(`DROP TABLE IF EXISTS public.mail_outbox;`).Error
+
+	return
+}
@@ -0,0 +1,87 @@
+package migrations
+
+import "devin/database"
+
+// Migrate the database to a new version
+func (Migration) MigrateIssueTrackerConstraints() (e error) {
+	db := database.NewGORMInstance()
+	defer db.Close()
+	e = db.Exec(`ALTER TABLE public.issue_statuses
+    ADD CONSTRAINT issue_statuses_pkey PRIMARY KEY (id);
+    INSERT INTO public.issue_statuses (id, title)
+        VALUES (1, 'pending'), (2, 'in progress'), (3, 'closed')
+        ON CONFLICT (id) DO NOTHING;
+    SELECT setval('issue_statuses_id_seq', (SELECT max(id) FROM public.issue_statuses));
+
+    UPDATE public.issues SET status_id=1 WHERE status_id IS NULL;
+    ALTER TABLE public.issues
+    ADD COLUMN IF NOT EXISTS title varchar(255) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS reporter_email varchar(255),
+    ALTER COLUMN repository_id DROP NOT NULL,
+    ALTER COLUMN created_by_id DROP NOT NULL,
+    ALTER COLUMN status_id SET DEFAULT 1,
+    ALTER COLUMN status_id SET NOT NULL,
+    ADD CONSTRAINT issues_status_id_issue_statuses_id FOREIGN KEY (status_id)
+        REFERENCES public.issue_statuses (id) MATCH SIMPLE
+        ON DELETE RESTRICT
+        ON UPDATE CASCADE,
+    ADD CONSTRAINT issues_reporter_check CHECK (created_by_id IS NOT NULL OR reporter_email IS NOT NULL);
+    CREATE INDEX IF NOT EXISTS issues_project_id_status_id_index ON public.issues (project_id, status_id);
+
+    ALTER TABLE public.issue_comments
+    ALTER COLUMN reply_to_id DROP NOT NULL;
+
+    ALTER TABLE public.issue_assignments
+    ADD CONSTRAINT issue_assignments_issue_id_user_id_unique UNIQUE (issue_id, user_id);
+
+    CREATE UNIQUE INDEX IF NOT EXISTS issue_labels_project_id_label_unique
+        ON public.issue_labels (project_id, lower(label)) WHERE deleted_at IS NULL;
+
+    CREATE TABLE IF NOT EXISTS public.labeled_issues (
+    issue_id bigint NOT NULL,
+    issue_label_id bigint NOT NULL,
+    created_by_id bigint NOT NULL,
+    created_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT labeled_issues_pkey PRIMARY KEY (issue_id, issue_label_id),
+    CONSTRAINT labeled_issues_issue_id_issues_id FOREIGN KEY (issue_id)
+        REFERENCES public.issues (id) MATCH SIMPLE
+        ON DELETE CASCADE
+        ON UPDATE CASCADE,
+    CONSTRAINT labeled_issues_issue_label_id_issue_labels_id FOREIGN KEY (issue_label_id)
+        REFERENCES public.issue_labels (id) MATCH SIMPLE
+        ON DELETE CASCADE
+        ON UPDATE CASCADE,
+    CONSTRAINT labeled_issues_created_by_id_users_id FOREIGN KEY (created_by_id)
+        REFERENCES public.users (id) MATCH SIMPLE
+        ON DELETE CASCADE
+        ON UPDATE CASCADE
+    );`).Error
+
+	return
+}
+
+// Rollback the database to previous version
+func (Migration) RollbackIssueTrackerConstraints() (e error) {
+	db := database.NewGORMInstance()
+	defer db.Close()
+	e = db.Exec(`DROP TABLE IF EXISTS public.labeled_issues;
+    DROP INDEX IF EXISTS public.issue_labels_project_id_label_unique;
+
+    ALTER TABLE public.issue_assignments
+    DROP CONSTRAINT IF EXISTS issue_assignments_issue_id_user_id_unique;
+
+    DROP INDEX IF EXISTS public.issues_project_id_status_id_index;
+    ALTER TABLE public.issues
+    DROP CONSTRAINT IF EXISTS issues_reporter_check,
+    DROP CONSTRAINT IF EXISTS issues_status_id_issue_statuses_id,
+    ALTER COLUMN status_id DROP NOT NULL,
+    ALTER COLUMN status_id DROP DEFAULT,
+    DROP COLUMN IF EXISTS reporter_email,
+    DROP COLUMN IF EXISTS title;
+
+    ALTER TABLE public.issue_statuses
+    DROP CONSTRAINT IF EXISTS issue_statuses_pkey;`).Error
+
+	return
+}
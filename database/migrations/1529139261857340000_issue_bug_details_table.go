@@ -0,0 +1,44 @@
+package migrations
+
+import "devin/database"
+
+// Migrate the database to a new version
+func (Migration) MigrateIssueBugDetailsTable() (e error) {
+	db := database.NewGORMInstance()
+	defer db.Close()
+	e = db.Exec(`CREATE TABLE IF NOT EXISTS public.issue_bug_details (
+    id bigserial NOT NULL,
+    issue_id bigint NOT NULL,
+    severity smallint NOT NULL DEFAULT 2,
+    reproduction_steps text,
+    affected_version varchar(100),
+    promoted_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP,
+    created_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP,
+    updated_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT issue_bug_details_pkey PRIMARY KEY (id),
+    CONSTRAINT issue_bug_details_issue_id_unique UNIQUE (issue_id),
+    CONSTRAINT issue_bug_details_issue_id_issues_id FOREIGN KEY (issue_id)
+        REFERENCES public.issues (id) MATCH SIMPLE
+        ON DELETE CASCADE
+        ON UPDATE CASCADE,
+    CONSTRAINT issue_bug_details_severity_check CHECK (severity BETWEEN 1 AND 4)
+    );
+
+    INSERT INTO public.issue_bug_details (issue_id)
+        SELECT DISTINCT li.issue_id FROM public.labeled_issues li
+        INNER JOIN public.issue_labels l ON l.id=li.issue_label_id
+        WHERE l.is_bug_label=true AND l.deleted_at IS NULL
+        ON CONFLICT (issue_id) DO NOTHING;`).Error
+
+	return
+}
+
+// Rollback the database to previous version
+func (Migration) RollbackIssueBugDetailsTable() (e error) {
+	db := database.NewGORMInstance()
+	defer db.Close()
+	e = db.Exec(`DROP TABLE IF EXISTS public.issue_bug_details;`).Error
+
+	return
+}
@@ -0,0 +1,86 @@
+package migrations
+
+import "devin/database"
+
+// Migrate the database to a new version
+func (Migration) MigrateWikiPageRevisionsTable() (e error) {
+	db := database.NewGORMInstance()
+	defer db.Close()
+	e = db.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS wikis_project_id_name_unique
+        ON public.wikis (project_id, lower(name)) WHERE deleted_at IS NULL;
+
+    ALTER TABLE public.wiki_pages
+    ADD COLUMN IF NOT EXISTS title varchar(255),
+    ADD COLUMN IF NOT EXISTS content text NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS revision integer NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS updated_by_id bigint,
+    ADD CONSTRAINT wiki_pages_updated_by_id_users_id FOREIGN KEY (updated_by_id)
+        REFERENCES public.users (id) MATCH SIMPLE
+        ON DELETE CASCADE
+        ON UPDATE CASCADE;
+    UPDATE public.wiki_pages SET title='Page ' || id WHERE title IS NULL;
+    ALTER TABLE public.wiki_pages
+    ALTER COLUMN title SET NOT NULL;
+    CREATE UNIQUE INDEX IF NOT EXISTS wiki_pages_wiki_id_title_unique
+        ON public.wiki_pages (wiki_id, lower(title)) WHERE deleted_at IS NULL;
+
+    CREATE TABLE IF NOT EXISTS public.wiki_page_revisions (
+    id bigserial NOT NULL,
+    wiki_page_id bigint NOT NULL,
+    revision integer NOT NULL,
+    title varchar(255) NOT NULL,
+    content text NOT NULL DEFAULT '',
+    summary varchar(255),
+    restored_from_revision integer,
+    lines_added integer NOT NULL DEFAULT 0,
+    lines_deleted integer NOT NULL DEFAULT 0,
+    created_by_id bigint NOT NULL,
+    created_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT wiki_page_revisions_pkey PRIMARY KEY (id),
+    CONSTRAINT wiki_page_revisions_wiki_page_id_revision_unique UNIQUE (wiki_page_id, revision),
+    CONSTRAINT wiki_page_revisions_wiki_page_id_wiki_pages_id FOREIGN KEY (wiki_page_id)
+        REFERENCES public.wiki_pages (id) MATCH SIMPLE
+        ON DELETE CASCADE
+        ON UPDATE CASCADE,
+    CONSTRAINT wiki_page_revisions_created_by_id_users_id FOREIGN KEY (created_by_id)
+        REFERENCES public.users (id) MATCH SIMPLE
+        ON DELETE CASCADE
+        ON UPDATE CASCADE
+    );
+
+    CREATE OR REPLACE FUNCTION public.wiki_page_revisions_immutable() RETURNS trigger AS $$
+    BEGIN
+        RAISE EXCEPTION 'wiki page revisions are immutable';
+    END;
+    $$ LANGUAGE plpgsql;
+    DROP TRIGGER IF EXISTS wiki_page_revisions_immutable ON public.wiki_page_revisions;
+    CREATE TRIGGER wiki_page_revisions_immutable BEFORE UPDATE ON public.wiki_page_revisions
+        FOR EACH ROW EXECUTE PROCEDURE public.wiki_page_revisions_immutable();
+
+    INSERT INTO public.wiki_page_revisions (wiki_page_id, revision, title, content, lines_added, created_by_id, created_at)
+        SELECT id, 1, title, content, 0, created_by_id, updated_at FROM public.wiki_pages WHERE revision=0;
+    UPDATE public.wiki_pages SET revision=1 WHERE revision=0;`).Error
+
+	return
+}
+
+// Rollback the database to previous version
+func (Migration) RollbackWikiPageRevisionsTable() (e error) {
+	db := database.NewGORMInstance()
+	defer db.Close()
+	e = db.Exec(`DROP TABLE IF EXISTS public.wiki_page_revisions;
+    DROP FUNCTION IF EXISTS public.wiki_page_revisions_immutable();
+
+    DROP INDEX IF EXISTS public.wiki_pages_wiki_id_title_unique;
+    ALTER TABLE public.wiki_pages
+    DROP CONSTRAINT IF EXISTS wiki_pages_updated_by_id_users_id,
+    DROP COLUMN IF EXISTS updated_by_id,
+    DROP COLUMN IF EXISTS revision,
+    DROP COLUMN IF EXISTS content,
+    DROP COLUMN IF EXISTS title;
+
+    DROP INDEX IF EXISTS public.wikis_project_id_name_unique;`).Error
+
+	return
+}
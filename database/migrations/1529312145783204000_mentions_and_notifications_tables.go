@@ -0,0 +1,79 @@
+package migrations
+
+import "devin/database"
+
+// Migrate the database to a new version
+func (Migration) MigrateMentionsAndNotificationsTables() (e error) {
+	db := database.NewGORMInstance()
+	defer db.Close()
+	e = db.Exec(`CREATE TABLE IF NOT EXISTS public.mentions (
+    id bigserial NOT NULL,
+    module_id smallint NOT NULL,
+    object_id bigint NOT NULL,
+    comment_id bigint NOT NULL,
+    user_id bigint NOT NULL,
+    created_by_id bigint NOT NULL,
+    created_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT mentions_pkey PRIMARY KEY (id),
+    CONSTRAINT mentions_module_id_comment_id_user_id_unique UNIQUE (module_id, comment_id, user_id),
+    CONSTRAINT mentions_user_id_users_id FOREIGN KEY (user_id)
+        REFERENCES public.users (id) MATCH SIMPLE
+        ON DELETE CASCADE
+        ON UPDATE CASCADE,
+    CONSTRAINT mentions_created_by_id_users_id FOREIGN KEY (created_by_id)
+        REFERENCES public.users (id) MATCH SIMPLE
+        ON DELETE CASCADE
+        ON UPDATE CASCADE
+    );
+    CREATE INDEX IF NOT EXISTS mentions_user_id_index ON public.mentions (user_id);
+
+    CREATE TABLE IF NOT EXISTS public.notifications (
+    id bigserial NOT NULL,
+    user_id bigint NOT NULL,
+    actor_id bigint,
+    type varchar(50) NOT NULL,
+    project_id bigint,
+    module_id smallint NOT NULL,
+    object_id bigint NOT NULL,
+    comment_id bigint,
+    message varchar(255) NOT NULL DEFAULT '',
+    read_at timestamp with time zone,
+    created_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT notifications_pkey PRIMARY KEY (id),
+    CONSTRAINT notifications_user_id_users_id FOREIGN KEY (user_id)
+        REFERENCES public.users (id) MATCH SIMPLE
+        ON DELETE CASCADE
+        ON UPDATE CASCADE,
+    CONSTRAINT notifications_actor_id_users_id FOREIGN KEY (actor_id)
+        REFERENCES public.users (id) MATCH SIMPLE
+        ON DELETE SET NULL
+        ON UPDATE CASCADE,
+    CONSTRAINT notifications_project_id_projects_id FOREIGN KEY (project_id)
+        REFERENCES public.projects (id) MATCH SIMPLE
+        ON DELETE CASCADE
+        ON UPDATE CASCADE
+    );
+    CREATE INDEX IF NOT EXISTS notifications_user_id_read_at_index
+        ON public.notifications (user_id, read_at, created_at DESC);
+
+    DELETE FROM public.task_followers a USING public.task_followers b
+        WHERE a.task_id=b.task_id AND a.user_id=b.user_id AND a.id > b.id;
+    ALTER TABLE public.task_followers
+    ADD CONSTRAINT task_followers_task_id_user_id_unique UNIQUE (task_id, user_id);`).Error
+
+	return
+}
+
+// Rollback the database to previous version
+func (Migration) RollbackMentionsAndNotificationsTables() (e error) {
+	db := database.NewGORMInstance()
+	defer db.Close()
+	e = db.Exec(`ALTER TABLE public.task_followers
+    DROP CONSTRAINT IF EXISTS task_followers_task_id_user_id_unique;
+    DROP TABLE IF EXISTS public.notifications;
+    DROP TABLE IF EXISTS public.mentions;`).Error
+
+	return
+}
@@ -0,0 +1,39 @@
+package migrations
+
+import "devin/database"
+
+// Migrate the database to a new version
+func (Migration) MigrateNotificationInvitations() (e error) {
+	db := database.NewGORMInstance()
+	defer db.Close()
+	e = db.Exec(`ALTER TABLE public.notifications
+    ALTER COLUMN module_id DROP NOT NULL,
+    ALTER COLUMN object_id DROP NOT NULL,
+    ADD COLUMN IF NOT EXISTS invitation_id bigint,
+    ADD CONSTRAINT notifications_invitation_id_user_organization_invitations_id FOREIGN KEY (invitation_id)
+        REFERENCES public.user_organization_invitations (id) MATCH SIMPLE
+        ON DELETE CASCADE
+        ON UPDATE CASCADE;
+
+    INSERT INTO public.notifications (user_id, actor_id, type, invitation_id, message, created_at)
+        SELECT i.user_id, i.created_by_id, 'invitation', i.id, 'You are invited to join ' || o.username, i.created_at
+        FROM public.user_organization_invitations i
+        INNER JOIN public.users o ON o.id=i.organization_id
+        WHERE i.user_id IS NOT NULL AND i.accepted IS NULL;`).Error
+
+	return
+}
+
+// Rollback the database to previous version
+func (Migration) RollbackNotificationInvitations() (e error) {
+	db := database.NewGORMInstance()
+	defer db.Close()
+	e = db.Exec(`DELETE FROM public.notifications WHERE invitation_id IS NOT NULL;
+    ALTER TABLE public.notifications
+    DROP CONSTRAINT IF EXISTS notifications_invitation_id_user_organization_invitations_id,
+    DROP COLUMN IF EXISTS invitation_id,
+    ALTER COLUMN module_id SET NOT NULL,
+    ALTER COLUMN object_id SET NOT NULL;`).Error
+
+	return
+}
@@ -0,0 +1,70 @@
+package migrations
+
+import "devin/database"
+
+// Migrate the database to a new version
+func (Migration) MigrateNotificationPreferencesTable() (e error) {
+	db := database.NewGORMInstance()
+	defer db.Close()
+	e = db.Exec(`ALTER TABLE public.users
+    ADD COLUMN IF NOT EXISTS timezone varchar(64),
+    ADD COLUMN IF NOT EXISTS digest_time varchar(5) NOT NULL DEFAULT '08:00',
+    ADD COLUMN IF NOT EXISTS webhook_url varchar(255),
+    ADD COLUMN IF NOT EXISTS last_digest_at timestamp with time zone;
+
+    CREATE TABLE IF NOT EXISTS public.notification_preferences (
+    id bigserial NOT NULL,
+    user_id bigint NOT NULL,
+    event varchar(50) NOT NULL,
+    channel varchar(20) NOT NULL,
+    mode varchar(20) NOT NULL,
+    created_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP,
+    updated_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT notification_preferences_pkey PRIMARY KEY (id),
+    CONSTRAINT notification_preferences_user_id_event_channel_unique UNIQUE (user_id, event, channel),
+    CONSTRAINT notification_preferences_user_id_users_id FOREIGN KEY (user_id)
+        REFERENCES public.users (id) MATCH SIMPLE
+        ON DELETE CASCADE
+        ON UPDATE CASCADE
+    );
+
+    CREATE TABLE IF NOT EXISTS public.notification_deliveries (
+    id bigserial NOT NULL,
+    user_id bigint NOT NULL,
+    channel varchar(20) NOT NULL,
+    event varchar(50) NOT NULL,
+    digest boolean NOT NULL DEFAULT false,
+    payload text NOT NULL,
+    attempts integer NOT NULL DEFAULT 0,
+    last_error text,
+    next_attempt_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP,
+    sent_at timestamp with time zone,
+    created_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT notification_deliveries_pkey PRIMARY KEY (id),
+    CONSTRAINT notification_deliveries_user_id_users_id FOREIGN KEY (user_id)
+        REFERENCES public.users (id) MATCH SIMPLE
+        ON DELETE CASCADE
+        ON UPDATE CASCADE
+    );
+    CREATE INDEX IF NOT EXISTS notification_deliveries_pending_index
+        ON public.notification_deliveries (digest, next_attempt_at) WHERE sent_at IS NULL;`).Error
+
+	return
+}
+
+// Rollback the database to previous version
+func (Migration) RollbackNotificationPreferencesTable() (e error) {
+	db := database.NewGORMInstance()
+	defer db.Close()
+	e = db.Exec(`DROP TABLE IF EXISTS public.notification_deliveries;
+    DROP TABLE IF EXISTS public.notification_preferences;
+    ALTER TABLE public.users
+    DROP COLUMN IF EXISTS last_digest_at,
+    DROP COLUMN IF EXISTS webhook_url,
+    DROP COLUMN IF EXISTS digest_time,
+    DROP COLUMN IF EXISTS timezone;`).Error
+
+	return
+}
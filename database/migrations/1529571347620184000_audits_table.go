@@ -0,0 +1,44 @@
+package migrations
+
+import "devin/database"
+
+// Migrate the database to a new version
+func (Migration) MigrateAuditsTable() (e error) {
+	db := database.NewGORMInstance()
+	defer db.Close()
+	e = db.Exec(`CREATE TABLE IF NOT EXISTS public.audits (
+    id bigserial NOT NULL,
+    actor_id bigint,
+    organization_id bigint,
+    action varchar(50) NOT NULL,
+    module_id smallint NOT NULL,
+    object_id bigint NOT NULL,
+    changes jsonb NOT NULL DEFAULT '{}',
+    ip varchar(45) NOT NULL DEFAULT '',
+    user_agent varchar(255) NOT NULL DEFAULT '',
+    created_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT audits_pkey PRIMARY KEY (id),
+    CONSTRAINT audits_actor_id_users_id FOREIGN KEY (actor_id)
+        REFERENCES public.users (id) MATCH SIMPLE
+        ON DELETE SET NULL
+        ON UPDATE CASCADE
+    );
+    CREATE INDEX IF NOT EXISTS audits_organization_id_created_at_index
+        ON public.audits (organization_id, created_at DESC);
+    CREATE INDEX IF NOT EXISTS audits_actor_id_created_at_index
+        ON public.audits (actor_id, created_at DESC);
+    CREATE INDEX IF NOT EXISTS audits_created_at_index
+        ON public.audits (created_at DESC);`).Error
+
+	return
+}
+
+// Rollback the database to previous version
+func (Migration) RollbackAuditsTable() (e error) {
+	db := database.NewGORMInstance()
+	defer db.Close()
+	e = db.Exec(`DROP TABLE IF EXISTS public.audits;`).Error
+
+	return
+}
@@ -0,0 +1,41 @@
+package migrations
+
+import "devin/database"
+
+// Migrate the database to a new version
+func (Migration) MigrateObjectPermissionsProjectId() (e error) {
+	db := database.NewGORMInstance()
+	defer db.Close()
+	e = db.Exec(`ALTER TABLE public.object_permissions
+    ADD COLUMN IF NOT EXISTS project_id bigint,
+    ADD CONSTRAINT object_permissions_project_id_projects_id FOREIGN KEY (project_id)
+        REFERENCES public.projects (id) MATCH SIMPLE
+        ON DELETE CASCADE
+        ON UPDATE CASCADE;
+
+    UPDATE public.object_permissions SET project_id=object_id WHERE module_id=1;
+    UPDATE public.object_permissions op SET project_id=t.project_id FROM public.tasks t
+        WHERE op.module_id=2 AND t.id=op.object_id;
+    UPDATE public.object_permissions op SET project_id=m.project_id FROM public.milestones m
+        WHERE op.module_id=3 AND m.id=op.object_id;
+    UPDATE public.object_permissions op SET project_id=t.project_id FROM public.task_spent_times st
+        INNER JOIN public.tasks t ON t.id=st.task_id
+        WHERE op.module_id=5 AND st.id=op.object_id;
+    UPDATE public.object_permissions op SET project_id=i.project_id FROM public.issues i
+        WHERE op.module_id IN (6, 7) AND i.id=op.object_id;
+    UPDATE public.object_permissions op SET project_id=w.project_id FROM public.wikis w
+        WHERE op.module_id=10 AND w.id=op.object_id;
+
+    CREATE INDEX IF NOT EXISTS object_permissions_project_id_index ON public.object_permissions (project_id);`).Error
+
+	return
+}
+
+// Rollback the database to previous version
+func (Migration) RollbackObjectPermissionsProjectId() (e error) {
+	db := database.NewGORMInstance()
+	defer db.Close()
+	e = db.Exec(`ALTER TABLE public.object_permissions DROP COLUMN IF EXISTS project_id;`).Error
+
+	return
+}
@@ -0,0 +1,157 @@
+package migrations
+
+import "devin/database"
+
+// builtInRole is a built-in role with a single permission key and the boolean column of
+// membership tables which was replaced by the role
+type builtInRole struct {
+	name   string
+	key    string
+	table  string
+	column string
+}
+
+var builtInRoles = []builtInRole{
+	{"Organization admin", "organization.admin", "user_organization", "is_admin_of_organization"},
+	{"Project creator", "organization.create_project", "user_organization", "can_create_project"},
+	{"Project editor", "organization.update_project", "user_organization", "can_update_project"},
+	{"Member manager", "organization.add_user", "user_organization", "can_add_user_to_organization"},
+	{"Project admin", "project.admin", "project_users", "id_admin"},
+	{"Project profile editor", "project.update_profile", "project_users", "can_update_prject_profile"},
+	{"Project member manager", "project.add_user", "project_users", "can_add_user_to_project"},
+	{"Milestone creator", "milestone.create", "project_users", "can_create_milestone"},
+	{"Task list creator", "task_list.create", "project_users", "can_create_task_list"},
+	{"Task creator", "task.create", "project_users", "can_create_task"},
+	{"Issue creator", "issue.create", "project_users", "can_create_issue"},
+	{"Repository creator", "repository.create", "project_users", "can_create_repository"},
+	{"Tag creator", "tag.create", "project_users", "can_create_tag"},
+	{"Board creator", "board.create", "project_users", "can_create_board"},
+	{"Reminder creator", "reminder.create", "project_users", "can_create_reminder"},
+	{"Time logger", "time_log.create", "project_users", "can_create_time_log"},
+	{"Time log viewer", "time_log.list_all", "project_users", "can_list_all_time_logs"},
+	{"Wiki creator", "wiki.create", "project_users", "can_create_wiki"},
+}
+
+// Migrate the database to a new version
+func (Migration) MigrateRolesTables() (e error) {
+	db := database.NewGORMInstance()
+	defer db.Close()
+	tx := db.Begin()
+	e = tx.Exec(`CREATE TABLE IF NOT EXISTS public.roles (
+    id bigserial NOT NULL,
+    organization_id bigint,
+    name varchar(100) NOT NULL,
+    description text NOT NULL DEFAULT '',
+    is_built_in bool NOT NULL DEFAULT false,
+    created_by_id bigint,
+    created_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP,
+    updated_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT roles_pkey PRIMARY KEY (id),
+    CONSTRAINT roles_organization_id_users_id FOREIGN KEY (organization_id)
+        REFERENCES public.users (id) MATCH SIMPLE
+        ON DELETE CASCADE
+        ON UPDATE CASCADE,
+    CONSTRAINT roles_created_by_id_users_id FOREIGN KEY (created_by_id)
+        REFERENCES public.users (id) MATCH SIMPLE
+        ON DELETE SET NULL
+        ON UPDATE CASCADE
+    );
+    CREATE UNIQUE INDEX IF NOT EXISTS roles_organization_id_name_unique
+        ON public.roles (COALESCE(organization_id, 0), name);
+
+    CREATE TABLE IF NOT EXISTS public.role_permissions (
+    id bigserial NOT NULL,
+    role_id bigint NOT NULL,
+    key varchar(100) NOT NULL,
+
+    CONSTRAINT role_permissions_pkey PRIMARY KEY (id),
+    CONSTRAINT role_permissions_role_id_key_unique UNIQUE (role_id, key),
+    CONSTRAINT role_permissions_role_id_roles_id FOREIGN KEY (role_id)
+        REFERENCES public.roles (id) MATCH SIMPLE
+        ON DELETE CASCADE
+        ON UPDATE CASCADE
+    );
+
+    CREATE TABLE IF NOT EXISTS public.role_assignments (
+    id bigserial NOT NULL,
+    role_id bigint NOT NULL,
+    user_id bigint NOT NULL,
+    organization_id bigint,
+    project_id bigint,
+    created_by_id bigint,
+    created_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT role_assignments_pkey PRIMARY KEY (id),
+    CONSTRAINT role_assignments_scope CHECK ((organization_id IS NULL) <> (project_id IS NULL)),
+    CONSTRAINT role_assignments_role_id_roles_id FOREIGN KEY (role_id)
+        REFERENCES public.roles (id) MATCH SIMPLE
+        ON DELETE CASCADE
+        ON UPDATE CASCADE,
+    CONSTRAINT role_assignments_user_id_users_id FOREIGN KEY (user_id)
+        REFERENCES public.users (id) MATCH SIMPLE
+        ON DELETE CASCADE
+        ON UPDATE CASCADE,
+    CONSTRAINT role_assignments_organization_id_users_id FOREIGN KEY (organization_id)
+        REFERENCES public.users (id) MATCH SIMPLE
+        ON DELETE CASCADE
+        ON UPDATE CASCADE,
+    CONSTRAINT role_assignments_project_id_projects_id FOREIGN KEY (project_id)
+        REFERENCES public.projects (id) MATCH SIMPLE
+        ON DELETE CASCADE
+        ON UPDATE CASCADE,
+    CONSTRAINT role_assignments_created_by_id_users_id FOREIGN KEY (created_by_id)
+        REFERENCES public.users (id) MATCH SIMPLE
+        ON DELETE SET NULL
+        ON UPDATE CASCADE
+    );
+    CREATE UNIQUE INDEX IF NOT EXISTS role_assignments_unique
+        ON public.role_assignments (role_id, user_id, COALESCE(organization_id, 0), COALESCE(project_id, 0));
+    CREATE INDEX IF NOT EXISTS role_assignments_user_id_index ON public.role_assignments (user_id);`).Error
+
+	// Each boolean permission of memberships becomes an assignment of its built-in role
+	for _, role := range builtInRoles {
+		if e != nil {
+			break
+		}
+
+		e = tx.Exec(`INSERT INTO public.roles (name, is_built_in) VALUES (?, true)`, role.name).Error
+		if e == nil {
+			e = tx.Exec(`INSERT INTO public.role_permissions (role_id, key)
+                SELECT id, ? FROM public.roles WHERE organization_id IS NULL AND name=?`, role.key, role.name).Error
+		}
+		if e == nil && role.table == "user_organization" {
+			e = tx.Exec(`INSERT INTO public.role_assignments (role_id, user_id, organization_id, created_by_id)
+                SELECT r.id, m.user_id, m.organization_id, m.created_by_id
+                FROM public.user_organization m, public.roles r
+                WHERE m.`+role.column+`=true AND m.deleted_at IS NULL
+                AND r.organization_id IS NULL AND r.name=?`, role.name).Error
+		}
+		if e == nil && role.table == "project_users" {
+			e = tx.Exec(`INSERT INTO public.role_assignments (role_id, user_id, project_id, created_by_id)
+                SELECT r.id, m.user_id, m.project_id, m.created_by_id
+                FROM public.project_users m, public.roles r
+                WHERE m.`+role.column+`=true AND m.deleted_at IS NULL
+                AND r.organization_id IS NULL AND r.name=?`, role.name).Error
+		}
+	}
+
+	if e == nil {
+		e = tx.Commit().Error
+	} else {
+		tx.Rollback()
+	}
+
+	return
+}
+
+// Rollback the database to previous version
+func (Migration) RollbackRolesTables() (e error) {
+	db := database.NewGORMInstance()
+	defer db.Close()
+	e = db.Exec(`DROP TABLE IF EXISTS public.role_assignments;
+    DROP TABLE IF EXISTS public.role_permissions;
+    DROP TABLE IF EXISTS public.roles;`).Error
+
+	return
+}
@@ -0,0 +1,42 @@
+package migrations
+
+import "devin/database"
+
+// Migrate the database to a new version
+func (Migration) MigrateSessionsTable() (e error) {
+	db := database.NewGORMInstance()
+	defer db.Close()
+	e = db.Exec(`CREATE TABLE IF NOT EXISTS public.sessions (
+    id bigserial NOT NULL,
+    user_id bigint NOT NULL,
+    refresh_token_hash varchar(64) NOT NULL DEFAULT '',
+    previous_token_hash varchar(64),
+    device varchar(100) NOT NULL DEFAULT '',
+    user_agent varchar(255) NOT NULL DEFAULT '',
+    ip varchar(45) NOT NULL DEFAULT '',
+    last_seen_at timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at timestamp with time zone NOT NULL,
+    revoked_at timestamp with time zone,
+    created_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP,
+    updated_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT sessions_pkey PRIMARY KEY (id),
+    CONSTRAINT sessions_user_id_users_id FOREIGN KEY (user_id)
+        REFERENCES public.users (id) MATCH SIMPLE
+        ON DELETE CASCADE
+        ON UPDATE CASCADE
+    );
+    CREATE INDEX IF NOT EXISTS sessions_user_id_last_seen_at_index
+        ON public.sessions (user_id, last_seen_at DESC);`).Error
+
+	return
+}
+
+// Rollback the database to previous version
+func (Migration) RollbackSessionsTable() (e error) {
+	db := database.NewGORMInstance()
+	defer db.Close()
+	e = db.Exec(`DROP TABLE IF EXISTS public.sessions;`).Error
+
+	return
+}
@@ -0,0 +1,49 @@
+package migrations
+
+import "devin/database"
+
+// Migrate the database to a new version
+func (Migration) MigrateTwoFactor() (e error) {
+	db := database.NewGORMInstance()
+	defer db.Close()
+	e = db.Exec(`ALTER TABLE public.users
+        ADD COLUMN IF NOT EXISTS two_factor_secret text,
+        ADD COLUMN IF NOT EXISTS two_factor_enabled_at timestamp with time zone,
+        ADD COLUMN IF NOT EXISTS two_factor_last_step bigint NOT NULL DEFAULT 0,
+        ADD COLUMN IF NOT EXISTS two_factor_failures integer NOT NULL DEFAULT 0,
+        ADD COLUMN IF NOT EXISTS two_factor_failed_at timestamp with time zone,
+        ADD COLUMN IF NOT EXISTS require_two_factor bool NOT NULL DEFAULT false;
+
+    CREATE TABLE IF NOT EXISTS public.recovery_codes (
+    id bigserial NOT NULL,
+    user_id bigint NOT NULL,
+    code_hash varchar(64) NOT NULL,
+    used_at timestamp with time zone,
+    created_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT recovery_codes_pkey PRIMARY KEY (id),
+    CONSTRAINT recovery_codes_user_id_code_hash_unique UNIQUE (user_id, code_hash),
+    CONSTRAINT recovery_codes_user_id_users_id FOREIGN KEY (user_id)
+        REFERENCES public.users (id) MATCH SIMPLE
+        ON DELETE CASCADE
+        ON UPDATE CASCADE
+    );`).Error
+
+	return
+}
+
+// Rollback the database to previous version
+func (Migration) RollbackTwoFactor() (e error) {
+	db := database.NewGORMInstance()
+	defer db.Close()
+	e = db.Exec(`DROP TABLE IF EXISTS public.recovery_codes;
+    ALTER TABLE public.users
+        DROP COLUMN IF EXISTS two_factor_secret,
+        DROP COLUMN IF EXISTS two_factor_enabled_at,
+        DROP COLUMN IF EXISTS two_factor_last_step,
+        DROP COLUMN IF EXISTS two_factor_failures,
+        DROP COLUMN IF EXISTS two_factor_failed_at,
+        DROP COLUMN IF EXISTS require_two_factor;`).Error
+
+	return
+}
@@ -0,0 +1,43 @@
+package migrations
+
+import "devin/database"
+
+// Migrate the database to a new version
+func (Migration) MigrateAccessTokensTable() (e error) {
+	db := database.NewGORMInstance()
+	defer db.Close()
+	e = db.Exec(`CREATE TABLE IF NOT EXISTS public.access_tokens (
+    id bigserial NOT NULL,
+    user_id bigint NOT NULL,
+    name varchar(100) NOT NULL,
+    token_hash varchar(64) NOT NULL,
+    token_prefix varchar(20) NOT NULL DEFAULT '',
+    scopes varchar(255) NOT NULL DEFAULT '',
+    expires_at timestamp with time zone NOT NULL,
+    last_used_at timestamp with time zone,
+    last_used_ip varchar(45) NOT NULL DEFAULT '',
+    revoked_at timestamp with time zone,
+    created_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP,
+    updated_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT access_tokens_pkey PRIMARY KEY (id),
+    CONSTRAINT access_tokens_token_hash_unique UNIQUE (token_hash),
+    CONSTRAINT access_tokens_user_id_users_id FOREIGN KEY (user_id)
+        REFERENCES public.users (id) MATCH SIMPLE
+        ON DELETE CASCADE
+        ON UPDATE CASCADE
+    );
+    CREATE INDEX IF NOT EXISTS access_tokens_user_id_index
+        ON public.access_tokens (user_id);`).Error
+
+	return
+}
+
+// Rollback the database to previous version
+func (Migration) RollbackAccessTokensTable() (e error) {
+	db := database.NewGORMInstance()
+	defer db.Close()
+	e = db.Exec(`DROP TABLE IF EXISTS public.access_tokens;`).Error
+
+	return
+}
@@ -0,0 +1,22 @@
+package migrations
+
+import "devin/database"
+
+// Migrate the database to a new version
+func (Migration) MigrateAddClaimedUntilToTaskRemindersTable() (e error) {
+	db := database.NewGORMInstance()
+	defer db.Close()
+	e = db.Exec(`ALTER TABLE public.task_reminders
+    ADD COLUMN IF NOT EXISTS claimed_until timestamp with time zone;`).Error
+
+	return
+}
+
+// Rollback the database to previous version
+func (Migration) RollbackAddClaimedUntilToTaskRemindersTable() (e error) {
+	db := database.NewGORMInstance()
+	defer db.Close()
+	e = db.Exec(`ALTER TABLE public.task_reminders DROP COLUMN IF EXISTS claimed_until;`).Error
+
+	return
+}
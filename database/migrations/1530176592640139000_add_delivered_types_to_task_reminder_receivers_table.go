@@ -0,0 +1,22 @@
+package migrations
+
+import "devin/database"
+
+// Migrate the database to a new version
+func (Migration) MigrateAddDeliveredTypesToTaskReminderReceiversTable() (e error) {
+	db := database.NewGORMInstance()
+	defer db.Close()
+	e = db.Exec(`ALTER TABLE public.task_reminder_receivers
+    ADD COLUMN IF NOT EXISTS delivered_types jsonb;`).Error
+
+	return
+}
+
+// Rollback the database to previous version
+func (Migration) RollbackAddDeliveredTypesToTaskReminderReceiversTable() (e error) {
+	db := database.NewGORMInstance()
+	defer db.Close()
+	e = db.Exec(`ALTER TABLE public.task_reminder_receivers DROP COLUMN IF EXISTS delivered_types;`).Error
+
+	return
+}
@@ -0,0 +1,85 @@
+package mailer
+
+import (
+	"errors"
+	"os"
+	"strings"
+)
+
+// Transports selectable by MAIL_DRIVER
+const (
+	DriverSMTP = "smtp"
+	DriverFile = "file"
+	DriverLog  = "log"
+)
+
+// ErrInvalidDriver returned by New when MAIL_DRIVER is not set or not one of the known drivers.
+// There is no default driver, so a missing variable doesn't mark emails as sent without sending them.
+var ErrInvalidDriver = errors.New("MAIL_DRIVER must be one of: smtp, file, log")
+
+// Config of the mail subsystem
+type Config struct {
+	Driver string
+
+	// Sender of application emails
+	From string
+
+	// Base URL of links inside emails
+	AppURL string
+
+	SMTPHost     string
+	SMTPPort     string
+	SMTPUsername string
+	SMTPPassword string
+
+	// Directory of FileMailer
+	Dir string
+}
+
+// ConfigFromEnv read configuration from MAIL_* and APP_URL environment variables.
+// MAIL_DRIVER has no default, MAIL_DRIVER=log must be set explicitly in development.
+func ConfigFromEnv() Config {
+	return Config{
+		Driver:       os.Getenv("MAIL_DRIVER"),
+		From:         getenv("MAIL_FROM", "no-reply@devin.local"),
+		AppURL:       strings.TrimRight(getenv("APP_URL", "http://localhost:13000"), "/"),
+		SMTPHost:     getenv("MAIL_HOST", "localhost"),
+		SMTPPort:     getenv("MAIL_PORT", "25"),
+		SMTPUsername: os.Getenv("MAIL_USERNAME"),
+		SMTPPassword: os.Getenv("MAIL_PASSWORD"),
+		Dir:          getenv("MAIL_DIR", "storage/mails"),
+	}
+}
+
+// New create the transport selected by config, ErrInvalidDriver is returned for empty or unknown drivers
+func New(config Config) (Mailer, error) {
+	switch config.Driver {
+	case DriverSMTP:
+		return SMTPMailer{
+			Host:     config.SMTPHost,
+			Port:     config.SMTPPort,
+			Username: config.SMTPUsername,
+			Password: config.SMTPPassword,
+			From:     config.From,
+		}, nil
+	case DriverFile:
+		return FileMailer{Dir: config.Dir, From: config.From}, nil
+	case DriverLog:
+		return LogMailer{}, nil
+	}
+
+	return nil, ErrInvalidDriver
+}
+
+// URL return the absolute link of path
+func (config Config) URL(path string) string {
+	return config.AppURL + "/" + strings.TrimLeft(path, "/")
+}
+
+func getenv(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+
+	return fallback
+}
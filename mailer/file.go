@@ -0,0 +1,61 @@
+package mailer
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FileMailer write each message as an .eml file into Dir.
+// It is useful in development and tests, files can be opened by any mail client.
+type FileMailer struct {
+	Dir  string
+	From string
+}
+
+var fileMailerSeq struct {
+	sync.Mutex
+	n uint64
+}
+
+// Send write msg into a new file of Dir
+func (m FileMailer) Send(msg Message) error {
+	if msg.From == "" {
+		msg.From = m.From
+	}
+
+	now := time.Now()
+	body, e := msg.Bytes(now)
+	if e != nil {
+		return e
+	}
+
+	e = os.MkdirAll(m.Dir, 0755)
+	if e != nil {
+		return e
+	}
+
+	fileMailerSeq.Lock()
+	fileMailerSeq.n++
+	seq := fileMailerSeq.n
+	fileMailerSeq.Unlock()
+
+	name := fmt.Sprintf("%d_%d.eml", now.UnixNano(), seq)
+
+	return ioutil.WriteFile(filepath.Join(m.Dir, name), body, 0644)
+}
+
+// LogMailer write messages to the standard logger
+type LogMailer struct{}
+
+// Send log recipients, subject and text body of msg
+func (LogMailer) Send(msg Message) error {
+	log.Printf("Mail to %s: %s\n%s", strings.Join(msg.To, ", "), msg.Subject, msg.Text)
+
+	return nil
+}
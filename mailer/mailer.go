@@ -0,0 +1,81 @@
+// Package mailer send outbound emails of the application.
+// Transports implement Mailer; messages of the application are built from
+// templates with NewMessage and are sent through the outbox package, so a failed
+// send is retried instead of being lost.
+package mailer
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+// Message is a single email
+type Message struct {
+	From    string
+	To      []string
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// Mailer send messages through a transport
+type Mailer interface {
+	Send(msg Message) error
+}
+
+// MailerFunc is an adapter to use ordinary functions as Mailer
+type MailerFunc func(msg Message) error
+
+// Send call f(msg)
+func (f MailerFunc) Send(msg Message) error {
+	return f(msg)
+}
+
+// Bytes encode the message in RFC 5322 format.
+// Messages with both text and html bodies are encoded as multipart/alternative.
+func (msg Message) Bytes(date time.Time) ([]byte, error) {
+	var buf bytes.Buffer
+
+	fmt.Fprintf(&buf, "From: %s\r\n", msg.From)
+	fmt.Fprintf(&buf, "To: %s\r\n", strings.Join(msg.To, ", "))
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+
+	if msg.HTML == "" {
+		buf.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+		buf.WriteString(msg.Text)
+		return buf.Bytes(), nil
+	}
+
+	parts := multipart.NewWriter(&buf)
+	fmt.Fprintf(&buf, "Content-Type: multipart/alternative; boundary=%s\r\n\r\n", parts.Boundary())
+
+	bodies := []struct {
+		contentType string
+		body        string
+	}{
+		{"text/plain; charset=utf-8", msg.Text},
+		{"text/html; charset=utf-8", msg.HTML},
+	}
+	for _, b := range bodies {
+		part, e := parts.CreatePart(textproto.MIMEHeader{"Content-Type": {b.contentType}})
+		if e != nil {
+			return nil, e
+		}
+		if _, e = part.Write([]byte(b.body)); e != nil {
+			return nil, e
+		}
+	}
+
+	if e := parts.Close(); e != nil {
+		return nil, e
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,126 @@
+package mailer
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMessageBytes(t *testing.T) {
+	msg := Message{From: "a@devin.local", To: []string{"b@devin.local", "c@devin.local"}, Subject: "Hi", Text: "plain"}
+	body, e := msg.Bytes(time.Now())
+	if e != nil {
+		t.Fatal(e)
+	}
+	s := string(body)
+	if strings.Contains(s, "To: b@devin.local, c@devin.local\r\n") == false || strings.Contains(s, "text/plain") == false {
+		t.Fatal("Invalid plain message", s)
+	}
+
+	msg.HTML = "<p>html</p>"
+	body, e = msg.Bytes(time.Now())
+	if e != nil {
+		t.Fatal(e)
+	}
+	s = string(body)
+	if strings.Contains(s, "multipart/alternative") == false || strings.Contains(s, "<p>html</p>") == false || strings.Contains(s, "plain") == false {
+		t.Fatal("Invalid multipart message", s)
+	}
+}
+
+func TestTemplates(t *testing.T) {
+	msg, e := InvitationMessage("user@devin.local", InvitationData{
+		Username:     "user",
+		Organization: "<script>",
+		InvitedBy:    "admin",
+		AcceptLink:   "http://localhost/accept",
+		RejectLink:   "http://localhost/reject",
+	})
+	if e != nil {
+		t.Fatal(e)
+	}
+	if msg.Subject != "admin invited you to join <script>" {
+		t.Fatal("Invalid subject", msg.Subject)
+	}
+	if strings.Contains(msg.HTML, "<script>") || strings.Contains(msg.HTML, "&lt;script&gt;") == false {
+		t.Fatal("HTML body must be escaped", msg.HTML)
+	}
+	if strings.Contains(msg.Text, "http://localhost/accept") == false {
+		t.Fatal("Text body must contain links", msg.Text)
+	}
+
+	msg, e = PasswordResetMessage("user@devin.local", PasswordResetData{Username: "user", Link: "http://localhost/reset", ExpiresAt: time.Now()})
+	if e != nil || len(msg.To) != 1 || strings.Contains(msg.HTML, `href="http://localhost/reset"`) == false {
+		t.Fatal("Invalid password reset message", msg, e)
+	}
+}
+
+func TestDigestMessage(t *testing.T) {
+	msg, e := DigestMessage("user@devin.local", DigestData{
+		Username: "user",
+		Date:     "1397/03/28",
+		Items: []NotificationItem{
+			{Title: "alice mentioned you", Message: "<b>look</b>", Link: "http://localhost/project/1/task/2", Time: "1397/03/27 18:20"},
+			{Title: "New comment", Link: "http://localhost/project/1/issue/3", Time: "1397/03/28 07:10"},
+		},
+	})
+	if e != nil {
+		t.Fatal(e)
+	}
+	if msg.Subject != "Your daily digest of 1397/03/28: 2 notifications" {
+		t.Fatal("Invalid subject", msg.Subject)
+	}
+	if strings.Contains(msg.HTML, "<b>look</b>") || strings.Count(msg.HTML, "<li>") != 2 {
+		t.Fatal("Invalid HTML body", msg.HTML)
+	}
+	if strings.Contains(msg.Text, "http://localhost/project/1/issue/3") == false {
+		t.Fatal("Text body must contain links", msg.Text)
+	}
+}
+
+func TestFileMailer(t *testing.T) {
+	dir, e := ioutil.TempDir("", "mailer")
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer os.RemoveAll(dir)
+
+	m := FileMailer{Dir: dir, From: "no-reply@devin.local"}
+	for i := 0; i < 2; i++ {
+		if e = m.Send(Message{To: []string{"user@devin.local"}, Subject: "Test", Text: "body"}); e != nil {
+			t.Fatal(e)
+		}
+	}
+
+	files, _ := ioutil.ReadDir(dir)
+	if len(files) != 2 {
+		t.Fatal("Each message must be written to a file, got", len(files))
+	}
+	body, _ := ioutil.ReadFile(dir + "/" + files[0].Name())
+	if strings.Contains(string(body), "From: no-reply@devin.local") == false {
+		t.Fatal("Default sender must be used", string(body))
+	}
+}
+
+func TestConfigURL(t *testing.T) {
+	config := Config{AppURL: "https://devin.io"}
+	if url := config.URL("/api/signup/verify?token=x"); url != "https://devin.io/api/signup/verify?token=x" {
+		t.Fatal("Invalid URL", url)
+	}
+}
+
+func TestNew(t *testing.T) {
+	for _, driver := range []string{"", "smpt", "LOG"} {
+		if _, e := New(Config{Driver: driver}); e != ErrInvalidDriver {
+			t.Fatal("Driver must be rejected:", driver)
+		}
+	}
+
+	if m, e := New(Config{Driver: DriverLog}); e != nil {
+		t.Fatal(e)
+	} else if _, ok := m.(LogMailer); ok == false {
+		t.Fatal("Log driver must be used only when it is set")
+	}
+}
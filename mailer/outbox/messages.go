@@ -0,0 +1,75 @@
+package outbox
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/jinzhu/gorm"
+
+	"devin/mailer"
+	"devin/models"
+)
+
+// EnqueueVerification queue the email verification link of a new user
+func EnqueueVerification(db *gorm.DB, user models.User) error {
+	if user.EmailVerificationToken == nil {
+		return fmt.Errorf("User %d has no verification token", user.ID)
+	}
+
+	config := mailer.ConfigFromEnv()
+	msg, e := mailer.VerificationMessage(user.Email, mailer.VerificationData{
+		Username: user.Username,
+		Link:     config.URL("/api/signup/verify?token=" + url.QueryEscape(*user.EmailVerificationToken)),
+	})
+	if e != nil {
+		return e
+	}
+	msg.From = config.From
+
+	return Enqueue(db, msg)
+}
+
+// EnqueuePasswordReset queue the password reset link of user
+func EnqueuePasswordReset(db *gorm.DB, user models.User, reset models.PasswordReset) error {
+	config := mailer.ConfigFromEnv()
+	msg, e := mailer.PasswordResetMessage(user.Email, mailer.PasswordResetData{
+		Username:  user.Username,
+		Link:      config.URL("/api/password_reset/validate?token=" + url.QueryEscape(reset.Token)),
+		ExpiresAt: reset.ExpiresAt,
+	})
+	if e != nil {
+		return e
+	}
+	msg.From = config.From
+
+	return Enqueue(db, msg)
+}
+
+// EnqueueInvitation queue the invitation of user to organization
+func EnqueueInvitation(db *gorm.DB, user models.User, organization models.User, invitedBy models.User, invitation models.UserOrganizationInvitation) error {
+	config := mailer.ConfigFromEnv()
+	link := fmt.Sprintf("/api/invitation/%d/set_acceptance/", invitation.ID)
+	msg, e := mailer.InvitationMessage(user.Email, mailer.InvitationData{
+		Username:     user.Username,
+		Organization: displayName(organization),
+		InvitedBy:    displayName(invitedBy),
+		AcceptLink:   config.URL(link + "accept"),
+		RejectLink:   config.URL(link + "reject"),
+	})
+	if e != nil {
+		return e
+	}
+	msg.From = config.From
+
+	return Enqueue(db, msg)
+}
+
+// displayName return full name of user, or username if it has no name
+func displayName(user models.User) string {
+	user.SetFullName()
+	if user.FullName != nil && *user.FullName != "" {
+		return *user.FullName
+	}
+
+	return user.Username
+}
@@ -0,0 +1,96 @@
+// Package outbox store outbound emails in the mail_outbox table and send them
+// with retries. Callers enqueue messages in the same transaction as the data they
+// belong to (e.g. a verification token), so a failed send never loses a link:
+// the worker retries it with exponential backoff until MaxAttempts is reached.
+package outbox
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/jinzhu/gorm"
+
+	"devin/mailer"
+	"devin/models"
+)
+
+// Enqueue store msg in the outbox, it is sent by the worker as soon as possible
+func Enqueue(db *gorm.DB, msg mailer.Message) error {
+	row, e := toRow(msg)
+	if e != nil {
+		return e
+	}
+	row.NextAttemptAt = time.Now()
+
+	return db.Create(&row).Error
+}
+
+// ClaimPending lock, load and claim pending messages which are due until now.
+// Rows locked by other workers are skipped. Claimed messages count an attempt and are
+// not due again before claimedUntil, so if the worker dies they are retried after it.
+// db must be a short transaction, the claim outlives it and lets the caller send without holding locks.
+func ClaimPending(db *gorm.DB, now time.Time, limit int, maxAttempts uint, claimedUntil time.Time) (rows []models.MailOutbox, e error) {
+	e = db.Raw(`SELECT * FROM mail_outbox
+        WHERE sent_at IS NULL AND attempts < ? AND next_attempt_at <= ?
+        ORDER BY next_attempt_at ASC, id ASC
+        LIMIT ? FOR UPDATE SKIP LOCKED`, maxAttempts, now, limit).
+		Scan(&rows).
+		Error
+	if e != nil || len(rows) == 0 {
+		return
+	}
+
+	var ids []uint64
+	for _, row := range rows {
+		ids = append(ids, row.ID)
+	}
+	e = db.Exec(`UPDATE mail_outbox SET attempts=attempts+1, next_attempt_at=?, updated_at=? WHERE id IN (?)`,
+		claimedUntil, now, ids).Error
+
+	return
+}
+
+// SaveDeliveryState store the result of sending a message
+func SaveDeliveryState(db *gorm.DB, row models.MailOutbox) error {
+	return db.Model(&models.MailOutbox{}).
+		Where("id=?", row.ID).
+		Updates(map[string]interface{}{
+			"attempts":        row.Attempts,
+			"last_error":      row.LastError,
+			"next_attempt_at": row.NextAttemptAt,
+			"sent_at":         row.SentAt,
+			"updated_at":      time.Now(),
+		}).
+		Error
+}
+
+// toRow convert a message to an outbox row
+func toRow(msg mailer.Message) (row models.MailOutbox, e error) {
+	recipients, e := json.Marshal(msg.To)
+	if e != nil {
+		return
+	}
+
+	row = models.MailOutbox{
+		Sender:     msg.From,
+		Recipients: string(recipients),
+		Subject:    msg.Subject,
+		TextBody:   msg.Text,
+		HTMLBody:   msg.HTML,
+	}
+
+	return
+}
+
+// toMessage convert an outbox row to a message
+func toMessage(row models.MailOutbox) (msg mailer.Message, e error) {
+	msg = mailer.Message{
+		From:    row.Sender,
+		Subject: row.Subject,
+		Text:    row.TextBody,
+		HTML:    row.HTMLBody,
+	}
+	e = json.Unmarshal([]byte(row.Recipients), &msg.To)
+
+	return
+}
@@ -0,0 +1,149 @@
+package outbox
+
+import (
+	"log"
+	"time"
+
+	"github.com/jinzhu/gorm"
+
+	"devin/database"
+	"devin/mailer"
+	"devin/models"
+)
+
+// Worker send pending messages of the outbox through Mailer
+type Worker struct {
+	Mailer mailer.Mailer
+
+	// Number of messages claimed in each transaction
+	BatchSize int
+
+	// Claimed messages are retried after this time if the worker dies, it must be longer than sending a batch
+	ClaimTimeout time.Duration
+
+	// Failed messages are retried until MaxAttempts is reached
+	MaxAttempts uint
+
+	// Time between runs, used by Run
+	Interval time.Duration
+
+	// NewDB return a new database instance, default is database.NewGORMInstance
+	NewDB func() *gorm.DB
+}
+
+// NewWorker create a worker with default settings
+func NewWorker(m mailer.Mailer) *Worker {
+	return &Worker{
+		Mailer:       m,
+		BatchSize:    50,
+		ClaimTimeout: 10 * time.Minute,
+		MaxAttempts:  8,
+		Interval:     30 * time.Second,
+		NewDB:        database.NewGORMInstance,
+	}
+}
+
+// Run call RunOnce every Interval until stop is closed
+func (wr *Worker) Run(stop <-chan struct{}) {
+	ticker := time.NewTicker(wr.Interval)
+	defer ticker.Stop()
+
+	for {
+		sent, e := wr.RunOnce(time.Now())
+		if e != nil {
+			log.Println("Mail worker:", e)
+		} else if sent > 0 {
+			log.Printf("Mail worker: %d messages sent", sent)
+		}
+
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce send all messages due until now and return the number of sent messages
+func (wr *Worker) RunOnce(now time.Time) (sent int, e error) {
+	db := wr.NewDB()
+	defer db.Close()
+
+	for {
+		var claimed, count int
+		claimed, count, e = wr.runBatch(db, now)
+		sent += count
+		if e != nil || claimed < wr.BatchSize {
+			return
+		}
+	}
+}
+
+// runBatch claim a batch of pending messages in a short transaction and send them.
+// The result of each message is stored right after sending it, so a failure doesn't
+// undo sent messages. Failed messages are rescheduled after now, so they are not claimed again by this run.
+func (wr *Worker) runBatch(db *gorm.DB, now time.Time) (claimed int, sent int, e error) {
+	tx := db.Begin()
+	rows, e := ClaimPending(tx, now, wr.BatchSize, wr.MaxAttempts, now.Add(wr.ClaimTimeout))
+	if e == nil {
+		e = tx.Commit().Error
+	} else {
+		tx.Rollback()
+	}
+	if e != nil {
+		return
+	}
+	claimed = len(rows)
+
+	for i := range rows {
+		if deliver(wr.Mailer, &rows[i], now) {
+			sent++
+		}
+
+		e = SaveDeliveryState(db, rows[i])
+		if e != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// deliver send the message of row and update its delivery state, Attempts of the row is
+// increased like the claim did in the database. It returns true if the message is sent.
+func deliver(m mailer.Mailer, row *models.MailOutbox, now time.Time) bool {
+	row.Attempts++
+
+	msg, e := toMessage(*row)
+	if e == nil {
+		e = m.Send(msg)
+	}
+	if e == nil {
+		sentAt := now
+		row.SentAt = &sentAt
+		row.LastError = nil
+		return true
+	}
+
+	message := e.Error()
+	row.LastError = &message
+	row.NextAttemptAt = now.Add(Backoff(row.Attempts))
+
+	return false
+}
+
+// Backoff return the delay before the next try of a message failed attempts times.
+// The delay doubles on each failure starting from one minute, up to 6 hours.
+func Backoff(attempts uint) time.Duration {
+	const max = 6 * time.Hour
+
+	delay := time.Minute
+	for i := uint(1); i < attempts; i++ {
+		delay *= 2
+		if delay >= max {
+			return max
+		}
+	}
+
+	return delay
+}
@@ -0,0 +1,74 @@
+package outbox
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"devin/mailer"
+	"devin/models"
+)
+
+func TestBackoff(t *testing.T) {
+	cases := map[uint]time.Duration{
+		1:  time.Minute,
+		2:  2 * time.Minute,
+		5:  16 * time.Minute,
+		20: 6 * time.Hour,
+	}
+	for attempts, expected := range cases {
+		if d := Backoff(attempts); d != expected {
+			t.Fatal("Invalid backoff of", attempts, "attempts:", d)
+		}
+	}
+}
+
+func TestRowRoundTrip(t *testing.T) {
+	msg := mailer.Message{From: "a@devin.local", To: []string{"b@devin.local"}, Subject: "s", Text: "t", HTML: "h"}
+	row, e := toRow(msg)
+	if e != nil {
+		t.Fatal(e)
+	}
+	back, e := toMessage(row)
+	if e != nil || reflect.DeepEqual(back, msg) == false {
+		t.Fatal("Message must survive the outbox", back, e)
+	}
+}
+
+func TestDeliver(t *testing.T) {
+	now := time.Now()
+	row, _ := toRow(mailer.Message{To: []string{"user@devin.local"}})
+
+	failing := mailer.MailerFunc(func(mailer.Message) error { return errors.New("SMTP is down") })
+	if deliver(failing, &row, now) {
+		t.Fatal("Failed message must not be sent")
+	}
+	if row.Attempts != 1 || row.SentAt != nil || row.LastError == nil || row.NextAttemptAt.Equal(now.Add(time.Minute)) == false {
+		t.Fatal("Failed message must be rescheduled", row)
+	}
+
+	var sent []string
+	ok := mailer.MailerFunc(func(msg mailer.Message) error {
+		sent = append(sent, msg.To...)
+		return nil
+	})
+	if deliver(ok, &row, now) == false {
+		t.Fatal("Message must be sent")
+	}
+	if row.Attempts != 2 || row.SentAt == nil || row.LastError != nil || len(sent) != 1 {
+		t.Fatal("Sent message must be marked", row)
+	}
+}
+
+func TestDeliverInvalidRecipients(t *testing.T) {
+	row := models.MailOutbox{Recipients: "not json"}
+	called := false
+	m := mailer.MailerFunc(func(mailer.Message) error {
+		called = true
+		return nil
+	})
+	if deliver(m, &row, time.Now()) || called {
+		t.Fatal("Invalid rows must not be sent")
+	}
+}
@@ -0,0 +1,42 @@
+package mailer
+
+import (
+	"errors"
+	"net"
+	"net/smtp"
+	"time"
+)
+
+// SMTPMailer send messages through an SMTP server
+type SMTPMailer struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+
+	// From is used when the message has no sender
+	From string
+}
+
+// Send deliver msg to the SMTP server.
+// PLAIN authentication is used when Username is set.
+func (m SMTPMailer) Send(msg Message) error {
+	if len(msg.To) == 0 {
+		return errors.New("Message has no recipient")
+	}
+	if msg.From == "" {
+		msg.From = m.From
+	}
+
+	body, e := msg.Bytes(time.Now())
+	if e != nil {
+		return e
+	}
+
+	var auth smtp.Auth
+	if m.Username != "" {
+		auth = smtp.PlainAuth("", m.Username, m.Password, m.Host)
+	}
+
+	return smtp.SendMail(net.JoinHostPort(m.Host, m.Port), auth, msg.From, msg.To, body)
+}
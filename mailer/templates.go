@@ -0,0 +1,196 @@
+package mailer
+
+import (
+	"bytes"
+	html_template "html/template"
+	"strings"
+	text_template "text/template"
+	"time"
+)
+
+// VerificationData is the data of email verification message
+type VerificationData struct {
+	Username string
+	Link     string
+}
+
+// PasswordResetData is the data of password reset message
+type PasswordResetData struct {
+	Username  string
+	Link      string
+	ExpiresAt time.Time
+}
+
+// InvitationData is the data of organization invitation message
+type InvitationData struct {
+	Username     string
+	Organization string
+	InvitedBy    string
+	AcceptLink   string
+	RejectLink   string
+}
+
+// NotificationItem is a notification inside notification and digest messages
+type NotificationItem struct {
+	Title   string
+	Message string
+	Link    string
+
+	// Time of the notification, formatted in calendar and time zone of the user
+	Time string
+}
+
+// NotificationData is the data of a single notification message
+type NotificationData struct {
+	Username string
+	Item     NotificationItem
+}
+
+// DigestData is the data of daily digest message
+type DigestData struct {
+	Username string
+
+	// Day of the digest, formatted in calendar of the user
+	Date  string
+	Items []NotificationItem
+}
+
+// mailTemplate keeps subject and bodies of a message.
+// Subject and text body are plain text, html body is escaped by html/template.
+type mailTemplate struct {
+	subject *text_template.Template
+	text    *text_template.Template
+	html    *html_template.Template
+}
+
+func newMailTemplate(name, subject, text, html string) mailTemplate {
+	return mailTemplate{
+		subject: text_template.Must(text_template.New(name + "_subject").Parse(subject)),
+		text:    text_template.Must(text_template.New(name + "_text").Parse(text)),
+		html:    html_template.Must(html_template.New(name + "_html").Parse(html)),
+	}
+}
+
+// render build a message of t to the given recipient
+func (t mailTemplate) render(to string, data interface{}) (msg Message, e error) {
+	var subject, text, html bytes.Buffer
+	if e = t.subject.Execute(&subject, data); e != nil {
+		return
+	}
+	if e = t.text.Execute(&text, data); e != nil {
+		return
+	}
+	if e = t.html.Execute(&html, data); e != nil {
+		return
+	}
+
+	msg = Message{
+		To:      []string{to},
+		Subject: strings.TrimSpace(subject.String()),
+		Text:    text.String(),
+		HTML:    html.String(),
+	}
+
+	return
+}
+
+var verificationTemplate = newMailTemplate("verification",
+	`Verify your email address`,
+	`Hi {{.Username}},
+
+Thanks for signing up! Please verify your email address by opening the link below:
+
+{{.Link}}
+`,
+	`<p>Hi {{.Username}},</p>
+<p>Thanks for signing up! Please verify your email address by clicking the link below:</p>
+<p><a href="{{.Link}}">Verify email address</a></p>
+`)
+
+var passwordResetTemplate = newMailTemplate("password_reset",
+	`Reset your password`,
+	`Hi {{.Username}},
+
+We received a request to reset your password. Open the link below to choose a new one:
+
+{{.Link}}
+
+The link expires at {{.ExpiresAt.Format "2006-01-02 15:04 MST"}}. If you didn't request it, ignore this email.
+`,
+	`<p>Hi {{.Username}},</p>
+<p>We received a request to reset your password. Click the link below to choose a new one:</p>
+<p><a href="{{.Link}}">Reset password</a></p>
+<p>The link expires at {{.ExpiresAt.Format "2006-01-02 15:04 MST"}}. If you didn't request it, ignore this email.</p>
+`)
+
+var invitationTemplate = newMailTemplate("invitation",
+	`{{.InvitedBy}} invited you to join {{.Organization}}`,
+	`Hi {{.Username}},
+
+{{.InvitedBy}} invited you to join {{.Organization}}.
+
+Accept: {{.AcceptLink}}
+Reject: {{.RejectLink}}
+`,
+	`<p>Hi {{.Username}},</p>
+<p>{{.InvitedBy}} invited you to join <b>{{.Organization}}</b>.</p>
+<p><a href="{{.AcceptLink}}">Accept</a> | <a href="{{.RejectLink}}">Reject</a></p>
+`)
+
+var notificationTemplate = newMailTemplate("notification",
+	`{{.Item.Title}}`,
+	`Hi {{.Username}},
+
+{{.Item.Title}} ({{.Item.Time}})
+{{if .Item.Message}}
+{{.Item.Message}}
+{{end}}
+{{.Item.Link}}
+`,
+	`<p>Hi {{.Username}},</p>
+<p><b>{{.Item.Title}}</b> <small>{{.Item.Time}}</small></p>
+{{if .Item.Message}}<blockquote>{{.Item.Message}}</blockquote>{{end}}
+<p><a href="{{.Item.Link}}">Open</a></p>
+`)
+
+var digestTemplate = newMailTemplate("digest",
+	`Your daily digest of {{.Date}}: {{len .Items}} notifications`,
+	`Hi {{.Username}},
+
+Here is what happened since your last digest:
+{{range .Items}}
+- {{.Title}} ({{.Time}})
+  {{if .Message}}{{.Message}}
+  {{end}}{{.Link}}
+{{end}}`,
+	`<p>Hi {{.Username}},</p>
+<p>Here is what happened since your last digest:</p>
+<ul>
+{{range .Items}}<li><a href="{{.Link}}">{{.Title}}</a> <small>{{.Time}}</small>{{if .Message}}<br>{{.Message}}{{end}}</li>
+{{end}}</ul>
+`)
+
+// VerificationMessage build the email verification message
+func VerificationMessage(to string, data VerificationData) (Message, error) {
+	return verificationTemplate.render(to, data)
+}
+
+// PasswordResetMessage build the password reset message
+func PasswordResetMessage(to string, data PasswordResetData) (Message, error) {
+	return passwordResetTemplate.render(to, data)
+}
+
+// InvitationMessage build the organization invitation message
+func InvitationMessage(to string, data InvitationData) (Message, error) {
+	return invitationTemplate.render(to, data)
+}
+
+// NotificationMessage build the message of a single notification
+func NotificationMessage(to string, data NotificationData) (Message, error) {
+	return notificationTemplate.render(to, data)
+}
+
+// DigestMessage build the daily digest message
+func DigestMessage(to string, data DigestData) (Message, error) {
+	return digestTemplate.render(to, data)
+}
@@ -3,10 +3,14 @@ package main
 import (
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/gorilla/mux"
 
+	"devin/crypto"
 	"devin/routes"
 )
 
@@ -15,6 +19,11 @@ func Init() {
 }
 
 func main() {
+	if e := crypto.LoadKeys(); e != nil {
+		log.Fatal("Fail to load keys, ", e)
+	}
+	go reloadKeysOnHangup()
+
 	r := mux.NewRouter()
 
 	u := r.PathPrefix("/api").Subrouter().StrictSlash(true)
@@ -31,3 +40,16 @@ func main() {
 
 	log.Fatal(srv.ListenAndServe())
 }
+
+// reloadKeysOnHangup load keys again on SIGHUP, after `keys:rotate` command
+func reloadKeysOnHangup() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+	for range signals {
+		if e := crypto.LoadKeys(); e != nil {
+			log.Println("Fail to reload keys, previous keys are kept,", e)
+			continue
+		}
+		log.Println("Keys reloaded")
+	}
+}
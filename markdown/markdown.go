@@ -0,0 +1,115 @@
+// Package markdown render markdown texts of wiki pages, issues, tasks, milestones
+// and their comments to sanitized HTML. References like #123 (issue), !45 (task)
+// and @username are converted to links when the resolver knows them.
+// Rendering is done on server side, so every client shows texts the same way.
+package markdown
+
+import (
+	"bytes"
+	"regexp"
+
+	"github.com/microcosm-cc/bluemonday"
+	"gopkg.in/russross/blackfriday.v2"
+)
+
+// Resolver find links of referenced objects.
+// The second return value is false for unknown or inaccessible objects, they are left as text.
+type Resolver interface {
+	IssueURL(id uint64) (string, bool)
+	TaskURL(id uint64) (string, bool)
+	UserURL(username string) (string, bool)
+}
+
+const extensions = blackfriday.CommonExtensions | blackfriday.HardLineBreak
+
+const htmlFlags = blackfriday.UseXHTML | blackfriday.Smartypants | blackfriday.SmartypantsDashes |
+	blackfriday.NofollowLinks | blackfriday.Safelink
+
+// policy remove scripts, event handlers, styles and unsafe urls from rendered HTML
+var policy = newPolicy()
+
+func newPolicy() *bluemonday.Policy {
+	p := bluemonday.UGCPolicy()
+	p.AllowAttrs("class").Matching(regexp.MustCompile(`^language-[a-zA-Z0-9_+-]+$`)).OnElements("code")
+	p.RequireNoFollowOnLinks(true)
+
+	return p
+}
+
+func parse(source string) *blackfriday.Node {
+	return blackfriday.New(blackfriday.WithExtensions(extensions)).Parse([]byte(source))
+}
+
+// Render convert markdown source to sanitized HTML.
+// resolver may be nil, then references are not linked.
+func Render(source string, resolver Resolver) string {
+	if source == "" {
+		return ""
+	}
+
+	ast := parse(source)
+	if resolver != nil {
+		linkReferences(ast, resolver)
+	}
+
+	renderer := blackfriday.NewHTMLRenderer(blackfriday.HTMLRendererParameters{Flags: htmlFlags})
+	var buf bytes.Buffer
+	ast.Walk(func(node *blackfriday.Node, entering bool) blackfriday.WalkStatus {
+		return renderer.RenderNode(&buf, node, entering)
+	})
+
+	return string(policy.SanitizeBytes(buf.Bytes()))
+}
+
+// RenderPtr render a nullable markdown source. nil is returned for nil sources.
+func RenderPtr(source *string, resolver Resolver) *string {
+	if source == nil {
+		return nil
+	}
+	html := Render(*source, resolver)
+
+	return &html
+}
+
+// linkReferences replace known references in text nodes of the tree with link nodes
+func linkReferences(ast *blackfriday.Node, resolver Resolver) {
+	for _, node := range textNodes(ast) {
+		text := node.Literal
+		pos := 0
+		for _, ref := range findReferences(string(text)) {
+			url, ok := resolve(resolver, ref)
+			if ok == false {
+				continue
+			}
+
+			if ref.start > pos {
+				before := blackfriday.NewNode(blackfriday.Text)
+				before.Literal = text[pos:ref.start]
+				node.InsertBefore(before)
+			}
+
+			label := blackfriday.NewNode(blackfriday.Text)
+			label.Literal = text[ref.start:ref.end]
+			link := blackfriday.NewNode(blackfriday.Link)
+			link.LinkData.Destination = []byte(url)
+			link.AppendChild(label)
+			node.InsertBefore(link)
+
+			pos = ref.end
+		}
+		node.Literal = text[pos:]
+	}
+}
+
+func resolve(resolver Resolver, ref Reference) (string, bool) {
+	switch ref.Kind {
+	case RefIssue:
+		return resolver.IssueURL(ref.ID)
+	case RefTask:
+		return resolver.TaskURL(ref.ID)
+	case RefUser:
+		return resolver.UserURL(ref.Username)
+	}
+
+	return "", false
+}
@@ -0,0 +1,96 @@
+package markdown
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+type testResolver struct{}
+
+func (testResolver) IssueURL(id uint64) (string, bool) {
+	return fmt.Sprintf("/issue/%d", id), id < 100
+}
+
+func (testResolver) TaskURL(id uint64) (string, bool) {
+	return fmt.Sprintf("/task/%d", id), true
+}
+
+func (testResolver) UserURL(username string) (string, bool) {
+	return "/user/" + username, username != "stranger"
+}
+
+func TestRender(t *testing.T) {
+	html := Render("# Runbook\n\nRestart the **worker**.", nil)
+	if strings.Contains(html, "<h1") == false || strings.Contains(html, "<strong>worker</strong>") == false {
+		t.Fatal("Markdown is not rendered", html)
+	}
+
+	if Render("", nil) != "" {
+		t.Fatal("Empty source must be rendered as empty string")
+	}
+	if RenderPtr(nil, nil) != nil {
+		t.Fatal("Nil source must be rendered as nil")
+	}
+}
+
+func TestRenderSanitize(t *testing.T) {
+	sources := []string{
+		"<script>alert(1)</script>",
+		"<img src=x onerror=alert(1)>",
+		"[click](javascript:alert(1))",
+		"<a href=\"javascript:alert(1)\">click</a>",
+		"<div style=\"background:url(javascript:alert(1))\">x</div>",
+		"<iframe src=\"https://evil.com\"></iframe>",
+	}
+
+	for _, source := range sources {
+		html := strings.ToLower(Render(source, nil))
+		for _, bad := range []string{"<script", "onerror", "javascript:", "style=", "<iframe"} {
+			if strings.Contains(html, bad) {
+				t.Fatalf("Unsafe html %q rendered from %q", html, source)
+			}
+		}
+	}
+}
+
+func TestRenderReferences(t *testing.T) {
+	html := Render("Fixed #12 and !45, see #500. Thanks @alice and @stranger!", testResolver{})
+
+	for _, expected := range []string{
+		`<a href="/issue/12" rel="nofollow">#12</a>`,
+		`<a href="/task/45" rel="nofollow">!45</a>`,
+		`<a href="/user/alice" rel="nofollow">@alice</a>`,
+		"#500",
+		"@stranger",
+	} {
+		if strings.Contains(html, expected) == false {
+			t.Fatalf("%q not found in %q", expected, html)
+		}
+	}
+	if strings.Contains(html, "/issue/500") || strings.Contains(html, "/user/stranger") {
+		t.Fatal("Unknown references must not be linked", html)
+	}
+}
+
+func TestRenderSkipReferencesInCode(t *testing.T) {
+	html := Render("`#12` [see #13](http://x.io)\n\n    !45\n\nmail me at bob@alice.com or a#12", testResolver{})
+	if strings.Contains(html, "/issue/") || strings.Contains(html, "/task/") || strings.Contains(html, "/user/") {
+		t.Fatal("References in code, links and words must not be linked", html)
+	}
+}
+
+func TestReferences(t *testing.T) {
+	refs := References("#1 #1 !2 @Alice, @bob. `@carol` @ab x@dave")
+	if len(refs) != 4 {
+		t.Fatal("Unexpected references", refs)
+	}
+	if refs[0].Kind != RefIssue || refs[0].ID != 1 || refs[1].Kind != RefTask || refs[1].ID != 2 {
+		t.Fatal("Unexpected references", refs)
+	}
+
+	usernames := Usernames("@Alice, @bob. `@carol` @ab x@dave")
+	if len(usernames) != 2 || usernames[0] != "alice" || usernames[1] != "bob" {
+		t.Fatal("Unexpected usernames", usernames)
+	}
+}
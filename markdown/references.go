@@ -0,0 +1,144 @@
+package markdown
+
+import (
+	"strconv"
+	"strings"
+
+	"gopkg.in/russross/blackfriday.v2"
+)
+
+// Kinds of references
+const (
+	RefIssue = iota + 1
+	RefTask
+	RefUser
+)
+
+// Reference is a cross-reference found in text: #123 for issues,
+// !45 for tasks and @username for users
+type Reference struct {
+	Kind     int
+	ID       uint64
+	Username string
+
+	// Position of the reference in its text node
+	start, end int
+}
+
+// Key of the reference, unique for each referenced object
+func (ref Reference) key() string {
+	if ref.Kind == RefUser {
+		return "@" + ref.Username
+	}
+
+	return strconv.Itoa(ref.Kind) + ":" + strconv.FormatUint(ref.ID, 10)
+}
+
+// References return issues, tasks and users referenced in the markdown source.
+// References inside code, links and raw html are ignored. Each object is returned once.
+func References(source string) (refs []Reference) {
+	seen := make(map[string]bool)
+	for _, node := range textNodes(parse(source)) {
+		for _, ref := range findReferences(string(node.Literal)) {
+			if seen[ref.key()] {
+				continue
+			}
+			seen[ref.key()] = true
+			refs = append(refs, ref)
+		}
+	}
+
+	return
+}
+
+// Usernames return usernames mentioned in the markdown source
+func Usernames(source string) (usernames []string) {
+	for _, ref := range References(source) {
+		if ref.Kind == RefUser {
+			usernames = append(usernames, ref.Username)
+		}
+	}
+
+	return
+}
+
+// textNodes collect text nodes of the tree which may contain references.
+// Adjacent text nodes are merged, the parser may split text on special characters.
+func textNodes(ast *blackfriday.Node) (nodes []*blackfriday.Node) {
+	var lastText *blackfriday.Node
+	ast.Walk(func(node *blackfriday.Node, entering bool) blackfriday.WalkStatus {
+		if entering == false {
+			return blackfriday.GoToNext
+		}
+
+		switch node.Type {
+		case blackfriday.Link, blackfriday.Image, blackfriday.Code, blackfriday.CodeBlock,
+			blackfriday.HTMLSpan, blackfriday.HTMLBlock:
+			return blackfriday.SkipChildren
+		case blackfriday.Text:
+			if node.Prev != nil && node.Prev == lastText {
+				merged := nodes[len(nodes)-1]
+				merged.Literal = append(merged.Literal, node.Literal...)
+				node.Literal = nil
+			} else {
+				nodes = append(nodes, node)
+			}
+			lastText = node
+		}
+
+		return blackfriday.GoToNext
+	})
+
+	return
+}
+
+// findReferences scan text for references. A reference must not be preceded
+// by a word character, so email addresses and anchors like a#1 are skipped.
+func findReferences(text string) (refs []Reference) {
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		if c != '#' && c != '!' && c != '@' {
+			continue
+		}
+		if i > 0 && (isWordChar(text[i-1]) || text[i-1] == '&' || text[i-1] == '/') {
+			continue
+		}
+
+		j := i + 1
+		for j < len(text) && isWordChar(text[j]) {
+			j++
+		}
+		word := text[i+1 : j]
+		if word == "" {
+			continue
+		}
+
+		ref := Reference{start: i, end: j}
+		if c == '@' {
+			if len(word) < 3 || len(word) > 100 {
+				continue
+			}
+			ref.Kind = RefUser
+			ref.Username = strings.ToLower(word)
+		} else {
+			id, e := strconv.ParseUint(word, 10, 64)
+			if e != nil || id == 0 {
+				continue
+			}
+			ref.Kind = RefIssue
+			if c == '!' {
+				ref.Kind = RefTask
+			}
+			ref.ID = id
+		}
+		refs = append(refs, ref)
+		i = j - 1
+	}
+
+	return
+}
+
+// isWordChar check c to be a character of usernames and numbers
+func isWordChar(c byte) bool {
+	return c == '_' || (c >= '0' && c <= '9') || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
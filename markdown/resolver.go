@@ -0,0 +1,71 @@
+package markdown
+
+import (
+	"fmt"
+
+	"github.com/jinzhu/gorm"
+
+	"devin/models"
+	project_repo "devin/modules/project/repository"
+)
+
+// ProjectResolver resolve references inside texts of a project.
+// Issues and tasks must belong to the project and users must be its members.
+// Lookups are cached, so a resolver should be used for a single request.
+type ProjectResolver struct {
+	db      *gorm.DB
+	project models.Project
+	issues  map[uint64]bool
+	tasks   map[uint64]bool
+	users   map[string]bool
+}
+
+// NewProjectResolver create a resolver for texts of the project
+func NewProjectResolver(db *gorm.DB, project models.Project) *ProjectResolver {
+	return &ProjectResolver{
+		db:      db,
+		project: project,
+		issues:  make(map[uint64]bool),
+		tasks:   make(map[uint64]bool),
+		users:   make(map[string]bool),
+	}
+}
+
+// IssueURL return link of an issue of the project
+func (r *ProjectResolver) IssueURL(id uint64) (string, bool) {
+	exists, ok := r.issues[id]
+	if ok == false {
+		var count uint64
+		r.db.Model(&models.Issue{}).Where("id=? AND project_id=?", id, r.project.ID).Count(&count)
+		exists = count > 0
+		r.issues[id] = exists
+	}
+
+	return fmt.Sprintf("/project/%d/issue/%d", r.project.ID, id), exists
+}
+
+// TaskURL return link of a task of the project
+func (r *ProjectResolver) TaskURL(id uint64) (string, bool) {
+	exists, ok := r.tasks[id]
+	if ok == false {
+		var count uint64
+		r.db.Model(&models.Task{}).Where("id=? AND project_id=?", id, r.project.ID).Count(&count)
+		exists = count > 0
+		r.tasks[id] = exists
+	}
+
+	return fmt.Sprintf("/project/%d/task/%d", r.project.ID, id), exists
+}
+
+// UserURL return profile link of a member of the project
+func (r *ProjectResolver) UserURL(username string) (string, bool) {
+	member, ok := r.users[username]
+	if ok == false {
+		var user models.User
+		r.db.Model(&user).Where("username=?", username).First(&user)
+		member = user.ID != 0 && project_repo.IsProjectMember(r.db, r.project, user.ID)
+		r.users[username] = member
+	}
+
+	return "/user/" + username, member
+}
@@ -13,10 +13,20 @@ import (
 	"devin/database"
 	"devin/helpers"
 	"devin/models"
+	"devin/modules/access_token"
 )
 
 func Authenticate(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Personal access tokens and JWTs may be sent as `Authorization: Bearer <token>`
+		if token := access_token.BearerToken(r); token != "" {
+			if access_token.IsAccessToken(token) {
+				authenticateAccessToken(w, r, next, token)
+				return
+			}
+			r.Header.Set("Authorization", token)
+		}
+
 		// check exist token
 		_, ok := r.Header["Authorization"]
 		if !ok {
@@ -36,9 +46,7 @@ func Authenticate(next http.Handler) http.Handler {
 		}
 
 		// validate the token
-		token, err := jwt_request.ParseFromRequestWithClaims(r, jwt_request.HeaderExtractor{"Authorization"}, &models.Claim{}, func(token *jwt.Token) (interface{}, error) {
-			return crypto.GetJWTVerifyKey()
-		})
+		token, err := jwt_request.ParseFromRequestWithClaims(r, jwt_request.HeaderExtractor{"Authorization"}, &models.Claim{}, crypto.GetJWTVerifyKey)
 
 		if err != nil {
 			switch err.(type) {
@@ -94,6 +102,18 @@ func Authenticate(next http.Handler) http.Handler {
 		}
 		var user models.User
 		claim := token.Claims.(*models.Claim)
+		if claim.MFAPending {
+			models.User{}.ExpireAuthorizationCookie(w)
+
+			err := helpers.ErrorResponse{}
+			err.ErrorCode = http.StatusUnauthorized
+			err.Message = "Auhtentication failed (Two-factor authentication pending)."
+			log.Println("Auhtentication failed, two-factor authentication pending")
+			helpers.NewErrorResponse(w, &err)
+
+			return
+		}
+
 		authUser, e := user.ExtractUserFromClaimPayload(claim.Payload)
 
 		if e != nil {
@@ -124,27 +144,45 @@ func Authenticate(next http.Handler) http.Handler {
 			return
 		}
 
-		// Check token remaining lifetime
-		if float64(claim.ExpiresAt-time.Now().Unix())/user.TokenLifetime().Seconds() < .25 {
-			log.Println("Token life time less than 0.25 of total lifetime")
+		// Tokens of revoked or expired sessions are rejected. Tokens without session,
+		// issued before sessions were added, are accepted until they expire.
+		if claim.SessionID != 0 {
+			session, e := models.GetActiveSession(db, claim.SessionID, user.ID)
+			if e != nil {
+				models.User{}.ExpireAuthorizationCookie(w)
+
+				err := helpers.ErrorResponse{}
+				err.ErrorCode = http.StatusUnauthorized
+				err.Message = "Auhtentication failed (Session revoked)."
+				log.Println("Auhtentication failed,", e)
+				helpers.NewErrorResponse(w, &err)
 
-			claim := user.GenerateNewTokenClaim()
-			tokenString, err := user.GenerateNewTokenString(claim)
-			if err != nil {
-				helpers.NewErrorResponse(w, err)
 				return
 			}
-			user.SetAuthorizationCookieAndHeader(w, tokenString)
+			session.Touch(db, time.Now())
+		}
 
-			ctx := context.WithValue(r.Context(), "Authorization", &claim)
+		// Access tokens are not re-issued, clients refresh them with the refresh token of the session
+		user.SetAuthorizationCookieAndHeader(w, token.Raw)
+		ctx := context.WithValue(r.Context(), "Authorization", claim)
 
-			next.ServeHTTP(w, r.WithContext(ctx))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
 
-		} else {
-			user.SetAuthorizationCookieAndHeader(w, token.Raw)
-			ctx := context.WithValue(r.Context(), "Authorization", claim)
+// authenticateAccessToken authenticate the request with a personal access token.
+// Endpoints out of the scopes of the token are forbidden and no cookie is set.
+func authenticateAccessToken(w http.ResponseWriter, r *http.Request, next http.Handler, tokenString string) {
+	db := database.NewGORMInstance()
+	defer db.Close()
 
-			next.ServeHTTP(w, r.WithContext(ctx))
-		}
-	})
+	_, claim, err := access_token.Authenticate(db, r, tokenString)
+	if err != nil {
+		helpers.NewErrorResponse(w, err)
+		return
+	}
+
+	ctx := context.WithValue(r.Context(), "Authorization", &claim)
+
+	next.ServeHTTP(w, r.WithContext(ctx))
 }
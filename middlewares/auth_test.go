@@ -195,6 +195,38 @@ func TestAuthenticate(t *testing.T) {
 
 	})
 
+	t.Run("Revoked_Session", func(t *testing.T) {
+		user := createValidUser()
+		db := database.NewGORMInstance()
+		defer db.Exec("delete from public.users where username='success_token'")
+		defer db.Close()
+
+		now := time.Now()
+		session := models.Session{UserID: user.ID, ExpiresAt: now.Add(time.Hour), LastSeenAt: now, RevokedAt: &now}
+		db.Create(&session)
+
+		claim := user.GenerateNewSessionTokenClaim(session)
+		tokenString, _ := user.GenerateNewTokenString(claim)
+		req, e := http.NewRequest("GET", server.URL, nil)
+		if e != nil {
+			t.Fatal(e)
+		}
+		req.Header.Add("Authorization", tokenString)
+
+		client := http.Client{}
+		res, e := client.Do(req)
+		if e != nil {
+			t.Fatal(e)
+		}
+		defer res.Body.Close()
+		bts, _ := ioutil.ReadAll(res.Body)
+		t.Log(string(bts))
+
+		if !strings.Contains(string(bts), "Auhtentication failed (Session revoked)") {
+			t.Fatal("Response dose not match")
+		}
+	})
+
 	t.Run("OK", func(t *testing.T) {
 		user := createValidUser()
 		db := database.NewGORMInstance()
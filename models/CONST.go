@@ -8,4 +8,8 @@ const (
 	MODULE_SPENT_TIME    = 5
 	MODULE_ISSUE_TRACKER = 6
 	MODULE_BUG_TRACKER   = 7
+	MODULE_USER          = 8
+	MODULE_ORGANIZATION  = 9
+	MODULE_WIKI          = 10
+	MODULE_BILLING       = 11
 )
@@ -0,0 +1,119 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+// Scopes of personal access tokens, write scopes include read scope of the same resource
+const (
+	SCOPE_READ_USER      = "read:user"
+	SCOPE_WRITE_USER     = "write:user"
+	SCOPE_READ_ORG       = "read:org"
+	SCOPE_ADMIN_ORG      = "admin:org"
+	SCOPE_READ_PROJECTS  = "read:projects"
+	SCOPE_WRITE_PROJECTS = "write:projects"
+	SCOPE_READ_TASKS     = "read:tasks"
+	SCOPE_WRITE_TASKS    = "write:tasks"
+)
+
+// Scopes is the list of valid scopes of personal access tokens
+var Scopes = []string{
+	SCOPE_READ_USER,
+	SCOPE_WRITE_USER,
+	SCOPE_READ_ORG,
+	SCOPE_ADMIN_ORG,
+	SCOPE_READ_PROJECTS,
+	SCOPE_WRITE_PROJECTS,
+	SCOPE_READ_TASKS,
+	SCOPE_WRITE_TASKS,
+}
+
+// impliedScopes maps each scope to the read scope it includes
+var impliedScopes = map[string]string{
+	SCOPE_WRITE_USER:     SCOPE_READ_USER,
+	SCOPE_ADMIN_ORG:      SCOPE_READ_ORG,
+	SCOPE_WRITE_PROJECTS: SCOPE_READ_PROJECTS,
+	SCOPE_WRITE_TASKS:    SCOPE_READ_TASKS,
+}
+
+// ErrAccessTokenRevoked returned for personal access tokens which are revoked, expired or unknown
+var ErrAccessTokenRevoked = errors.New("Personal access token is revoked or expired")
+
+// AccessToken is a personal access token of a user for scripts and CI.
+// It is sent as `Authorization: Bearer <token>` and only allows endpoints of its scopes.
+type AccessToken struct {
+	tableName struct{} `sql:"public.access_tokens"`
+	ID        uint64
+	UserID    uint64
+	Name      string
+
+	// SHA-256 hash of the token, the token itself is only shown on creation
+	TokenHash string `json:"-"`
+
+	// First characters of the token, to recognize it in the list
+	TokenPrefix string
+
+	Scopes     string `doc:"Space separated list of models.SCOPE_*"`
+	ExpiresAt  time.Time
+	LastUsedAt *time.Time
+	LastUsedIP string
+	RevokedAt  *time.Time
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+// IsActive check the token to be neither revoked nor expired
+func (token AccessToken) IsActive(now time.Time) bool {
+	return token.ID != 0 && token.RevokedAt == nil && token.ExpiresAt.After(now)
+}
+
+// HasScope check the scopes of the token to include the scope
+func (token AccessToken) HasScope(scope string) bool {
+	return HasScope(token.Scopes, scope)
+}
+
+// GetActiveAccessToken load the token of the hash and check it to be active
+func GetActiveAccessToken(db *gorm.DB, hash string) (token AccessToken, e error) {
+	db.Where("token_hash=?", hash).First(&token)
+	if token.IsActive(time.Now()) == false {
+		e = ErrAccessTokenRevoked
+	}
+
+	return
+}
+
+// Touch update last used time and IP of the token, at most once a minute
+func (token AccessToken) Touch(db *gorm.DB, now time.Time, ip string) error {
+	if token.LastUsedAt != nil && now.Sub(*token.LastUsedAt) < time.Minute && token.LastUsedIP == ip {
+		return nil
+	}
+
+	return db.Model(&AccessToken{}).Where("id=?", token.ID).
+		UpdateColumns(map[string]interface{}{"last_used_at": now, "last_used_ip": ip}).Error
+}
+
+// HasScope check the space separated scopes to include the scope directly or by a write scope
+func HasScope(scopes, scope string) bool {
+	for _, s := range strings.Fields(scopes) {
+		if s == scope || impliedScopes[s] == scope {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsValidScope check the scope to be one of models.SCOPE_*
+func IsValidScope(scope string) bool {
+	for _, s := range Scopes {
+		if s == scope {
+			return true
+		}
+	}
+
+	return false
+}
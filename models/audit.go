@@ -1,5 +1,54 @@
 package models
 
+import "time"
+
+// Actions recorded in audit log
+const (
+	AUDIT_ACTION_PROFILE_UPDATE      = "user.profile.update"
+	AUDIT_ACTION_USERNAME_UPDATE     = "user.username.update"
+	AUDIT_ACTION_EMAIL_UPDATE        = "user.email.update"
+	AUDIT_ACTION_AVATAR_UPDATE       = "user.avatar.update"
+	AUDIT_ACTION_PASSWORD_UPDATE     = "user.password.update"
+	AUDIT_ACTION_PASSWORD_RESET      = "user.password.reset"
+	AUDIT_ACTION_PERMISSIONS_UPDATE  = "organization.permissions.update"
+	AUDIT_ACTION_INVITATION_CREATE   = "organization.invitation.create"
+	AUDIT_ACTION_INVITATION_ACCEPT   = "organization.invitation.accept"
+	AUDIT_ACTION_INVITATION_REJECT   = "organization.invitation.reject"
+	AUDIT_ACTION_PROJECT_CREATE      = "project.create"
+	AUDIT_ACTION_PROJECT_UPDATE      = "project.update"
+	AUDIT_ACTION_MEMBER_ADD          = "project.member.add"
+	AUDIT_ACTION_MEMBER_UPDATE       = "project.member.update"
+	AUDIT_ACTION_MEMBER_REMOVE       = "project.member.remove"
+	AUDIT_ACTION_OBJECT_GRANT        = "object_permission.grant"
+	AUDIT_ACTION_OBJECT_REVOKE       = "object_permission.revoke"
+	AUDIT_ACTION_ROLE_CREATE         = "role.create"
+	AUDIT_ACTION_ROLE_UPDATE         = "role.update"
+	AUDIT_ACTION_ROLE_DELETE         = "role.delete"
+	AUDIT_ACTION_ROLE_ASSIGN         = "role.assign"
+	AUDIT_ACTION_ROLE_UNASSIGN       = "role.unassign"
+	AUDIT_ACTION_TWO_FACTOR_ENABLE   = "user.two_factor.enable"
+	AUDIT_ACTION_TWO_FACTOR_DISABLE  = "user.two_factor.disable"
+	AUDIT_ACTION_TWO_FACTOR_REQUIRE  = "organization.two_factor.require"
+	AUDIT_ACTION_ACCESS_TOKEN_CREATE = "user.access_token.create"
+	AUDIT_ACTION_ACCESS_TOKEN_REVOKE = "user.access_token.revoke"
+)
+
+// Audit is a record of a change made by a user
 type Audit struct {
-	ID uint64
+	tableName struct{} `sql:"public.audits"`
+	ID        uint64
+	ActorID   *uint64 `doc:"Null when the actor is deleted"`
+	Actor     *User
+
+	// Organization which the changed object belongs to, used to show audit log to admins of organization
+	OrganizationID *uint64
+
+	Action   string `doc:"From this list: models.AUDIT_ACTION_*"`
+	ModuleID uint   `doc:"From this list: models.MODULE_*"`
+	ObjectID uint64
+	Changes  string `doc:"A jsonb object of changed fields, each field as {Before, After}"`
+
+	IP        string
+	UserAgent string
+	CreatedAt time.Time
 }
@@ -0,0 +1,60 @@
+package models
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+
+	"devin/helpers"
+)
+
+// AuditSearch is model to filter audit log
+type AuditSearch struct {
+	// Required for users who are not root
+	OrganizationID *uint64
+
+	// Changes made by this user
+	UserID *uint64
+
+	ModuleID *uint
+	ObjectID *uint64
+	Action   *string
+
+	// Changes made in [From, To) are included
+	From *time.Time
+	To   *time.Time
+
+	// =-=-=-=-=-=-=-=-=-=
+	// Pagination options
+	// =-=-=-=-=-=-=-=-=-=
+
+	CurrentPage uint64 `json:"-"`
+	PerPage     uint64 `json:"-"`
+}
+
+// GetWhereClause generate where clause using given filters
+func (search *AuditSearch) GetWhereClause(db *gorm.DB) *gorm.DB {
+	if helpers.IsNilUint64(search.OrganizationID) == false {
+		db = db.Where("organization_id = ?", *search.OrganizationID)
+	}
+	if helpers.IsNilUint64(search.UserID) == false {
+		db = db.Where("actor_id = ?", *search.UserID)
+	}
+	if search.ModuleID != nil {
+		db = db.Where("module_id = ?", *search.ModuleID)
+	}
+	if helpers.IsNilUint64(search.ObjectID) == false {
+		db = db.Where("object_id = ?", *search.ObjectID)
+	}
+	if helpers.IsNilOrEmptyString(search.Action) == false {
+		db = db.Where("action = ?", *search.Action)
+	}
+	if search.From != nil {
+		db = db.Where("created_at >= ?", *search.From)
+	}
+	if search.To != nil {
+		db = db.Where("created_at < ?", *search.To)
+	}
+
+	return db
+}
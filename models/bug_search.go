@@ -0,0 +1,32 @@
+package models
+
+import (
+	"github.com/jinzhu/gorm"
+
+	"devin/helpers"
+)
+
+// BugSearch is model to performe search on issues promoted to the bug tracker.
+// All filters of IssueSearch are accepted too.
+type BugSearch struct {
+	IssueSearch
+
+	Severities      []uint
+	AffectedVersion *string
+}
+
+// GetWhereClause generate where clause using given filters.
+// Only issues having a bug label are matched.
+func (search *BugSearch) GetWhereClause(db *gorm.DB) *gorm.DB {
+	db = search.IssueSearch.GetWhereClause(db)
+	db = db.Where("id IN (" + bugIssueIDsSQL + ")")
+
+	if len(search.Severities) > 0 {
+		db = db.Where("id IN (SELECT issue_id FROM issue_bug_details WHERE severity IN (?))", search.Severities)
+	}
+	if helpers.IsNilOrEmptyString(search.AffectedVersion) == false {
+		db = db.Where("id IN (SELECT issue_id FROM issue_bug_details WHERE affected_version = ?)", *search.AffectedVersion)
+	}
+
+	return db
+}
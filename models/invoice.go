@@ -0,0 +1,50 @@
+package models
+
+import "time"
+
+// Status of invoices
+const (
+	INVOICE_STATUS_DRAFT     = 1
+	INVOICE_STATUS_FINALIZED = 2
+)
+
+// Invoice is the bill of unbilled billable spent times of a project in a date range.
+// Amounts are in the smallest unit of the currency, durations are in nanoseconds.
+type Invoice struct {
+	tableName     struct{} `sql:"public.invoices"`
+	ID            uint64
+	ProjectID     uint64
+	Project       *Project `json:",omitempty"`
+	StatusID      uint     `doc:"1=Draft ; 2=Finalized"`
+	FromDate      time.Time
+	ToDate        time.Time
+	Currency      string
+	TotalDuration time.Duration
+	TotalAmount   int64
+	Items         []InvoiceItem
+	FinalizedAt   *time.Time
+	FinalizedByID *uint64
+	CreatedByID   uint64
+	CreatedBy     *User `json:",omitempty"`
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	DeletedAt     *time.Time
+}
+
+// InvoiceItem is a billed spent time in an invoice
+type InvoiceItem struct {
+	tableName       struct{} `sql:"public.invoice_items"`
+	ID              uint64
+	InvoiceID       uint64
+	TaskSpentTimeID uint64
+	TaskSpentTime   *TaskSpentTime `json:",omitempty"`
+	UserID          uint64
+	TaskID          uint64
+	Description     string
+	StartDate       time.Time
+	Duration        time.Duration
+	HourlyRate      int64
+	Amount          int64
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+}
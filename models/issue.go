@@ -2,25 +2,58 @@ package models
 
 import "time"
 
+// Status IDs of issues, seeded in issue_statuses table
+const (
+	ISSUE_STATUS_PENDING     uint = 1
+	ISSUE_STATUS_IN_PROGRESS uint = 2
+	ISSUE_STATUS_CLOSED      uint = 3
+)
+
 type Issue struct {
-	tableName               struct{} `sql:"public.issues"`
-	ID                      uint64
-	ProjectID               uint64
-	Project                 *Project
-	RepositoryID            uint64 `doc:"nullable column"`
-	Repository              *Repository
-	Message                 string
+	tableName    struct{} `sql:"public.issues"`
+	ID           uint64
+	ProjectID    uint64
+	Project      *Project
+	RepositoryID *uint64 `doc:"nullable column"`
+	Repository   *Repository
+	Title        string
+	Message      string
+	MessageHTML  string `sql:"-" doc:"Rendered markdown of Message"`
+
+	// Set for issues filed by anonymous users, when public issues are allowed
+	ReporterEmail *string
+
 	AttachmentFilePath      string
-	Labels                  []*IssueLabel
+	Labels                  []*IssueLabel `gorm:"many2many:labeled_issues"`
 	IssueAssignments        []*IssueAssignment
 	StatusID                uint
 	Status                  *IssueStatus
-	SetAsInProgressDateTime *time.Time
-	SetAsResolvedDateTime   *time.Time
+	SetAsInProgressDateTime *time.Time `gorm:"column:set_as_in_progress_datetime"`
+	SetAsResolvedDateTime   *time.Time `gorm:"column:set_as_resolved_datetime"`
 	Comments                []*IssueComment
-	CreatedByID             uint64
-	CreatedBy               *User
-	CreatedAt               time.Time
-	UpdatedAt               time.Time
-	DeletedAt               *time.Time
+
+	// Set when the issue is promoted to the bug tracker
+	BugDetail *IssueBugDetail `gorm:"ForeignKey:IssueID"`
+
+	CreatedByID *uint64 `doc:"nullable for anonymous issues"`
+	CreatedBy   *User
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	DeletedAt   *time.Time
+}
+
+// bugIssueIDsSQL select IDs of issues having a bug label
+const bugIssueIDsSQL = `SELECT li.issue_id FROM labeled_issues li
+        INNER JOIN issue_labels l ON l.id=li.issue_label_id
+        WHERE l.is_bug_label=true AND l.deleted_at IS NULL`
+
+// IsBug check the issue to have a bug label, Labels must be loaded
+func (issue Issue) IsBug() bool {
+	for _, label := range issue.Labels {
+		if label != nil && label.IsBugLable {
+			return true
+		}
+	}
+
+	return false
 }
@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// Severities of bugs
+const (
+	BUG_SEVERITY_LOW      uint = 1
+	BUG_SEVERITY_NORMAL   uint = 2
+	BUG_SEVERITY_HIGH     uint = 3
+	BUG_SEVERITY_CRITICAL uint = 4
+)
+
+// IssueBugDetail keeps bug tracker fields of an issue.
+// It is created automatically when a bug label is attached to the issue.
+type IssueBugDetail struct {
+	tableName         struct{} `sql:"public.issue_bug_details"`
+	ID                uint64
+	IssueID           uint64 `json:"-"`
+	Severity          uint
+	ReproductionSteps *string
+	AffectedVersion   *string
+	PromotedAt        time.Time
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+}
@@ -7,9 +7,10 @@ type IssueComment struct {
 	ID             uint64
 	IssueID        uint64
 	Issue          *Issue
-	ReplyToID      uint64
+	ReplyToID      *uint64
 	ReplyTo        *IssueComment
 	Comment        string
+	CommentHTML    string `sql:"-" doc:"Rendered markdown of Comment"`
 	AttachmentPath string
 	CreatedByID    uint64
 	CreatedBy      *User
@@ -9,7 +9,7 @@ type IssueLabel struct {
 	Color       string
 	ProjectID   uint64
 	Project     *Project
-	IsBugLable  bool `doc:"زمانیکه لیبل های یک پروژه برای بخش مسایل ایجاد میشود اگر این مقدار ۱ باشد یعنی این مسئله مطرح شده یک باگ است و به صورت خودکار به بخش باگ ها نیز ارجاع میشود"`
+	IsBugLable  bool `gorm:"column:is_bug_label" doc:"زمانیکه لیبل های یک پروژه برای بخش مسایل ایجاد میشود اگر این مقدار ۱ باشد یعنی این مسئله مطرح شده یک باگ است و به صورت خودکار به بخش باگ ها نیز ارجاع میشود"`
 	CreatedByID uint64
 	CreatedBy   *User
 	CreatedAt   time.Time
@@ -0,0 +1,67 @@
+package models
+
+import (
+	"github.com/jinzhu/gorm"
+
+	"devin/helpers"
+)
+
+// IssueSearch is model to performe search on Issue model
+type IssueSearch struct {
+	ID *uint64
+
+	// Search in title and message of issues
+	Query *string
+
+	// Always set from URL, search is limited to issues of a single project
+	ProjectID uint64 `json:"-"`
+
+	StatusIDs []uint
+
+	// Issues having any of these labels
+	LabelIDs []uint64
+
+	// Search on issues assigned to this user
+	AssignedToID *uint64
+
+	CreatedByID *uint64
+
+	// Set when the user can't view bugs, issues having a bug label are not matched
+	ExcludeBugs bool `json:"-"`
+
+	// =-=-=-=-=-=-=-=-=-=
+	// Pagination options
+	// =-=-=-=-=-=-=-=-=-=
+
+	CurrentPage uint64
+	PerPage     uint64
+}
+
+// GetWhereClause generate where clause using given filters
+func (search *IssueSearch) GetWhereClause(db *gorm.DB) *gorm.DB {
+	db = db.Where("project_id = ?", search.ProjectID)
+
+	if helpers.IsNilUint64(search.ID) == false {
+		db = db.Where("id = ?", *search.ID)
+	}
+	if helpers.IsNilOrEmptyString(search.Query) == false {
+		db = db.Where("(title ILIKE ? OR message ILIKE ?)", "%"+*search.Query+"%", "%"+*search.Query+"%")
+	}
+	if len(search.StatusIDs) > 0 {
+		db = db.Where("status_id IN (?)", search.StatusIDs)
+	}
+	if len(search.LabelIDs) > 0 {
+		db = db.Where("id IN (SELECT issue_id FROM labeled_issues WHERE issue_label_id IN (?))", search.LabelIDs)
+	}
+	if helpers.IsNilUint64(search.AssignedToID) == false {
+		db = db.Where("id IN (SELECT issue_id FROM issue_assignments WHERE user_id = ?)", *search.AssignedToID)
+	}
+	if helpers.IsNilUint64(search.CreatedByID) == false {
+		db = db.Where("created_by_id = ?", *search.CreatedByID)
+	}
+	if search.ExcludeBugs {
+		db = db.Where("id NOT IN (" + bugIssueIDsSQL + ")")
+	}
+
+	return db
+}
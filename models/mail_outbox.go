@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// MailOutbox is an email waiting to be sent by the mail worker
+type MailOutbox struct {
+	ID            uint64
+	Sender        string
+	Recipients    string `doc:"A jsonb array of email addresses"`
+	Subject       string
+	TextBody      string
+	HTMLBody      string
+	Attempts      uint
+	LastError     *string
+	NextAttemptAt time.Time
+	SentAt        *time.Time
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+func (MailOutbox) TableName() string {
+	return "public.mail_outbox"
+}
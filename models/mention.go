@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// Mention is a @username reference to a project member inside a comment.
+// ModuleID tells which kind of object is commented (task, milestone or issue).
+type Mention struct {
+	tableName   struct{} `sql:"public.mentions"`
+	ID          uint64
+	ModuleID    uint
+	ObjectID    uint64 `doc:"ID of the commented task, milestone or issue"`
+	CommentID   uint64
+	UserID      uint64 `doc:"Mentioned user"`
+	User        *User
+	CreatedByID uint64
+	CreatedBy   *User
+	CreatedAt   time.Time
+}
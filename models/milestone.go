@@ -8,8 +8,11 @@ type Milestone struct {
 	tableName        struct{} `sql:"public.milestones"`
 	ID               uint64
 	Name             string
+	ProjectID        uint64 `doc:"Every milestone belongs to a project"`
+	Project          *Project
 	DueDate          time.Time                   `doc:"تاریخ دستیابی به هدف"`
 	Description      string                      `doc:"Full description about the milestone"`
+	DescriptionHTML  string                      `sql:"-" doc:"Rendered markdown of Description"`
 	ResponsibleUsers []*MilestoneResponsibleUser ``
 	Followers        []*MilestoneFollower        ``
 	Tags             []*TaggedObject             `doc:"A HasMany relation, where ModuleID = models.MODULE_MILESTONE"`
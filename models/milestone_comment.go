@@ -7,9 +7,10 @@ type MilestoneComment struct {
 	ID             uint64
 	MilestoneID    uint64
 	Milestone      *Milestone
-	ReplyToID      uint64
+	ReplyToID      *uint64
 	ReplyTo        *MilestoneComment
 	Comment        string
+	CommentHTML    string `sql:"-" doc:"Rendered markdown of Comment"`
 	AttachmentPath string
 	CreatedByID    uint64
 	CreatedBy      *User
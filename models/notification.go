@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// Types of notifications, they are the events of notification preferences
+const (
+	NOTIFICATION_TYPE_ASSIGNMENT = NOTIFICATION_EVENT_ASSIGNMENT
+	NOTIFICATION_TYPE_MENTION    = NOTIFICATION_EVENT_MENTION
+	NOTIFICATION_TYPE_COMMENT    = NOTIFICATION_EVENT_COMMENT
+	NOTIFICATION_TYPE_REMINDER   = NOTIFICATION_EVENT_REMINDER
+	NOTIFICATION_TYPE_INVITATION = NOTIFICATION_EVENT_INVITATION
+)
+
+// Notification is an in-app notification of a user about an event in a module object,
+// or about an invitation to an organization
+type Notification struct {
+	tableName    struct{} `sql:"public.notifications"`
+	ID           uint64
+	UserID       uint64 `doc:"Receiver of the notification"`
+	User         *User
+	ActorID      *uint64 `doc:"User who caused the event, nullable"`
+	Actor        *User
+	Type         string
+	ProjectID    *uint64
+	Project      *Project
+	ModuleID     *uint   `doc:"Module of the object, null for invitations"`
+	ObjectID     *uint64 `doc:"ID of the task, milestone or issue, null for invitations"`
+	CommentID    *uint64
+	InvitationID *uint64
+	Invitation   *UserOrganizationInvitation
+	Message      string
+	ReadAt       *time.Time
+	CreatedAt    time.Time
+}
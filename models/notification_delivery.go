@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// NotificationDelivery is a notification waiting to be sent through email digest or webhook
+type NotificationDelivery struct {
+	tableName     struct{} `sql:"public.notification_deliveries"`
+	ID            uint64
+	UserID        uint64
+	User          *User
+	Channel       string
+	Event         string
+	Digest        bool   `doc:"Sent in the daily digest of the user instead of instantly"`
+	Payload       string `doc:"Json encoded notification"`
+	Attempts      uint
+	LastError     *string
+	NextAttemptAt time.Time
+	SentAt        *time.Time
+	CreatedAt     time.Time
+}
@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+// Events which users are notified about
+const (
+	NOTIFICATION_EVENT_ASSIGNMENT = "assignment"
+	NOTIFICATION_EVENT_MENTION    = "mention"
+	NOTIFICATION_EVENT_COMMENT    = "comment"
+	NOTIFICATION_EVENT_REMINDER   = "reminder"
+	NOTIFICATION_EVENT_INVITATION = "invitation"
+)
+
+// Channels which notifications are delivered through
+const (
+	NOTIFICATION_CHANNEL_IN_APP  = "in-app"
+	NOTIFICATION_CHANNEL_EMAIL   = "email"
+	NOTIFICATION_CHANNEL_WEBHOOK = "webhook"
+)
+
+// Delivery modes of a channel
+const (
+	NOTIFICATION_MODE_INSTANT = "instant"
+	NOTIFICATION_MODE_DIGEST  = "digest"
+	NOTIFICATION_MODE_OFF     = "off"
+)
+
+// NotificationPreference is the delivery mode which user chose for an event on a channel.
+// Events without a stored preference use the default mode of the channel.
+type NotificationPreference struct {
+	tableName struct{} `sql:"public.notification_preferences"`
+	ID        uint64
+	UserID    uint64 `json:"-"`
+	Event     string
+	Channel   string
+	Mode      string
+	CreatedAt time.Time `json:"-"`
+	UpdatedAt time.Time `json:"-"`
+}
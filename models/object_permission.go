@@ -10,9 +10,10 @@ type ObjectPermission struct {
 	ID          uint64
 	UserID      uint64
 	User        *User
-	ModuleID    uint `doc:"From this list: models.MODULE_PROJECT, models.MODULE_TASK, models.MODULE_MILESTONE, models.MODULE_REPOSITORY, models.MODULE_SPENT_TIME, models.MODULE_ISSUE_TRACKER, models.MODULE_BUG_TRACKER"`
+	ModuleID    uint `doc:"From this list: models.MODULE_PROJECT, models.MODULE_TASK, models.MODULE_MILESTONE, models.MODULE_REPOSITORY, models.MODULE_SPENT_TIME, models.MODULE_ISSUE_TRACKER, models.MODULE_BUG_TRACKER, models.MODULE_WIKI"`
 	ObjectID    uint64
-	CanRead     bool `doc:"Permission to view in list and its details"`
+	ProjectID   *uint64 `doc:"Project which the object belongs to"`
+	CanRead     bool    `doc:"Permission to view in list and its details"`
 	CanUpdate   bool
 	CanDelete   bool
 	CreatedByID uint64
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// ProjectHourlyRate is the price of an hour of work in a project.
+// A record with nil UserID is the default rate of the project,
+// other records override the default rate for a single user.
+type ProjectHourlyRate struct {
+	tableName   struct{} `sql:"public.project_hourly_rates"`
+	ID          uint64
+	ProjectID   uint64
+	Project     *Project `json:",omitempty"`
+	UserID      *uint64
+	User        *User  `json:",omitempty"`
+	HourlyRate  int64  `doc:"In the smallest unit of the currency, e.g. cents"`
+	Currency    string `doc:"ISO 4217 currency code"`
+	CreatedByID uint64
+	CreatedBy   *User `json:",omitempty"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	DeletedAt   *time.Time
+}
@@ -18,7 +18,8 @@ type ProjectSearch struct {
 	StatusIDs []uint
 
 	// If UserID != nil, then search for projects with OwnerUserID and all project members
-	// to find projects which this user is its owner or its member
+	// to find projects which this user is its owner or its member.
+	// Projects of other users are limited to the ones visible to the searcher.
 	UserID *uint64
 
 	// =-=-=-=-=-=-=-=-=-=
@@ -35,13 +36,13 @@ type ProjectSearch struct {
 // GetWhereClause generate where clause using given filters
 func (search *ProjectSearch) GetWhereClause(db *gorm.DB) *gorm.DB {
 	if helpers.IsNilUint64(search.ID) == false {
-		db.Where("id = ? ", search.ID)
+		db = db.Where("id = ? ", search.ID)
 	}
 	if helpers.IsNilOrEmptyString(search.Name) == false {
-		db = db.Where("name LIKE ", "%"+*search.Name+"%")
+		db = db.Where("name LIKE ?", "%"+*search.Name+"%")
 	}
 	if helpers.IsNilOrEmptyString(search.Title) == false {
-		db = db.Where("title LIKE ", "%"+*search.Title+"%")
+		db = db.Where("title LIKE ?", "%"+*search.Title+"%")
 	}
 
 	if helpers.IsNilUint64(search.OrganizationID) == false {
@@ -49,11 +50,7 @@ func (search *ProjectSearch) GetWhereClause(db *gorm.DB) *gorm.DB {
 	}
 
 	if len(search.StatusIDs) > 0 {
-		var statusesInterface []interface{}
-		for _, v := range search.StatusIDs {
-			statusesInterface = append(statusesInterface, v)
-		}
-		db = db.Where("staus_id IN ?", statusesInterface...)
+		db = db.Where("status_id IN (?)", search.StatusIDs)
 	}
 
 	return db
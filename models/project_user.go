@@ -4,6 +4,8 @@ import (
 	"time"
 )
 
+// ProjectUser is membership of a user in a project.
+// Permission flags are replaced by built-in roles assigned on the project, see models.RoleAssignment.
 type ProjectUser struct {
 	tableName               struct{} `sql:"project_users"`
 	ID                      uint64
@@ -13,8 +15,8 @@ type ProjectUser struct {
 	Project                 *Project
 	CreatedByID             uint64 `doc:"Who add this user to this project?"`
 	CreatedBy               *User
-	IsAdmin                 bool `doc:"Full access to all project features"`
-	CanUpdateProjectProfile bool `doc:"امکان ویرایش اطلاعات پروژه"`
+	IsAdmin                 bool `doc:"Full access to all project features" gorm:"column:id_admin"`
+	CanUpdateProjectProfile bool `doc:"امکان ویرایش اطلاعات پروژه" gorm:"column:can_update_prject_profile"`
 	CanAddUserToProject     bool
 	CanCreateMilestone      bool
 	CanCreateTaskList       bool
@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+// Role is a named set of permission keys of an organization.
+// Built-in roles have no organization and are available in all organizations.
+type Role struct {
+	tableName      struct{} `sql:"public.roles"`
+	ID             uint64
+	OrganizationID *uint64 `doc:"Null for built-in roles"`
+	Name           string
+	Description    string
+	IsBuiltIn      bool `doc:"Built-in roles can't be changed or deleted"`
+	Permissions    []RolePermission
+	CreatedByID    *uint64
+	CreatedBy      *User
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+// RolePermission is a permission key of a role
+type RolePermission struct {
+	tableName struct{} `sql:"public.role_permissions"`
+	ID        uint64
+	RoleID    uint64
+	Key       string `doc:"From this list: authorizer.Key*"`
+}
+
+// RoleAssignment give a role to a member of organization, on the whole organization or on one of its projects
+type RoleAssignment struct {
+	tableName      struct{} `sql:"public.role_assignments"`
+	ID             uint64
+	RoleID         uint64
+	Role           *Role
+	UserID         uint64
+	User           *User
+	OrganizationID *uint64 `doc:"Set for assignments on the whole organization, null for assignments on a project"`
+	ProjectID      *uint64 `doc:"Set for assignments on a project, null for assignments on the whole organization"`
+	CreatedByID    *uint64
+	CreatedBy      *User
+	CreatedAt      time.Time
+}
@@ -0,0 +1,106 @@
+package models
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+// ErrSessionRevoked returned for access and refresh tokens of revoked or expired sessions
+var ErrSessionRevoked = errors.New("Session revoked, sign in again")
+
+// Session is a signin of a user on a device.
+// Access tokens of the session are accepted until the session is revoked or expired,
+// its refresh token rotates on each use.
+type Session struct {
+	tableName struct{} `sql:"public.sessions"`
+	ID        uint64
+	UserID    uint64
+	User      *User
+
+	// SHA-256 hash of the secret part of the current refresh token
+	RefreshTokenHash string `json:"-"`
+
+	// Hash of the previous refresh token. Reusing it means the token is stolen and revokes the session.
+	PreviousTokenHash *string `json:"-"`
+
+	Device     string `doc:"Browser and OS detected from user agent, e.g 'Firefox on Linux'"`
+	UserAgent  string
+	IP         string
+	LastSeenAt time.Time
+	ExpiresAt  time.Time `doc:"Expiration time of the refresh token, extended on each refresh"`
+	RevokedAt  *time.Time
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+
+	// Is this the session of the current request
+	Current bool `sql:"-"`
+}
+
+// RefreshTokenLifetime get the max time between two refreshes of a session
+func (Session) RefreshTokenLifetime() time.Duration {
+	return 30 * 24 * time.Hour
+}
+
+// SetRefreshTokenCookieAndHeader set `RefreshToken` cookie and `Refresh-Token` header.
+// The cookie is only sent to the refresh endpoint.
+func (session Session) SetRefreshTokenCookieAndHeader(w http.ResponseWriter, value string) {
+	cookie := &http.Cookie{}
+	cookie.Name = "RefreshToken"
+	// cookie.Secure = true
+	cookie.Value = value
+	cookie.HttpOnly = true
+	cookie.Expires = session.ExpiresAt
+	cookie.Path = "/api/token/refresh"
+	http.SetCookie(w, cookie)
+	w.Header().Add("Refresh-Token", value)
+}
+
+// ExpireRefreshTokenCookie expire `RefreshToken` cookie if exists
+func (session Session) ExpireRefreshTokenCookie(w http.ResponseWriter) {
+	cookie := &http.Cookie{}
+	cookie.Name = "RefreshToken"
+	// cookie.Secure = true
+	cookie.Value = ""
+	cookie.HttpOnly = true
+	cookie.Expires = time.Now().Add(-10 * time.Hour)
+	cookie.Path = "/api/token/refresh"
+	http.SetCookie(w, cookie)
+}
+
+// IsActive check the session to be neither revoked nor expired
+func (session Session) IsActive(now time.Time) bool {
+	return session.ID != 0 && session.RevokedAt == nil && session.ExpiresAt.After(now)
+}
+
+// GetActiveSession load the session of user and check it to be active
+func GetActiveSession(db *gorm.DB, sessionID, userID uint64) (session Session, e error) {
+	db.Where("id=? AND user_id=?", sessionID, userID).First(&session)
+	if session.IsActive(time.Now()) == false {
+		e = ErrSessionRevoked
+	}
+
+	return
+}
+
+// Touch update last seen time of the session, at most once a minute
+func (session Session) Touch(db *gorm.DB, now time.Time) error {
+	if now.Sub(session.LastSeenAt) < time.Minute {
+		return nil
+	}
+
+	return db.Model(&Session{}).Where("id=?", session.ID).UpdateColumn("last_seen_at", now).Error
+}
+
+// CurrentSessionID get ID of the session of the authenticated request,
+// zero if the request is not authenticated or its token has no session
+func CurrentSessionID(r *http.Request) uint64 {
+	claim, ok := r.Context().Value("Authorization").(*Claim)
+	if ok == false {
+		return 0
+	}
+
+	return claim.SessionID
+}
@@ -8,8 +8,11 @@ type Task struct {
 	tableName               struct{} `sql:"public.tasks"`
 	ID                      uint64
 	Title                   string
+	ProjectID               uint64 `doc:"Every task belongs to a project"`
+	Project                 *Project
 	OrderID                 uint `doc:"شماره ترتیب قرارگیری در لیست"`
 	Description             *string
+	DescriptionHTML         *string `sql:"-" doc:"Rendered markdown of Description"`
 	ScheduledStartDate      *time.Time
 	ScheduledCompletionDate *time.Time
 	StartDate               *time.Time
@@ -21,11 +24,13 @@ type Task struct {
 	FontColor               *string
 	BackgroundColor         *string
 	Progress                *int
-	EstimatedTime           *time.Duration
+	EstimatedTime           *time.Duration `doc:"Stored as nanoseconds in a bigint column"`
 	Followers               []TaskFollower
 	PrerequisiteTasks       []TaskPrerequisite
 	Reminders               []TaskReminder
-	TaskBoardID             *uint
+	TaskListID              *uint64 `doc:"Task lists are attached to milestones"`
+	TaskList                *TaskList
+	TaskBoardID             *uint64
 	TaskBoard               *TaskBoard
 	Tags                    []TaggedObject `doc:"A HasMany relation, where ModuleID = models.MODULE_TASK"`
 	SpentTimes              []TaskSpentTime
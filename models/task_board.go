@@ -9,6 +9,8 @@ type TaskBoard struct {
 	ProjectID   uint64
 	Project     *Project
 	Color       string
+	OrderID     uint   `doc:"Position of the column in the board view"`
+	Tasks       []Task `sql:"-" doc:"Tasks of this column sorted by OrderID, loaded manually"`
 	CreatedByID uint64
 	CreatedBy   *User
 	CreatedAt   time.Time
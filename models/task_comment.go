@@ -7,9 +7,10 @@ type TaskComment struct {
 	ID             uint64
 	TaskID         uint64
 	Task           *Task
-	ReplyToID      uint64
+	ReplyToID      *uint64
 	ReplyTo        *TaskComment
 	Comment        string
+	CommentHTML    string `sql:"-" doc:"Rendered markdown of Comment"`
 	AttachmentPath string
 	CreatedByID    uint64
 	CreatedBy      *User
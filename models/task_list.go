@@ -9,6 +9,8 @@ type TaskList struct {
 	ID           uint64
 	Name         string
 	Description  string
+	ProjectID    uint64
+	Project      *Project
 	AllowedUsers []*TaskListUser
 	MilestoneID  uint64 `doc:"This task is belong to which milestone"`
 	Milestone    *Milestone
@@ -11,7 +11,9 @@ type TaskReminder struct {
 	Task              *Task
 	Title             string
 	RemindeOn         time.Time
-	ReminderReceivers []*TaskReminderReceiver
+	ReminderReceivers []*TaskReminderReceiver `gorm:"ForeignKey:ReminderID"`
+	ProcessedAt       *time.Time              `doc:"Set when delivery to all receivers is finished (delivered or failed)"`
+	ClaimedUntil      *time.Time              `doc:"Set while a scheduler delivers the reminder, other schedulers skip it until this time"`
 	CreatedByID       uint64
 	CreatedBy         *User
 	CreatedAt         time.Time
@@ -10,6 +10,10 @@ type TaskReminderReceiver struct {
 	UserID            uint64
 	User              *User
 	NotificationTypes string `doc:"A jsonb feild. Allowed types are : sms, email, in-app, telegram"`
+	DeliveredAt       *time.Time
+	DeliveredTypes    *string `doc:"A jsonb feild. Notification types which are delivered, they are skipped on retries"`
+	Attempts          uint
+	LastError         *string
 	CreatedByID       uint64
 	CreatedBy         *User
 	CreatedAt         time.Time
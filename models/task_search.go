@@ -0,0 +1,62 @@
+package models
+
+import (
+	"github.com/jinzhu/gorm"
+
+	"devin/helpers"
+)
+
+// TaskSearch is model to performe search on Task model
+type TaskSearch struct {
+	ID    *uint64
+	Title *string
+
+	// Always set from URL, search is limited to tasks of a single project
+	ProjectID uint64 `json:"-"`
+
+	TaskBoardID *uint64
+	PriorityIDs []uint
+
+	// Search on tasks assigned to this user
+	AssignedToID *uint64
+
+	// nil = all tasks, true = completed tasks, false = open tasks
+	Completed *bool
+
+	// =-=-=-=-=-=-=-=-=-=
+	// Pagination options
+	// =-=-=-=-=-=-=-=-=-=
+
+	CurrentPage uint64
+	PerPage     uint64
+}
+
+// GetWhereClause generate where clause using given filters
+func (search *TaskSearch) GetWhereClause(db *gorm.DB) *gorm.DB {
+	db = db.Where("project_id = ?", search.ProjectID)
+
+	if helpers.IsNilUint64(search.ID) == false {
+		db = db.Where("id = ?", *search.ID)
+	}
+	if helpers.IsNilOrEmptyString(search.Title) == false {
+		db = db.Where("title ILIKE ?", "%"+*search.Title+"%")
+	}
+	if helpers.IsNilUint64(search.TaskBoardID) == false {
+		db = db.Where("task_board_id = ?", *search.TaskBoardID)
+	}
+	if len(search.PriorityIDs) > 0 {
+		db = db.Where("priority_id IN (?)", search.PriorityIDs)
+	}
+	if helpers.IsNilUint64(search.AssignedToID) == false {
+		db = db.Where("id IN (SELECT task_id FROM task_assignments WHERE user_id = ?)", *search.AssignedToID)
+	}
+	if search.Completed != nil {
+		if *search.Completed {
+			db = db.Where("completion_date IS NOT NULL")
+		} else {
+			db = db.Where("completion_date IS NULL")
+		}
+	}
+
+	return db
+}
@@ -10,8 +10,8 @@ type TaskSpentTime struct {
 	TaskID      uint64
 	Task        *Task
 	StartDate   time.Time
-	EndDate     *time.Time
-	IsBillable  bool
+	EndDate     *time.Time `doc:"Null while the timer is running"`
+	IsBillable  bool       `gorm:"column:is_billabel"`
 	IsBilled    bool
 	Description string
 	CreatedByID uint64
@@ -0,0 +1,55 @@
+package models
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+
+	"devin/helpers"
+)
+
+// TimesheetSearch is model to filter spent times of a project for timesheet report
+type TimesheetSearch struct {
+	// Always set from URL, report is limited to spent times of a single project
+	ProjectID uint64 `json:"-"`
+
+	// Users without CanListAllTimeLogs permission always get their own entries
+	UserID *uint64
+	TaskID *uint64
+
+	// Entries started in [From, To) are included
+	From *time.Time
+	To   *time.Time
+
+	// nil = all entries, true = billable entries, false = non-billable entries
+	IsBillable *bool
+
+	// One of: user, task, day, week
+	GroupBy string
+
+	// IANA time zone name used for day and week grouping. Default is UTC
+	TimeZone string
+}
+
+// GetWhereClause generate where clause using given filters
+func (search *TimesheetSearch) GetWhereClause(db *gorm.DB) *gorm.DB {
+	db = db.Where("task_id IN (SELECT id FROM tasks WHERE project_id = ? AND deleted_at IS NULL)", search.ProjectID)
+
+	if helpers.IsNilUint64(search.UserID) == false {
+		db = db.Where("spent_by_id = ?", *search.UserID)
+	}
+	if helpers.IsNilUint64(search.TaskID) == false {
+		db = db.Where("task_id = ?", *search.TaskID)
+	}
+	if search.From != nil {
+		db = db.Where("start_date >= ?", *search.From)
+	}
+	if search.To != nil {
+		db = db.Where("start_date < ?", *search.To)
+	}
+	if search.IsBillable != nil {
+		db = db.Where("is_billabel = ?", *search.IsBillable)
+	}
+
+	return db
+}
@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+// TwoFactorSettings store TOTP second factor of user and the requirement of organizations
+type TwoFactorSettings struct {
+	// TOTP secret encrypted with crypto.Encrypt, set on enrollment
+	TwoFactorSecret *string
+
+	// Time of verifying the first code, the second factor is checked on signin after it
+	TwoFactorEnabledAt *time.Time
+
+	// Last accepted time step, codes of this step and older steps are rejected to prevent replay
+	TwoFactorLastStep int64
+
+	// Failed codes in a row, further codes are rejected for a while after too many failures
+	TwoFactorFailures uint
+	TwoFactorFailedAt *time.Time
+
+	// Used for users of type organization, members must enable two-factor authentication to sign in
+	RequireTwoFactor bool
+}
+
+// IsTwoFactorEnabled check the user to have a verified TOTP second factor
+func (settings TwoFactorSettings) IsTwoFactorEnabled() bool {
+	return settings.TwoFactorSecret != nil && settings.TwoFactorEnabledAt != nil
+}
+
+// RecoveryCode is a one-time code to sign in when TOTP device of user is lost
+type RecoveryCode struct {
+	tableName struct{} `sql:"public.recovery_codes"`
+	ID        uint64
+	UserID    uint64
+
+	// SHA-256 hash of the normalized code
+	CodeHash  string `json:"-"`
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
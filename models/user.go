@@ -52,6 +52,12 @@ type User struct {
 	DeletedAt              *time.Time `json:"-"`
 
 	PublicProfile
+
+	// Private to the user, returned by notification preferences API
+	NotificationSettings `json:"-"`
+
+	// Private to the user, returned by two-factor status API
+	TwoFactorSettings `json:"-"`
 }
 
 // PublicProfile store data about profile of user or organization
@@ -127,6 +133,22 @@ type PublicProfile struct {
 	Website *string
 }
 
+// NotificationSettings store delivery settings of notifications of user.
+// Preferences of each event and channel are stored in notification_preferences table.
+type NotificationSettings struct {
+	// IANA time zone name e.g 'Asia/Tehran', used to send the daily digest in local time
+	Timezone *string
+
+	// Local time of the daily digest in 15:04 format
+	DigestTime string
+
+	// URL which receives webhook notifications as json POST requests
+	WebhookURL *string
+
+	// Time of the last sent digest
+	LastDigestAt *time.Time
+}
+
 func (User) TableName() string {
 	return "public.users"
 }
@@ -147,12 +169,13 @@ func (user *User) SetNewEmailVerificationToken() {
 
 // CookieLifetime get the max time of Authorization cookie.
 func (user User) CookieLifetime() time.Duration {
-	return 13 * time.Hour
+	return user.TokenLifetime()
 }
 
-// TokenLifetime get the max time of Authorization token
+// TokenLifetime get the max time of Authorization token.
+// Access tokens are short-lived, clients get a new one with the refresh token of their session.
 func (user User) TokenLifetime() time.Duration {
-	return 13 * time.Hour
+	return 15 * time.Minute
 }
 
 // SetAuthorizationCookie set a cookie with `Authorization` name
@@ -176,11 +199,13 @@ func (user User) ExpireAuthorizationCookie(w http.ResponseWriter) {
 	cookie.Value = ""
 	cookie.HttpOnly = true
 	cookie.Expires = time.Now().Add(-10 * time.Hour)
+	cookie.Path = "/"
 	http.SetCookie(w, cookie)
 }
 
 func (user User) ExtractUserFromRequestContext(r *http.Request) (User, *Claim, error) {
 	var clm *Claim
+	parsed := false
 	if r.Context().Value("Authorization") == nil {
 		_, ok := r.Header["Authorization"]
 		if !ok {
@@ -191,19 +216,18 @@ func (user User) ExtractUserFromRequestContext(r *http.Request) (User, *Claim, e
 			r.Header.Set("Authorization", cookie.Value)
 		}
 
-		token, err := jwt_request.ParseFromRequestWithClaims(r, jwt_request.HeaderExtractor{"Authorization"}, &Claim{}, func(token *jwt.Token) (interface{}, error) {
-			return crypto.GetJWTVerifyKey()
-		})
+		token, err := jwt_request.ParseFromRequestWithClaims(r, jwt_request.HeaderExtractor{"Authorization"}, &Claim{}, crypto.GetJWTVerifyKey)
 		if err != nil || !token.Valid {
 			return User{}, nil, errors.New("Invalid authentication token")
 		}
 
 		clm = token.Claims.(*Claim)
+		parsed = true
 
 	} else {
 		clm = r.Context().Value("Authorization").(*Claim)
 	}
-	jsonString, e := crypto.CBCDecrypter(clm.Payload)
+	jsonString, e := crypto.DecryptWithLegacy(clm.Payload, crypto.PurposeClaimPayload)
 	if e != nil {
 		return User{}, nil, e
 	}
@@ -214,12 +238,24 @@ func (user User) ExtractUserFromRequestContext(r *http.Request) (User, *Claim, e
 	defer db.Close()
 	e = db.Where("id=?", u.ID).First(&dbUser).Error
 
+	if parsed && clm.MFAPending {
+		return User{}, nil, errors.New("Two-factor authentication pending")
+	}
+
+	// Authenticate middleware already checked the session of tokens passed in the context
+	if parsed && clm.SessionID != 0 {
+		_, e = GetActiveSession(db, clm.SessionID, dbUser.ID)
+		if e != nil {
+			return User{}, nil, e
+		}
+	}
+
 	return dbUser, clm, nil
 }
 
 func (user User) ExtractUserFromClaimPayload(payload string) (User, error) {
 
-	jsonString, e := crypto.CBCDecrypter(payload)
+	jsonString, e := crypto.DecryptWithLegacy(payload, crypto.PurposeClaimPayload)
 	if e != nil {
 		return User{}, e
 	}
@@ -239,12 +275,13 @@ func (user User) GenerateNewTokenClaim() Claim {
 	claimPayload.Email = user.Email
 
 	bts, _ := json.Marshal(&claimPayload)
-	payload, _ := crypto.CBCEncrypter(string(bts))
+	payload, _ := crypto.Encrypt(string(bts), crypto.PurposeClaimPayload)
 
 	claim := Claim{
 		Payload: payload,
 		StandardClaims: jwt.StandardClaims{
 			ExpiresAt: time.Now().Add(user.TokenLifetime()).Unix(),
+			IssuedAt:  time.Now().Unix(),
 			Issuer:    "devin",
 		},
 	}
@@ -252,6 +289,29 @@ func (user User) GenerateNewTokenClaim() Claim {
 	return claim
 }
 
+// MFAPendingTokenLifetime get the max time between password and second factor steps of signin
+func (user User) MFAPendingTokenLifetime() time.Duration {
+	return 5 * time.Minute
+}
+
+// GenerateMFAPendingTokenClaim create claim of the token of the first signin step,
+// it is rejected by Authenticate middleware
+func (user User) GenerateMFAPendingTokenClaim() Claim {
+	claim := user.GenerateNewTokenClaimWithCustomLifetime(user.MFAPendingTokenLifetime())
+	claim.MFAPending = true
+
+	return claim
+}
+
+// GenerateNewSessionTokenClaim create claim of an access token bound to the session,
+// the token is rejected as soon as the session is revoked
+func (user User) GenerateNewSessionTokenClaim(session Session) Claim {
+	claim := user.GenerateNewTokenClaim()
+	claim.SessionID = session.ID
+
+	return claim
+}
+
 func (user User) GenerateNewTokenClaimWithCustomLifetime(duration time.Duration) Claim {
 	var claimPayload struct {
 		ID       uint64 `json:"id"`
@@ -263,7 +323,7 @@ func (user User) GenerateNewTokenClaimWithCustomLifetime(duration time.Duration)
 	claimPayload.Email = user.Email
 	bts, _ := json.Marshal(&claimPayload)
 
-	payload, _ := crypto.CBCEncrypter(string(bts))
+	payload, _ := crypto.Encrypt(string(bts), crypto.PurposeClaimPayload)
 	claim := Claim{
 		Payload: payload,
 		StandardClaims: jwt.StandardClaims{
@@ -276,9 +336,7 @@ func (user User) GenerateNewTokenClaimWithCustomLifetime(duration time.Duration)
 }
 
 func (user User) GenerateNewTokenString(claim Claim) (string, *helpers.ErrorResponse) {
-	t := jwt.NewWithClaims(jwt.SigningMethodRS512, claim)
-
-	sk, e := crypto.GetJWTSignKey()
+	kid, sk, e := crypto.GetJWTSignKey()
 	if e != nil {
 		err := helpers.ErrorResponse{
 			Message:   "Internal server error(load jwt)",
@@ -287,6 +345,9 @@ func (user User) GenerateNewTokenString(claim Claim) (string, *helpers.ErrorResp
 
 		return "", &err
 	}
+	// Verifiers select the public key by kid, so keys can rotate while issued tokens are valid
+	t := jwt.NewWithClaims(jwt.SigningMethodRS512, claim)
+	t.Header["kid"] = kid
 	tokenString, err := t.SignedString(sk)
 	if err != nil {
 		err := helpers.ErrorResponse{
@@ -343,5 +404,16 @@ func (user *User) SetFullName() {
 // Claim is claim structure of JWT
 type Claim struct {
 	jwt.StandardClaims
-	Payload string `json:"payload" doc:"Hex string encrypted with AES-256. Decrypted of this string contains id, username and email of user"`
+	Payload    string `json:"payload" doc:"Encrypted with AES-256-GCM (v1.<key id>.<base64url>), old tokens have hex AES-CBC payloads. Decrypted of this string contains id, username and email of user"`
+	SessionID  uint64 `json:"sid,omitempty" doc:"ID of the session of token. Tokens without session are accepted until they expire"`
+	MFAPending bool   `json:"mfa,omitempty" doc:"Token of the first signin step, only accepted to verify the second factor"`
+
+	// Set by Authenticate middleware for requests authenticated with a personal access token, never signed in JWTs
+	AccessTokenID uint64 `json:"-"`
+	Scopes        string `json:"-" doc:"Space separated scopes of the personal access token"`
+}
+
+// IsAccessToken check the claim to be of a personal access token
+func (claim Claim) IsAccessToken() bool {
+	return claim.AccessTokenID != 0
 }
@@ -16,6 +16,9 @@ type UserOrganization struct {
 	OrganizationID *uint64
 	Organization   *User
 
+	// Permission flags below are replaced by built-in roles, see models.RoleAssignment.
+	// They are kept in sync by the permissions endpoint of organization and not checked anymore.
+
 	// If IsAdminOfOrganization equals true , then user will has full access
 	IsAdminOfOrganization bool
 
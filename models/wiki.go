@@ -8,7 +8,7 @@ type Wiki struct {
 	Name         string
 	ProjectID    uint64
 	Project      *Project
-	RepositoryID uint64
+	RepositoryID *uint64
 	Repository   *Repository
 	Pages        []*WikiPage
 	CreatedByID  uint64
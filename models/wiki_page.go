@@ -9,8 +9,13 @@ type WikiPage struct {
 	Wiki        *Wiki
 	Title       string `doc:"A unique title in Wiki"`
 	Content     string
+	ContentHTML string `sql:"-" doc:"Rendered markdown of Content"`
+	Revision    uint   `doc:"Number of the latest revision"`
 	CreatedByID uint64
 	CreatedBy   *User
+	UpdatedByID *uint64
+	UpdatedBy   *User
 	CreatedAt   time.Time
 	UpdatedAt   time.Time
+	DeletedAt   *time.Time
 }
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// WikiPageRevision is an immutable snapshot of a wiki page, stored on every save
+type WikiPageRevision struct {
+	tableName            struct{} `sql:"public.wiki_page_revisions"`
+	ID                   uint64
+	WikiPageID           uint64
+	WikiPage             *WikiPage
+	Revision             uint `doc:"Sequential number of the revision in the page"`
+	Title                string
+	Content              string
+	ContentHTML          string `sql:"-" doc:"Rendered markdown of Content"`
+	Summary              *string
+	RestoredFromRevision *uint
+	LinesAdded           int
+	LinesDeleted         int
+	CreatedByID          uint64
+	CreatedBy            *User
+	CreatedAt            time.Time
+}
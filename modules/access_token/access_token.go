@@ -0,0 +1,227 @@
+// Package access_token generate personal access tokens and map requests to the scopes they need
+package access_token
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/jinzhu/gorm"
+
+	"devin/helpers"
+	"devin/models"
+	"devin/modules/audit"
+)
+
+const (
+	// Prefix of personal access tokens, to tell them from JWTs and find leaked tokens by scanners
+	Prefix = "devin_pat_"
+
+	// secretLength is the number of random bytes of tokens
+	secretLength = 32
+
+	// displayLength is the number of characters of tokens kept to recognize them
+	displayLength = len(Prefix) + 8
+
+	// MaxNameLength is the size of name column
+	MaxNameLength = 100
+
+	// DefaultLifetimeDays is the lifetime of tokens created without expiry
+	DefaultLifetimeDays = 30
+
+	// MaxLifetimeDays is the longest allowed lifetime of tokens
+	MaxLifetimeDays = 365
+)
+
+// ErrInvalidScope returned when a requested scope is not one of models.SCOPE_*
+var ErrInvalidScope = errors.New("Invalid scope")
+
+// deniedSegments are endpoints which are never allowed with personal access tokens,
+// so a leaked token can not take over the account
+var deniedSegments = map[string]bool{
+	"signout":         true,
+	"sessions":        true,
+	"session":         true,
+	"two_factor":      true,
+	"access_tokens":   true,
+	"access_token":    true,
+	"update_email":    true,
+	"update_password": true,
+}
+
+// taskSegments are resources under /project/{id} which need task scopes, others need project scopes
+var taskSegments = map[string]bool{
+	"tasks":     true,
+	"task":      true,
+	"boards":    true,
+	"board":     true,
+	"time_logs": true,
+	"time_log":  true,
+	"timesheet": true,
+}
+
+// NewToken generate a personal access token, hash and prefix of it.
+// Only the hash and the prefix are stored.
+func NewToken() (token, hash, prefix string, e error) {
+	bts := make([]byte, secretLength)
+	_, e = rand.Read(bts)
+	if e != nil {
+		return
+	}
+
+	token = Prefix + hex.EncodeToString(bts)
+	hash = HashToken(token)
+	prefix = token[:displayLength]
+
+	return
+}
+
+// IsAccessToken check the value of Authorization header to be a personal access token
+func IsAccessToken(token string) bool {
+	return strings.HasPrefix(token, Prefix)
+}
+
+// BearerToken get the token of `Authorization: Bearer <token>` header, empty if the header has no Bearer scheme
+func BearerToken(r *http.Request) string {
+	value := r.Header.Get("Authorization")
+	if len(value) < 7 || strings.EqualFold(value[:7], "Bearer ") == false {
+		return ""
+	}
+
+	return strings.TrimSpace(value[7:])
+}
+
+// Authenticate load the user of the personal access token and check the token to have the scope
+// which the request needs. Error response is nil if the request is allowed, the token is touched then.
+// It is shared by the Authenticate middleware and routes which are open to anonymous users.
+func Authenticate(db *gorm.DB, r *http.Request, tokenString string) (models.User, models.Claim, *helpers.ErrorResponse) {
+	var user models.User
+	var claim models.Claim
+
+	token, e := models.GetActiveAccessToken(db, HashToken(tokenString))
+	if e != nil {
+		log.Println("Auhtentication failed,", e)
+		return user, claim, &helpers.ErrorResponse{
+			ErrorCode: http.StatusUnauthorized,
+			Message:   "Auhtentication failed (Invalid or expired access token).",
+		}
+	}
+
+	db.Where("id=?", token.UserID).First(&user)
+	if user.ID == 0 {
+		log.Println("Auhtentication failed, user of access token not found")
+		return user, claim, &helpers.ErrorResponse{
+			ErrorCode: http.StatusUnauthorized,
+			Message:   "Auhtentication failed (User not found).",
+		}
+	}
+
+	scope, allowed := RequiredScope(r.Method, r.URL.Path)
+	if allowed == false {
+		return models.User{}, claim, &helpers.ErrorResponse{
+			ErrorCode: http.StatusForbidden,
+			Message:   "This endpoint is not allowed with personal access tokens.",
+		}
+	}
+	if token.HasScope(scope) == false {
+		return models.User{}, claim, &helpers.ErrorResponse{
+			ErrorCode: http.StatusForbidden,
+			Message:   "Personal access token does not have the required scope: " + scope,
+		}
+	}
+
+	token.Touch(db, time.Now(), audit.ClientIP(r))
+
+	claim = user.GenerateNewTokenClaim()
+	claim.AccessTokenID = token.ID
+	claim.Scopes = token.Scopes
+
+	return user, claim, nil
+}
+
+// HashToken return hex encoded SHA-256 hash of the token
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(strings.TrimSpace(token)))
+	return hex.EncodeToString(sum[:])
+}
+
+// NormalizeScopes validate the scopes and return them space separated without duplicates
+func NormalizeScopes(scopes []string) (string, error) {
+	seen := map[string]bool{}
+	var normalized []string
+	for _, scope := range scopes {
+		scope = strings.TrimSpace(scope)
+		if models.IsValidScope(scope) == false {
+			return "", ErrInvalidScope
+		}
+		if seen[scope] {
+			continue
+		}
+		seen[scope] = true
+		normalized = append(normalized, scope)
+	}
+
+	return strings.Join(normalized, " "), nil
+}
+
+// RequiredScope return the scope which a personal access token needs for the request.
+// It returns false for endpoints which are not allowed with personal access tokens.
+// Path may start with /api, e.g /api/project/1/tasks
+func RequiredScope(method, path string) (string, bool) {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	if segments[0] == "api" {
+		segments = segments[1:]
+	}
+	if len(segments) == 0 {
+		return "", false
+	}
+	for _, segment := range segments {
+		if deniedSegments[segment] {
+			return "", false
+		}
+	}
+
+	read := method == http.MethodGet || method == http.MethodHead
+	if segments[0] == "user" && len(segments) > 2 && segments[2] == "organization" {
+		return models.SCOPE_ADMIN_ORG, true
+	}
+
+	switch segments[0] {
+	case "user", "notifications", "notification":
+		if read {
+			return models.SCOPE_READ_USER, true
+		}
+		return models.SCOPE_WRITE_USER, true
+
+	case "organization", "audits", "invitation":
+		if read {
+			return models.SCOPE_READ_ORG, true
+		}
+		return models.SCOPE_ADMIN_ORG, true
+
+	case "time_logs":
+		if read {
+			return models.SCOPE_READ_TASKS, true
+		}
+		return models.SCOPE_WRITE_TASKS, true
+
+	case "projects", "project":
+		if len(segments) > 2 && taskSegments[segments[2]] {
+			if read {
+				return models.SCOPE_READ_TASKS, true
+			}
+			return models.SCOPE_WRITE_TASKS, true
+		}
+		if read {
+			return models.SCOPE_READ_PROJECTS, true
+		}
+		return models.SCOPE_WRITE_PROJECTS, true
+	}
+
+	return "", false
+}
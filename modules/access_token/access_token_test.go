@@ -0,0 +1,108 @@
+package access_token
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"devin/models"
+)
+
+func TestNewToken(t *testing.T) {
+	token, hash, prefix, e := NewToken()
+	if e != nil {
+		t.Fatal(e)
+	}
+	if IsAccessToken(token) == false || len(token) != len(Prefix)+2*secretLength {
+		t.Fatal("Invalid token", token)
+	}
+	if hash != HashToken(token) || hash == token {
+		t.Fatal("Invalid hash", hash)
+	}
+	if prefix != token[:displayLength] {
+		t.Fatal("Invalid prefix", prefix)
+	}
+
+	other, _, _, _ := NewToken()
+	if other == token {
+		t.Fatal("Tokens must be random")
+	}
+}
+
+func TestNormalizeScopes(t *testing.T) {
+	scopes, e := NormalizeScopes([]string{"read:projects", " write:tasks", "read:projects"})
+	if e != nil || scopes != "read:projects write:tasks" {
+		t.Fatal("Invalid scopes", scopes, e)
+	}
+
+	if _, e = NormalizeScopes([]string{"read:projects", "root"}); e != ErrInvalidScope {
+		t.Fatal("Unknown scope must be rejected")
+	}
+}
+
+func TestRequiredScope(t *testing.T) {
+	cases := []struct {
+		method, path, scope string
+	}{
+		{http.MethodGet, "/api/projects/basic_info", models.SCOPE_READ_PROJECTS},
+		{http.MethodPost, "/api/projects/save", models.SCOPE_WRITE_PROJECTS},
+		{http.MethodPost, "/api/project/1/members/add", models.SCOPE_WRITE_PROJECTS},
+		{http.MethodGet, "/api/project/1/tasks", models.SCOPE_READ_TASKS},
+		{http.MethodPost, "/api/project/1/task/2/comments/add", models.SCOPE_WRITE_TASKS},
+		{http.MethodPost, "/api/time_logs/timer/stop", models.SCOPE_WRITE_TASKS},
+		{http.MethodGet, "/api/organization/list", models.SCOPE_READ_ORG},
+		{http.MethodPost, "/api/organization/1/roles/save", models.SCOPE_ADMIN_ORG},
+		{http.MethodPost, "/api/user/1/organization/save", models.SCOPE_ADMIN_ORG},
+		{http.MethodPost, "/api/user/1/update", models.SCOPE_WRITE_USER},
+		{http.MethodGet, "/notifications", models.SCOPE_READ_USER},
+	}
+	for _, c := range cases {
+		scope, ok := RequiredScope(c.method, c.path)
+		if ok == false || scope != c.scope {
+			t.Fatal("Expected", c.scope, "for", c.method, c.path, "got", scope, ok)
+		}
+	}
+
+	denied := []string{
+		"/api/access_tokens/create",
+		"/api/access_token/1/revoke",
+		"/api/sessions",
+		"/api/signout/everywhere",
+		"/api/two_factor/disable",
+		"/api/user/1/update_password",
+		"/api/user/1/update_email",
+		"/api",
+	}
+	for _, path := range denied {
+		if _, ok := RequiredScope(http.MethodPost, path); ok {
+			t.Fatal("Personal access tokens must not be allowed on", path)
+		}
+	}
+}
+
+func TestHasScope(t *testing.T) {
+	token := models.AccessToken{Scopes: "write:tasks admin:org"}
+	if token.HasScope(models.SCOPE_READ_TASKS) == false || token.HasScope(models.SCOPE_READ_ORG) == false {
+		t.Fatal("Write scopes must include read scopes")
+	}
+	if token.HasScope(models.SCOPE_READ_PROJECTS) || token.HasScope(models.SCOPE_WRITE_PROJECTS) {
+		t.Fatal("Scopes must not include other resources")
+	}
+}
+
+func TestBearerToken(t *testing.T) {
+	cases := map[string]string{
+		"":                         "",
+		"eyJhbGciOi.payload.sig":   "",
+		"Bearer devin_pat_abc":     "devin_pat_abc",
+		"bearer  eyJhbGciOi.p.sig": "eyJhbGciOi.p.sig",
+		"Basic dXNlcjpwYXNz":       "",
+	}
+	for header, expected := range cases {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Authorization", header)
+		if token := BearerToken(req); token != expected {
+			t.Fatal("Expected", expected, "for", header, "got", token)
+		}
+	}
+}
@@ -0,0 +1,240 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"devin/database"
+	"devin/helpers"
+	"devin/models"
+	"devin/modules/access_token"
+	access_token_repo "devin/modules/access_token/repository"
+	"devin/modules/audit"
+	audit_repo "devin/modules/audit/repository"
+	"devin/modules/rw_helpers"
+)
+
+// AccessTokenController handle personal access tokens of users
+type AccessTokenController struct{}
+
+// createReqModel is the request of creating a token, ExpiresInDays defaults to access_token.DefaultLifetimeDays
+type createReqModel struct {
+	Name          string
+	Scopes        []string
+	ExpiresInDays int
+}
+
+// createResponse contains the token, which is only shown once
+type createResponse struct {
+	AccessToken models.AccessToken
+	Token       string
+}
+
+// tokenChanges are audited fields of tokens
+type tokenChanges struct {
+	Name      string
+	Scopes    string
+	ExpiresAt time.Time
+}
+
+func validateCreateReqModel(reqModel *createReqModel) map[string][]string {
+	errs := make(map[string][]string)
+	reqModel.Name = strings.TrimSpace(reqModel.Name)
+	if reqModel.Name == "" {
+		errs["Name"] = []string{"Name is required"}
+	} else if len(reqModel.Name) > access_token.MaxNameLength {
+		errs["Name"] = []string{fmt.Sprintf("Name can't be longer than %d characters", access_token.MaxNameLength)}
+	}
+
+	if len(reqModel.Scopes) == 0 {
+		errs["Scopes"] = []string{"At least one scope is required"}
+	}
+	for _, scope := range reqModel.Scopes {
+		if models.IsValidScope(strings.TrimSpace(scope)) == false {
+			errs["Scopes"] = append(errs["Scopes"], "Invalid scope: "+scope)
+		}
+	}
+
+	if reqModel.ExpiresInDays == 0 {
+		reqModel.ExpiresInDays = access_token.DefaultLifetimeDays
+	} else if reqModel.ExpiresInDays < 0 || reqModel.ExpiresInDays > access_token.MaxLifetimeDays {
+		errs["ExpiresInDays"] = []string{fmt.Sprintf("Expiry must be between 1 and %d days", access_token.MaxLifetimeDays)}
+	}
+
+	return errs
+}
+
+// AccessTokensIndex return personal access tokens of the authenticated user which are not revoked
+// @Route: /api/access_tokens
+// @Method: GET
+func (AccessTokenController) AccessTokensIndex(w http.ResponseWriter, r *http.Request) {
+	authUser, e := rw_helpers.GetAuthenticatedUser(w, r)
+	if e != nil {
+		return
+	}
+
+	db := database.NewGORMInstance()
+	defer db.Close()
+
+	data, e := access_token_repo.GetAccessTokensOfUser(db, authUser.ID)
+	if e != nil {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusInternalServerError,
+			Message:   "Fail to load personal access tokens",
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&data)
+}
+
+// Create create a personal access token of the authenticated user.
+// The token is only returned in this response, just its hash is stored.
+// @Route: /api/access_tokens/create
+// @Method: POST
+// @Content-Type: application/json
+func (AccessTokenController) Create(w http.ResponseWriter, r *http.Request) {
+	if rw_helpers.IsJSONRequest(w, r) == false {
+		return
+	}
+
+	authUser, e := rw_helpers.GetAuthenticatedUser(w, r)
+	if e != nil {
+		return
+	}
+
+	if helpers.IsRequestBodyNil(w, r) {
+		return
+	}
+
+	var reqModel createReqModel
+	e = json.NewDecoder(r.Body).Decode(&reqModel)
+	if e != nil {
+		err := helpers.ErrorResponse{
+			Message:   "Invalid request body",
+			ErrorCode: http.StatusBadRequest,
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+	defer r.Body.Close()
+
+	errs := validateCreateReqModel(&reqModel)
+	if len(errs) > 0 {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusUnprocessableEntity,
+			Message:   "Invalid personal access token",
+			Errors:    errs,
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+
+	scopes, _ := access_token.NormalizeScopes(reqModel.Scopes)
+	token, hash, prefix, e := access_token.NewToken()
+	if e != nil {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusInternalServerError,
+			Message:   "Fail to create personal access token",
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+
+	accessToken := models.AccessToken{
+		UserID:      authUser.ID,
+		Name:        reqModel.Name,
+		TokenHash:   hash,
+		TokenPrefix: prefix,
+		Scopes:      scopes,
+		ExpiresAt:   time.Now().AddDate(0, 0, reqModel.ExpiresInDays),
+	}
+
+	db := database.NewGORMInstance()
+	defer db.Close()
+
+	tx := db.Begin()
+	e = access_token_repo.CreateAccessToken(tx, &accessToken)
+	if e == nil {
+		entry := audit.New(r, authUser, models.AUDIT_ACTION_ACCESS_TOKEN_CREATE, models.MODULE_USER, accessToken.ID)
+		e = audit.SetChanges(&entry, nil, tokenChanges{accessToken.Name, accessToken.Scopes, accessToken.ExpiresAt})
+		if e == nil {
+			e = audit_repo.SaveAudit(tx, &entry)
+		}
+	}
+	if e == nil {
+		e = tx.Commit().Error
+	} else {
+		tx.Rollback()
+	}
+	if e != nil {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusInternalServerError,
+			Message:   "Fail to create personal access token",
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+
+	res := createResponse{AccessToken: accessToken, Token: token}
+	w.Header().Add("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&res)
+}
+
+// Revoke revoke a personal access token of the authenticated user, it is rejected afterward
+// @Route: /api/access_token/{id}/revoke
+// @Method: POST
+func (AccessTokenController) Revoke(w http.ResponseWriter, r *http.Request) {
+	authUser, e := rw_helpers.GetAuthenticatedUser(w, r)
+	if e != nil {
+		return
+	}
+
+	tokenID, e := rw_helpers.ExtractIDFromURL(w, r)
+	if e != nil {
+		return
+	}
+
+	db := database.NewGORMInstance()
+	defer db.Close()
+
+	accessToken, e := access_token_repo.GetAccessTokenOfUser(db, authUser.ID, tokenID)
+	if e != nil {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusNotFound,
+			Message:   "No matching personal access token found!",
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+
+	tx := db.Begin()
+	e = access_token_repo.RevokeAccessToken(tx, accessToken)
+	if e == nil {
+		entry := audit.New(r, authUser, models.AUDIT_ACTION_ACCESS_TOKEN_REVOKE, models.MODULE_USER, accessToken.ID)
+		e = audit.SetChanges(&entry, tokenChanges{accessToken.Name, accessToken.Scopes, accessToken.ExpiresAt}, nil)
+		if e == nil {
+			e = audit_repo.SaveAudit(tx, &entry)
+		}
+	}
+	if e == nil {
+		e = tx.Commit().Error
+	} else {
+		tx.Rollback()
+	}
+	if e != nil {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusInternalServerError,
+			Message:   "Fail to revoke personal access token",
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+
+	helpers.NewSuccessResponse(w, "Personal access token revoked.")
+}
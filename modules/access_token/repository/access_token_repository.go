@@ -0,0 +1,46 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/jinzhu/gorm"
+
+	"devin/models"
+)
+
+// ErrAccessTokenNotFound returned when the token is not found in tokens of the user
+var ErrAccessTokenNotFound = errors.New("Personal access token not found")
+
+// CreateAccessToken insert the token
+func CreateAccessToken(db *gorm.DB, token *models.AccessToken) error {
+	return db.Create(token).Error
+}
+
+// GetAccessTokenOfUser load a token of the user which is not revoked
+func GetAccessTokenOfUser(db *gorm.DB, userID, ID uint64) (token models.AccessToken, e error) {
+	db.Where("id=? AND user_id=? AND revoked_at IS NULL", ID, userID).First(&token)
+	if token.ID == 0 {
+		e = ErrAccessTokenNotFound
+	}
+
+	return
+}
+
+// GetAccessTokensOfUser load tokens of the user which are not revoked, expired ones included, newest first
+func GetAccessTokensOfUser(db *gorm.DB, userID uint64) (data []models.AccessToken, e error) {
+	e = db.Where("user_id=? AND revoked_at IS NULL", userID).
+		Order("created_at DESC, id DESC").
+		Find(&data).
+		Error
+
+	return
+}
+
+// RevokeAccessToken revoke the token, it is rejected afterward
+func RevokeAccessToken(db *gorm.DB, token models.AccessToken) error {
+	return db.Model(&models.AccessToken{}).
+		Where("id=? AND revoked_at IS NULL", token.ID).
+		UpdateColumns(map[string]interface{}{"revoked_at": time.Now(), "updated_at": time.Now()}).
+		Error
+}
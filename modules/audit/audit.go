@@ -0,0 +1,138 @@
+// Package audit create records of changes made by users
+package audit
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"devin/models"
+)
+
+// Redacted replaced values of secret fields in changes
+const Redacted = "[redacted]"
+
+// maxUserAgentLength is the size of user_agent column
+const maxUserAgentLength = 255
+
+// secretFields never written to audit log, only their change is recorded
+var secretFields = map[string]bool{
+	"Password":               true,
+	"EmailVerificationToken": true,
+}
+
+// ignoredFields change on every update and are not useful in audit log
+var ignoredFields = map[string]bool{
+	"UpdatedAt": true,
+}
+
+// Change is the value of a field before and after a change
+type Change struct {
+	Before interface{}
+	After  interface{}
+}
+
+// New create an audit record of the action made by actor through the request
+func New(r *http.Request, actor models.User, action string, moduleID uint, objectID uint64) models.Audit {
+	audit := models.Audit{
+		Action:    action,
+		ModuleID:  moduleID,
+		ObjectID:  objectID,
+		Changes:   "{}",
+		IP:        ClientIP(r),
+		UserAgent: r.UserAgent(),
+	}
+	if actor.ID != 0 {
+		audit.ActorID = &actor.ID
+	}
+	if ua := []rune(audit.UserAgent); len(ua) > maxUserAgentLength {
+		audit.UserAgent = string(ua[:maxUserAgentLength])
+	}
+
+	return audit
+}
+
+// ClientIP return IP address of the client.
+// X-Real-IP is set by the reverse proxy in front of the application, otherwise remote address is used.
+func ClientIP(r *http.Request) string {
+	if ip := strings.TrimSpace(r.Header.Get("X-Real-IP")); net.ParseIP(ip) != nil {
+		return ip
+	}
+
+	host, _, e := net.SplitHostPort(r.RemoteAddr)
+	if e != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
+
+// Diff compare json encoded fields of before and after and return the changed ones.
+// A nil before or after is an object without fields, e.g. before of a created object.
+func Diff(before, after interface{}) (changes map[string]Change, e error) {
+	beforeFields, e := fields(before)
+	if e != nil {
+		return
+	}
+	afterFields, e := fields(after)
+	if e != nil {
+		return
+	}
+
+	changes = make(map[string]Change)
+	for name, value := range afterFields {
+		if old, ok := beforeFields[name]; ok == false || reflect.DeepEqual(old, value) == false {
+			changes[name] = Change{Before: old, After: value}
+		}
+	}
+	for name, old := range beforeFields {
+		if _, ok := afterFields[name]; ok == false {
+			changes[name] = Change{Before: old}
+		}
+	}
+
+	for name := range ignoredFields {
+		delete(changes, name)
+	}
+	for name := range secretFields {
+		if _, ok := changes[name]; ok {
+			changes[name] = Change{Before: Redacted, After: Redacted}
+		}
+	}
+
+	return
+}
+
+// SetChanges save diff of before and after in the audit record
+func SetChanges(audit *models.Audit, before, after interface{}) error {
+	changes, e := Diff(before, after)
+	if e != nil {
+		return e
+	}
+
+	bts, e := json.Marshal(changes)
+	if e != nil {
+		return e
+	}
+	audit.Changes = string(bts)
+
+	return nil
+}
+
+// fields decode json encoded object to a map of its fields
+func fields(object interface{}) (m map[string]interface{}, e error) {
+	m = make(map[string]interface{})
+	if object == nil {
+		return
+	}
+
+	bts, e := json.Marshal(object)
+	if e != nil {
+		return
+	}
+	e = json.Unmarshal(bts, &m)
+
+	return
+}
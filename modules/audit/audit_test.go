@@ -0,0 +1,97 @@
+package audit
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"devin/models"
+)
+
+func TestNew(t *testing.T) {
+	r := httptest.NewRequest("POST", "/api/projects/save", nil)
+	r.RemoteAddr = "10.0.0.5:51234"
+	r.Header.Set("User-Agent", strings.Repeat("a", 300))
+
+	audit := New(r, models.User{ID: 7}, models.AUDIT_ACTION_PROJECT_CREATE, models.MODULE_PROJECT, 12)
+	if audit.ActorID == nil || *audit.ActorID != 7 || audit.ObjectID != 12 || audit.ModuleID != models.MODULE_PROJECT {
+		t.Fatal("Invalid audit", audit)
+	}
+	if audit.IP != "10.0.0.5" || len(audit.UserAgent) != maxUserAgentLength || audit.Changes != "{}" {
+		t.Fatal("Invalid client data", audit.IP, len(audit.UserAgent), audit.Changes)
+	}
+
+	audit = New(r, models.User{}, models.AUDIT_ACTION_PASSWORD_RESET, models.MODULE_USER, 7)
+	if audit.ActorID != nil {
+		t.Fatal("Unknown actor must be saved as null", *audit.ActorID)
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	r := httptest.NewRequest("GET", "/", nil)
+	r.RemoteAddr = "[::1]:8080"
+	if ip := ClientIP(r); ip != "::1" {
+		t.Fatal("Expected remote address, got", ip)
+	}
+
+	r.Header.Set("X-Real-IP", "203.0.113.9")
+	if ip := ClientIP(r); ip != "203.0.113.9" {
+		t.Fatal("Expected X-Real-IP, got", ip)
+	}
+
+	r.Header.Set("X-Real-IP", "not an ip")
+	if ip := ClientIP(r); ip != "::1" {
+		t.Fatal("Invalid X-Real-IP must be ignored, got", ip)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	type profile struct {
+		FirstName *string
+		JobTitle  string
+		Password  string
+		UpdatedAt string
+	}
+	name := "Ali"
+	before := profile{JobTitle: "Developer", Password: "old hash", UpdatedAt: "yesterday"}
+	after := profile{FirstName: &name, JobTitle: "Developer", Password: "new hash", UpdatedAt: "today"}
+
+	changes, e := Diff(before, after)
+	if e != nil {
+		t.Fatal(e)
+	}
+	if len(changes) != 2 {
+		t.Fatal("Expected FirstName and Password changes, got", changes)
+	}
+	if c := changes["FirstName"]; c.Before != nil || c.After != "Ali" {
+		t.Fatal("Invalid FirstName change", c)
+	}
+	if c := changes["Password"]; c.Before != Redacted || c.After != Redacted {
+		t.Fatal("Password must be redacted", c)
+	}
+
+	changes, _ = Diff(nil, map[string]interface{}{"Name": "devin"})
+	if c, ok := changes["Name"]; ok == false || c.Before != nil || c.After != "devin" {
+		t.Fatal("All fields of a created object must be changed", changes)
+	}
+
+	changes, _ = Diff(map[string]interface{}{"Name": "devin"}, nil)
+	if c, ok := changes["Name"]; ok == false || c.Before != "devin" || c.After != nil {
+		t.Fatal("Removed fields must be changed", changes)
+	}
+}
+
+func TestSetChanges(t *testing.T) {
+	var audit models.Audit
+	e := SetChanges(&audit, map[string]bool{"CanCreateProject": false}, map[string]bool{"CanCreateProject": true})
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	var changes map[string]Change
+	json.Unmarshal([]byte(audit.Changes), &changes)
+	if c := changes["CanCreateProject"]; c.Before != false || c.After != true {
+		t.Fatal("Invalid changes", audit.Changes)
+	}
+}
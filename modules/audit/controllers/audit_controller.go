@@ -0,0 +1,58 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"devin/database"
+	"devin/helpers"
+	"devin/models"
+	audit_repo "devin/modules/audit/repository"
+	"devin/modules/rw_helpers"
+)
+
+// AuditController handle audit log of changes made by users
+type AuditController struct{}
+
+// AuditsIndex return paginated audit log, newest first.
+// Filters are a json encoded models.AuditSearch in 'q' parameter.
+// Root users can search all records, other users must give an OrganizationID
+// of an organization which they own or are admin of.
+// @Route: /api/audits?q={"OrganizationID":1,"UserID":2,"From":"2018-06-01T00:00:00Z","To":"2018-07-01T00:00:00Z"}
+// @Method: GET
+func (AuditController) AuditsIndex(w http.ResponseWriter, r *http.Request) {
+	authUser, e := rw_helpers.GetAuthenticatedUser(w, r)
+	if e != nil {
+		return
+	}
+
+	searchModel, e := rw_helpers.DecodeAuditSearchFilters(w, r)
+	if e != nil {
+		return
+	}
+	searchModel.PerPage = rw_helpers.GetPerPage(r)
+	searchModel.CurrentPage = rw_helpers.GetCurrectpage(r)
+
+	db := database.NewGORMInstance()
+	defer db.Close()
+
+	if rw_helpers.CanViewAudits(w, db, authUser, searchModel.OrganizationID) == false {
+		return
+	}
+
+	data, total, e := audit_repo.SearchAudits(db, searchModel)
+	if e != nil {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusInternalServerError,
+			Message:   "Fail to load audit log",
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+
+	var pgn models.Pagination
+	pgn.Make(data, total, searchModel.CurrentPage, searchModel.PerPage)
+
+	w.Header().Add("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&pgn)
+}
@@ -0,0 +1,36 @@
+package repository
+
+import (
+	"github.com/jinzhu/gorm"
+
+	"devin/models"
+)
+
+// SaveAudit insert the audit record
+func SaveAudit(db *gorm.DB, audit *models.Audit) error {
+	return db.Set("gorm:save_associations", false).Create(audit).Error
+}
+
+// SearchAudits load paginated audit records matching the search filters, newest first
+func SearchAudits(db *gorm.DB, search models.AuditSearch) (data []models.Audit, total uint64, e error) {
+	db = search.GetWhereClause(db.Model(&models.Audit{}))
+
+	e = db.Count(&total).Error
+	if e != nil {
+		return
+	}
+
+	if search.PerPage > 0 {
+		db = db.Limit(search.PerPage)
+	}
+	if search.CurrentPage > 0 {
+		db = db.Offset((search.CurrentPage - 1) * search.PerPage)
+	}
+
+	e = db.Preload("Actor").
+		Order("created_at DESC, id DESC").
+		Find(&data).
+		Error
+
+	return
+}
@@ -0,0 +1,324 @@
+// Package authorizer decide access of users to projects and their objects.
+// A decision combines root status, membership of the owner organization,
+// membership of the project, roles assigned to the user and object permissions granted to the user.
+package authorizer
+
+import (
+	"strings"
+
+	"github.com/jinzhu/gorm"
+
+	"devin/models"
+)
+
+// Actions which are checked by authorizer
+const (
+	ActionRead   = "read"
+	ActionCreate = "create"
+	ActionUpdate = "update"
+	ActionDelete = "delete"
+
+	// Administrative actions on a module, like listing time logs of all members
+	ActionManage = "manage"
+)
+
+// Keys of organization permissions, they are only effective in roles assigned on the whole organization
+const (
+	KeyOrganizationAdmin          = "organization.admin"
+	KeyCreateOrganizationProject  = "organization.create_project"
+	KeyUpdateOrganizationProjects = "organization.update_project"
+	KeyAddUserToOrganization      = "organization.add_user"
+)
+
+// Keys of project permissions. Roles assigned on the whole organization
+// give them on all projects of the organization.
+const (
+	KeyProjectAdmin         = "project.admin"
+	KeyUpdateProjectProfile = "project.update_profile"
+	KeyAddUserToProject     = "project.add_user"
+	KeyCreateMilestone      = "milestone.create"
+	KeyCreateTaskList       = "task_list.create"
+	KeyCreateTask           = "task.create"
+	KeyCreateIssue          = "issue.create"
+	KeyCreateRepository     = "repository.create"
+	KeyCreateTag            = "tag.create"
+	KeyCreateBoard          = "board.create"
+	KeyCreateReminder       = "reminder.create"
+	KeyCreateTimeLog        = "time_log.create"
+	KeyListAllTimeLogs      = "time_log.list_all"
+	KeyCreateWiki           = "wiki.create"
+)
+
+// Keys is the list of all permission keys which roles can have
+var Keys = []string{
+	KeyOrganizationAdmin,
+	KeyCreateOrganizationProject,
+	KeyUpdateOrganizationProjects,
+	KeyAddUserToOrganization,
+	KeyProjectAdmin,
+	KeyUpdateProjectProfile,
+	KeyAddUserToProject,
+	KeyCreateMilestone,
+	KeyCreateTaskList,
+	KeyCreateTask,
+	KeyCreateIssue,
+	KeyCreateRepository,
+	KeyCreateTag,
+	KeyCreateBoard,
+	KeyCreateReminder,
+	KeyCreateTimeLog,
+	KeyListAllTimeLogs,
+	KeyCreateWiki,
+}
+
+// ReadableObjectsSQL is a sub query of IDs of objects which the user has read permission on them.
+// Its parameters are ID of the user and ID of the module.
+const ReadableObjectsSQL = "SELECT object_id FROM object_permissions WHERE user_id=? AND module_id=? AND can_read=true"
+
+// OrganizationsWithKeySQL is a sub query of IDs of organizations which the user is a member of
+// with a role on the whole organization which has the key.
+// Its parameters are ID of the user and the permission key.
+const OrganizationsWithKeySQL = `SELECT ra.organization_id FROM role_assignments ra
+    INNER JOIN role_permissions rp ON rp.role_id=ra.role_id
+    INNER JOIN user_organization uo ON uo.user_id=ra.user_id AND uo.organization_id=ra.organization_id
+    WHERE ra.user_id=? AND rp.key=? AND uo.deleted_at IS NULL`
+
+// roleKeysSQL select permission keys of roles assigned to a member of organization,
+// on the whole organization or on a project which the user is a member of.
+// Its parameters are ID of the user, ID of the organization and ID of the project.
+const roleKeysSQL = `SELECT DISTINCT rp.key, ra.project_id IS NOT NULL AS on_project
+    FROM role_assignments ra
+    INNER JOIN role_permissions rp ON rp.role_id=ra.role_id
+    WHERE ra.user_id=? AND (
+        (ra.organization_id=? AND EXISTS (SELECT 1 FROM user_organization uo
+            WHERE uo.user_id=ra.user_id AND uo.organization_id=ra.organization_id AND uo.deleted_at IS NULL))
+        OR
+        (ra.project_id=? AND EXISTS (SELECT 1 FROM project_users pu
+            WHERE pu.user_id=ra.user_id AND pu.project_id=ra.project_id AND pu.deleted_at IS NULL))
+    )`
+
+// IsValidKey check the key to be a known permission key
+func IsValidKey(key string) bool {
+	for _, k := range Keys {
+		if k == key {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsOrganizationKey check the key to be a permission on the organization itself
+func IsOrganizationKey(key string) bool {
+	return strings.HasPrefix(key, "organization.")
+}
+
+// Permission is an action on a module of a project or on a single object of that module
+type Permission struct {
+	Action   string
+	ModuleID uint
+
+	// Zero for actions on the whole module, like listing or creating objects.
+	// Object permissions are only checked for a non-zero ObjectID.
+	ObjectID uint64
+
+	// Permission key which allows a project member to do the action.
+	// Empty key means only project admins can do it, members can always read.
+	Key string
+}
+
+// Subject is everything that affects access of a user to a project
+type Subject struct {
+	User models.User
+
+	// Membership of the project, empty if user is not a member
+	ProjectUser models.ProjectUser
+
+	// Membership of the owner organization of the project, empty if user is not a member
+	UserOrganization    models.UserOrganization
+	IsOrganizationOwner bool
+
+	// Permission keys of roles assigned to the user on the organization and on the project
+	Keys map[string]bool
+
+	// Object permissions granted to the user on the checked object and on the project
+	Grants []models.ObjectPermission
+}
+
+// Authorize load the subject of user on the project and decide the permission
+func Authorize(db *gorm.DB, authUser models.User, project models.Project, permission Permission) bool {
+	return Decide(LoadSubject(db, authUser, project, permission), project, permission)
+}
+
+// LoadSubject load memberships of the user and its object permissions related to the permission
+func LoadSubject(db *gorm.DB, authUser models.User, project models.Project, permission Permission) (subject Subject) {
+	subject.User = authUser
+	if authUser.ID == 0 || authUser.IsRootUser {
+		return
+	}
+
+	db.Model(&models.ProjectUser{}).
+		Where("user_id=? AND project_id=?", authUser.ID, project.ID).
+		First(&subject.ProjectUser)
+
+	var organizationID uint64
+	if project.OwnerOrganizationID != nil {
+		organizationID = *project.OwnerOrganizationID
+		db.Model(&models.UserOrganization{}).
+			Where("user_id=? AND organization_id=?", authUser.ID, *project.OwnerOrganizationID).
+			First(&subject.UserOrganization)
+
+		var cnt uint64
+		db.Model(&models.User{}).
+			Where("id=? AND owner_id=? AND user_type=2", *project.OwnerOrganizationID, authUser.ID).
+			Count(&cnt)
+		subject.IsOrganizationOwner = cnt > 0
+	}
+
+	subject.Keys = loadKeys(db, authUser.ID, organizationID, project.ID)
+
+	db.Model(&models.ObjectPermission{}).
+		Where("user_id=? AND ((module_id=? AND object_id=?) OR (module_id=? AND object_id=?))",
+			authUser.ID, permission.ModuleID, permission.ObjectID, models.MODULE_PROJECT, project.ID).
+		Find(&subject.Grants)
+
+	return
+}
+
+// loadKeys load permission keys of roles assigned to the user. Organization keys
+// are ignored in roles assigned on a project.
+func loadKeys(db *gorm.DB, userID, organizationID, projectID uint64) map[string]bool {
+	keys := make(map[string]bool)
+	rows, e := db.Raw(roleKeysSQL, userID, organizationID, projectID).Rows()
+	if e != nil {
+		return keys
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var key string
+		var onProject bool
+		if rows.Scan(&key, &onProject) != nil {
+			continue
+		}
+		if onProject && IsOrganizationKey(key) {
+			continue
+		}
+		keys[key] = true
+	}
+
+	return keys
+}
+
+// OrganizationKeys load permission keys of roles assigned to the user on the whole organization
+func OrganizationKeys(db *gorm.DB, userID, organizationID uint64) map[string]bool {
+	return loadKeys(db, userID, organizationID, 0)
+}
+
+// HasOrganizationKey check the user to be a member of the organization with a role
+// on the whole organization which has the key or is admin of organization
+func HasOrganizationKey(db *gorm.DB, userID, organizationID uint64, key string) bool {
+	keys := OrganizationKeys(db, userID, organizationID)
+	return keys[KeyOrganizationAdmin] || keys[key]
+}
+
+// Decide whether the subject has the permission on the project.
+// In order: root users can do anything; public modules can be read by everyone;
+// project owner, project manager, owner and admins of the owner organization can do anything;
+// organization members read projects which are public in organization;
+// project members read everything, project admins do anything;
+// roles of the user allow actions of their permission keys;
+// object permissions grant their actions on the object and project level read grants read all modules.
+func Decide(subject Subject, project models.Project, permission Permission) bool {
+	user := subject.User
+	if user.IsRootUser == true {
+		return true
+	}
+
+	if permission.Action == ActionRead && IsPublic(project, permission.ModuleID) {
+		return true
+	}
+
+	if user.ID == 0 {
+		return false
+	}
+
+	if user.ID == project.OwnerUserID || user.ID == project.ProjectManagerID {
+		return true
+	}
+
+	keys := subject.Keys
+	if subject.IsOrganizationOwner || keys[KeyOrganizationAdmin] {
+		return true
+	}
+	if subject.UserOrganization.ID != 0 && isProjectLevel(permission.ModuleID) {
+		if permission.Action == ActionRead && project.VisibilityTypeID == 2 {
+			return true
+		}
+		if permission.ModuleID == models.MODULE_PROJECT && permission.Action == ActionUpdate && keys[KeyUpdateOrganizationProjects] {
+			return true
+		}
+	}
+
+	if subject.ProjectUser.ID != 0 && permission.Action == ActionRead {
+		return true
+	}
+	if keys[KeyProjectAdmin] || (permission.Key != "" && keys[permission.Key]) {
+		return true
+	}
+
+	for _, grant := range subject.Grants {
+		if allows(grant, project, permission) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsPublic check the module of project to be readable by everyone, even anonymous users
+func IsPublic(project models.Project, moduleID uint) bool {
+	switch moduleID {
+	case models.MODULE_ISSUE_TRACKER:
+		return project.AllowPublicIssues
+	case models.MODULE_BUG_TRACKER:
+		return project.AllowPublicBugs
+	case models.MODULE_WIKI:
+		return project.AllowPublicWiki
+	}
+
+	return isProjectLevel(moduleID) && project.VisibilityTypeID == 3
+}
+
+// isProjectLevel check the module to follow visibility of the project
+func isProjectLevel(moduleID uint) bool {
+	switch moduleID {
+	case models.MODULE_PROJECT, models.MODULE_TASK, models.MODULE_MILESTONE, models.MODULE_REPOSITORY, models.MODULE_SPENT_TIME:
+		return true
+	}
+
+	return false
+}
+
+// allows check the object permission to grant the permission
+func allows(grant models.ObjectPermission, project models.Project, permission Permission) bool {
+	// Read on the project is read on all of its modules
+	if grant.ModuleID == models.MODULE_PROJECT && grant.ObjectID == project.ID && permission.Action == ActionRead {
+		return grant.CanRead
+	}
+
+	if permission.ObjectID == 0 || grant.ModuleID != permission.ModuleID || grant.ObjectID != permission.ObjectID {
+		return false
+	}
+
+	switch permission.Action {
+	case ActionRead:
+		return grant.CanRead
+	case ActionUpdate:
+		return grant.CanUpdate
+	case ActionDelete:
+		return grant.CanDelete
+	}
+
+	return false
+}
@@ -0,0 +1,126 @@
+package authorizer
+
+import (
+	"testing"
+
+	"devin/models"
+)
+
+func TestDecide(t *testing.T) {
+	orgID := uint64(50)
+	project := models.Project{ID: 10, OwnerUserID: 1, ProjectManagerID: 2, VisibilityTypeID: 1, OwnerOrganizationID: &orgID}
+
+	readProject := Permission{Action: ActionRead, ModuleID: models.MODULE_PROJECT, ObjectID: project.ID}
+	readIssues := Permission{Action: ActionRead, ModuleID: models.MODULE_ISSUE_TRACKER}
+	createTask := Permission{Action: ActionCreate, ModuleID: models.MODULE_TASK, Key: KeyCreateTask}
+	updateTask := Permission{Action: ActionUpdate, ModuleID: models.MODULE_TASK, ObjectID: 7, Key: KeyCreateTask}
+	deleteTask := Permission{Action: ActionDelete, ModuleID: models.MODULE_TASK, ObjectID: 7, Key: KeyCreateTask}
+	manageIssues := Permission{Action: ActionManage, ModuleID: models.MODULE_ISSUE_TRACKER}
+	updateProject := Permission{Action: ActionUpdate, ModuleID: models.MODULE_PROJECT, ObjectID: project.ID, Key: KeyUpdateProjectProfile}
+
+	stranger := Subject{User: models.User{ID: 9}}
+	member := Subject{User: models.User{ID: 9}, ProjectUser: models.ProjectUser{ID: 1}}
+	taskCreator := Subject{User: models.User{ID: 9}, ProjectUser: models.ProjectUser{ID: 1}, Keys: map[string]bool{KeyCreateTask: true}}
+	projectAdmin := Subject{User: models.User{ID: 9}, ProjectUser: models.ProjectUser{ID: 1}, Keys: map[string]bool{KeyProjectAdmin: true}}
+	orgMember := Subject{User: models.User{ID: 9}, UserOrganization: models.UserOrganization{ID: 1}}
+	orgUpdater := Subject{User: models.User{ID: 9}, UserOrganization: models.UserOrganization{ID: 1}, Keys: map[string]bool{KeyUpdateOrganizationProjects: true}}
+	orgAdmin := Subject{User: models.User{ID: 9}, UserOrganization: models.UserOrganization{ID: 1}, Keys: map[string]bool{KeyOrganizationAdmin: true}}
+	orgTaskCreator := Subject{User: models.User{ID: 9}, UserOrganization: models.UserOrganization{ID: 1}, Keys: map[string]bool{KeyCreateTask: true}}
+	orgOwner := Subject{User: models.User{ID: 9}, IsOrganizationOwner: true}
+	taskGrant := Subject{User: models.User{ID: 9}, Grants: []models.ObjectPermission{
+		{ModuleID: models.MODULE_TASK, ObjectID: 7, CanRead: true, CanUpdate: true},
+	}}
+	projectGrant := Subject{User: models.User{ID: 9}, Grants: []models.ObjectPermission{
+		{ModuleID: models.MODULE_PROJECT, ObjectID: project.ID, CanRead: true},
+	}}
+
+	cases := []struct {
+		name       string
+		subject    Subject
+		project    models.Project
+		permission Permission
+		expected   bool
+	}{
+		{"Root", Subject{User: models.User{ID: 9, IsRootUser: true}}, project, deleteTask, true},
+		{"Anonymous", Subject{}, project, readProject, false},
+		{"Owner", Subject{User: models.User{ID: 1}}, project, deleteTask, true},
+		{"Manager", Subject{User: models.User{ID: 2}}, project, manageIssues, true},
+		{"Stranger", stranger, project, readProject, false},
+		{"Member reads", member, project, readIssues, true},
+		{"Member without flag", member, project, createTask, false},
+		{"Member with flag", taskCreator, project, createTask, true},
+		{"Member with flag updates", taskCreator, project, updateTask, true},
+		{"Member with flag can't manage", taskCreator, project, manageIssues, false},
+		{"Project admin", projectAdmin, project, manageIssues, true},
+		{"Organization member of private project", orgMember, project, readProject, false},
+		{"Organization updater", orgUpdater, project, updateProject, true},
+		{"Organization updater can't create tasks", orgUpdater, project, createTask, false},
+		{"Organization admin", orgAdmin, project, deleteTask, true},
+		{"Organization owner", orgOwner, project, manageIssues, true},
+		{"Organization role with project key", orgTaskCreator, project, createTask, true},
+		{"Organization role with project key can't manage", orgTaskCreator, project, manageIssues, false},
+		{"Granted read of task", taskGrant, project, Permission{Action: ActionRead, ModuleID: models.MODULE_TASK, ObjectID: 7}, true},
+		{"Granted update of task", taskGrant, project, updateTask, true},
+		{"Not granted delete of task", taskGrant, project, deleteTask, false},
+		{"Grant of another task", taskGrant, project, Permission{Action: ActionRead, ModuleID: models.MODULE_TASK, ObjectID: 8}, false},
+		{"Grant doesn't allow module actions", taskGrant, project, createTask, false},
+		{"Granted read of project", projectGrant, project, readIssues, true},
+		{"Read grant of project doesn't allow update", projectGrant, project, updateProject, false},
+	}
+
+	for _, c := range cases {
+		if got := Decide(c.subject, c.project, c.permission); got != c.expected {
+			t.Error(c.name, "expected", c.expected, "got", got)
+		}
+	}
+}
+
+func TestDecidePublicModules(t *testing.T) {
+	anonymous := Subject{}
+	orgMember := Subject{User: models.User{ID: 9}, UserOrganization: models.UserOrganization{ID: 1}}
+	readIssues := Permission{Action: ActionRead, ModuleID: models.MODULE_ISSUE_TRACKER}
+	readTasks := Permission{Action: ActionRead, ModuleID: models.MODULE_TASK}
+	createIssue := Permission{Action: ActionCreate, ModuleID: models.MODULE_ISSUE_TRACKER, Key: KeyCreateIssue}
+
+	public := models.Project{ID: 10, VisibilityTypeID: 3}
+	if Decide(anonymous, public, readTasks) == false {
+		t.Error("Tasks of public projects must be readable by everyone")
+	}
+	if Decide(anonymous, public, readIssues) == true {
+		t.Error("Issues follow AllowPublicIssues, not visibility of project")
+	}
+
+	public.AllowPublicIssues = true
+	if Decide(anonymous, public, readIssues) == false {
+		t.Error("Public issues must be readable by everyone")
+	}
+	if Decide(anonymous, public, createIssue) == true {
+		t.Error("Anonymous users can only read")
+	}
+
+	inOrganization := models.Project{ID: 10, VisibilityTypeID: 2}
+	if Decide(orgMember, inOrganization, readTasks) == false {
+		t.Error("Organization members must read projects which are public in organization")
+	}
+	if Decide(orgMember, inOrganization, readIssues) == true {
+		t.Error("Organization members must not read private issues")
+	}
+}
+
+func TestKeys(t *testing.T) {
+	for _, key := range Keys {
+		if IsValidKey(key) == false {
+			t.Error("Key must be valid", key)
+		}
+	}
+	if IsValidKey("project.unknown") {
+		t.Error("Unknown keys must be invalid")
+	}
+
+	if IsOrganizationKey(KeyOrganizationAdmin) == false || IsOrganizationKey(KeyAddUserToOrganization) == false {
+		t.Error("Organization keys not detected")
+	}
+	if IsOrganizationKey(KeyProjectAdmin) || IsOrganizationKey(KeyCreateTask) {
+		t.Error("Project keys detected as organization keys")
+	}
+}
@@ -0,0 +1,297 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/jinzhu/gorm"
+
+	"devin/database"
+	"devin/helpers"
+	"devin/models"
+	"devin/modules/audit"
+	audit_repo "devin/modules/audit/repository"
+	"devin/modules/authorizer"
+	"devin/modules/authorizer/repository"
+	project_repo "devin/modules/project/repository"
+	"devin/modules/rw_helpers"
+)
+
+// ObjectPermissionController handle grant and revoke of permissions on single objects of a project
+type ObjectPermissionController struct{}
+
+type objectPermissionReqModel struct {
+	UserID    uint64
+	ModuleID  uint `doc:"From this list: models.MODULE_PROJECT, models.MODULE_TASK, models.MODULE_MILESTONE, models.MODULE_SPENT_TIME, models.MODULE_ISSUE_TRACKER, models.MODULE_BUG_TRACKER, models.MODULE_WIKI"`
+	ObjectID  uint64
+	CanRead   bool
+	CanUpdate bool
+	CanDelete bool
+}
+
+// grantChanges is the audited part of an object permission
+type grantChanges struct {
+	UserID    uint64
+	CanRead   bool
+	CanUpdate bool
+	CanDelete bool
+}
+
+// grantedActions return authorizer actions which the request grants
+func grantedActions(reqModel objectPermissionReqModel) (actions []string) {
+	if reqModel.CanRead {
+		actions = append(actions, authorizer.ActionRead)
+	}
+	if reqModel.CanUpdate {
+		actions = append(actions, authorizer.ActionUpdate)
+	}
+	if reqModel.CanDelete {
+		actions = append(actions, authorizer.ActionDelete)
+	}
+
+	return
+}
+
+// saveAudit record the grant or revoke of an object permission
+func saveAudit(db *gorm.DB, r *http.Request, authUser models.User, project models.Project, action string, permission models.ObjectPermission, before, after interface{}) error {
+	entry := audit.New(r, authUser, action, permission.ModuleID, permission.ObjectID)
+	entry.OrganizationID = project.OwnerOrganizationID
+
+	e := audit.SetChanges(&entry, before, after)
+	if e != nil {
+		return e
+	}
+
+	return audit_repo.SaveAudit(db, &entry)
+}
+
+// ObjectPermissionsIndex return object permissions granted on objects of the project.
+// List can be limited to a module and a single object of that module.
+// @Route: /api/project/{project_id}/object_permissions?module_id={module_id}&object_id={object_id}
+// @Method: GET
+func (ObjectPermissionController) ObjectPermissionsIndex(w http.ResponseWriter, r *http.Request) {
+	authUser, e := rw_helpers.GetAuthenticatedUser(w, r)
+	if e != nil {
+		return
+	}
+
+	var moduleID *uint
+	var objectID *uint64
+	if value := r.URL.Query().Get("module_id"); value != "" {
+		ID, e := strconv.ParseUint(value, 10, 32)
+		if e != nil {
+			err := helpers.ErrorResponse{
+				Message:   "Invalid module ID. Just integer values accepted",
+				ErrorCode: http.StatusUnprocessableEntity,
+			}
+			helpers.NewErrorResponse(w, &err)
+			return
+		}
+		module := uint(ID)
+		moduleID = &module
+	}
+	if value := r.URL.Query().Get("object_id"); value != "" {
+		ID, e := strconv.ParseUint(value, 10, 64)
+		if e != nil {
+			err := helpers.ErrorResponse{
+				Message:   "Invalid object ID. Just integer values accepted",
+				ErrorCode: http.StatusUnprocessableEntity,
+			}
+			helpers.NewErrorResponse(w, &err)
+			return
+		}
+		objectID = &ID
+	}
+
+	db := database.NewGORMInstance()
+	defer db.Close()
+
+	project, e := rw_helpers.LoadProjectForModule(w, r, db, authUser, models.MODULE_PROJECT)
+	if e != nil || rw_helpers.CanManageObjectPermissions(w, db, authUser, project) == false {
+		return
+	}
+
+	data, e := repository.GetObjectPermissions(db, project.ID, moduleID, objectID)
+	if e != nil {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusInternalServerError,
+			Message:   "Fail to load object permissions",
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&data)
+}
+
+// Grant give read, update or delete permission on an object of the project to a user.
+// An existing grant of the user on the same object is replaced.
+// @Route: /api/project/{project_id}/object_permissions/grant
+// @Method: POST
+// @Content-Type: application/json
+func (ObjectPermissionController) Grant(w http.ResponseWriter, r *http.Request) {
+	if rw_helpers.IsJSONRequest(w, r) == false {
+		return
+	}
+
+	authUser, e := rw_helpers.GetAuthenticatedUser(w, r)
+	if e != nil {
+		return
+	}
+
+	if helpers.IsRequestBodyNil(w, r) {
+		return
+	}
+
+	var reqModel objectPermissionReqModel
+	e = json.NewDecoder(r.Body).Decode(&reqModel)
+	if e != nil {
+		err := helpers.ErrorResponse{
+			Message:   "Invalid request body",
+			ErrorCode: http.StatusBadRequest,
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+	defer r.Body.Close()
+
+	db := database.NewGORMInstance()
+	defer db.Close()
+
+	project, e := rw_helpers.LoadProjectForModule(w, r, db, authUser, models.MODULE_PROJECT)
+	if e != nil || rw_helpers.CanManageObjectPermissions(w, db, authUser, project) == false {
+		return
+	}
+
+	errs := make(map[string][]string)
+	if reqModel.CanRead == false && reqModel.CanUpdate == false && reqModel.CanDelete == false {
+		errs["CanRead"] = []string{"At least one permission must be granted, revoke the permission instead."}
+	}
+	if e := repository.CheckObjectOfProject(db, project.ID, reqModel.ModuleID, reqModel.ObjectID); e != nil {
+		errs["ObjectID"] = []string{e.Error()}
+	}
+	if reqModel.UserID == authUser.ID {
+		errs["UserID"] = []string{"You can't grant permissions to yourself"}
+	} else if project_repo.CanJoinProject(db, project, reqModel.UserID) == false {
+		errs["UserID"] = []string{"The user is not a member of the owner organization of the project"}
+	}
+	if len(errs) > 0 {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusUnprocessableEntity,
+			Message:   "Invalid object permission",
+			Errors:    errs,
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+
+	if rw_helpers.IsUserExists(w, db, reqModel.UserID) != nil {
+		return
+	}
+
+	// Users can only grant what they are allowed to do on the object
+	for _, action := range grantedActions(reqModel) {
+		permission := authorizer.Permission{Action: action, ModuleID: reqModel.ModuleID, ObjectID: reqModel.ObjectID}
+		if rw_helpers.Authorize(w, db, authUser, project, permission) == false {
+			return
+		}
+	}
+
+	permission := models.ObjectPermission{
+		UserID:      reqModel.UserID,
+		ModuleID:    reqModel.ModuleID,
+		ObjectID:    reqModel.ObjectID,
+		ProjectID:   &project.ID,
+		CanRead:     reqModel.CanRead,
+		CanUpdate:   reqModel.CanUpdate,
+		CanDelete:   reqModel.CanDelete,
+		CreatedByID: authUser.ID,
+	}
+
+	var before interface{}
+	var old models.ObjectPermission
+	db.Where("user_id=? AND module_id=? AND object_id=?", permission.UserID, permission.ModuleID, permission.ObjectID).First(&old)
+	if old.ID != 0 {
+		before = grantChanges{old.UserID, old.CanRead, old.CanUpdate, old.CanDelete}
+	}
+	after := grantChanges{permission.UserID, permission.CanRead, permission.CanUpdate, permission.CanDelete}
+
+	tx := db.Begin()
+	e = repository.SaveObjectPermission(tx, &permission)
+	if e == nil {
+		e = saveAudit(tx, r, authUser, project, models.AUDIT_ACTION_OBJECT_GRANT, permission, before, after)
+	}
+	if e == nil {
+		e = tx.Commit().Error
+	} else {
+		tx.Rollback()
+	}
+	if e != nil {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusInternalServerError,
+			Message:   "Fail to grant permission",
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&permission)
+}
+
+// Revoke remove an object permission of the project
+// @Route: /api/project/{project_id}/object_permission/{id}/revoke
+// @Method: POST
+func (ObjectPermissionController) Revoke(w http.ResponseWriter, r *http.Request) {
+	authUser, e := rw_helpers.GetAuthenticatedUser(w, r)
+	if e != nil {
+		return
+	}
+
+	permissionID, e := rw_helpers.ExtractIDFromURL(w, r)
+	if e != nil {
+		return
+	}
+
+	db := database.NewGORMInstance()
+	defer db.Close()
+
+	project, e := rw_helpers.LoadProjectForModule(w, r, db, authUser, models.MODULE_PROJECT)
+	if e != nil || rw_helpers.CanManageObjectPermissions(w, db, authUser, project) == false {
+		return
+	}
+
+	permission, e := repository.GetObjectPermissionByID(db, project.ID, permissionID)
+	if e != nil {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusNotFound,
+			Message:   "No matching object permission found!",
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+	before := grantChanges{permission.UserID, permission.CanRead, permission.CanUpdate, permission.CanDelete}
+
+	tx := db.Begin()
+	e = repository.DeleteObjectPermission(tx, permission)
+	if e == nil {
+		e = saveAudit(tx, r, authUser, project, models.AUDIT_ACTION_OBJECT_REVOKE, permission, before, nil)
+	}
+	if e == nil {
+		e = tx.Commit().Error
+	} else {
+		tx.Rollback()
+	}
+	if e != nil {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusInternalServerError,
+			Message:   "Fail to revoke permission",
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+
+	helpers.NewSuccessResponse(w, "Permission revoked.")
+}
@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/jinzhu/gorm"
+
+	"devin/models"
+)
+
+// ErrObjectPermissionNotFound returned when the object permission is not found in the project
+var ErrObjectPermissionNotFound = errors.New("Object permission not found")
+
+// ErrObjectNotFound returned when the object is not found in the project
+var ErrObjectNotFound = errors.New("Object not found in the project")
+
+// ErrUnsupportedModule returned for modules which have no object permissions
+var ErrUnsupportedModule = errors.New("Object permissions are not supported for this module")
+
+// objectTables map modules to the table of their objects, all of them have project_id column
+var objectTables = map[uint]string{
+	models.MODULE_TASK:          "tasks",
+	models.MODULE_MILESTONE:     "milestones",
+	models.MODULE_ISSUE_TRACKER: "issues",
+	models.MODULE_BUG_TRACKER:   "issues",
+	models.MODULE_WIKI:          "wikis",
+}
+
+// CheckObjectOfProject check the object of module to belong to the project
+func CheckObjectOfProject(db *gorm.DB, projectID uint64, moduleID uint, objectID uint64) error {
+	var cnt uint64
+	switch moduleID {
+	case models.MODULE_PROJECT:
+		if objectID == projectID {
+			return nil
+		}
+		return ErrObjectNotFound
+
+	case models.MODULE_SPENT_TIME:
+		e := db.Model(&models.TaskSpentTime{}).
+			Where("id=? AND deleted_at IS NULL", objectID).
+			Where("task_id IN (SELECT id FROM tasks WHERE project_id=? AND deleted_at IS NULL)", projectID).
+			Count(&cnt).
+			Error
+		if e != nil {
+			return e
+		}
+
+	default:
+		table, ok := objectTables[moduleID]
+		if ok == false {
+			return ErrUnsupportedModule
+		}
+
+		e := db.Table(table).
+			Where("id=? AND project_id=? AND deleted_at IS NULL", objectID, projectID).
+			Count(&cnt).
+			Error
+		if e != nil {
+			return e
+		}
+	}
+
+	if cnt == 0 {
+		return ErrObjectNotFound
+	}
+
+	return nil
+}
+
+// GetObjectPermissions load object permissions granted on objects of the project,
+// optionally limited to a module or a single object
+func GetObjectPermissions(db *gorm.DB, projectID uint64, moduleID *uint, objectID *uint64) (data []models.ObjectPermission, e error) {
+	db = db.Where("project_id=?", projectID)
+	if moduleID != nil {
+		db = db.Where("module_id=?", *moduleID)
+	}
+	if objectID != nil {
+		db = db.Where("object_id=?", *objectID)
+	}
+
+	e = db.Preload("User").
+		Order("module_id ASC, object_id ASC, id ASC").
+		Find(&data).
+		Error
+
+	return
+}
+
+// GetObjectPermissionByID load the object permission of the project
+func GetObjectPermissionByID(db *gorm.DB, projectID, ID uint64) (permission models.ObjectPermission, e error) {
+	db.Where("id=? AND project_id=?", ID, projectID).First(&permission)
+	if permission.ID == 0 {
+		e = ErrObjectPermissionNotFound
+	}
+
+	return
+}
+
+// SaveObjectPermission insert the object permission, or replace flags of the existing one
+// of the same user and object
+func SaveObjectPermission(db *gorm.DB, permission *models.ObjectPermission) error {
+	return db.Raw(`INSERT INTO object_permissions
+    (user_id, module_id, object_id, project_id, can_read, can_update, can_delete, created_by_id)
+    VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+    ON CONFLICT (user_id, object_id, module_id) DO UPDATE SET
+    project_id=EXCLUDED.project_id,
+    can_read=EXCLUDED.can_read,
+    can_update=EXCLUDED.can_update,
+    can_delete=EXCLUDED.can_delete,
+    created_by_id=EXCLUDED.created_by_id
+    RETURNING id, created_at`,
+		permission.UserID, permission.ModuleID, permission.ObjectID, permission.ProjectID,
+		permission.CanRead, permission.CanUpdate, permission.CanDelete, permission.CreatedByID).
+		Row().
+		Scan(&permission.ID, &permission.CreatedAt)
+}
+
+// DeleteObjectPermission revoke the object permission
+func DeleteObjectPermission(db *gorm.DB, permission models.ObjectPermission) error {
+	return db.Where("id=?", permission.ID).Delete(&models.ObjectPermission{}).Error
+}
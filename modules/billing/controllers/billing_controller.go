@@ -0,0 +1,421 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"devin/database"
+	"devin/helpers"
+	"devin/models"
+	"devin/modules/billing/invoice"
+	billing_repo "devin/modules/billing/repository"
+	project_repo "devin/modules/project/repository"
+	"devin/modules/rw_helpers"
+)
+
+// BillingController handle hourly rates and invoices of projects
+type BillingController struct{}
+
+var currencyPattern = regexp.MustCompile(`^[A-Z]{3}$`)
+
+type draftReqModel struct {
+	FromDate time.Time
+	ToDate   time.Time
+}
+
+// RatesIndex return default and per-user hourly rates of the project
+// @Route: /api/project/{project_id}/billing/rates
+// @Method: GET
+func (BillingController) RatesIndex(w http.ResponseWriter, r *http.Request) {
+	authUser, e := rw_helpers.GetAuthenticatedUser(w, r)
+	if e != nil {
+		return
+	}
+
+	db := database.NewGORMInstance()
+	defer db.Close()
+
+	project, e := rw_helpers.LoadProjectForModule(w, r, db, authUser, models.MODULE_BILLING)
+	if e != nil {
+		return
+	}
+
+	rates, e := billing_repo.GetRatesOfProject(db, project.ID)
+	if e != nil {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusInternalServerError,
+			Message:   "Fail to load hourly rates",
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&rates)
+}
+
+// SaveRate set hourly rate of the project. Request with UserID overrides the rate of that member.
+// @Route: /api/project/{project_id}/billing/rates/save
+// @Method: POST
+// @Content-Type: application/json
+func (BillingController) SaveRate(w http.ResponseWriter, r *http.Request) {
+	if rw_helpers.IsJSONRequest(w, r) == false {
+		return
+	}
+
+	authUser, e := rw_helpers.GetAuthenticatedUser(w, r)
+	if e != nil {
+		return
+	}
+
+	if helpers.IsRequestBodyNil(w, r) {
+		return
+	}
+
+	var reqModel models.ProjectHourlyRate
+	e = json.NewDecoder(r.Body).Decode(&reqModel)
+	if e != nil {
+		err := helpers.ErrorResponse{
+			Message:   "Invalid request!",
+			ErrorCode: http.StatusBadRequest,
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+	defer r.Body.Close()
+
+	db := database.NewGORMInstance()
+	defer db.Close()
+
+	project, e := rw_helpers.LoadProjectForModule(w, r, db, authUser, models.MODULE_BILLING)
+	if e != nil {
+		return
+	}
+
+	resErr := helpers.ErrorResponse{}
+	resErr.Errors = make(map[string][]string)
+	if reqModel.HourlyRate < 0 {
+		resErr.Errors["HourlyRate"] = []string{"Hourly rate can't be negative!"}
+	}
+	reqModel.Currency = strings.ToUpper(strings.TrimSpace(reqModel.Currency))
+	if currencyPattern.MatchString(reqModel.Currency) == false {
+		resErr.Errors["Currency"] = []string{"Currency must be a 3 letters ISO 4217 code!"}
+	}
+	if helpers.IsNilUint64(reqModel.UserID) {
+		reqModel.UserID = nil
+	} else if project_repo.IsProjectMember(db, project, *reqModel.UserID) == false {
+		resErr.Errors["UserID"] = []string{"User is not a member of this project!"}
+	}
+	if len(resErr.Errors) > 0 {
+		resErr.ErrorCode = http.StatusUnprocessableEntity
+		resErr.Message = "Invalid data!"
+		helpers.NewErrorResponse(w, &resErr)
+		return
+	}
+
+	rate := models.ProjectHourlyRate{
+		ProjectID:   project.ID,
+		UserID:      reqModel.UserID,
+		HourlyRate:  reqModel.HourlyRate,
+		Currency:    reqModel.Currency,
+		CreatedByID: authUser.ID,
+	}
+	e = billing_repo.SaveRate(db, &rate)
+	if e != nil {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusInternalServerError,
+			Message:   "Fail to save hourly rate",
+		}
+		err.Errors = make(map[string][]string)
+		err.Errors["dev"] = []string{e.Error()}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&rate)
+}
+
+// CreateDraft create a draft invoice from unbilled billable time logs started in [FromDate, ToDate)
+// @Route: /api/project/{project_id}/invoices/draft
+// @Method: POST
+// @Content-Type: application/json
+func (BillingController) CreateDraft(w http.ResponseWriter, r *http.Request) {
+	if rw_helpers.IsJSONRequest(w, r) == false {
+		return
+	}
+
+	authUser, e := rw_helpers.GetAuthenticatedUser(w, r)
+	if e != nil {
+		return
+	}
+
+	if helpers.IsRequestBodyNil(w, r) {
+		return
+	}
+
+	var reqModel draftReqModel
+	e = json.NewDecoder(r.Body).Decode(&reqModel)
+	if e != nil {
+		err := helpers.ErrorResponse{
+			Message:   "Invalid request!",
+			ErrorCode: http.StatusBadRequest,
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+	defer r.Body.Close()
+
+	if reqModel.ToDate.After(reqModel.FromDate) == false {
+		err := helpers.ErrorResponse{
+			Message:   "Invalid data!",
+			ErrorCode: http.StatusUnprocessableEntity,
+		}
+		err.Errors = make(map[string][]string)
+		err.Errors["ToDate"] = []string{"To date must be after the from date!"}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+
+	db := database.NewGORMInstance()
+	defer db.Close()
+
+	project, e := rw_helpers.LoadProjectForModule(w, r, db, authUser, models.MODULE_BILLING)
+	if e != nil {
+		return
+	}
+
+	rates, e := billing_repo.GetRatesOfProject(db, project.ID)
+	if e != nil {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusInternalServerError,
+			Message:   "Fail to load hourly rates",
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+
+	entries, e := billing_repo.GetUnbilledEntries(db, project.ID, reqModel.FromDate, reqModel.ToDate)
+	if e != nil {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusInternalServerError,
+			Message:   "Fail to load time logs",
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+
+	if len(entries) == 0 {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusUnprocessableEntity,
+			Message:   "No unbilled billable time log found in this date range!",
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+
+	inv := models.Invoice{
+		ProjectID:   project.ID,
+		StatusID:    models.INVOICE_STATUS_DRAFT,
+		FromDate:    reqModel.FromDate,
+		ToDate:      reqModel.ToDate,
+		CreatedByID: authUser.ID,
+	}
+	e = invoice.Build(&inv, entries, invoice.NewRates(rates))
+	if e != nil {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusUnprocessableEntity,
+			Message:   e.Error(),
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+
+	e = billing_repo.CreateInvoice(db, &inv)
+	if e != nil {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusInternalServerError,
+			Message:   "Fail to save invoice",
+		}
+		err.Errors = make(map[string][]string)
+		err.Errors["dev"] = []string{e.Error()}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&inv)
+}
+
+// InvoicesIndex return invoices of the project without their items
+// @Route: /api/project/{project_id}/invoices
+// @Method: GET
+func (BillingController) InvoicesIndex(w http.ResponseWriter, r *http.Request) {
+	authUser, e := rw_helpers.GetAuthenticatedUser(w, r)
+	if e != nil {
+		return
+	}
+
+	db := database.NewGORMInstance()
+	defer db.Close()
+
+	project, e := rw_helpers.LoadProjectForModule(w, r, db, authUser, models.MODULE_BILLING)
+	if e != nil {
+		return
+	}
+
+	invoices, e := billing_repo.GetInvoicesOfProject(db, project.ID)
+	if e != nil {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusInternalServerError,
+			Message:   "Fail to load invoices",
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&invoices)
+}
+
+// Export return the invoice with its items.
+// Use format=csv in query string to download the invoice as CSV, default format is json
+// @Route: /api/project/{project_id}/invoice/{id}?format={json|csv}
+// @Method: GET
+func (BillingController) Export(w http.ResponseWriter, r *http.Request) {
+	authUser, e := rw_helpers.GetAuthenticatedUser(w, r)
+	if e != nil {
+		return
+	}
+
+	invoiceID, e := rw_helpers.ExtractIDFromURL(w, r)
+	if e != nil {
+		return
+	}
+
+	db := database.NewGORMInstance()
+	defer db.Close()
+
+	project, e := rw_helpers.LoadProjectForModule(w, r, db, authUser, models.MODULE_BILLING)
+	if e != nil {
+		return
+	}
+
+	inv, e := rw_helpers.GetInvoiceByID(w, db, project.ID, invoiceID)
+	if e != nil {
+		return
+	}
+
+	switch r.URL.Query().Get("format") {
+	case "", "json":
+		w.Header().Add("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(&inv)
+	case "csv":
+		w.Header().Add("Content-Type", "text/csv")
+		w.Header().Add("Content-Disposition", fmt.Sprintf(`attachment; filename="invoice-%d.csv"`, inv.ID))
+		invoice.WriteCSV(w, inv)
+	default:
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusUnprocessableEntity,
+			Message:   "Invalid format. Valid values are: json, csv",
+		}
+		helpers.NewErrorResponse(w, &err)
+	}
+}
+
+// Finalize finalize the draft invoice and mark all of its time logs as billed atomically
+// @Route: /api/project/{project_id}/invoice/{id}/finalize
+// @Method: POST
+func (BillingController) Finalize(w http.ResponseWriter, r *http.Request) {
+	authUser, e := rw_helpers.GetAuthenticatedUser(w, r)
+	if e != nil {
+		return
+	}
+
+	invoiceID, e := rw_helpers.ExtractIDFromURL(w, r)
+	if e != nil {
+		return
+	}
+
+	db := database.NewGORMInstance()
+	defer db.Close()
+
+	project, e := rw_helpers.LoadProjectForModule(w, r, db, authUser, models.MODULE_BILLING)
+	if e != nil {
+		return
+	}
+
+	inv, e := rw_helpers.GetInvoiceByID(w, db, project.ID, invoiceID)
+	if e != nil {
+		return
+	}
+
+	e = billing_repo.FinalizeInvoice(db, &inv, authUser.ID)
+	if e != nil {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusInternalServerError,
+			Message:   "Fail to finalize invoice",
+		}
+		switch e {
+		case billing_repo.ErrInvoiceIsFinalized, billing_repo.ErrEntriesAlreadyBilled, billing_repo.ErrEntriesChanged:
+			err.ErrorCode = http.StatusConflict
+			err.Message = e.Error()
+		default:
+			err.Errors = make(map[string][]string)
+			err.Errors["dev"] = []string{e.Error()}
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&inv)
+}
+
+// Delete soft delete a draft invoice. Finalized invoices can't be deleted.
+// @Route: /api/project/{project_id}/invoice/{id}/delete
+// @Method: POST
+func (BillingController) Delete(w http.ResponseWriter, r *http.Request) {
+	authUser, e := rw_helpers.GetAuthenticatedUser(w, r)
+	if e != nil {
+		return
+	}
+
+	invoiceID, e := rw_helpers.ExtractIDFromURL(w, r)
+	if e != nil {
+		return
+	}
+
+	db := database.NewGORMInstance()
+	defer db.Close()
+
+	project, e := rw_helpers.LoadProjectForModule(w, r, db, authUser, models.MODULE_BILLING)
+	if e != nil {
+		return
+	}
+
+	inv, e := rw_helpers.GetInvoiceByID(w, db, project.ID, invoiceID)
+	if e != nil {
+		return
+	}
+
+	e = billing_repo.DeleteInvoice(db, inv)
+	if e != nil {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusInternalServerError,
+			Message:   "Fail to delete invoice",
+		}
+		if e == billing_repo.ErrInvoiceIsFinalized {
+			err.ErrorCode = http.StatusConflict
+			err.Message = e.Error()
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+
+	helpers.NewSuccessResponse(w, "Invoice deleted.")
+}
@@ -0,0 +1,176 @@
+// Package invoice calculate and export invoices from billable spent times
+package invoice
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"devin/models"
+)
+
+// ErrMixedCurrencies returned when hourly rates of a project use different currencies
+var ErrMixedCurrencies = errors.New("All hourly rates of the project must use the same currency")
+
+// MissingRateError returned when some users have spent time but no hourly rate is set for them
+type MissingRateError struct {
+	UserIDs []uint64
+}
+
+func (e MissingRateError) Error() string {
+	return fmt.Sprintf("No hourly rate is set for users %v and the project has no default rate", e.UserIDs)
+}
+
+// Rates resolve hourly rate of users of a project
+type Rates struct {
+	Default *models.ProjectHourlyRate
+	Users   map[uint64]models.ProjectHourlyRate
+}
+
+// NewRates index hourly rates of a project. Rate with nil UserID is the project default.
+func NewRates(rates []models.ProjectHourlyRate) Rates {
+	r := Rates{Users: make(map[uint64]models.ProjectHourlyRate)}
+	for i := range rates {
+		if rates[i].UserID == nil {
+			r.Default = &rates[i]
+			continue
+		}
+		r.Users[*rates[i].UserID] = rates[i]
+	}
+
+	return r
+}
+
+// For return hourly rate of the user. User override has priority over the project default.
+func (r Rates) For(userID uint64) (rate models.ProjectHourlyRate, ok bool) {
+	if rate, ok = r.Users[userID]; ok {
+		return
+	}
+
+	if r.Default != nil {
+		return *r.Default, true
+	}
+
+	return
+}
+
+// Currency return the currency shared by all rates
+func (r Rates) Currency() (currency string, e error) {
+	if r.Default != nil {
+		currency = r.Default.Currency
+	}
+	for _, rate := range r.Users {
+		if currency == "" {
+			currency = rate.Currency
+		}
+		if rate.Currency != currency {
+			e = ErrMixedCurrencies
+			return
+		}
+	}
+
+	return
+}
+
+// Matches check the spent time to be billable as it was when the item was built,
+// with the same user, task, start date and duration. Billed or deleted spent times don't match.
+func Matches(item models.InvoiceItem, entry models.TaskSpentTime) bool {
+	if entry.ID != item.TaskSpentTimeID || entry.IsBillable == false || entry.IsBilled || entry.DeletedAt != nil || entry.EndDate == nil {
+		return false
+	}
+
+	return entry.SpentByID == item.UserID &&
+		entry.TaskID == item.TaskID &&
+		entry.StartDate.Equal(item.StartDate) &&
+		entry.EndDate.Sub(entry.StartDate) == item.Duration
+}
+
+// Amount calculate price of the duration. Duration is rounded to seconds
+// and the result is rounded to the nearest unit of the currency.
+func Amount(d time.Duration, hourlyRate int64) int64 {
+	seconds := int64((d + time.Second/2) / time.Second)
+
+	return (seconds*hourlyRate + 1800) / 3600
+}
+
+// Build fill items and totals of the invoice from spent times.
+// Running timers (nil EndDate) must not be passed.
+func Build(inv *models.Invoice, entries []models.TaskSpentTime, rates Rates) (e error) {
+	inv.Currency, e = rates.Currency()
+	if e != nil {
+		return
+	}
+
+	missing := MissingRateError{}
+	seen := make(map[uint64]bool)
+	inv.Items = []models.InvoiceItem{}
+	inv.TotalAmount = 0
+	inv.TotalDuration = 0
+	for _, entry := range entries {
+		rate, ok := rates.For(entry.SpentByID)
+		if ok == false {
+			if seen[entry.SpentByID] == false {
+				missing.UserIDs = append(missing.UserIDs, entry.SpentByID)
+				seen[entry.SpentByID] = true
+			}
+			continue
+		}
+
+		item := models.InvoiceItem{
+			TaskSpentTimeID: entry.ID,
+			UserID:          entry.SpentByID,
+			TaskID:          entry.TaskID,
+			Description:     entry.Description,
+			StartDate:       entry.StartDate,
+			Duration:        entry.EndDate.Sub(entry.StartDate),
+			HourlyRate:      rate.HourlyRate,
+		}
+		item.Amount = Amount(item.Duration, item.HourlyRate)
+
+		inv.Items = append(inv.Items, item)
+		inv.TotalAmount += item.Amount
+		inv.TotalDuration += item.Duration
+	}
+
+	if len(missing.UserIDs) > 0 {
+		e = missing
+	}
+
+	return
+}
+
+// FormatAmount format an amount in the smallest unit of the currency with two decimals
+func FormatAmount(amount int64) string {
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+
+	return fmt.Sprintf("%s%d.%02d", sign, amount/100, amount%100)
+}
+
+// WriteCSV export items of the invoice as CSV, followed by a total row
+func WriteCSV(w io.Writer, inv models.Invoice) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"Date", "User ID", "Task ID", "Description", "Hours", "Hourly Rate", "Amount", "Currency"})
+	for _, item := range inv.Items {
+		cw.Write([]string{
+			item.StartDate.Format("2006-01-02"),
+			strconv.FormatUint(item.UserID, 10),
+			strconv.FormatUint(item.TaskID, 10),
+			item.Description,
+			strconv.FormatFloat(item.Duration.Hours(), 'f', 2, 64),
+			FormatAmount(item.HourlyRate),
+			FormatAmount(item.Amount),
+			inv.Currency,
+		})
+	}
+	cw.Write([]string{"Total", "", "", "", strconv.FormatFloat(inv.TotalDuration.Hours(), 'f', 2, 64), "", FormatAmount(inv.TotalAmount), inv.Currency})
+	cw.Flush()
+
+	return cw.Error()
+}
@@ -0,0 +1,129 @@
+package invoice
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"devin/models"
+)
+
+func spentTime(id, userID uint64, duration time.Duration) models.TaskSpentTime {
+	start := time.Date(2018, 6, 11, 9, 0, 0, 0, time.UTC)
+	end := start.Add(duration)
+	return models.TaskSpentTime{ID: id, SpentByID: userID, TaskID: 7, StartDate: start, EndDate: &end, IsBillable: true}
+}
+
+func TestRatesUserOverride(t *testing.T) {
+	userID := uint64(2)
+	rates := NewRates([]models.ProjectHourlyRate{
+		{HourlyRate: 5000, Currency: "USD"},
+		{UserID: &userID, HourlyRate: 8000, Currency: "USD"},
+	})
+
+	if rate, _ := rates.For(1); rate.HourlyRate != 5000 {
+		t.Fatal("Expected default rate, got", rate.HourlyRate)
+	}
+	if rate, _ := rates.For(2); rate.HourlyRate != 8000 {
+		t.Fatal("Expected user rate, got", rate.HourlyRate)
+	}
+}
+
+func TestAmount(t *testing.T) {
+	if a := Amount(90*time.Minute, 5000); a != 7500 {
+		t.Fatal("Expected 7500, got", a)
+	}
+	// 1 second of 10.00/h = 0.28 cent, rounded to 0
+	if a := Amount(time.Second, 1000); a != 0 {
+		t.Fatal("Expected 0, got", a)
+	}
+	// 2 seconds of 1000.00/h = 55.56 cents
+	if a := Amount(2*time.Second, 100000); a != 56 {
+		t.Fatal("Expected 56, got", a)
+	}
+}
+
+func TestBuild(t *testing.T) {
+	userID := uint64(2)
+	rates := NewRates([]models.ProjectHourlyRate{
+		{HourlyRate: 5000, Currency: "EUR"},
+		{UserID: &userID, HourlyRate: 8000, Currency: "EUR"},
+	})
+
+	inv := models.Invoice{}
+	e := Build(&inv, []models.TaskSpentTime{spentTime(1, 1, time.Hour), spentTime(2, 2, 30*time.Minute)}, rates)
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	if len(inv.Items) != 2 || inv.TotalAmount != 9000 || inv.TotalDuration != 90*time.Minute || inv.Currency != "EUR" {
+		t.Fatalf("Invalid invoice: %+v", inv)
+	}
+}
+
+func TestMatches(t *testing.T) {
+	entry := spentTime(1, 2, time.Hour)
+	inv := models.Invoice{}
+	Build(&inv, []models.TaskSpentTime{entry}, NewRates([]models.ProjectHourlyRate{{HourlyRate: 5000, Currency: "USD"}}))
+	item := inv.Items[0]
+
+	if Matches(item, entry) == false {
+		t.Fatal("Unchanged spent time must match its item")
+	}
+
+	changed := []models.TaskSpentTime{spentTime(1, 2, 2*time.Hour), entry, entry, entry}
+	changed[1].IsBillable = false
+	changed[2].IsBilled = true
+	changed[3].StartDate = entry.StartDate.Add(-time.Hour)
+	for i, c := range changed {
+		if Matches(item, c) {
+			t.Fatal("Changed spent time must not match its item", i)
+		}
+	}
+}
+
+func TestBuildMissingRate(t *testing.T) {
+	userID := uint64(2)
+	rates := NewRates([]models.ProjectHourlyRate{{UserID: &userID, HourlyRate: 8000, Currency: "EUR"}})
+
+	inv := models.Invoice{}
+	e := Build(&inv, []models.TaskSpentTime{spentTime(1, 1, time.Hour), spentTime(2, 1, time.Hour)}, rates)
+	missing, ok := e.(MissingRateError)
+	if ok == false || len(missing.UserIDs) != 1 || missing.UserIDs[0] != 1 {
+		t.Fatal("Expected missing rate of user 1, got", e)
+	}
+}
+
+func TestBuildMixedCurrencies(t *testing.T) {
+	userID := uint64(2)
+	rates := NewRates([]models.ProjectHourlyRate{
+		{HourlyRate: 5000, Currency: "USD"},
+		{UserID: &userID, HourlyRate: 8000, Currency: "EUR"},
+	})
+
+	if e := Build(&models.Invoice{}, nil, rates); e != ErrMixedCurrencies {
+		t.Fatal("Expected ErrMixedCurrencies, got", e)
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	inv := models.Invoice{Currency: "USD"}
+	Build(&inv, []models.TaskSpentTime{spentTime(1, 1, 90*time.Minute)}, NewRates([]models.ProjectHourlyRate{{HourlyRate: 5000, Currency: "USD"}}))
+
+	var buf bytes.Buffer
+	if e := WriteCSV(&buf, inv); e != nil {
+		t.Fatal(e)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Expected header, one item and total, got %q", lines)
+	}
+	if lines[1] != "2018-06-11,1,7,,1.50,50.00,75.00,USD" {
+		t.Fatal("Invalid item row:", lines[1])
+	}
+	if lines[2] != "Total,,,,1.50,,75.00,USD" {
+		t.Fatal("Invalid total row:", lines[2])
+	}
+}
@@ -0,0 +1,205 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/jinzhu/gorm"
+
+	"devin/models"
+	"devin/modules/billing/invoice"
+)
+
+// ErrInvoiceNotFound returned when the invoice is not found in the project
+var ErrInvoiceNotFound = errors.New("Invoice not found")
+
+// ErrInvoiceIsFinalized returned when trying to change a finalized invoice
+var ErrInvoiceIsFinalized = errors.New("Invoice is already finalized")
+
+// ErrEntriesAlreadyBilled returned when some spent times of the invoice are billed
+// or deleted after the draft is created. A new draft must be created.
+var ErrEntriesAlreadyBilled = errors.New("Some time logs of this invoice are already billed or deleted, create a new draft")
+
+// ErrEntriesChanged returned when some spent times of the invoice are edited after the draft
+// is created, e.g their duration or billability. A new draft must be created.
+var ErrEntriesChanged = errors.New("Some time logs of this invoice are changed, create a new draft")
+
+// GetRatesOfProject load the default and per-user hourly rates of the project
+func GetRatesOfProject(db *gorm.DB, projectID uint64) (rates []models.ProjectHourlyRate, e error) {
+	e = db.Model(&models.ProjectHourlyRate{}).
+		Preload("User").
+		Where("project_id=?", projectID).
+		Order("user_id ASC NULLS FIRST").
+		Find(&rates).
+		Error
+
+	return
+}
+
+// SaveRate insert or update the hourly rate of the project for rate.UserID.
+// A nil UserID sets the default rate of the project.
+func SaveRate(db *gorm.DB, rate *models.ProjectHourlyRate) error {
+	var existing models.ProjectHourlyRate
+	query := db.Model(&existing).Where("project_id=?", rate.ProjectID)
+	if rate.UserID == nil {
+		query = query.Where("user_id IS NULL")
+	} else {
+		query = query.Where("user_id=?", *rate.UserID)
+	}
+	query.First(&existing)
+
+	if existing.ID == 0 {
+		return db.Create(rate).Error
+	}
+
+	existing.HourlyRate = rate.HourlyRate
+	existing.Currency = rate.Currency
+	*rate = existing
+
+	return db.Save(rate).Error
+}
+
+// GetUnbilledEntries load finished, billable and not billed spent times of
+// the project which are started in [from, to)
+func GetUnbilledEntries(db *gorm.DB, projectID uint64, from, to time.Time) (entries []models.TaskSpentTime, e error) {
+	e = db.Model(&models.TaskSpentTime{}).
+		Where("task_id IN (SELECT id FROM tasks WHERE project_id=? AND deleted_at IS NULL)", projectID).
+		Where("is_billabel=true AND is_billed=false AND end_date IS NOT NULL").
+		Where("start_date >= ? AND start_date < ?", from, to).
+		Order("start_date ASC, id ASC").
+		Find(&entries).
+		Error
+
+	return
+}
+
+// CreateInvoice insert the invoice with its items
+func CreateInvoice(db *gorm.DB, inv *models.Invoice) error {
+	return db.Create(inv).Error
+}
+
+// GetInvoicesOfProject load invoices of the project without their items, newest first
+func GetInvoicesOfProject(db *gorm.DB, projectID uint64) (invoices []models.Invoice, e error) {
+	e = db.Model(&models.Invoice{}).
+		Where("project_id=?", projectID).
+		Order("id DESC").
+		Find(&invoices).
+		Error
+
+	return
+}
+
+// GetInvoiceByID load an invoice of the project with its items
+func GetInvoiceByID(db *gorm.DB, projectID, invoiceID uint64) (inv models.Invoice, e error) {
+	db.Model(&inv).
+		Preload("Items", func(db *gorm.DB) *gorm.DB {
+			return db.Order("start_date ASC, id ASC")
+		}).
+		Where("id=? AND project_id=?", invoiceID, projectID).
+		First(&inv)
+	if inv.ID == 0 {
+		e = ErrInvoiceNotFound
+	}
+
+	return
+}
+
+// FinalizeInvoice mark all spent times of the draft invoice as billed and finalize it.
+// Everything is done in one transaction: if any of the spent times is billed by another
+// invoice or deleted meanwhile, nothing is changed and ErrEntriesAlreadyBilled is returned.
+// Spent times are locked and compared with the items, ErrEntriesChanged is returned
+// if any of them is edited after the draft is created.
+func FinalizeInvoice(db *gorm.DB, inv *models.Invoice, finalizedByID uint64) (e error) {
+	tx := db.Begin()
+	defer func() {
+		if e != nil {
+			tx.Rollback()
+		}
+	}()
+
+	var locked models.Invoice
+	tx.Set("gorm:query_option", "FOR UPDATE").
+		Where("id=?", inv.ID).
+		First(&locked)
+	if locked.ID == 0 {
+		e = ErrInvoiceNotFound
+		return
+	}
+	if locked.StatusID != models.INVOICE_STATUS_DRAFT {
+		e = ErrInvoiceIsFinalized
+		return
+	}
+
+	ids := make([]uint64, 0, len(inv.Items))
+	for _, item := range inv.Items {
+		ids = append(ids, item.TaskSpentTimeID)
+	}
+
+	now := time.Now()
+	if len(ids) > 0 {
+		var entries []models.TaskSpentTime
+		e = tx.Set("gorm:query_option", "FOR UPDATE").
+			Where("id IN (?)", ids).
+			Find(&entries).
+			Error
+		if e != nil {
+			return
+		}
+
+		byID := make(map[uint64]models.TaskSpentTime, len(entries))
+		for _, entry := range entries {
+			byID[entry.ID] = entry
+		}
+		for _, item := range inv.Items {
+			entry, ok := byID[item.TaskSpentTimeID]
+			if ok == false || entry.IsBilled {
+				e = ErrEntriesAlreadyBilled
+				return
+			}
+			if invoice.Matches(item, entry) == false {
+				e = ErrEntriesChanged
+				return
+			}
+		}
+
+		res := tx.Exec(`UPDATE task_spent_times SET is_billed=true, updated_at=?
+            WHERE id IN (?) AND is_billed=false AND deleted_at IS NULL`, now, ids)
+		if res.Error != nil {
+			e = res.Error
+			return
+		}
+		if res.RowsAffected != int64(len(ids)) {
+			e = ErrEntriesAlreadyBilled
+			return
+		}
+	}
+
+	e = tx.Model(&models.Invoice{}).Where("id=?", inv.ID).Updates(map[string]interface{}{
+		"status_id":       models.INVOICE_STATUS_FINALIZED,
+		"finalized_at":    now,
+		"finalized_by_id": finalizedByID,
+	}).Error
+	if e != nil {
+		return
+	}
+
+	e = tx.Commit().Error
+	if e != nil {
+		return
+	}
+
+	inv.StatusID = models.INVOICE_STATUS_FINALIZED
+	inv.FinalizedAt = &now
+	inv.FinalizedByID = &finalizedByID
+
+	return
+}
+
+// DeleteInvoice soft delete a draft invoice
+func DeleteInvoice(db *gorm.DB, inv models.Invoice) error {
+	if inv.StatusID != models.INVOICE_STATUS_DRAFT {
+		return ErrInvoiceIsFinalized
+	}
+
+	return db.Delete(&inv).Error
+}
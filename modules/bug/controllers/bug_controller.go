@@ -0,0 +1,167 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/jinzhu/gorm"
+
+	"devin/database"
+	"devin/helpers"
+	"devin/models"
+	bug_repo "devin/modules/bug/repository"
+	"devin/modules/rw_helpers"
+)
+
+// BugController handle functionalities of the bug tracker.
+// Bugs are issues having a bug label, they are promoted automatically
+// when a bug label is attached to them.
+type BugController struct{}
+
+// loadBug load project and the bug given in URL
+func loadBug(w http.ResponseWriter, r *http.Request, db *gorm.DB, authUser models.User) (project models.Project, issue models.Issue, e error) {
+	issueID, e := rw_helpers.ExtractIDFromURL(w, r)
+	if e != nil {
+		return
+	}
+
+	project, e = rw_helpers.LoadProjectForModule(w, r, db, authUser, models.MODULE_BUG_TRACKER)
+	if e != nil {
+		return
+	}
+
+	issue, e = rw_helpers.GetBugByID(w, db, project.ID, issueID)
+
+	return
+}
+
+// BugsIndex return paginated list of bugs of the project, most severe first.
+// Filters are passed as json in 'q' parameter of query string.
+// This route is accessible for anonymous users when public bugs are allowed.
+// @Route: /api/project/{project_id}/bugs?q={BugSearch}
+// @Method: GET
+func (BugController) BugsIndex(w http.ResponseWriter, r *http.Request) {
+	authUser, e := rw_helpers.GetOptionalAuthenticatedUser(w, r)
+	if e != nil {
+		return
+	}
+
+	searchModel, e := rw_helpers.DecodeBugSearchFilters(w, r)
+	if e != nil {
+		return
+	}
+
+	searchModel.PerPage = rw_helpers.GetPerPage(r)
+	searchModel.CurrentPage = rw_helpers.GetCurrectpage(r)
+
+	db := database.NewGORMInstance()
+	defer db.Close()
+
+	project, e := rw_helpers.LoadProjectForModule(w, r, db, authUser, models.MODULE_BUG_TRACKER)
+	if e != nil {
+		return
+	}
+	searchModel.ProjectID = project.ID
+
+	data, total, e := bug_repo.SearchBugs(db, searchModel)
+	if e != nil {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusInternalServerError,
+			Message:   "Fail to load bugs",
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+	rw_helpers.HideReporterEmails(db, authUser, project, data)
+	rw_helpers.RenderIssueMessages(db, project, data)
+
+	var pgn models.Pagination
+	pgn.Make(data, total, searchModel.CurrentPage, searchModel.PerPage)
+
+	w.Header().Add("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&pgn)
+}
+
+// Show return details of a bug.
+// This route is accessible for anonymous users when public bugs are allowed.
+// @Route: /api/project/{project_id}/bug/{id}
+// @Method: GET
+func (BugController) Show(w http.ResponseWriter, r *http.Request) {
+	authUser, e := rw_helpers.GetOptionalAuthenticatedUser(w, r)
+	if e != nil {
+		return
+	}
+
+	db := database.NewGORMInstance()
+	defer db.Close()
+
+	project, issue, e := loadBug(w, r, db, authUser)
+	if e != nil {
+		return
+	}
+
+	issues := []models.Issue{issue}
+	rw_helpers.HideReporterEmails(db, authUser, project, issues)
+	rw_helpers.RenderIssueMessages(db, project, issues)
+
+	w.Header().Add("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&issues[0])
+}
+
+// UpdateDetails update severity, reproduction steps and affected version of a bug
+// @Route: /api/project/{project_id}/bug/{id}/update
+// @Method: POST
+// @Content-Type: application/json
+func (BugController) UpdateDetails(w http.ResponseWriter, r *http.Request) {
+	if rw_helpers.IsJSONRequest(w, r) == false {
+		return
+	}
+
+	authUser, e := rw_helpers.GetAuthenticatedUser(w, r)
+	if e != nil {
+		return
+	}
+
+	reqModel, e := rw_helpers.DecodeBugDetailRequestModel(w, r)
+	if e != nil {
+		return
+	}
+	defer r.Body.Close()
+
+	if rw_helpers.ValidateBugDetailRequestModel(w, reqModel) != nil {
+		return
+	}
+
+	db := database.NewGORMInstance()
+	defer db.Close()
+
+	project, issue, e := loadBug(w, r, db, authUser)
+	if e != nil {
+		return
+	}
+
+	if rw_helpers.CanUpdateBug(w, db, authUser, project, issue) == false {
+		return
+	}
+
+	detail := models.IssueBugDetail{
+		IssueID:           issue.ID,
+		Severity:          reqModel.Severity,
+		ReproductionSteps: reqModel.ReproductionSteps,
+		AffectedVersion:   reqModel.AffectedVersion,
+	}
+	e = bug_repo.SaveBugDetail(db, &detail)
+	if e != nil {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusInternalServerError,
+			Message:   "Fail to save bug details",
+		}
+		err.Errors = make(map[string][]string)
+		err.Errors["dev"] = []string{e.Error()}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&detail)
+}
@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/jinzhu/gorm"
+
+	"devin/models"
+)
+
+// ErrBugNotFound returned when the issue is not found in the project or has no bug label
+var ErrBugNotFound = errors.New("Bug not found")
+
+// SearchBugs search on bugs of a project by given filters, most severe and newest first
+// @param db A new instance of database
+// @param searchModel search filters, searchModel.ProjectID is required
+func SearchBugs(db *gorm.DB, searchModel models.BugSearch) (data []models.Issue, total uint64, e error) {
+	db = db.Model(&models.Issue{})
+	db = searchModel.GetWhereClause(db)
+
+	e = db.Count(&total).Error
+	if e != nil {
+		return
+	}
+
+	if searchModel.PerPage > 0 {
+		db = db.Limit(searchModel.PerPage)
+	}
+	if searchModel.CurrentPage > 0 {
+		db = db.Offset((searchModel.CurrentPage - 1) * searchModel.PerPage)
+	}
+
+	e = db.Preload("Labels").
+		Preload("IssueAssignments").
+		Preload("BugDetail").
+		Order("(SELECT severity FROM issue_bug_details WHERE issue_id=issues.id) DESC NULLS LAST, created_at DESC, id DESC").
+		Find(&data).
+		Error
+
+	return
+}
+
+// GetBugByID load an issue of the project having a bug label, with its bug details
+func GetBugByID(db *gorm.DB, projectID, issueID uint64) (issue models.Issue, e error) {
+	var search models.BugSearch
+	search.ProjectID = projectID
+	search.ID = &issueID
+
+	search.GetWhereClause(db.Model(&issue)).
+		Preload("Status").
+		Preload("Labels").
+		Preload("IssueAssignments").
+		Preload("IssueAssignments.User").
+		Preload("CreatedBy").
+		Preload("BugDetail").
+		First(&issue)
+	if issue.ID == 0 {
+		e = ErrBugNotFound
+	}
+
+	return
+}
+
+// SaveBugDetail insert or update bug fields of an issue
+func SaveBugDetail(db *gorm.DB, detail *models.IssueBugDetail) error {
+	now := time.Now()
+	e := db.Exec(`INSERT INTO issue_bug_details (issue_id, severity, reproduction_steps, affected_version)
+        VALUES (?, ?, ?, ?)
+        ON CONFLICT (issue_id) DO UPDATE SET
+            severity=EXCLUDED.severity,
+            reproduction_steps=EXCLUDED.reproduction_steps,
+            affected_version=EXCLUDED.affected_version,
+            updated_at=?`,
+		detail.IssueID, detail.Severity, detail.ReproductionSteps, detail.AffectedVersion, now).Error
+	if e != nil {
+		return e
+	}
+
+	return db.Model(&models.IssueBugDetail{}).Where("issue_id=?", detail.IssueID).First(detail).Error
+}
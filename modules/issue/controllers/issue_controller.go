@@ -0,0 +1,467 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/jinzhu/gorm"
+
+	"devin/database"
+	"devin/helpers"
+	"devin/markdown"
+	"devin/models"
+	issue_repo "devin/modules/issue/repository"
+	"devin/modules/issue/workflow"
+	"devin/modules/notification/notify"
+	project_repo "devin/modules/project/repository"
+	"devin/modules/rw_helpers"
+	"devin/policies"
+)
+
+// IssueController handle functionalities of the issue tracker
+type IssueController struct{}
+
+type labelReqModel struct {
+	LabelID uint64
+}
+
+type userReqModel struct {
+	UserID uint64
+}
+
+// loadIssue load project and the issue given in URL
+func loadIssue(w http.ResponseWriter, r *http.Request, db *gorm.DB, authUser models.User) (project models.Project, issue models.Issue, e error) {
+	issueID, e := rw_helpers.ExtractIDFromURL(w, r)
+	if e != nil {
+		return
+	}
+
+	project, e = rw_helpers.LoadProjectForModule(w, r, db, authUser, models.MODULE_ISSUE_TRACKER)
+	if e != nil {
+		return
+	}
+
+	issue, e = rw_helpers.GetIssueByID(w, db, project.ID, issueID)
+	if e != nil {
+		return
+	}
+
+	// Bugs follow visibility of the bug tracker, e.g AllowPublicBugs for anonymous users
+	if policies.CanViewBugs(db, authUser, project) == false {
+		if issue.IsBug() {
+			err := helpers.ErrorResponse{
+				ErrorCode: http.StatusNotFound,
+				Message:   "No matching issue found!",
+			}
+			helpers.NewErrorResponse(w, &err)
+			e = errors.New(err.Message)
+			return
+		}
+		issue.BugDetail = nil
+	}
+
+	return
+}
+
+// decodeJSONBody decode request body to v. Handle request errors
+func decodeJSONBody(w http.ResponseWriter, r *http.Request, v interface{}) (e error) {
+	if helpers.IsRequestBodyNil(w, r) {
+		return errors.New("Request body is nil!")
+	}
+	defer r.Body.Close()
+
+	e = json.NewDecoder(r.Body).Decode(v)
+	if e != nil {
+		err := helpers.ErrorResponse{
+			Message:   "Invalid request!",
+			ErrorCode: http.StatusBadRequest,
+		}
+		helpers.NewErrorResponse(w, &err)
+	}
+
+	return
+}
+
+// IssuesIndex return paginated list of issues of the project.
+// Filters are passed as json in 'q' parameter of query string.
+// This route is accessible for anonymous users when public issues are allowed.
+// @Route: /api/project/{project_id}/issues?q={IssueSearch}
+// @Method: GET
+func (IssueController) IssuesIndex(w http.ResponseWriter, r *http.Request) {
+	authUser, e := rw_helpers.GetOptionalAuthenticatedUser(w, r)
+	if e != nil {
+		return
+	}
+
+	searchModel, e := rw_helpers.DecodeIssueSearchFilters(w, r)
+	if e != nil {
+		return
+	}
+
+	searchModel.PerPage = rw_helpers.GetPerPage(r)
+	searchModel.CurrentPage = rw_helpers.GetCurrectpage(r)
+
+	db := database.NewGORMInstance()
+	defer db.Close()
+
+	project, e := rw_helpers.LoadProjectForModule(w, r, db, authUser, models.MODULE_ISSUE_TRACKER)
+	if e != nil {
+		return
+	}
+	searchModel.ProjectID = project.ID
+	searchModel.ExcludeBugs = policies.CanViewBugs(db, authUser, project) == false
+
+	data, total, e := issue_repo.SearchIssues(db, searchModel)
+	if e != nil {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusInternalServerError,
+			Message:   "Fail to load issues",
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+	rw_helpers.HideReporterEmails(db, authUser, project, data)
+	rw_helpers.RenderIssueMessages(db, project, data)
+
+	var pgn models.Pagination
+	pgn.Make(data, total, searchModel.CurrentPage, searchModel.PerPage)
+
+	w.Header().Add("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&pgn)
+}
+
+// Show return details of an issue with its labels and assignees.
+// This route is accessible for anonymous users when public issues are allowed.
+// @Route: /api/project/{project_id}/issue/{id}
+// @Method: GET
+func (IssueController) Show(w http.ResponseWriter, r *http.Request) {
+	authUser, e := rw_helpers.GetOptionalAuthenticatedUser(w, r)
+	if e != nil {
+		return
+	}
+
+	db := database.NewGORMInstance()
+	defer db.Close()
+
+	project, issue, e := loadIssue(w, r, db, authUser)
+	if e != nil {
+		return
+	}
+
+	issues := []models.Issue{issue}
+	rw_helpers.HideReporterEmails(db, authUser, project, issues)
+	rw_helpers.RenderIssueMessages(db, project, issues)
+
+	w.Header().Add("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&issues[0])
+}
+
+// Open file a new issue in the project.
+// Anonymous users can open issues when public issues are allowed, they must give ReporterEmail.
+// @Route: /api/project/{project_id}/issues/open
+// @Method: POST
+// @Content-Type: application/json
+func (IssueController) Open(w http.ResponseWriter, r *http.Request) {
+	if rw_helpers.IsJSONRequest(w, r) == false {
+		return
+	}
+
+	authUser, e := rw_helpers.GetOptionalAuthenticatedUser(w, r)
+	if e != nil {
+		return
+	}
+
+	reqModel, e := rw_helpers.DecodeIssueRequestModel(w, r)
+	if e != nil {
+		return
+	}
+	defer r.Body.Close()
+
+	db := database.NewGORMInstance()
+	defer db.Close()
+
+	project, e := rw_helpers.LoadProjectForModule(w, r, db, authUser, models.MODULE_ISSUE_TRACKER)
+	if e != nil {
+		return
+	}
+
+	if rw_helpers.CanCreateIssue(w, db, authUser, project) == false {
+		return
+	}
+
+	if rw_helpers.ValidateIssueRequestModel(w, db, project, authUser, reqModel) != nil {
+		return
+	}
+
+	issue := models.Issue{
+		ProjectID:    project.ID,
+		RepositoryID: reqModel.RepositoryID,
+		Title:        reqModel.Title,
+		Message:      reqModel.Message,
+		StatusID:     models.ISSUE_STATUS_PENDING,
+	}
+	if helpers.IsNilUint64(issue.RepositoryID) {
+		issue.RepositoryID = nil
+	}
+	if authUser.ID != 0 {
+		issue.CreatedByID = &authUser.ID
+	} else {
+		issue.ReporterEmail = reqModel.ReporterEmail
+	}
+
+	e = issue_repo.SaveIssue(db, &issue)
+	if e != nil {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusInternalServerError,
+			Message:   "Fail to save issue",
+		}
+		err.Errors = make(map[string][]string)
+		err.Errors["dev"] = []string{e.Error()}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+	issue.MessageHTML = markdown.Render(issue.Message, markdown.NewProjectResolver(db, project))
+
+	w.Header().Add("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&issue)
+}
+
+// Delete soft delete the given issue
+// @Route: /api/project/{project_id}/issue/{id}/delete
+// @Method: POST
+func (IssueController) Delete(w http.ResponseWriter, r *http.Request) {
+	authUser, e := rw_helpers.GetAuthenticatedUser(w, r)
+	if e != nil {
+		return
+	}
+
+	db := database.NewGORMInstance()
+	defer db.Close()
+
+	project, issue, e := loadIssue(w, r, db, authUser)
+	if e != nil {
+		return
+	}
+
+	if rw_helpers.CanManageIssues(w, db, authUser, project) == false {
+		return
+	}
+
+	e = issue_repo.DeleteIssue(db, issue)
+	if e != nil {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusInternalServerError,
+			Message:   "Fail to delete issue",
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+
+	helpers.NewSuccessResponse(w, "Issue deleted.")
+}
+
+// SetStatus transit the issue to the status given in URL: pending, in_progress or closed.
+// Going in progress sets SetAsInProgressDateTime and closing sets SetAsResolvedDateTime.
+// @Route: /api/project/{project_id}/issue/{id}/set_status/{status}
+// @Method: POST
+func (IssueController) SetStatus(w http.ResponseWriter, r *http.Request) {
+	authUser, e := rw_helpers.GetAuthenticatedUser(w, r)
+	if e != nil {
+		return
+	}
+
+	statusID, ok := workflow.StatusByName(mux.Vars(r)["status"])
+	if ok == false {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusUnprocessableEntity,
+			Message:   "Invalid status!",
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+
+	db := database.NewGORMInstance()
+	defer db.Close()
+
+	project, issue, e := loadIssue(w, r, db, authUser)
+	if e != nil {
+		return
+	}
+
+	if rw_helpers.CanChangeIssueStatus(w, db, authUser, project, issue) == false {
+		return
+	}
+
+	e = workflow.Transit(&issue, statusID, time.Now())
+	if e != nil {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusUnprocessableEntity,
+			Message:   "Issue can't go to this status from its current status!",
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+
+	e = issue_repo.UpdateStatus(db, issue)
+	if e != nil {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusInternalServerError,
+			Message:   "Fail to update status",
+		}
+		err.Errors = make(map[string][]string)
+		err.Errors["dev"] = []string{e.Error()}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+	issue.Status = nil
+
+	w.Header().Add("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&issue)
+}
+
+// AddLabel add a label of the project to the issue
+// @Route: /api/project/{project_id}/issue/{id}/labels/add
+// @Method: POST
+// @Content-Type: application/json
+func (IssueController) AddLabel(w http.ResponseWriter, r *http.Request) {
+	changeLabel(w, r, true)
+}
+
+// RemoveLabel remove a label from the issue
+// @Route: /api/project/{project_id}/issue/{id}/labels/remove
+// @Method: POST
+// @Content-Type: application/json
+func (IssueController) RemoveLabel(w http.ResponseWriter, r *http.Request) {
+	changeLabel(w, r, false)
+}
+
+func changeLabel(w http.ResponseWriter, r *http.Request, add bool) {
+	if rw_helpers.IsJSONRequest(w, r) == false {
+		return
+	}
+
+	authUser, e := rw_helpers.GetAuthenticatedUser(w, r)
+	if e != nil {
+		return
+	}
+
+	var reqModel labelReqModel
+	if decodeJSONBody(w, r, &reqModel) != nil {
+		return
+	}
+
+	db := database.NewGORMInstance()
+	defer db.Close()
+
+	project, issue, e := loadIssue(w, r, db, authUser)
+	if e != nil {
+		return
+	}
+
+	if rw_helpers.CanManageIssues(w, db, authUser, project) == false {
+		return
+	}
+
+	if add {
+		e = issue_repo.AddLabel(db, issue, reqModel.LabelID, authUser.ID)
+	} else {
+		e = issue_repo.RemoveLabel(db, issue.ID, reqModel.LabelID)
+	}
+	if e != nil {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusInternalServerError,
+			Message:   "Fail to update labels",
+		}
+		if e == issue_repo.ErrLabelNotFound {
+			err.ErrorCode = http.StatusUnprocessableEntity
+			err.Errors = make(map[string][]string)
+			err.Errors["LabelID"] = []string{"Selected label doesn't belong to this project!"}
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+
+	helpers.NewSuccessResponse(w, "Labels updated.")
+}
+
+// Assign assign a member of the project to the issue
+// @Route: /api/project/{project_id}/issue/{id}/assign
+// @Method: POST
+// @Content-Type: application/json
+func (IssueController) Assign(w http.ResponseWriter, r *http.Request) {
+	changeAssignee(w, r, true)
+}
+
+// Unassign remove a user from assignees of the issue
+// @Route: /api/project/{project_id}/issue/{id}/unassign
+// @Method: POST
+// @Content-Type: application/json
+func (IssueController) Unassign(w http.ResponseWriter, r *http.Request) {
+	changeAssignee(w, r, false)
+}
+
+func changeAssignee(w http.ResponseWriter, r *http.Request, assign bool) {
+	if rw_helpers.IsJSONRequest(w, r) == false {
+		return
+	}
+
+	authUser, e := rw_helpers.GetAuthenticatedUser(w, r)
+	if e != nil {
+		return
+	}
+
+	var reqModel userReqModel
+	if decodeJSONBody(w, r, &reqModel) != nil {
+		return
+	}
+
+	db := database.NewGORMInstance()
+	defer db.Close()
+
+	project, issue, e := loadIssue(w, r, db, authUser)
+	if e != nil {
+		return
+	}
+
+	if rw_helpers.CanManageIssues(w, db, authUser, project) == false {
+		return
+	}
+
+	if assign {
+		if project_repo.IsProjectMember(db, project, reqModel.UserID) == false {
+			err := helpers.ErrorResponse{
+				Message:   "User is not a member of this project!",
+				ErrorCode: http.StatusUnprocessableEntity,
+			}
+			helpers.NewErrorResponse(w, &err)
+			return
+		}
+		// Assignee is notified once, when the assignment is created
+		notifyAssignee := issue_repo.IsAssigned(db, issue.ID, reqModel.UserID) == false
+		tx := db.Begin()
+		e = issue_repo.Assign(tx, issue.ID, reqModel.UserID, authUser.ID)
+		if e == nil && notifyAssignee {
+			e = notify.IssueAssignment(tx, issue, reqModel.UserID, authUser.ID)
+		}
+		if e == nil {
+			e = tx.Commit().Error
+		} else {
+			tx.Rollback()
+		}
+	} else {
+		e = issue_repo.Unassign(db, issue.ID, reqModel.UserID)
+	}
+	if e != nil {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusInternalServerError,
+			Message:   "Fail to update assignees",
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+
+	helpers.NewSuccessResponse(w, "Assignees updated.")
+}
@@ -0,0 +1,157 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"devin/database"
+	"devin/helpers"
+	"devin/models"
+	issue_repo "devin/modules/issue/repository"
+	"devin/modules/rw_helpers"
+)
+
+// LabelsIndex return all issue labels of the project.
+// This route is accessible for anonymous users when public issues are allowed.
+// @Route: /api/project/{project_id}/issue_labels
+// @Method: GET
+func (IssueController) LabelsIndex(w http.ResponseWriter, r *http.Request) {
+	authUser, e := rw_helpers.GetOptionalAuthenticatedUser(w, r)
+	if e != nil {
+		return
+	}
+
+	db := database.NewGORMInstance()
+	defer db.Close()
+
+	project, e := rw_helpers.LoadProjectForModule(w, r, db, authUser, models.MODULE_ISSUE_TRACKER)
+	if e != nil {
+		return
+	}
+
+	labels, e := issue_repo.GetLabelsOfProject(db, project.ID)
+	if e != nil {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusInternalServerError,
+			Message:   "Fail to load labels",
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&labels)
+}
+
+// SaveLabel handle inserting and updating of issue labels
+// If no ID present in the request model, it will insert as new label
+// otherwise the given label will be updated
+// @Route: /api/project/{project_id}/issue_labels/save
+// @Method: POST
+// @Content-Type: application/json
+func (IssueController) SaveLabel(w http.ResponseWriter, r *http.Request) {
+	if rw_helpers.IsJSONRequest(w, r) == false {
+		return
+	}
+
+	authUser, e := rw_helpers.GetAuthenticatedUser(w, r)
+	if e != nil {
+		return
+	}
+
+	reqModel, e := rw_helpers.DecodeIssueLabelRequestModel(w, r)
+	if e != nil {
+		return
+	}
+	defer r.Body.Close()
+
+	db := database.NewGORMInstance()
+	defer db.Close()
+
+	project, e := rw_helpers.LoadProjectForModule(w, r, db, authUser, models.MODULE_ISSUE_TRACKER)
+	if e != nil {
+		return
+	}
+
+	if rw_helpers.CanManageIssues(w, db, authUser, project) == false {
+		return
+	}
+
+	label := models.IssueLabel{}
+	if reqModel.ID != 0 {
+		// Edit mode
+		label, e = rw_helpers.GetIssueLabelByID(w, db, project.ID, reqModel.ID)
+		if e != nil {
+			return
+		}
+	} else {
+		label.ProjectID = project.ID
+		label.CreatedByID = authUser.ID
+	}
+	label.Label = reqModel.Label
+	label.Color = reqModel.Color
+	label.IsBugLable = reqModel.IsBugLable
+
+	if rw_helpers.ValidateIssueLabelRequestModel(w, db, label) != nil {
+		return
+	}
+
+	e = issue_repo.SaveLabel(db, &label)
+	if e != nil {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusInternalServerError,
+			Message:   "Fail to save label",
+		}
+		err.Errors = make(map[string][]string)
+		err.Errors["dev"] = []string{e.Error()}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&label)
+}
+
+// DeleteLabel soft delete the label and remove it from issues of the project
+// @Route: /api/project/{project_id}/issue_label/{id}/delete
+// @Method: POST
+func (IssueController) DeleteLabel(w http.ResponseWriter, r *http.Request) {
+	authUser, e := rw_helpers.GetAuthenticatedUser(w, r)
+	if e != nil {
+		return
+	}
+
+	labelID, e := rw_helpers.ExtractIDFromURL(w, r)
+	if e != nil {
+		return
+	}
+
+	db := database.NewGORMInstance()
+	defer db.Close()
+
+	project, e := rw_helpers.LoadProjectForModule(w, r, db, authUser, models.MODULE_ISSUE_TRACKER)
+	if e != nil {
+		return
+	}
+
+	if rw_helpers.CanManageIssues(w, db, authUser, project) == false {
+		return
+	}
+
+	label, e := rw_helpers.GetIssueLabelByID(w, db, project.ID, labelID)
+	if e != nil {
+		return
+	}
+
+	e = issue_repo.DeleteLabel(db, label)
+	if e != nil {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusInternalServerError,
+			Message:   "Fail to delete label",
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+
+	helpers.NewSuccessResponse(w, "Label deleted.")
+}
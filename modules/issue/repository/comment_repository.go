@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"github.com/jinzhu/gorm"
+
+	"devin/models"
+)
+
+// GetComments load paginated comments of the issue, oldest first
+func GetComments(db *gorm.DB, issueID, currentPage, perPage uint64) (data []models.IssueComment, total uint64, e error) {
+	db = db.Model(&models.IssueComment{}).Where("issue_id=?", issueID)
+
+	e = db.Count(&total).Error
+	if e != nil {
+		return
+	}
+
+	if perPage > 0 {
+		db = db.Limit(perPage)
+	}
+	if currentPage > 0 {
+		db = db.Offset((currentPage - 1) * perPage)
+	}
+
+	e = db.Preload("CreatedBy").
+		Order("created_at ASC, id ASC").
+		Find(&data).
+		Error
+
+	return
+}
+
+// GetCommentByID load a comment of the issue
+func GetCommentByID(db *gorm.DB, issueID, commentID uint64) (comment models.IssueComment, e error) {
+	db.Model(&comment).Where("id=? AND issue_id=?", commentID, issueID).First(&comment)
+	if comment.ID == 0 {
+		e = ErrCommentNotFound
+	}
+
+	return
+}
+
+// SaveComment insert a new comment
+func SaveComment(db *gorm.DB, comment *models.IssueComment) error {
+	return db.Set("gorm:save_associations", false).Create(comment).Error
+}
+
+// DeleteComment soft delete the comment
+func DeleteComment(db *gorm.DB, comment models.IssueComment) error {
+	return db.Delete(&comment).Error
+}
@@ -0,0 +1,156 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/jinzhu/gorm"
+
+	"devin/models"
+)
+
+// ErrIssueNotFound returned when the issue is not found in the project
+var ErrIssueNotFound = errors.New("Issue not found")
+
+// ErrLabelNotFound returned when the label is not found in the project
+var ErrLabelNotFound = errors.New("Label not found")
+
+// ErrCommentNotFound returned when the comment is not found in the issue
+var ErrCommentNotFound = errors.New("Comment not found")
+
+// SearchIssues search on issues of a project by given filters, newest first
+// @param db A new instance of database
+// @param searchModel search filters, searchModel.ProjectID is required
+func SearchIssues(db *gorm.DB, searchModel models.IssueSearch) (data []models.Issue, total uint64, e error) {
+	db = db.Model(&models.Issue{})
+	db = searchModel.GetWhereClause(db)
+
+	e = db.Count(&total).Error
+	if e != nil {
+		return
+	}
+
+	if searchModel.PerPage > 0 {
+		db = db.Limit(searchModel.PerPage)
+	}
+	if searchModel.CurrentPage > 0 {
+		db = db.Offset((searchModel.CurrentPage - 1) * searchModel.PerPage)
+	}
+
+	e = db.Preload("Labels").
+		Preload("IssueAssignments").
+		Order("created_at DESC, id DESC").
+		Find(&data).
+		Error
+
+	return
+}
+
+// GetIssueByID load an issue of the project with its labels, assignments and reporter
+func GetIssueByID(db *gorm.DB, projectID, issueID uint64) (issue models.Issue, e error) {
+	db.Model(&issue).
+		Preload("Status").
+		Preload("Labels").
+		Preload("IssueAssignments").
+		Preload("IssueAssignments.User").
+		Preload("CreatedBy").
+		Preload("BugDetail").
+		Where("id=? AND project_id=?", issueID, projectID).
+		First(&issue)
+	if issue.ID == 0 {
+		e = ErrIssueNotFound
+	}
+
+	return
+}
+
+// SaveIssue insert new issue or update the existing one.
+// Relations are not saved, they have their own functions.
+func SaveIssue(db *gorm.DB, issue *models.Issue) error {
+	db = db.Set("gorm:save_associations", false)
+	if issue.ID == 0 {
+		return db.Create(issue).Error
+	}
+
+	return db.Save(issue).Error
+}
+
+// UpdateStatus store status and status datetimes of the issue
+func UpdateStatus(db *gorm.DB, issue models.Issue) error {
+	return db.Model(&models.Issue{}).
+		Where("id=? AND project_id=?", issue.ID, issue.ProjectID).
+		Updates(map[string]interface{}{
+			"status_id":                   issue.StatusID,
+			"set_as_in_progress_datetime": issue.SetAsInProgressDateTime,
+			"set_as_resolved_datetime":    issue.SetAsResolvedDateTime,
+		}).
+		Error
+}
+
+// DeleteIssue soft delete the issue
+func DeleteIssue(db *gorm.DB, issue models.Issue) error {
+	return db.Delete(&issue).Error
+}
+
+// AddLabel add a label of the project to the issue, if not added before.
+// Adding a bug label promotes the issue to the bug tracker.
+func AddLabel(db *gorm.DB, issue models.Issue, labelID, createdByID uint64) (e error) {
+	label, e := GetLabelByID(db, issue.ProjectID, labelID)
+	if e != nil {
+		return
+	}
+
+	tx := db.Begin()
+	defer func() {
+		if e != nil {
+			tx.Rollback()
+		}
+	}()
+
+	e = tx.Exec(`INSERT INTO labeled_issues (issue_id, issue_label_id, created_by_id)
+        VALUES (?, ?, ?) ON CONFLICT (issue_id, issue_label_id) DO NOTHING`, issue.ID, label.ID, createdByID).Error
+	if e != nil {
+		return
+	}
+
+	if label.IsBugLable {
+		e = tx.Exec(`INSERT INTO issue_bug_details (issue_id, severity)
+            VALUES (?, ?) ON CONFLICT (issue_id) DO NOTHING`, issue.ID, models.BUG_SEVERITY_NORMAL).Error
+		if e != nil {
+			return
+		}
+	}
+
+	return tx.Commit().Error
+}
+
+// RemoveLabel remove a label from the issue
+func RemoveLabel(db *gorm.DB, issueID, labelID uint64) error {
+	return db.Exec("DELETE FROM labeled_issues WHERE issue_id=? AND issue_label_id=?", issueID, labelID).Error
+}
+
+// Assign add a user to assignees of the issue, if not added before
+func Assign(db *gorm.DB, issueID, userID, createdByID uint64) error {
+	return db.Exec(`INSERT INTO issue_assignments (issue_id, user_id, created_by_id)
+        VALUES (?, ?, ?) ON CONFLICT (issue_id, user_id) DO NOTHING`, issueID, userID, createdByID).Error
+}
+
+// Unassign remove a user from assignees of the issue
+func Unassign(db *gorm.DB, issueID, userID uint64) error {
+	return db.Exec("DELETE FROM issue_assignments WHERE issue_id=? AND user_id=?", issueID, userID).Error
+}
+
+// promoteIssuesOfLabel create bug details of all issues having the label.
+// Issues which are already promoted keep their details.
+func promoteIssuesOfLabel(db *gorm.DB, labelID uint64) error {
+	return db.Exec(`INSERT INTO issue_bug_details (issue_id, severity)
+        SELECT issue_id, ? FROM labeled_issues WHERE issue_label_id=?
+        ON CONFLICT (issue_id) DO NOTHING`, models.BUG_SEVERITY_NORMAL, labelID).Error
+}
+
+// IsAssigned check the user to be assigned to the issue
+func IsAssigned(db *gorm.DB, issueID, userID uint64) bool {
+	var count uint64
+	db.Model(&models.IssueAssignment{}).Where("issue_id=? AND user_id=?", issueID, userID).Count(&count)
+
+	return count > 0
+}
@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"github.com/jinzhu/gorm"
+
+	"devin/models"
+)
+
+// GetLabelsOfProject load all labels of the project sorted by name
+func GetLabelsOfProject(db *gorm.DB, projectID uint64) (labels []models.IssueLabel, e error) {
+	e = db.Model(&models.IssueLabel{}).
+		Where("project_id=?", projectID).
+		Order("label ASC").
+		Find(&labels).
+		Error
+
+	return
+}
+
+// GetLabelByID load a label of the project
+func GetLabelByID(db *gorm.DB, projectID, labelID uint64) (label models.IssueLabel, e error) {
+	db.Model(&label).Where("id=? AND project_id=?", labelID, projectID).First(&label)
+	if label.ID == 0 {
+		e = ErrLabelNotFound
+	}
+
+	return
+}
+
+// IsUniqueLabel check name of the label to be unique in its project
+func IsUniqueLabel(db *gorm.DB, label models.IssueLabel) bool {
+	var count uint64
+	db.Model(&models.IssueLabel{}).
+		Where("project_id=? AND lower(label)=lower(?) AND id<>?", label.ProjectID, label.Label, label.ID).
+		Count(&count)
+
+	return count == 0
+}
+
+// SaveLabel insert new label or update the existing one.
+// When a label is marked as bug label, issues having it are promoted to the bug tracker.
+func SaveLabel(db *gorm.DB, label *models.IssueLabel) (e error) {
+	tx := db.Set("gorm:save_associations", false).Begin()
+	defer func() {
+		if e != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if label.ID == 0 {
+		e = tx.Create(label).Error
+	} else {
+		e = tx.Save(label).Error
+	}
+	if e != nil {
+		return
+	}
+
+	if label.IsBugLable {
+		e = promoteIssuesOfLabel(tx, label.ID)
+		if e != nil {
+			return
+		}
+	}
+
+	return tx.Commit().Error
+}
+
+// DeleteLabel soft delete the label and remove it from issues
+func DeleteLabel(db *gorm.DB, label models.IssueLabel) (e error) {
+	tx := db.Begin()
+	defer func() {
+		if e != nil {
+			tx.Rollback()
+		}
+	}()
+
+	e = tx.Exec("DELETE FROM labeled_issues WHERE issue_label_id=?", label.ID).Error
+	if e != nil {
+		return
+	}
+
+	e = tx.Delete(&label).Error
+	if e != nil {
+		return
+	}
+
+	return tx.Commit().Error
+}
@@ -0,0 +1,69 @@
+// Package workflow implement status transitions of issues.
+// An issue is opened as pending, goes in progress when the team starts to follow it
+// and is closed when it is resolved. Pending issues can be closed directly
+// (e.g. duplicates) and closed issues can be reopened.
+package workflow
+
+import (
+	"errors"
+	"time"
+
+	"devin/models"
+)
+
+// ErrInvalidTransition returned when an issue can't go from its status to the target one
+var ErrInvalidTransition = errors.New("Invalid status transition")
+
+// transitions maps each status to the statuses reachable from it
+var transitions = map[uint][]uint{
+	models.ISSUE_STATUS_PENDING:     {models.ISSUE_STATUS_IN_PROGRESS, models.ISSUE_STATUS_CLOSED},
+	models.ISSUE_STATUS_IN_PROGRESS: {models.ISSUE_STATUS_PENDING, models.ISSUE_STATUS_CLOSED},
+	models.ISSUE_STATUS_CLOSED:      {models.ISSUE_STATUS_PENDING},
+}
+
+var statusNames = map[string]uint{
+	"pending":     models.ISSUE_STATUS_PENDING,
+	"in_progress": models.ISSUE_STATUS_IN_PROGRESS,
+	"closed":      models.ISSUE_STATUS_CLOSED,
+}
+
+// StatusByName return status ID of the given name: pending, in_progress or closed
+func StatusByName(name string) (statusID uint, ok bool) {
+	statusID, ok = statusNames[name]
+
+	return
+}
+
+// CanTransit check the issue to be able to go from status 'from' to status 'to'
+func CanTransit(from, to uint) bool {
+	for _, s := range transitions[from] {
+		if s == to {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Transit change status of the issue and set its datetimes:
+// going in progress sets SetAsInProgressDateTime, closing sets SetAsResolvedDateTime,
+// going back to a previous status clears the datetimes of the later ones.
+func Transit(issue *models.Issue, to uint, now time.Time) error {
+	if CanTransit(issue.StatusID, to) == false {
+		return ErrInvalidTransition
+	}
+
+	switch to {
+	case models.ISSUE_STATUS_PENDING:
+		issue.SetAsInProgressDateTime = nil
+		issue.SetAsResolvedDateTime = nil
+	case models.ISSUE_STATUS_IN_PROGRESS:
+		issue.SetAsInProgressDateTime = &now
+		issue.SetAsResolvedDateTime = nil
+	case models.ISSUE_STATUS_CLOSED:
+		issue.SetAsResolvedDateTime = &now
+	}
+	issue.StatusID = to
+
+	return nil
+}
@@ -0,0 +1,58 @@
+package workflow
+
+import (
+	"testing"
+	"time"
+
+	"devin/models"
+)
+
+func TestTransit(t *testing.T) {
+	now := time.Now()
+	issue := models.Issue{StatusID: models.ISSUE_STATUS_PENDING}
+
+	if e := Transit(&issue, models.ISSUE_STATUS_IN_PROGRESS, now); e != nil {
+		t.Fatal(e)
+	}
+	if issue.SetAsInProgressDateTime == nil || issue.SetAsInProgressDateTime.Equal(now) == false || issue.SetAsResolvedDateTime != nil {
+		t.Fatal("In progress datetime must be set", issue)
+	}
+
+	closedAt := now.Add(time.Hour)
+	if e := Transit(&issue, models.ISSUE_STATUS_CLOSED, closedAt); e != nil {
+		t.Fatal(e)
+	}
+	if issue.SetAsResolvedDateTime == nil || issue.SetAsResolvedDateTime.Equal(closedAt) == false || issue.SetAsInProgressDateTime == nil {
+		t.Fatal("Resolved datetime must be set and in progress datetime kept", issue)
+	}
+
+	if e := Transit(&issue, models.ISSUE_STATUS_IN_PROGRESS, now); e != ErrInvalidTransition {
+		t.Fatal("Closed issue must be reopened before going in progress")
+	}
+
+	if e := Transit(&issue, models.ISSUE_STATUS_PENDING, now); e != nil {
+		t.Fatal(e)
+	}
+	if issue.StatusID != models.ISSUE_STATUS_PENDING || issue.SetAsInProgressDateTime != nil || issue.SetAsResolvedDateTime != nil {
+		t.Fatal("Reopened issue must be pending without datetimes", issue)
+	}
+}
+
+func TestTransitToSameStatus(t *testing.T) {
+	issue := models.Issue{StatusID: models.ISSUE_STATUS_PENDING}
+	if Transit(&issue, models.ISSUE_STATUS_PENDING, time.Now()) != ErrInvalidTransition {
+		t.Fatal("Transition to the same status must fail")
+	}
+	if Transit(&issue, 99, time.Now()) != ErrInvalidTransition {
+		t.Fatal("Transition to unknown status must fail")
+	}
+}
+
+func TestStatusByName(t *testing.T) {
+	if s, ok := StatusByName("in_progress"); ok == false || s != models.ISSUE_STATUS_IN_PROGRESS {
+		t.Fatal("Invalid status", s)
+	}
+	if _, ok := StatusByName("resolved"); ok {
+		t.Fatal("Unknown names must not be accepted")
+	}
+}
@@ -0,0 +1,90 @@
+// Package burndown calculate daily burndown chart data of a milestone
+package burndown
+
+import (
+	"time"
+
+	"devin/models"
+)
+
+// MaxDays limit length of the chart, to protect against far due dates
+const MaxDays = 730
+
+// Day is the state of tasks at the end of a day.
+// Completed and Remaining are nil for days after today.
+type Day struct {
+	Date      time.Time
+	Total     *int
+	Completed *int
+	Remaining *int
+	Ideal     float64 `doc:"Remaining tasks on the ideal line, from the total of the first day to zero on the due date"`
+}
+
+// Chart is burndown data of a milestone from start date to due date
+type Chart struct {
+	StartDate time.Time
+	DueDate   time.Time
+	Days      []Day
+}
+
+// Calculate count total, completed and remaining tasks at the end of each day from
+// start to dueDate. A task is counted since its CreatedAt and completed since its CompletionDate,
+// tasks created before start are counted on the first day.
+// Days are calculated in loc time zone.
+func Calculate(tasks []models.Task, start, dueDate, now time.Time, loc *time.Location) (chart Chart) {
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	chart.StartDate = startOfDay(start.In(loc))
+	chart.DueDate = startOfDay(dueDate.In(loc))
+	if chart.DueDate.Before(chart.StartDate) {
+		chart.DueDate = chart.StartDate
+	}
+
+	chart.Days = []Day{}
+	for day := chart.StartDate; day.After(chart.DueDate) == false && len(chart.Days) < MaxDays; day = day.AddDate(0, 0, 1) {
+		chart.Days = append(chart.Days, Day{Date: day})
+	}
+
+	for i := range chart.Days {
+		endOfDay := chart.Days[i].Date.AddDate(0, 0, 1)
+		if chart.Days[i].Date.After(now) {
+			continue
+		}
+
+		total, completed := 0, 0
+		for _, task := range tasks {
+			if task.CreatedAt.Before(endOfDay) == false {
+				continue
+			}
+			total++
+			if task.CompletionDate != nil && task.CompletionDate.Before(endOfDay) {
+				completed++
+			}
+		}
+		remaining := total - completed
+
+		chart.Days[i].Total = &total
+		chart.Days[i].Completed = &completed
+		chart.Days[i].Remaining = &remaining
+	}
+
+	if len(chart.Days) > 0 && chart.Days[0].Total != nil {
+		first := float64(*chart.Days[0].Total)
+		steps := float64(len(chart.Days) - 1)
+		for i := range chart.Days {
+			if steps == 0 {
+				chart.Days[i].Ideal = 0
+				continue
+			}
+			chart.Days[i].Ideal = first - first*float64(i)/steps
+		}
+	}
+
+	return
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
@@ -0,0 +1,77 @@
+package burndown
+
+import (
+	"testing"
+	"time"
+
+	"devin/models"
+)
+
+func date(day, hour int) time.Time {
+	return time.Date(2018, 6, day, hour, 0, 0, 0, time.UTC)
+}
+
+func task(createdDay int, completedDay int) models.Task {
+	t := models.Task{CreatedAt: date(createdDay, 10)}
+	if completedDay > 0 {
+		completion := date(completedDay, 15)
+		t.CompletionDate = &completion
+	}
+
+	return t
+}
+
+func TestCalculate(t *testing.T) {
+	tasks := []models.Task{
+		task(1, 2),
+		task(1, 0),
+		task(3, 3),
+	}
+
+	chart := Calculate(tasks, date(1, 8), date(5, 0), date(3, 18), time.UTC)
+
+	if len(chart.Days) != 5 {
+		t.Fatalf("Expected 5 days, got %d", len(chart.Days))
+	}
+
+	expected := []struct{ total, completed, remaining int }{
+		{2, 0, 2},
+		{2, 1, 1},
+		{3, 2, 1},
+	}
+	for i, exp := range expected {
+		day := chart.Days[i]
+		if *day.Total != exp.total || *day.Completed != exp.completed || *day.Remaining != exp.remaining {
+			t.Fatalf("Invalid day %d: total=%d completed=%d remaining=%d", i, *day.Total, *day.Completed, *day.Remaining)
+		}
+	}
+
+	if chart.Days[3].Remaining != nil || chart.Days[4].Remaining != nil {
+		t.Fatal("Future days must not have actual values")
+	}
+
+	if chart.Days[0].Ideal != 2 || chart.Days[4].Ideal != 0 || chart.Days[2].Ideal != 1 {
+		t.Fatalf("Invalid ideal line: %v, %v, %v", chart.Days[0].Ideal, chart.Days[2].Ideal, chart.Days[4].Ideal)
+	}
+}
+
+func TestCalculateCountsEarlierTasksOnFirstDay(t *testing.T) {
+	chart := Calculate([]models.Task{task(2, 0), task(1, 3)}, date(4, 0), date(5, 0), date(5, 0), time.UTC)
+
+	if chart.StartDate.Day() != 4 || len(chart.Days) != 2 {
+		t.Fatalf("Chart must start from start date of milestone, got %v with %d days", chart.StartDate, len(chart.Days))
+	}
+
+	first := chart.Days[0]
+	if *first.Total != 2 || *first.Completed != 1 || first.Ideal != 2 {
+		t.Fatalf("Tasks created before start must be counted on the first day: total=%d completed=%d ideal=%v", *first.Total, *first.Completed, first.Ideal)
+	}
+}
+
+func TestCalculateDueDateBeforeStart(t *testing.T) {
+	chart := Calculate(nil, date(5, 0), date(1, 0), date(5, 0), time.UTC)
+
+	if len(chart.Days) != 1 {
+		t.Fatalf("Expected a single day, got %d", len(chart.Days))
+	}
+}
@@ -0,0 +1,207 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"devin/database"
+	"devin/helpers"
+	"devin/markdown"
+	"devin/models"
+	milestone_repo "devin/modules/milestone/repository"
+	"devin/modules/notification/mention"
+	"devin/modules/rw_helpers"
+)
+
+type commentReqModel struct {
+	ReplyToID *uint64
+	Comment   string
+}
+
+// CommentsIndex return paginated comments of the milestone
+// @Route: /api/project/{project_id}/milestone/{id}/comments
+// @Method: GET
+func (MilestoneController) CommentsIndex(w http.ResponseWriter, r *http.Request) {
+	authUser, e := rw_helpers.GetAuthenticatedUser(w, r)
+	if e != nil {
+		return
+	}
+
+	perPage := rw_helpers.GetPerPage(r)
+	currentPage := rw_helpers.GetCurrectpage(r)
+
+	db := database.NewGORMInstance()
+	defer db.Close()
+
+	project, milestone, e := loadMilestone(w, r, db, authUser)
+	if e != nil {
+		return
+	}
+
+	data, total, e := milestone_repo.GetComments(db, milestone.ID, currentPage, perPage)
+	if e != nil {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusInternalServerError,
+			Message:   "Fail to load comments",
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+	resolver := markdown.NewProjectResolver(db, project)
+	for i := range data {
+		data[i].CommentHTML = markdown.Render(data[i].Comment, resolver)
+	}
+
+	var pgn models.Pagination
+	pgn.Make(data, total, currentPage, perPage)
+
+	w.Header().Add("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&pgn)
+}
+
+// AddComment add a comment to the milestone. Only members of the project can comment.
+// Mentioned members are notified and added to followers of the milestone.
+// @Route: /api/project/{project_id}/milestone/{id}/comments/add
+// @Method: POST
+// @Content-Type: application/json
+func (MilestoneController) AddComment(w http.ResponseWriter, r *http.Request) {
+	if rw_helpers.IsJSONRequest(w, r) == false {
+		return
+	}
+
+	authUser, e := rw_helpers.GetAuthenticatedUser(w, r)
+	if e != nil {
+		return
+	}
+
+	var reqModel commentReqModel
+	if decodeJSONBody(w, r, &reqModel) != nil {
+		return
+	}
+
+	if strings.EqualFold(strings.TrimSpace(reqModel.Comment), "") {
+		err := helpers.ErrorResponse{
+			Message:   "Invalid data!",
+			ErrorCode: http.StatusUnprocessableEntity,
+		}
+		err.Errors = make(map[string][]string)
+		err.Errors["Comment"] = []string{"Comment can't be empty!"}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+
+	db := database.NewGORMInstance()
+	defer db.Close()
+
+	project, milestone, e := loadMilestone(w, r, db, authUser)
+	if e != nil {
+		return
+	}
+
+	if isProjectMember(w, db, project, authUser, authUser.ID) == false {
+		return
+	}
+
+	if helpers.IsNilUint64(reqModel.ReplyToID) {
+		reqModel.ReplyToID = nil
+	} else if _, e = milestone_repo.GetCommentByID(db, milestone.ID, *reqModel.ReplyToID); e != nil {
+		err := helpers.ErrorResponse{
+			Message:   "Invalid data!",
+			ErrorCode: http.StatusUnprocessableEntity,
+		}
+		err.Errors = make(map[string][]string)
+		err.Errors["ReplyToID"] = []string{"Replied comment not found in this milestone!"}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+
+	comment := models.MilestoneComment{
+		MilestoneID: milestone.ID,
+		ReplyToID:   reqModel.ReplyToID,
+		Comment:     reqModel.Comment,
+		CreatedByID: authUser.ID,
+	}
+
+	// Mentions and notifications are saved with the comment, or none of them
+	tx := db.Begin()
+	e = milestone_repo.SaveComment(tx, &comment)
+	if e == nil {
+		e = mention.Process(tx, project, mention.Comment{
+			ModuleID:  models.MODULE_MILESTONE,
+			ObjectID:  milestone.ID,
+			CommentID: comment.ID,
+			AuthorID:  authUser.ID,
+			Text:      comment.Comment,
+		})
+	}
+	if e == nil {
+		e = tx.Commit().Error
+	} else {
+		tx.Rollback()
+	}
+
+	if e != nil {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusInternalServerError,
+			Message:   "Fail to save comment",
+		}
+		err.Errors = make(map[string][]string)
+		err.Errors["dev"] = []string{e.Error()}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+	comment.CommentHTML = markdown.Render(comment.Comment, markdown.NewProjectResolver(db, project))
+
+	w.Header().Add("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&comment)
+}
+
+// DeleteComment soft delete a comment of the milestone
+// @Route: /api/project/{project_id}/milestone/{id}/comment/{comment_id}/delete
+// @Method: POST
+func (MilestoneController) DeleteComment(w http.ResponseWriter, r *http.Request) {
+	authUser, e := rw_helpers.GetAuthenticatedUser(w, r)
+	if e != nil {
+		return
+	}
+
+	commentID, e := rw_helpers.ExtractCommentIDFromURL(w, r, "comment_id")
+	if e != nil {
+		return
+	}
+
+	db := database.NewGORMInstance()
+	defer db.Close()
+
+	project, milestone, e := loadMilestone(w, r, db, authUser)
+	if e != nil {
+		return
+	}
+
+	comment, e := milestone_repo.GetCommentByID(db, milestone.ID, commentID)
+	if e != nil {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusNotFound,
+			Message:   "No matching comment found!",
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+
+	if rw_helpers.CanDeleteMilestoneComment(w, db, authUser, project, comment) == false {
+		return
+	}
+
+	e = milestone_repo.DeleteComment(db, comment)
+	if e != nil {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusInternalServerError,
+			Message:   "Fail to delete comment",
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+
+	helpers.NewSuccessResponse(w, "Comment deleted.")
+}
@@ -0,0 +1,482 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/jinzhu/gorm"
+
+	"devin/database"
+	"devin/helpers"
+	"devin/markdown"
+	"devin/models"
+	"devin/modules/milestone/burndown"
+	milestone_repo "devin/modules/milestone/repository"
+	project_repo "devin/modules/project/repository"
+	"devin/modules/rw_helpers"
+)
+
+// MilestoneController handle functionalities of milestones
+type MilestoneController struct{}
+
+type userReqModel struct {
+	UserID uint64
+}
+
+type taskListReqModel struct {
+	TaskListID uint64
+}
+
+// loadMilestone load project and the milestone given in URL
+func loadMilestone(w http.ResponseWriter, r *http.Request, db *gorm.DB, authUser models.User) (project models.Project, milestone models.Milestone, e error) {
+	milestoneID, e := rw_helpers.ExtractIDFromURL(w, r)
+	if e != nil {
+		return
+	}
+
+	project, e = rw_helpers.LoadProjectForModule(w, r, db, authUser, models.MODULE_MILESTONE)
+	if e != nil {
+		return
+	}
+
+	milestone, e = rw_helpers.GetMilestoneByID(w, db, project.ID, milestoneID)
+
+	return
+}
+
+// decodeJSONBody decode request body to v. Handle request errors
+func decodeJSONBody(w http.ResponseWriter, r *http.Request, v interface{}) (e error) {
+	if helpers.IsRequestBodyNil(w, r) {
+		return errors.New("Request body is nil!")
+	}
+	defer r.Body.Close()
+
+	e = json.NewDecoder(r.Body).Decode(v)
+	if e != nil {
+		err := helpers.ErrorResponse{
+			Message:   "Invalid request!",
+			ErrorCode: http.StatusBadRequest,
+		}
+		helpers.NewErrorResponse(w, &err)
+	}
+
+	return
+}
+
+// isProjectMember check the user to be a member of the project. Handle response errors
+func isProjectMember(w http.ResponseWriter, db *gorm.DB, project models.Project, authUser models.User, userID uint64) bool {
+	if (userID == authUser.ID && authUser.IsRootUser) || project_repo.IsProjectMember(db, project, userID) {
+		return true
+	}
+
+	err := helpers.ErrorResponse{
+		Message:   "User is not a member of this project!",
+		ErrorCode: http.StatusUnprocessableEntity,
+	}
+	helpers.NewErrorResponse(w, &err)
+
+	return false
+}
+
+// MilestonesIndex return paginated list of milestones of the project
+// @Route: /api/project/{project_id}/milestones
+// @Method: GET
+func (MilestoneController) MilestonesIndex(w http.ResponseWriter, r *http.Request) {
+	authUser, e := rw_helpers.GetAuthenticatedUser(w, r)
+	if e != nil {
+		return
+	}
+
+	perPage := rw_helpers.GetPerPage(r)
+	currentPage := rw_helpers.GetCurrectpage(r)
+
+	db := database.NewGORMInstance()
+	defer db.Close()
+
+	project, e := rw_helpers.LoadProjectForModule(w, r, db, authUser, models.MODULE_MILESTONE)
+	if e != nil {
+		return
+	}
+
+	data, total, e := milestone_repo.GetMilestonesOfProject(db, project.ID, currentPage, perPage)
+	if e != nil {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusInternalServerError,
+			Message:   "Fail to load milestones",
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+	resolver := markdown.NewProjectResolver(db, project)
+	for i := range data {
+		data[i].DescriptionHTML = markdown.Render(data[i].Description, resolver)
+	}
+
+	var pgn models.Pagination
+	pgn.Make(data, total, currentPage, perPage)
+
+	w.Header().Add("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&pgn)
+}
+
+// Show return details of a milestone with its responsible users, followers and task lists
+// @Route: /api/project/{project_id}/milestone/{id}
+// @Method: GET
+func (MilestoneController) Show(w http.ResponseWriter, r *http.Request) {
+	authUser, e := rw_helpers.GetAuthenticatedUser(w, r)
+	if e != nil {
+		return
+	}
+
+	db := database.NewGORMInstance()
+	defer db.Close()
+
+	project, milestone, e := loadMilestone(w, r, db, authUser)
+	if e != nil {
+		return
+	}
+	milestone.DescriptionHTML = markdown.Render(milestone.Description, markdown.NewProjectResolver(db, project))
+
+	w.Header().Add("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&milestone)
+}
+
+// Save handle inserting and updating of milestone
+// If no ID present in the request model, it will insert as new milestone
+// otherwise the given milestone will be updated
+// @Route: /api/project/{project_id}/milestones/save
+// @Method: POST
+// @Content-Type: application/json
+func (MilestoneController) Save(w http.ResponseWriter, r *http.Request) {
+	if rw_helpers.IsJSONRequest(w, r) == false {
+		return
+	}
+
+	authUser, e := rw_helpers.GetAuthenticatedUser(w, r)
+	if e != nil {
+		return
+	}
+
+	reqModel, e := rw_helpers.DecodeMilestoneRequestModel(w, r)
+	if e != nil {
+		return
+	}
+	defer r.Body.Close()
+
+	if rw_helpers.ValidateMilestoneRequestModel(w, reqModel) != nil {
+		return
+	}
+
+	db := database.NewGORMInstance()
+	defer db.Close()
+
+	project, e := rw_helpers.LoadProjectForModule(w, r, db, authUser, models.MODULE_MILESTONE)
+	if e != nil {
+		return
+	}
+
+	if rw_helpers.CanCreateMilestone(w, db, authUser, project) == false {
+		return
+	}
+
+	milestone := models.Milestone{}
+	if reqModel.ID != 0 {
+		// Edit mode
+		milestone, e = rw_helpers.GetMilestoneByID(w, db, project.ID, reqModel.ID)
+		if e != nil {
+			return
+		}
+	} else {
+		milestone.ProjectID = project.ID
+		milestone.CreatedByID = authUser.ID
+	}
+	milestone.Name = reqModel.Name
+	milestone.DueDate = reqModel.DueDate
+	milestone.Description = reqModel.Description
+
+	e = milestone_repo.SaveMilestone(db, &milestone)
+	if e != nil {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusInternalServerError,
+			Message:   "Fail to save milestone",
+		}
+		err.Errors = make(map[string][]string)
+		err.Errors["dev"] = []string{e.Error()}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+	milestone.DescriptionHTML = markdown.Render(milestone.Description, markdown.NewProjectResolver(db, project))
+
+	w.Header().Add("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&milestone)
+}
+
+// Delete soft delete the given milestone
+// @Route: /api/project/{project_id}/milestone/{id}/delete
+// @Method: POST
+func (MilestoneController) Delete(w http.ResponseWriter, r *http.Request) {
+	authUser, e := rw_helpers.GetAuthenticatedUser(w, r)
+	if e != nil {
+		return
+	}
+
+	db := database.NewGORMInstance()
+	defer db.Close()
+
+	project, milestone, e := loadMilestone(w, r, db, authUser)
+	if e != nil {
+		return
+	}
+
+	if rw_helpers.CanCreateMilestone(w, db, authUser, project) == false {
+		return
+	}
+
+	e = milestone_repo.DeleteMilestone(db, milestone)
+	if e != nil {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusInternalServerError,
+			Message:   "Fail to delete milestone",
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+
+	helpers.NewSuccessResponse(w, "Milestone deleted.")
+}
+
+// AddResponsibleUser add a member of the project to responsible users of the milestone
+// @Route: /api/project/{project_id}/milestone/{id}/responsible_users/add
+// @Method: POST
+// @Content-Type: application/json
+func (MilestoneController) AddResponsibleUser(w http.ResponseWriter, r *http.Request) {
+	changeResponsibleUser(w, r, true)
+}
+
+// RemoveResponsibleUser remove a user from responsible users of the milestone
+// @Route: /api/project/{project_id}/milestone/{id}/responsible_users/remove
+// @Method: POST
+// @Content-Type: application/json
+func (MilestoneController) RemoveResponsibleUser(w http.ResponseWriter, r *http.Request) {
+	changeResponsibleUser(w, r, false)
+}
+
+func changeResponsibleUser(w http.ResponseWriter, r *http.Request, add bool) {
+	if rw_helpers.IsJSONRequest(w, r) == false {
+		return
+	}
+
+	authUser, e := rw_helpers.GetAuthenticatedUser(w, r)
+	if e != nil {
+		return
+	}
+
+	var reqModel userReqModel
+	if decodeJSONBody(w, r, &reqModel) != nil {
+		return
+	}
+
+	db := database.NewGORMInstance()
+	defer db.Close()
+
+	project, milestone, e := loadMilestone(w, r, db, authUser)
+	if e != nil {
+		return
+	}
+
+	if rw_helpers.CanCreateMilestone(w, db, authUser, project) == false {
+		return
+	}
+
+	if add {
+		if isProjectMember(w, db, project, authUser, reqModel.UserID) == false {
+			return
+		}
+		e = milestone_repo.AddResponsibleUser(db, milestone.ID, reqModel.UserID, authUser.ID)
+	} else {
+		e = milestone_repo.RemoveResponsibleUser(db, milestone.ID, reqModel.UserID)
+	}
+	if e != nil {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusInternalServerError,
+			Message:   "Fail to update responsible users",
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+
+	helpers.NewSuccessResponse(w, "Responsible users updated.")
+}
+
+// Follow add the authenticated user to followers of the milestone.
+// Users with CanCreateMilestone permission can add other members by passing UserID.
+// @Route: /api/project/{project_id}/milestone/{id}/follow
+// @Method: POST
+func (MilestoneController) Follow(w http.ResponseWriter, r *http.Request) {
+	changeFollower(w, r, true)
+}
+
+// Unfollow remove the authenticated user, or the given UserID, from followers of the milestone
+// @Route: /api/project/{project_id}/milestone/{id}/unfollow
+// @Method: POST
+func (MilestoneController) Unfollow(w http.ResponseWriter, r *http.Request) {
+	changeFollower(w, r, false)
+}
+
+func changeFollower(w http.ResponseWriter, r *http.Request, follow bool) {
+	authUser, e := rw_helpers.GetAuthenticatedUser(w, r)
+	if e != nil {
+		return
+	}
+
+	reqModel := userReqModel{UserID: authUser.ID}
+	if r.ContentLength > 0 && decodeJSONBody(w, r, &reqModel) != nil {
+		return
+	}
+	if reqModel.UserID == 0 {
+		reqModel.UserID = authUser.ID
+	}
+
+	db := database.NewGORMInstance()
+	defer db.Close()
+
+	project, milestone, e := loadMilestone(w, r, db, authUser)
+	if e != nil {
+		return
+	}
+
+	if reqModel.UserID != authUser.ID && rw_helpers.CanCreateMilestone(w, db, authUser, project) == false {
+		return
+	}
+
+	if follow {
+		if isProjectMember(w, db, project, authUser, reqModel.UserID) == false {
+			return
+		}
+		e = milestone_repo.Follow(db, milestone.ID, reqModel.UserID, authUser.ID)
+	} else {
+		e = milestone_repo.Unfollow(db, milestone.ID, reqModel.UserID)
+	}
+	if e != nil {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusInternalServerError,
+			Message:   "Fail to update followers",
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+
+	helpers.NewSuccessResponse(w, "Followers updated.")
+}
+
+// AttachTaskList attach a task list of the project to the milestone
+// @Route: /api/project/{project_id}/milestone/{id}/task_lists/attach
+// @Method: POST
+// @Content-Type: application/json
+func (MilestoneController) AttachTaskList(w http.ResponseWriter, r *http.Request) {
+	changeTaskList(w, r, true)
+}
+
+// DetachTaskList detach a task list from the milestone
+// @Route: /api/project/{project_id}/milestone/{id}/task_lists/detach
+// @Method: POST
+// @Content-Type: application/json
+func (MilestoneController) DetachTaskList(w http.ResponseWriter, r *http.Request) {
+	changeTaskList(w, r, false)
+}
+
+func changeTaskList(w http.ResponseWriter, r *http.Request, attach bool) {
+	if rw_helpers.IsJSONRequest(w, r) == false {
+		return
+	}
+
+	authUser, e := rw_helpers.GetAuthenticatedUser(w, r)
+	if e != nil {
+		return
+	}
+
+	var reqModel taskListReqModel
+	if decodeJSONBody(w, r, &reqModel) != nil {
+		return
+	}
+
+	db := database.NewGORMInstance()
+	defer db.Close()
+
+	project, milestone, e := loadMilestone(w, r, db, authUser)
+	if e != nil {
+		return
+	}
+
+	if rw_helpers.CanCreateMilestone(w, db, authUser, project) == false {
+		return
+	}
+
+	if attach {
+		e = milestone_repo.AttachTaskList(db, milestone, reqModel.TaskListID, authUser.ID)
+	} else {
+		e = milestone_repo.DetachTaskList(db, milestone.ID, reqModel.TaskListID)
+	}
+	if e != nil {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusInternalServerError,
+			Message:   "Fail to update task lists",
+		}
+		if e == milestone_repo.ErrTaskListNotFound {
+			err.ErrorCode = http.StatusUnprocessableEntity
+			err.Errors = make(map[string][]string)
+			err.Errors["TaskListID"] = []string{"Selected task list doesn't belong to this project!"}
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+
+	helpers.NewSuccessResponse(w, "Task lists updated.")
+}
+
+// Burndown return daily total, completed and remaining task counts of the task lists
+// attached to the milestone, from the creation of the milestone up to its due date.
+// Days are calculated in the time zone given by 'tz' query string, default is UTC
+// @Route: /api/project/{project_id}/milestone/{id}/burndown?tz={IANA time zone}
+// @Method: GET
+func (MilestoneController) Burndown(w http.ResponseWriter, r *http.Request) {
+	authUser, e := rw_helpers.GetAuthenticatedUser(w, r)
+	if e != nil {
+		return
+	}
+
+	loc, e := time.LoadLocation(r.URL.Query().Get("tz"))
+	if e != nil {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusUnprocessableEntity,
+			Message:   "Invalid time zone!",
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+
+	db := database.NewGORMInstance()
+	defer db.Close()
+
+	_, milestone, e := loadMilestone(w, r, db, authUser)
+	if e != nil {
+		return
+	}
+
+	tasks, e := milestone_repo.GetTasksOfMilestone(db, milestone)
+	if e != nil {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusInternalServerError,
+			Message:   "Fail to load tasks",
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+
+	chart := burndown.Calculate(tasks, milestone.CreatedAt, milestone.DueDate, time.Now(), loc)
+
+	w.Header().Add("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&chart)
+}
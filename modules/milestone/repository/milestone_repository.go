@@ -0,0 +1,171 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/jinzhu/gorm"
+
+	"devin/models"
+)
+
+// ErrMilestoneNotFound returned when the milestone is not found in the project
+var ErrMilestoneNotFound = errors.New("Milestone not found")
+
+// ErrCommentNotFound returned when the comment is not found in the milestone
+var ErrCommentNotFound = errors.New("Comment not found")
+
+// ErrTaskListNotFound returned when the task list is not found in the project
+var ErrTaskListNotFound = errors.New("Task list not found")
+
+// GetMilestonesOfProject load paginated list of milestones of the project sorted by due date
+func GetMilestonesOfProject(db *gorm.DB, projectID, currentPage, perPage uint64) (data []models.Milestone, total uint64, e error) {
+	db = db.Model(&models.Milestone{}).Where("project_id=?", projectID)
+
+	e = db.Count(&total).Error
+	if e != nil {
+		return
+	}
+
+	if perPage > 0 {
+		db = db.Limit(perPage)
+	}
+	if currentPage > 0 {
+		db = db.Offset((currentPage - 1) * perPage)
+	}
+
+	e = db.Preload("ResponsibleUsers").
+		Order("due_date ASC, id ASC").
+		Find(&data).
+		Error
+
+	return
+}
+
+// GetMilestoneByID load a milestone of the project with its responsible users, followers and task lists
+func GetMilestoneByID(db *gorm.DB, projectID, milestoneID uint64) (milestone models.Milestone, e error) {
+	db.Model(&milestone).
+		Preload("ResponsibleUsers").
+		Preload("ResponsibleUsers.User").
+		Preload("Followers").
+		Preload("Followers.User").
+		Preload("TaskLists").
+		Preload("TaskLists.TaskList").
+		Where("id=? AND project_id=?", milestoneID, projectID).
+		First(&milestone)
+	if milestone.ID == 0 {
+		e = ErrMilestoneNotFound
+	}
+
+	return
+}
+
+// SaveMilestone insert new milestone or update the existing one.
+// Relations are not saved, they have their own functions.
+func SaveMilestone(db *gorm.DB, milestone *models.Milestone) error {
+	db = db.Set("gorm:save_associations", false)
+	if milestone.ID == 0 {
+		return db.Create(milestone).Error
+	}
+
+	return db.Save(milestone).Error
+}
+
+// DeleteMilestone soft delete the milestone
+func DeleteMilestone(db *gorm.DB, milestone models.Milestone) error {
+	return db.Delete(&milestone).Error
+}
+
+// AddResponsibleUser add a user to responsible users of the milestone, if not added before
+func AddResponsibleUser(db *gorm.DB, milestoneID, userID, createdByID uint64) error {
+	return db.Exec(`INSERT INTO milestone_responsible_users (milestone_id, user_id, created_by_id)
+        VALUES (?, ?, ?) ON CONFLICT (milestone_id, user_id) DO NOTHING`, milestoneID, userID, createdByID).Error
+}
+
+// RemoveResponsibleUser remove a user from responsible users of the milestone
+func RemoveResponsibleUser(db *gorm.DB, milestoneID, userID uint64) error {
+	return db.Exec("DELETE FROM milestone_responsible_users WHERE milestone_id=? AND user_id=?", milestoneID, userID).Error
+}
+
+// Follow add a user to followers of the milestone, if not added before
+func Follow(db *gorm.DB, milestoneID, userID, createdByID uint64) error {
+	return db.Exec(`INSERT INTO milestone_followers (milestone_id, user_id, created_by_id)
+        VALUES (?, ?, ?) ON CONFLICT (milestone_id, user_id) DO NOTHING`, milestoneID, userID, createdByID).Error
+}
+
+// Unfollow remove a user from followers of the milestone
+func Unfollow(db *gorm.DB, milestoneID, userID uint64) error {
+	return db.Exec("DELETE FROM milestone_followers WHERE milestone_id=? AND user_id=?", milestoneID, userID).Error
+}
+
+// AttachTaskList attach a task list of the project to the milestone
+func AttachTaskList(db *gorm.DB, milestone models.Milestone, taskListID, createdByID uint64) error {
+	var count uint64
+	db.Model(&models.TaskList{}).Where("id=? AND project_id=?", taskListID, milestone.ProjectID).Count(&count)
+	if count == 0 {
+		return ErrTaskListNotFound
+	}
+
+	return db.Exec(`INSERT INTO milestone_task_lists (milestone_id, task_list_id, created_by_id)
+        VALUES (?, ?, ?) ON CONFLICT (milestone_id, task_list_id) DO NOTHING`, milestone.ID, taskListID, createdByID).Error
+}
+
+// DetachTaskList detach a task list from the milestone
+func DetachTaskList(db *gorm.DB, milestoneID, taskListID uint64) error {
+	return db.Exec("DELETE FROM milestone_task_lists WHERE milestone_id=? AND task_list_id=?", milestoneID, taskListID).Error
+}
+
+// GetTasksOfMilestone load tasks of all task lists attached to the milestone
+func GetTasksOfMilestone(db *gorm.DB, milestone models.Milestone) (tasks []models.Task, e error) {
+	e = db.Model(&models.Task{}).
+		Where("project_id=?", milestone.ProjectID).
+		Where("task_list_id IN (SELECT task_list_id FROM milestone_task_lists WHERE milestone_id=?)", milestone.ID).
+		Order("created_at ASC").
+		Find(&tasks).
+		Error
+
+	return
+}
+
+// GetComments load paginated comments of the milestone, oldest first
+func GetComments(db *gorm.DB, milestoneID, currentPage, perPage uint64) (data []models.MilestoneComment, total uint64, e error) {
+	db = db.Model(&models.MilestoneComment{}).Where("milestone_id=?", milestoneID)
+
+	e = db.Count(&total).Error
+	if e != nil {
+		return
+	}
+
+	if perPage > 0 {
+		db = db.Limit(perPage)
+	}
+	if currentPage > 0 {
+		db = db.Offset((currentPage - 1) * perPage)
+	}
+
+	e = db.Preload("CreatedBy").
+		Order("created_at ASC, id ASC").
+		Find(&data).
+		Error
+
+	return
+}
+
+// GetCommentByID load a comment of the milestone
+func GetCommentByID(db *gorm.DB, milestoneID, commentID uint64) (comment models.MilestoneComment, e error) {
+	db.Model(&comment).Where("id=? AND milestone_id=?", commentID, milestoneID).First(&comment)
+	if comment.ID == 0 {
+		e = ErrCommentNotFound
+	}
+
+	return
+}
+
+// SaveComment insert a new comment
+func SaveComment(db *gorm.DB, comment *models.MilestoneComment) error {
+	return db.Set("gorm:save_associations", false).Create(comment).Error
+}
+
+// DeleteComment soft delete the comment
+func DeleteComment(db *gorm.DB, comment models.MilestoneComment) error {
+	return db.Delete(&comment).Error
+}
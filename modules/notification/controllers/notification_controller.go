@@ -0,0 +1,226 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/jinzhu/gorm"
+
+	"devin/database"
+	"devin/helpers"
+	"devin/models"
+	notification_repo "devin/modules/notification/repository"
+	"devin/modules/notification/stream"
+	"devin/modules/rw_helpers"
+)
+
+// NotificationController handle notifications of the authenticated user
+type NotificationController struct{}
+
+// streamOptions keep streams shorter than write timeout of the http server.
+// Browsers reconnect automatically and missed notifications are sent then.
+var streamOptions = stream.Options{
+	PollInterval: 2 * time.Second,
+	MaxDuration:  12 * time.Second,
+	Retry:        1000,
+}
+
+type unreadCountResModel struct {
+	Count uint64
+}
+
+// loadNotification load the notification given in URL, it must belong to the authenticated user
+func loadNotification(w http.ResponseWriter, r *http.Request, db *gorm.DB, authUser models.User) (notification models.Notification, e error) {
+	notificationID, e := rw_helpers.ExtractIDFromURL(w, r)
+	if e != nil {
+		return
+	}
+
+	notification, e = notification_repo.GetNotificationByID(db, authUser.ID, notificationID)
+	if e != nil {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusNotFound,
+			Message:   "No matching notification found!",
+		}
+		helpers.NewErrorResponse(w, &err)
+	}
+
+	return
+}
+
+// NotificationsIndex return paginated notifications of the authenticated user, newest first.
+// Only unread notifications are returned when unread=true is given.
+// @Route: /api/notifications?unread={true|false}
+// @Method: GET
+func (NotificationController) NotificationsIndex(w http.ResponseWriter, r *http.Request) {
+	authUser, e := rw_helpers.GetAuthenticatedUser(w, r)
+	if e != nil {
+		return
+	}
+
+	perPage := rw_helpers.GetPerPage(r)
+	currentPage := rw_helpers.GetCurrectpage(r)
+	unreadOnly := r.URL.Query().Get("unread") == "true"
+
+	db := database.NewGORMInstance()
+	defer db.Close()
+
+	data, total, e := notification_repo.GetNotifications(db, authUser.ID, unreadOnly, currentPage, perPage)
+	if e != nil {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusInternalServerError,
+			Message:   "Fail to load notifications",
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+
+	var pgn models.Pagination
+	pgn.Make(data, total, currentPage, perPage)
+
+	w.Header().Add("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&pgn)
+}
+
+// UnreadCount return number of unread notifications of the authenticated user
+// @Route: /api/notifications/unread_count
+// @Method: GET
+func (NotificationController) UnreadCount(w http.ResponseWriter, r *http.Request) {
+	authUser, e := rw_helpers.GetAuthenticatedUser(w, r)
+	if e != nil {
+		return
+	}
+
+	db := database.NewGORMInstance()
+	defer db.Close()
+
+	var res unreadCountResModel
+	res.Count, e = notification_repo.CountUnread(db, authUser.ID)
+	if e != nil {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusInternalServerError,
+			Message:   "Fail to count notifications",
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&res)
+}
+
+// MarkRead mark a notification of the authenticated user as read
+// @Route: /api/notification/{id}/read
+// @Method: POST
+func (NotificationController) MarkRead(w http.ResponseWriter, r *http.Request) {
+	setRead(w, r, true)
+}
+
+// MarkUnread mark a notification of the authenticated user as unread
+// @Route: /api/notification/{id}/unread
+// @Method: POST
+func (NotificationController) MarkUnread(w http.ResponseWriter, r *http.Request) {
+	setRead(w, r, false)
+}
+
+func setRead(w http.ResponseWriter, r *http.Request, read bool) {
+	authUser, e := rw_helpers.GetAuthenticatedUser(w, r)
+	if e != nil {
+		return
+	}
+
+	db := database.NewGORMInstance()
+	defer db.Close()
+
+	notification, e := loadNotification(w, r, db, authUser)
+	if e != nil {
+		return
+	}
+
+	e = notification_repo.SetRead(db, &notification, read)
+	if e != nil {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusInternalServerError,
+			Message:   "Fail to update notification",
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&notification)
+}
+
+// MarkAllRead mark all notifications of the authenticated user as read
+// @Route: /api/notifications/read_all
+// @Method: POST
+func (NotificationController) MarkAllRead(w http.ResponseWriter, r *http.Request) {
+	authUser, e := rw_helpers.GetAuthenticatedUser(w, r)
+	if e != nil {
+		return
+	}
+
+	db := database.NewGORMInstance()
+	defer db.Close()
+
+	e = notification_repo.MarkAllRead(db, authUser.ID)
+	if e != nil {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusInternalServerError,
+			Message:   "Fail to update notifications",
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+
+	helpers.NewSuccessResponse(w, "All notifications marked as read.")
+}
+
+// Stream push new notifications of the authenticated user as Server-Sent Events.
+// Without Last-Event-ID header (or last_event_id parameter) only notifications
+// created after opening the stream are sent.
+// @Route: /api/notifications/stream
+// @Method: GET
+// @Content-Type: text/event-stream
+func (NotificationController) Stream(w http.ResponseWriter, r *http.Request) {
+	authUser, e := rw_helpers.GetAuthenticatedUser(w, r)
+	if e != nil {
+		return
+	}
+
+	db := database.NewGORMInstance()
+	defer db.Close()
+
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = r.URL.Query().Get("last_event_id")
+	}
+
+	lastID, e := strconv.ParseUint(lastEventID, 10, 64)
+	if e != nil {
+		lastID, e = notification_repo.GetLastNotificationID(db, authUser.ID)
+		if e != nil {
+			err := helpers.ErrorResponse{
+				ErrorCode: http.StatusInternalServerError,
+				Message:   "Fail to load notifications",
+			}
+			helpers.NewErrorResponse(w, &err)
+			return
+		}
+	}
+
+	source := func(afterID uint64) ([]models.Notification, error) {
+		return notification_repo.GetNotificationsAfter(db, authUser.ID, afterID)
+	}
+
+	e = stream.Serve(w, r, lastID, source, streamOptions)
+	if e == stream.ErrStreamingUnsupported {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusInternalServerError,
+			Message:   "Streaming is not supported",
+		}
+		helpers.NewErrorResponse(w, &err)
+	}
+}
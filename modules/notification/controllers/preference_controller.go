@@ -0,0 +1,187 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"devin/database"
+	"devin/helpers"
+	"devin/models"
+	"devin/modules/notification/preference"
+	notification_repo "devin/modules/notification/repository"
+	"devin/modules/notification/worker"
+	"devin/modules/rw_helpers"
+)
+
+type preferencesReqModel struct {
+	// IANA time zone name e.g 'Asia/Tehran'
+	Timezone *string
+
+	// Local time of the daily digest in time format of the user
+	DigestTime string
+	WebhookURL *string
+
+	// Only changed preferences may be sent
+	Preferences []models.NotificationPreference
+}
+
+type preferencesResModel struct {
+	Timezone    *string
+	DigestTime  string
+	WebhookURL  *string
+	Preferences []models.NotificationPreference
+}
+
+// PreferencesIndex return notification settings of the authenticated user
+// and the delivery mode of every event on every channel
+// @Route: /api/notifications/preferences
+// @Method: GET
+func (NotificationController) PreferencesIndex(w http.ResponseWriter, r *http.Request) {
+	authUser, e := rw_helpers.GetAuthenticatedUser(w, r)
+	if e != nil {
+		return
+	}
+
+	db := database.NewGORMInstance()
+	defer db.Close()
+
+	preferences, e := notification_repo.GetPreferences(db, authUser.ID)
+	if e != nil {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusInternalServerError,
+			Message:   "Fail to load preferences",
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+
+	res := preferencesResModel{
+		Timezone:    authUser.Timezone,
+		DigestTime:  authUser.DigestTime,
+		WebhookURL:  authUser.WebhookURL,
+		Preferences: preference.Matrix(preferences),
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&res)
+}
+
+// SavePreferences update notification settings and preferences of the authenticated user
+// @Route: /api/notifications/preferences/save
+// @Method: POST
+// @Content-Type: application/json
+func (NotificationController) SavePreferences(w http.ResponseWriter, r *http.Request) {
+	if rw_helpers.IsJSONRequest(w, r) == false {
+		return
+	}
+
+	authUser, e := rw_helpers.GetAuthenticatedUser(w, r)
+	if e != nil {
+		return
+	}
+
+	if helpers.IsRequestBodyNil(w, r) {
+		return
+	}
+
+	var reqModel preferencesReqModel
+	e = json.NewDecoder(r.Body).Decode(&reqModel)
+	if e != nil {
+		err := helpers.ErrorResponse{
+			Message:   "Invalid request!",
+			ErrorCode: http.StatusBadRequest,
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+	defer r.Body.Close()
+
+	settings, errs := validatePreferences(authUser, reqModel)
+	if len(errs) > 0 {
+		err := helpers.ErrorResponse{
+			Message:   "Invalid data!",
+			ErrorCode: http.StatusUnprocessableEntity,
+			Errors:    errs,
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+
+	db := database.NewGORMInstance()
+	defer db.Close()
+
+	e = notification_repo.SavePreferences(db, authUser.ID, settings, reqModel.Preferences)
+	if e != nil {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusInternalServerError,
+			Message:   "Fail to save preferences",
+		}
+		err.Errors = make(map[string][]string)
+		err.Errors["dev"] = []string{e.Error()}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+
+	helpers.NewSuccessResponse(w, "Notification preferences saved.")
+}
+
+// isInternalHost check the host of a webhook URL to be localhost or a non-public IP address
+func isInternalHost(host string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return true
+	}
+
+	ip := net.ParseIP(host)
+	return ip != nil && worker.IsPublicIP(ip) == false
+}
+
+// validatePreferences validate the request model and return the settings to be saved
+func validatePreferences(authUser models.User, reqModel preferencesReqModel) (settings models.NotificationSettings, errs map[string][]string) {
+	errs = make(map[string][]string)
+
+	if reqModel.Timezone != nil && strings.TrimSpace(*reqModel.Timezone) == "" {
+		reqModel.Timezone = nil
+	}
+	if reqModel.Timezone != nil {
+		if _, e := time.LoadLocation(*reqModel.Timezone); e != nil {
+			errs["Timezone"] = []string{"Unknown time zone!"}
+		}
+	}
+	settings.Timezone = reqModel.Timezone
+
+	settings.DigestTime = preference.DefaultDigestTime
+	if reqModel.DigestTime != "" {
+		digestTime, e := preference.ParseDigestTime(reqModel.DigestTime, authUser.TimeFormat)
+		if e != nil {
+			errs["DigestTime"] = []string{"Invalid time!"}
+		}
+		settings.DigestTime = digestTime
+	}
+
+	if reqModel.WebhookURL != nil && strings.TrimSpace(*reqModel.WebhookURL) == "" {
+		reqModel.WebhookURL = nil
+	}
+	if reqModel.WebhookURL != nil {
+		u, e := url.Parse(*reqModel.WebhookURL)
+		if e != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || len(*reqModel.WebhookURL) > 255 {
+			errs["WebhookURL"] = []string{"Webhook URL must be an absolute http or https URL!"}
+		} else if isInternalHost(u.Hostname()) {
+			// Resolved addresses are checked again on each delivery
+			errs["WebhookURL"] = []string{"Webhook URL must be a public address!"}
+		}
+	}
+	settings.WebhookURL = reqModel.WebhookURL
+
+	for _, p := range reqModel.Preferences {
+		if e := preference.Validate(p); e != nil {
+			errs["Preferences"] = append(errs["Preferences"], p.Event+" on "+p.Channel+": "+e.Error())
+		}
+	}
+
+	return
+}
@@ -0,0 +1,62 @@
+package controllers
+
+import (
+	"testing"
+
+	"devin/models"
+)
+
+func TestValidatePreferences(t *testing.T) {
+	timeFormat := "15:04:05"
+	authUser := models.User{}
+	authUser.TimeFormat = &timeFormat
+
+	tehran, webhook := "Asia/Tehran", "https://hooks.local/devin"
+	settings, errs := validatePreferences(authUser, preferencesReqModel{
+		Timezone:   &tehran,
+		DigestTime: "07:30:00",
+		WebhookURL: &webhook,
+		Preferences: []models.NotificationPreference{
+			{Event: models.NOTIFICATION_EVENT_COMMENT, Channel: models.NOTIFICATION_CHANNEL_EMAIL, Mode: models.NOTIFICATION_MODE_DIGEST},
+		},
+	})
+	if len(errs) > 0 || settings.DigestTime != "07:30" || *settings.Timezone != tehran {
+		t.Fatal("Valid preferences must be accepted", settings, errs)
+	}
+
+	empty := ""
+	settings, errs = validatePreferences(authUser, preferencesReqModel{Timezone: &empty, WebhookURL: &empty})
+	if len(errs) > 0 || settings.Timezone != nil || settings.WebhookURL != nil || settings.DigestTime != "08:00" {
+		t.Fatal("Empty settings must be reset", settings, errs)
+	}
+
+	invalidZone, invalidURL := "Mars/Olympus", "javascript:alert(1)"
+	_, errs = validatePreferences(authUser, preferencesReqModel{
+		Timezone:   &invalidZone,
+		DigestTime: "8 am",
+		WebhookURL: &invalidURL,
+		Preferences: []models.NotificationPreference{
+			{Event: models.NOTIFICATION_EVENT_REMINDER, Channel: models.NOTIFICATION_CHANNEL_EMAIL, Mode: models.NOTIFICATION_MODE_DIGEST},
+		},
+	})
+	for _, field := range []string{"Timezone", "DigestTime", "WebhookURL", "Preferences"} {
+		if len(errs[field]) == 0 {
+			t.Fatal(field, "must be rejected", errs)
+		}
+	}
+}
+
+func TestValidatePreferencesInternalWebhook(t *testing.T) {
+	for _, webhook := range []string{
+		"http://localhost:8080/hook",
+		"http://127.0.0.1/hook",
+		"http://169.254.169.254/latest/meta-data",
+		"https://10.0.0.5/hook",
+		"http://[::1]/hook",
+	} {
+		_, errs := validatePreferences(models.User{}, preferencesReqModel{WebhookURL: &webhook})
+		if len(errs["WebhookURL"]) == 0 {
+			t.Fatal("Internal webhook URL must be rejected", webhook)
+		}
+	}
+}
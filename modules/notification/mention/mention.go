@@ -0,0 +1,185 @@
+// Package mention find @username mentions of project members in comments of
+// tasks, milestones and issues, stores them and notifies the mentioned users
+// and followers of the commented object.
+package mention
+
+import (
+	"fmt"
+
+	"github.com/jinzhu/gorm"
+
+	"devin/markdown"
+	"devin/models"
+	milestone_repo "devin/modules/milestone/repository"
+	"devin/modules/notification/notify"
+	notification_repo "devin/modules/notification/repository"
+	project_repo "devin/modules/project/repository"
+	task_repo "devin/modules/task/repository"
+)
+
+// Length of comment excerpt stored in notification message
+const excerptLength = 200
+
+// Comment is a saved comment of a task, milestone or issue
+type Comment struct {
+	ModuleID  uint
+	ObjectID  uint64
+	CommentID uint64
+	AuthorID  uint64
+	Text      string
+}
+
+// Recipient is a user who must be notified about a comment
+type Recipient struct {
+	UserID uint64
+	Type   string
+}
+
+// Recipients return receivers of notifications of a comment.
+// Mentioned users receive a mention notification, other followers a comment notification.
+// Author of the comment is never notified and each user is notified once.
+func Recipients(authorID uint64, mentioned, followers []uint64) (recipients []Recipient) {
+	seen := map[uint64]bool{authorID: true}
+	add := func(userIDs []uint64, notificationType string) {
+		for _, userID := range userIDs {
+			if userID == 0 || seen[userID] {
+				continue
+			}
+			seen[userID] = true
+			recipients = append(recipients, Recipient{UserID: userID, Type: notificationType})
+		}
+	}
+	add(mentioned, models.NOTIFICATION_TYPE_MENTION)
+	add(followers, models.NOTIFICATION_TYPE_COMMENT)
+
+	return
+}
+
+// Excerpt return the beginning of the comment to be used as notification message
+func Excerpt(text string) string {
+	runes := []rune(text)
+	if len(runes) <= excerptLength {
+		return text
+	}
+
+	return string(runes[:excerptLength-1]) + "…"
+}
+
+// Process store mentions of the comment, add mentioned users to followers of
+// the commented object and notify mentioned users and followers according to their preferences.
+// db should be the transaction which saved the comment.
+func Process(db *gorm.DB, project models.Project, comment Comment) error {
+	mentioned, e := mentionedMembers(db, project, comment.Text)
+	if e != nil {
+		return e
+	}
+
+	for _, userID := range mentioned {
+		if userID == comment.AuthorID {
+			continue
+		}
+
+		e = notification_repo.SaveMention(db, models.Mention{
+			ModuleID:    comment.ModuleID,
+			ObjectID:    comment.ObjectID,
+			CommentID:   comment.CommentID,
+			UserID:      userID,
+			CreatedByID: comment.AuthorID,
+		})
+		if e != nil {
+			return e
+		}
+
+		e = follow(db, comment, userID)
+		if e != nil {
+			return e
+		}
+	}
+
+	followers, e := getFollowers(db, comment)
+	if e != nil {
+		return e
+	}
+
+	var notifications []models.Notification
+	for _, recipient := range Recipients(comment.AuthorID, mentioned, followers) {
+		notifications = append(notifications, models.Notification{
+			UserID:    recipient.UserID,
+			ActorID:   &comment.AuthorID,
+			Type:      recipient.Type,
+			ProjectID: &project.ID,
+			ModuleID:  &comment.ModuleID,
+			ObjectID:  &comment.ObjectID,
+			CommentID: &comment.CommentID,
+			Message:   Excerpt(comment.Text),
+		})
+	}
+
+	return notify.Notify(db, notifications)
+}
+
+// mentionedMembers return IDs of project members mentioned in the text
+func mentionedMembers(db *gorm.DB, project models.Project, text string) (userIDs []uint64, e error) {
+	users, e := notification_repo.GetUsersByUsernames(db, markdown.Usernames(text))
+	if e != nil {
+		return
+	}
+
+	for _, user := range users {
+		if project_repo.IsProjectMember(db, project, user.ID) {
+			userIDs = append(userIDs, user.ID)
+		}
+	}
+
+	return
+}
+
+// follow add the user to followers of the commented object.
+// Issues have no followers, their creator and assignees are notified.
+func follow(db *gorm.DB, comment Comment, userID uint64) error {
+	switch comment.ModuleID {
+	case models.MODULE_TASK:
+		return task_repo.Follow(db, comment.ObjectID, userID, comment.AuthorID)
+	case models.MODULE_MILESTONE:
+		return milestone_repo.Follow(db, comment.ObjectID, userID, comment.AuthorID)
+	}
+
+	return nil
+}
+
+// getFollowers return IDs of users who follow the commented object
+func getFollowers(db *gorm.DB, comment Comment) (userIDs []uint64, e error) {
+	var query string
+	switch comment.ModuleID {
+	case models.MODULE_TASK:
+		query = "SELECT user_id FROM task_followers WHERE task_id=?"
+	case models.MODULE_MILESTONE:
+		query = "SELECT user_id FROM milestone_followers WHERE milestone_id=?"
+	case models.MODULE_ISSUE_TRACKER:
+		query = `SELECT created_by_id FROM issues WHERE id=? AND created_by_id IS NOT NULL
+            UNION SELECT user_id FROM issue_assignments WHERE issue_id=?`
+	default:
+		return nil, fmt.Errorf("Module %d has no followers", comment.ModuleID)
+	}
+
+	args := []interface{}{comment.ObjectID}
+	if comment.ModuleID == models.MODULE_ISSUE_TRACKER {
+		args = append(args, comment.ObjectID)
+	}
+
+	rows, e := db.Raw(query, args...).Rows()
+	if e != nil {
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var userID uint64
+		if e = rows.Scan(&userID); e != nil {
+			return
+		}
+		userIDs = append(userIDs, userID)
+	}
+
+	return
+}
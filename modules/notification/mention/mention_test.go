@@ -0,0 +1,42 @@
+package mention
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"devin/models"
+)
+
+func TestRecipients(t *testing.T) {
+	recipients := Recipients(1, []uint64{2, 3, 1, 2}, []uint64{3, 4, 1, 0, 4})
+
+	expected := []Recipient{
+		{UserID: 2, Type: models.NOTIFICATION_TYPE_MENTION},
+		{UserID: 3, Type: models.NOTIFICATION_TYPE_MENTION},
+		{UserID: 4, Type: models.NOTIFICATION_TYPE_COMMENT},
+	}
+	if len(recipients) != len(expected) {
+		t.Fatal("Unexpected recipients", recipients)
+	}
+	for i := range expected {
+		if recipients[i] != expected[i] {
+			t.Fatal("Unexpected recipients", recipients)
+		}
+	}
+
+	if len(Recipients(1, nil, []uint64{1})) != 0 {
+		t.Fatal("Author must not be notified")
+	}
+}
+
+func TestExcerpt(t *testing.T) {
+	if Excerpt("@alice please review") != "@alice please review" {
+		t.Fatal("Short comments must not be changed")
+	}
+
+	excerpt := Excerpt(strings.Repeat("ش", 300))
+	if utf8.RuneCountInString(excerpt) != excerptLength || strings.HasSuffix(excerpt, "…") == false {
+		t.Fatal("Unexpected excerpt", excerpt)
+	}
+}
@@ -0,0 +1,132 @@
+// Package notify deliver notifications through the channels which receivers chose
+// in their notification preferences. In-app notifications are stored at once, email
+// and webhook deliveries are queued for the notifications worker, which sends them
+// instantly or in the daily digest of the receiver.
+package notify
+
+import (
+	"encoding/json"
+
+	"github.com/jinzhu/gorm"
+
+	"devin/models"
+	"devin/modules/notification/preference"
+	notification_repo "devin/modules/notification/repository"
+)
+
+// queuedChannels are delivered by the notifications worker
+var queuedChannels = []string{models.NOTIFICATION_CHANNEL_EMAIL, models.NOTIFICATION_CHANNEL_WEBHOOK}
+
+// Notify deliver the notifications according to preferences of their receivers.
+// Type of a notification is its event. db should be the transaction of the event.
+func Notify(db *gorm.DB, notifications []models.Notification) error {
+	if len(notifications) == 0 {
+		return nil
+	}
+
+	var userIDs []uint64
+	for _, n := range notifications {
+		userIDs = append(userIDs, n.UserID)
+	}
+
+	preferences, e := notification_repo.GetPreferencesOfUsers(db, userIDs)
+	if e != nil {
+		return e
+	}
+
+	withWebhook, e := notification_repo.GetUsersWithWebhook(db, userIDs)
+	if e != nil {
+		return e
+	}
+
+	for i := range notifications {
+		n := &notifications[i]
+		userPreferences := preferences[n.UserID]
+
+		if preference.Mode(userPreferences, n.Type, models.NOTIFICATION_CHANNEL_IN_APP) != models.NOTIFICATION_MODE_OFF {
+			e = notification_repo.CreateNotifications(db, notifications[i:i+1])
+			if e != nil {
+				return e
+			}
+		}
+
+		for _, channel := range queuedChannels {
+			mode := preference.Mode(userPreferences, n.Type, channel)
+			if mode == models.NOTIFICATION_MODE_OFF {
+				continue
+			}
+			if channel == models.NOTIFICATION_CHANNEL_WEBHOOK && withWebhook[n.UserID] == false {
+				continue
+			}
+
+			payload, e := json.Marshal(n)
+			if e != nil {
+				return e
+			}
+
+			e = notification_repo.QueueDelivery(db, models.NotificationDelivery{
+				UserID:  n.UserID,
+				Channel: channel,
+				Event:   n.Type,
+				Digest:  mode == models.NOTIFICATION_MODE_DIGEST,
+				Payload: string(payload),
+			})
+			if e != nil {
+				return e
+			}
+		}
+	}
+
+	return nil
+}
+
+// Invitation notify the invited user about an invitation to the organization
+func Invitation(db *gorm.DB, invitation models.UserOrganizationInvitation, organization models.User) error {
+	if invitation.UserID == nil {
+		return nil
+	}
+
+	return Notify(db, []models.Notification{{
+		UserID:       *invitation.UserID,
+		ActorID:      &invitation.CreatedByID,
+		Type:         models.NOTIFICATION_TYPE_INVITATION,
+		InvitationID: &invitation.ID,
+		Message:      "You are invited to join " + organization.Username,
+	}})
+}
+
+// IssueAssignment notify a user who is assigned to the issue
+func IssueAssignment(db *gorm.DB, issue models.Issue, userID, actorID uint64) error {
+	if userID == actorID {
+		return nil
+	}
+	moduleID := uint(models.MODULE_ISSUE_TRACKER)
+
+	return Notify(db, []models.Notification{{
+		UserID:    userID,
+		ActorID:   &actorID,
+		Type:      models.NOTIFICATION_TYPE_ASSIGNMENT,
+		ProjectID: &issue.ProjectID,
+		ModuleID:  &moduleID,
+		ObjectID:  &issue.ID,
+		Message:   issue.Title,
+	}})
+}
+
+// Reminder notify the receiver of a task reminder. reminder.Task must be loaded.
+func Reminder(db *gorm.DB, reminder models.TaskReminder, receiver models.TaskReminderReceiver) error {
+	moduleID := uint(models.MODULE_TASK)
+	n := models.Notification{
+		UserID:   receiver.UserID,
+		ActorID:  &reminder.CreatedByID,
+		Type:     models.NOTIFICATION_TYPE_REMINDER,
+		ModuleID: &moduleID,
+		ObjectID: &reminder.TaskID,
+		Message:  reminder.Title,
+	}
+	if reminder.Task != nil {
+		n.ProjectID = &reminder.Task.ProjectID
+	}
+
+	return Notify(db, []models.Notification{n})
+}
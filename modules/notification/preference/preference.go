@@ -0,0 +1,182 @@
+// Package preference decide how notifications of an event are delivered to a user
+// and when the daily digest of the user is due.
+package preference
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"devin/calendar"
+	"devin/models"
+)
+
+// Events is the list of events which users can set preferences for
+var Events = []string{
+	models.NOTIFICATION_EVENT_ASSIGNMENT,
+	models.NOTIFICATION_EVENT_MENTION,
+	models.NOTIFICATION_EVENT_COMMENT,
+	models.NOTIFICATION_EVENT_REMINDER,
+	models.NOTIFICATION_EVENT_INVITATION,
+}
+
+// Channels is the list of delivery channels
+var Channels = []string{
+	models.NOTIFICATION_CHANNEL_IN_APP,
+	models.NOTIFICATION_CHANNEL_EMAIL,
+	models.NOTIFICATION_CHANNEL_WEBHOOK,
+}
+
+// DefaultDigestTime is the local time of digest for users who didn't choose one
+const DefaultDigestTime = "08:00"
+
+// Validation errors of preferences
+var (
+	ErrUnknownEvent   = errors.New("Unknown event")
+	ErrUnknownChannel = errors.New("Unknown channel")
+	ErrUnknownMode    = errors.New("Unknown mode")
+	ErrDigestChannel  = errors.New("Only emails can be sent in digest")
+	ErrDigestUrgent   = errors.New("Urgent events can't be sent in digest")
+)
+
+// IsUrgent check the event to be urgent. Urgent events are never batched in digest.
+func IsUrgent(event string) bool {
+	return event == models.NOTIFICATION_EVENT_REMINDER || event == models.NOTIFICATION_EVENT_INVITATION
+}
+
+// DefaultMode return the mode of an event on a channel when user has no preference for it
+func DefaultMode(event, channel string) string {
+	switch channel {
+	case models.NOTIFICATION_CHANNEL_IN_APP:
+		return models.NOTIFICATION_MODE_INSTANT
+	case models.NOTIFICATION_CHANNEL_EMAIL:
+		if event == models.NOTIFICATION_EVENT_COMMENT {
+			return models.NOTIFICATION_MODE_DIGEST
+		}
+		return models.NOTIFICATION_MODE_INSTANT
+	}
+
+	return models.NOTIFICATION_MODE_OFF
+}
+
+// Mode return the mode of an event on a channel using stored preferences of the user
+func Mode(preferences []models.NotificationPreference, event, channel string) string {
+	for _, p := range preferences {
+		if p.Event == event && p.Channel == channel && Validate(p) == nil {
+			return p.Mode
+		}
+	}
+
+	return DefaultMode(event, channel)
+}
+
+// Matrix return the mode of every event on every channel
+func Matrix(preferences []models.NotificationPreference) (matrix []models.NotificationPreference) {
+	for _, event := range Events {
+		for _, channel := range Channels {
+			matrix = append(matrix, models.NotificationPreference{
+				Event:   event,
+				Channel: channel,
+				Mode:    Mode(preferences, event, channel),
+			})
+		}
+	}
+
+	return
+}
+
+// Validate check event, channel and mode of a preference
+func Validate(p models.NotificationPreference) error {
+	if contains(Events, p.Event) == false {
+		return ErrUnknownEvent
+	}
+	if contains(Channels, p.Channel) == false {
+		return ErrUnknownChannel
+	}
+
+	switch p.Mode {
+	case models.NOTIFICATION_MODE_INSTANT, models.NOTIFICATION_MODE_OFF:
+		return nil
+	case models.NOTIFICATION_MODE_DIGEST:
+		if p.Channel != models.NOTIFICATION_CHANNEL_EMAIL {
+			return ErrDigestChannel
+		}
+		if IsUrgent(p.Event) {
+			return ErrDigestUrgent
+		}
+		return nil
+	}
+
+	return ErrUnknownMode
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ParseDigestTime parse the digest time given in time format of the user and return it in 15:04 format
+func ParseDigestTime(value string, timeFormat *string) (string, error) {
+	layout := calendar.DefaultTimeLayout
+	if timeFormat != nil && *timeFormat != "" {
+		layout = *timeFormat
+	}
+
+	t, e := time.Parse(layout, strings.TrimSpace(value))
+	if e != nil {
+		// Accept 15:04 from users who have a time format with seconds
+		t, e = time.Parse(calendar.DefaultTimeLayout, strings.TrimSpace(value))
+	}
+	if e != nil {
+		return "", e
+	}
+
+	return t.Format(calendar.DefaultTimeLayout), nil
+}
+
+// Location return time zone of the user, UTC if it's not set or invalid
+func Location(settings models.NotificationSettings) *time.Location {
+	if settings.Timezone == nil || *settings.Timezone == "" {
+		return time.UTC
+	}
+
+	loc, e := time.LoadLocation(*settings.Timezone)
+	if e != nil {
+		return time.UTC
+	}
+
+	return loc
+}
+
+// LastDigestSchedule return the latest time until now which the digest of the user was scheduled for
+func LastDigestSchedule(settings models.NotificationSettings, now time.Time) time.Time {
+	digestTime, e := time.Parse(calendar.DefaultTimeLayout, settings.DigestTime)
+	if e != nil {
+		digestTime, _ = time.Parse(calendar.DefaultTimeLayout, DefaultDigestTime)
+	}
+
+	local := now.In(Location(settings))
+	y, m, d := local.Date()
+	schedule := time.Date(y, m, d, digestTime.Hour(), digestTime.Minute(), 0, 0, local.Location())
+	if local.Before(schedule) {
+		schedule = schedule.AddDate(0, 0, -1)
+	}
+
+	return schedule
+}
+
+// IsDigestDue check the digest of the user to be due. A digest is sent at the digest time
+// of the user, at most once a day, when there are items queued before that time.
+func IsDigestDue(settings models.NotificationSettings, oldestItem time.Time, now time.Time) bool {
+	schedule := LastDigestSchedule(settings, now)
+	if settings.LastDigestAt != nil && settings.LastDigestAt.Before(schedule) == false {
+		return false
+	}
+
+	return oldestItem.Before(schedule)
+}
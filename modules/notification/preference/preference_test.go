@@ -0,0 +1,112 @@
+package preference
+
+import (
+	"testing"
+	"time"
+
+	"devin/models"
+)
+
+func TestMode(t *testing.T) {
+	preferences := []models.NotificationPreference{
+		{Event: models.NOTIFICATION_EVENT_MENTION, Channel: models.NOTIFICATION_CHANNEL_EMAIL, Mode: models.NOTIFICATION_MODE_DIGEST},
+		{Event: models.NOTIFICATION_EVENT_COMMENT, Channel: models.NOTIFICATION_CHANNEL_IN_APP, Mode: models.NOTIFICATION_MODE_OFF},
+		// Invalid preferences are ignored
+		{Event: models.NOTIFICATION_EVENT_REMINDER, Channel: models.NOTIFICATION_CHANNEL_EMAIL, Mode: models.NOTIFICATION_MODE_DIGEST},
+	}
+
+	cases := []struct {
+		event, channel, mode string
+	}{
+		{models.NOTIFICATION_EVENT_MENTION, models.NOTIFICATION_CHANNEL_EMAIL, models.NOTIFICATION_MODE_DIGEST},
+		{models.NOTIFICATION_EVENT_COMMENT, models.NOTIFICATION_CHANNEL_IN_APP, models.NOTIFICATION_MODE_OFF},
+		{models.NOTIFICATION_EVENT_REMINDER, models.NOTIFICATION_CHANNEL_EMAIL, models.NOTIFICATION_MODE_INSTANT},
+		{models.NOTIFICATION_EVENT_COMMENT, models.NOTIFICATION_CHANNEL_EMAIL, models.NOTIFICATION_MODE_DIGEST},
+		{models.NOTIFICATION_EVENT_ASSIGNMENT, models.NOTIFICATION_CHANNEL_WEBHOOK, models.NOTIFICATION_MODE_OFF},
+	}
+	for _, c := range cases {
+		if mode := Mode(preferences, c.event, c.channel); mode != c.mode {
+			t.Fatalf("Mode of %s on %s must be %s, got %s", c.event, c.channel, c.mode, mode)
+		}
+	}
+
+	if len(Matrix(preferences)) != len(Events)*len(Channels) {
+		t.Fatal("Matrix must contain every event and channel")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	cases := map[error]models.NotificationPreference{
+		nil:               {Event: models.NOTIFICATION_EVENT_COMMENT, Channel: models.NOTIFICATION_CHANNEL_EMAIL, Mode: models.NOTIFICATION_MODE_DIGEST},
+		ErrUnknownEvent:   {Event: "deploy", Channel: models.NOTIFICATION_CHANNEL_EMAIL, Mode: models.NOTIFICATION_MODE_OFF},
+		ErrUnknownChannel: {Event: models.NOTIFICATION_EVENT_COMMENT, Channel: "sms", Mode: models.NOTIFICATION_MODE_OFF},
+		ErrUnknownMode:    {Event: models.NOTIFICATION_EVENT_COMMENT, Channel: models.NOTIFICATION_CHANNEL_EMAIL, Mode: "weekly"},
+		ErrDigestChannel:  {Event: models.NOTIFICATION_EVENT_COMMENT, Channel: models.NOTIFICATION_CHANNEL_WEBHOOK, Mode: models.NOTIFICATION_MODE_DIGEST},
+		ErrDigestUrgent:   {Event: models.NOTIFICATION_EVENT_INVITATION, Channel: models.NOTIFICATION_CHANNEL_EMAIL, Mode: models.NOTIFICATION_MODE_DIGEST},
+	}
+	for expected, p := range cases {
+		if e := Validate(p); e != expected {
+			t.Fatalf("Validation of %v must return %v, got %v", p, expected, e)
+		}
+	}
+}
+
+func TestParseDigestTime(t *testing.T) {
+	withSeconds := "15:04:05"
+	cases := []struct {
+		value    string
+		format   *string
+		expected string
+	}{
+		{"08:30", nil, "08:30"},
+		{"21:05:00", &withSeconds, "21:05"},
+		{"21:05", &withSeconds, "21:05"},
+	}
+	for _, c := range cases {
+		if value, e := ParseDigestTime(c.value, c.format); e != nil || value != c.expected {
+			t.Fatalf("%s must be parsed as %s, got %s %v", c.value, c.expected, value, e)
+		}
+	}
+
+	if _, e := ParseDigestTime("25:00", nil); e == nil {
+		t.Fatal("Invalid time must not be parsed")
+	}
+}
+
+func TestIsDigestDue(t *testing.T) {
+	tehran := "Asia/Tehran"
+	settings := models.NotificationSettings{Timezone: &tehran, DigestTime: "08:00"}
+	loc := Location(settings)
+	if loc.String() != tehran {
+		t.Skip("Time zone database is not available")
+	}
+
+	now := time.Date(2018, time.June, 18, 9, 0, 0, 0, loc)
+	schedule := LastDigestSchedule(settings, now)
+	if schedule.Equal(time.Date(2018, time.June, 18, 8, 0, 0, 0, loc)) == false {
+		t.Fatal("Unexpected schedule", schedule)
+	}
+
+	beforeSchedule := time.Date(2018, time.June, 18, 7, 0, 0, 0, loc)
+	if LastDigestSchedule(settings, beforeSchedule).Day() != 17 {
+		t.Fatal("Schedule of yesterday must be returned before digest time")
+	}
+
+	oldItem := time.Date(2018, time.June, 17, 20, 0, 0, 0, loc)
+	newItem := time.Date(2018, time.June, 18, 8, 30, 0, 0, loc)
+	if IsDigestDue(settings, oldItem, now) == false {
+		t.Fatal("Digest must be due for items queued before digest time")
+	}
+	if IsDigestDue(settings, newItem, now) {
+		t.Fatal("Items queued after digest time must wait for the next digest")
+	}
+
+	sentAt := time.Date(2018, time.June, 18, 8, 1, 0, 0, loc)
+	settings.LastDigestAt = &sentAt
+	if IsDigestDue(settings, oldItem, now) {
+		t.Fatal("Digest must be sent once a day")
+	}
+	if IsDigestDue(settings, newItem, now.AddDate(0, 0, 1)) == false {
+		t.Fatal("Digest of the next day must be due")
+	}
+}
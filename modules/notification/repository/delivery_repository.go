@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+
+	"devin/models"
+)
+
+// QueueDelivery store a notification to be sent by the notifications worker
+func QueueDelivery(db *gorm.DB, delivery models.NotificationDelivery) error {
+	if delivery.NextAttemptAt.IsZero() {
+		delivery.NextAttemptAt = time.Now()
+	}
+
+	return db.Set("gorm:save_associations", false).Create(&delivery).Error
+}
+
+// ClaimInstantDeliveries lock, load and claim pending instant deliveries which are due until now.
+// Rows locked by other workers are skipped. Claimed deliveries count an attempt and are
+// not due again before claimedUntil, so if the worker dies they are retried after it.
+// db must be a short transaction, the claim outlives it and lets the caller send without holding locks.
+func ClaimInstantDeliveries(db *gorm.DB, now time.Time, limit int, maxAttempts uint, claimedUntil time.Time) (rows []models.NotificationDelivery, e error) {
+	e = db.Raw(`SELECT * FROM notification_deliveries
+        WHERE digest=false AND sent_at IS NULL AND attempts < ? AND next_attempt_at <= ?
+        ORDER BY next_attempt_at ASC, id ASC
+        LIMIT ? FOR UPDATE SKIP LOCKED`, maxAttempts, now, limit).
+		Scan(&rows).
+		Error
+	if e != nil || len(rows) == 0 {
+		return
+	}
+
+	var ids []uint64
+	for _, row := range rows {
+		ids = append(ids, row.ID)
+	}
+	e = db.Exec(`UPDATE notification_deliveries SET attempts=attempts+1, next_attempt_at=? WHERE id IN (?)`,
+		claimedUntil, ids).Error
+
+	return
+}
+
+// DigestUser is a user who has pending digest items
+type DigestUser struct {
+	UserID     uint64
+	OldestItem time.Time
+	models.NotificationSettings
+}
+
+// GetDigestUsers load users who have pending digest items, with their settings and time of their oldest item
+func GetDigestUsers(db *gorm.DB) (users []DigestUser, e error) {
+	e = db.Raw(`SELECT d.user_id, MIN(d.created_at) AS oldest_item, u.timezone, u.digest_time, u.webhook_url, u.last_digest_at
+        FROM notification_deliveries d
+        INNER JOIN users u ON u.id=d.user_id AND u.deleted_at IS NULL
+        WHERE d.digest=true AND d.sent_at IS NULL
+        GROUP BY d.user_id, u.id ORDER BY d.user_id`).
+		Scan(&users).
+		Error
+
+	return
+}
+
+// ClaimDigest lock the user and load the pending digest items created before the given time.
+// It returns no items when the digest is being sent by another worker. db must be a transaction.
+func ClaimDigest(db *gorm.DB, userID uint64, before time.Time) (user models.User, items []models.NotificationDelivery, e error) {
+	e = db.Raw("SELECT * FROM users WHERE id=? AND deleted_at IS NULL FOR UPDATE SKIP LOCKED", userID).Scan(&user).Error
+	if e != nil {
+		if e == gorm.ErrRecordNotFound {
+			e = nil
+		}
+		return
+	}
+
+	e = db.Model(&models.NotificationDelivery{}).
+		Where("user_id=? AND digest=true AND sent_at IS NULL AND created_at < ?", userID, before).
+		Order("created_at ASC, id ASC").
+		Find(&items).
+		Error
+
+	return
+}
+
+// SaveDeliveryState store the result of sending a delivery
+func SaveDeliveryState(db *gorm.DB, row models.NotificationDelivery) error {
+	return db.Model(&models.NotificationDelivery{}).
+		Where("id=?", row.ID).
+		UpdateColumns(map[string]interface{}{
+			"attempts":        row.Attempts,
+			"last_error":      row.LastError,
+			"next_attempt_at": row.NextAttemptAt,
+			"sent_at":         row.SentAt,
+		}).
+		Error
+}
+
+// MarkDigestSent mark digest items as sent and store the digest time of the user
+func MarkDigestSent(db *gorm.DB, userID uint64, itemIDs []uint64, now time.Time) error {
+	e := db.Model(&models.NotificationDelivery{}).
+		Where("id IN (?)", itemIDs).
+		UpdateColumn("sent_at", now).
+		Error
+	if e != nil {
+		return e
+	}
+
+	return db.Model(&models.User{}).
+		Where("id=?", userID).
+		UpdateColumn("last_digest_at", now).
+		Error
+}
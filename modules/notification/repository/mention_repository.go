@@ -0,0 +1,24 @@
+package repository
+
+import (
+	"github.com/jinzhu/gorm"
+
+	"devin/models"
+)
+
+// SaveMention store a mention, if not stored before for the comment and user
+func SaveMention(db *gorm.DB, mention models.Mention) error {
+	return db.Exec(`INSERT INTO mentions (module_id, object_id, comment_id, user_id, created_by_id)
+        VALUES (?, ?, ?, ?, ?) ON CONFLICT (module_id, comment_id, user_id) DO NOTHING`,
+		mention.ModuleID, mention.ObjectID, mention.CommentID, mention.UserID, mention.CreatedByID).Error
+}
+
+// GetUsersByUsernames load users of the given lowercased usernames
+func GetUsersByUsernames(db *gorm.DB, usernames []string) (users []models.User, e error) {
+	if len(usernames) == 0 {
+		return
+	}
+	e = db.Model(&models.User{}).Where("lower(username) IN (?)", usernames).Find(&users).Error
+
+	return
+}
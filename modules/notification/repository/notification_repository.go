@@ -0,0 +1,145 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/jinzhu/gorm"
+
+	"devin/models"
+)
+
+// ErrNotificationNotFound returned when the notification is not found for the user
+var ErrNotificationNotFound = errors.New("Notification not found")
+
+// CreateNotifications insert the notifications
+func CreateNotifications(db *gorm.DB, notifications []models.Notification) error {
+	db = db.Set("gorm:save_associations", false)
+	for i := range notifications {
+		if e := db.Create(&notifications[i]).Error; e != nil {
+			return e
+		}
+	}
+
+	return nil
+}
+
+// preloadRelations preload relations which are needed to show a notification
+func preloadRelations(db *gorm.DB) *gorm.DB {
+	return db.Preload("Actor").
+		Preload("Project").
+		Preload("Invitation").
+		Preload("Invitation.Organization")
+}
+
+// GetNotifications load paginated notifications of the user, newest first
+func GetNotifications(db *gorm.DB, userID uint64, unreadOnly bool, currentPage, perPage uint64) (data []models.Notification, total uint64, e error) {
+	db = db.Model(&models.Notification{}).Where("user_id=?", userID)
+	if unreadOnly {
+		db = db.Where("read_at IS NULL")
+	}
+
+	e = db.Count(&total).Error
+	if e != nil {
+		return
+	}
+
+	if perPage > 0 {
+		db = db.Limit(perPage)
+	}
+	if currentPage > 0 {
+		db = db.Offset((currentPage - 1) * perPage)
+	}
+
+	e = preloadRelations(db).
+		Order("created_at DESC, id DESC").
+		Find(&data).
+		Error
+
+	return
+}
+
+// GetNotificationsAfter load notifications of the user which are created after the given one, oldest first
+func GetNotificationsAfter(db *gorm.DB, userID, afterID uint64) (data []models.Notification, e error) {
+	e = preloadRelations(db).
+		Where("user_id=? AND id > ?", userID, afterID).
+		Order("id ASC").
+		Find(&data).
+		Error
+
+	return
+}
+
+// GetLastNotificationID return ID of the newest notification of the user, 0 if there is none
+func GetLastNotificationID(db *gorm.DB, userID uint64) (ID uint64, e error) {
+	e = db.Raw("SELECT COALESCE(MAX(id), 0) FROM notifications WHERE user_id=?", userID).Row().Scan(&ID)
+
+	return
+}
+
+// CountUnread count unread notifications of the user
+func CountUnread(db *gorm.DB, userID uint64) (count uint64, e error) {
+	e = db.Model(&models.Notification{}).Where("user_id=? AND read_at IS NULL", userID).Count(&count).Error
+
+	return
+}
+
+// GetNotificationByID load a notification of the user
+func GetNotificationByID(db *gorm.DB, userID, notificationID uint64) (notification models.Notification, e error) {
+	preloadRelations(db).Where("id=? AND user_id=?", notificationID, userID).First(&notification)
+	if notification.ID == 0 {
+		e = ErrNotificationNotFound
+	}
+
+	return
+}
+
+// SetRead mark the notification as read or unread
+func SetRead(db *gorm.DB, notification *models.Notification, read bool) error {
+	notification.ReadAt = nil
+	if read {
+		now := time.Now()
+		notification.ReadAt = &now
+	}
+
+	return db.Model(&models.Notification{}).
+		Where("id=?", notification.ID).
+		UpdateColumn("read_at", notification.ReadAt).
+		Error
+}
+
+// MarkAllRead mark all unread notifications of the user as read
+func MarkAllRead(db *gorm.DB, userID uint64) error {
+	return db.Model(&models.Notification{}).
+		Where("user_id=? AND read_at IS NULL", userID).
+		UpdateColumn("read_at", time.Now()).
+		Error
+}
+
+// MarkInvitationRead mark notifications of the invitation as read, used when it's accepted or rejected
+func MarkInvitationRead(db *gorm.DB, invitationID uint64) error {
+	return db.Model(&models.Notification{}).
+		Where("invitation_id=? AND read_at IS NULL", invitationID).
+		UpdateColumn("read_at", time.Now()).
+		Error
+}
+
+// GetUsersWithWebhook return IDs of the given users who have a webhook URL
+func GetUsersWithWebhook(db *gorm.DB, userIDs []uint64) (withWebhook map[uint64]bool, e error) {
+	withWebhook = make(map[uint64]bool)
+	if len(userIDs) == 0 {
+		return
+	}
+
+	var users []models.User
+	e = db.Model(&models.User{}).
+		Select("id").
+		Where("id IN (?) AND webhook_url IS NOT NULL AND webhook_url <> ''", userIDs).
+		Find(&users).
+		Error
+	for _, user := range users {
+		withWebhook[user.ID] = true
+	}
+
+	return
+}
@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"github.com/jinzhu/gorm"
+
+	"devin/models"
+)
+
+// GetPreferences load stored notification preferences of the user
+func GetPreferences(db *gorm.DB, userID uint64) (preferences []models.NotificationPreference, e error) {
+	e = db.Model(&models.NotificationPreference{}).
+		Where("user_id=?", userID).
+		Find(&preferences).
+		Error
+
+	return
+}
+
+// GetPreferencesOfUsers load stored notification preferences of the users, grouped by user ID
+func GetPreferencesOfUsers(db *gorm.DB, userIDs []uint64) (preferences map[uint64][]models.NotificationPreference, e error) {
+	preferences = make(map[uint64][]models.NotificationPreference)
+	if len(userIDs) == 0 {
+		return
+	}
+
+	var rows []models.NotificationPreference
+	e = db.Model(&models.NotificationPreference{}).
+		Where("user_id IN (?)", userIDs).
+		Find(&rows).
+		Error
+	for _, row := range rows {
+		preferences[row.UserID] = append(preferences[row.UserID], row)
+	}
+
+	return
+}
+
+// SavePreferences insert or update notification preferences and settings of the user in a transaction
+func SavePreferences(db *gorm.DB, userID uint64, settings models.NotificationSettings, preferences []models.NotificationPreference) (e error) {
+	tx := db.Begin()
+	defer func() {
+		if e != nil {
+			tx.Rollback()
+		}
+	}()
+
+	e = tx.Model(&models.User{}).
+		Where("id=?", userID).
+		UpdateColumns(map[string]interface{}{
+			"timezone":    settings.Timezone,
+			"digest_time": settings.DigestTime,
+			"webhook_url": settings.WebhookURL,
+		}).
+		Error
+	if e != nil {
+		return
+	}
+
+	for _, p := range preferences {
+		e = tx.Exec(`INSERT INTO notification_preferences (user_id, event, channel, mode)
+            VALUES (?, ?, ?, ?) ON CONFLICT (user_id, event, channel)
+            DO UPDATE SET mode=EXCLUDED.mode, updated_at=CURRENT_TIMESTAMP`, userID, p.Event, p.Channel, p.Mode).Error
+		if e != nil {
+			return
+		}
+	}
+
+	e = tx.Commit().Error
+
+	return
+}
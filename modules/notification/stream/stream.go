@@ -0,0 +1,99 @@
+// Package stream push notifications to browsers using Server-Sent Events.
+// Each event carries the notification ID, so a reconnecting EventSource sends it
+// back in Last-Event-ID header and receives the notifications it has missed.
+package stream
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"devin/models"
+)
+
+// EventNotification is the name of events which carry a notification
+const EventNotification = "notification"
+
+// ErrStreamingUnsupported returned when the response writer can't be flushed
+var ErrStreamingUnsupported = errors.New("Streaming is not supported")
+
+// Source load notifications of the user which are created after the given ID, oldest first
+type Source func(afterID uint64) ([]models.Notification, error)
+
+// Options of a stream
+type Options struct {
+	// How often the source is checked for new notifications
+	PollInterval time.Duration
+
+	// The stream is closed after this duration and the client reconnects.
+	// It must be shorter than write timeout of the http server.
+	MaxDuration time.Duration
+
+	// Milliseconds which the client waits before reconnecting
+	Retry uint
+}
+
+// WriteEvent write a single event with json encoded data
+func WriteEvent(w io.Writer, id uint64, event string, data interface{}) error {
+	payload, e := json.Marshal(data)
+	if e != nil {
+		return e
+	}
+
+	_, e = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", id, event, payload)
+
+	return e
+}
+
+// Serve stream notifications newer than lastID until the client disconnects or MaxDuration passes
+func Serve(w http.ResponseWriter, r *http.Request, lastID uint64, source Source, options Options) error {
+	flusher, ok := w.(http.Flusher)
+	if ok == false {
+		return ErrStreamingUnsupported
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	if options.Retry > 0 {
+		fmt.Fprintf(w, "retry: %d\n\n", options.Retry)
+	}
+	flusher.Flush()
+
+	ticker := time.NewTicker(options.PollInterval)
+	defer ticker.Stop()
+	timeout := time.NewTimer(options.MaxDuration)
+	defer timeout.Stop()
+
+	for {
+		notifications, e := source(lastID)
+		if e != nil {
+			return e
+		}
+
+		for _, notification := range notifications {
+			e = WriteEvent(w, notification.ID, EventNotification, notification)
+			if e != nil {
+				return e
+			}
+			lastID = notification.ID
+		}
+		if len(notifications) > 0 {
+			flusher.Flush()
+		}
+
+		select {
+		case <-r.Context().Done():
+			return nil
+		case <-timeout.C:
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package stream
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"devin/models"
+)
+
+func TestWriteEvent(t *testing.T) {
+	w := httptest.NewRecorder()
+	e := WriteEvent(w, 7, EventNotification, map[string]string{"Message": "line 1\nline 2"})
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	expected := "id: 7\nevent: notification\ndata: {\"Message\":\"line 1\\nline 2\"}\n\n"
+	if w.Body.String() != expected {
+		t.Fatalf("Unexpected event %q", w.Body.String())
+	}
+}
+
+func TestServe(t *testing.T) {
+	var requestedIDs []uint64
+	source := func(afterID uint64) ([]models.Notification, error) {
+		requestedIDs = append(requestedIDs, afterID)
+		if afterID == 3 {
+			return []models.Notification{{ID: 4, Message: "@alice ping"}, {ID: 6, Message: "done"}}, nil
+		}
+
+		return nil, nil
+	}
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/api/notifications/stream", nil)
+	e := Serve(w, r, 3, source, Options{
+		PollInterval: 10 * time.Millisecond,
+		MaxDuration:  45 * time.Millisecond,
+		Retry:        1000,
+	})
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	if w.Header().Get("Content-Type") != "text/event-stream" {
+		t.Fatal("Unexpected content type", w.Header().Get("Content-Type"))
+	}
+
+	body := w.Body.String()
+	if strings.HasPrefix(body, "retry: 1000\n\n") == false ||
+		strings.Contains(body, "id: 4\nevent: notification\n") == false ||
+		strings.Contains(body, "id: 6\nevent: notification\n") == false {
+		t.Fatalf("Unexpected stream %q", body)
+	}
+
+	if len(requestedIDs) < 2 || requestedIDs[0] != 3 || requestedIDs[len(requestedIDs)-1] != 6 {
+		t.Fatal("Stream must continue after the last sent notification", requestedIDs)
+	}
+}
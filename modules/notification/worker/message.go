@@ -0,0 +1,107 @@
+package worker
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"devin/calendar"
+	"devin/mailer"
+	"devin/models"
+	"devin/modules/notification/preference"
+)
+
+// Delivery errors which are not retried
+var (
+	ErrNoRecipient    = errors.New("User has no address for this channel")
+	ErrUnknownChannel = errors.New("Unknown channel")
+)
+
+var titles = map[string]string{
+	models.NOTIFICATION_TYPE_ASSIGNMENT: "You are assigned",
+	models.NOTIFICATION_TYPE_MENTION:    "You are mentioned in a comment",
+	models.NOTIFICATION_TYPE_COMMENT:    "New comment",
+	models.NOTIFICATION_TYPE_REMINDER:   "Reminder",
+	models.NOTIFICATION_TYPE_INVITATION: "New invitation",
+}
+
+var modulePaths = map[uint]string{
+	models.MODULE_TASK:          "task",
+	models.MODULE_MILESTONE:     "milestone",
+	models.MODULE_ISSUE_TRACKER: "issue",
+}
+
+// Item convert a notification to an email item. Time is formatted in calendar, time zone
+// and date and time formats of the user.
+func Item(n models.Notification, createdAt time.Time, user models.User, config mailer.Config) mailer.NotificationItem {
+	title, ok := titles[n.Type]
+	if ok == false {
+		title = "New notification"
+	}
+
+	return mailer.NotificationItem{
+		Title:   title,
+		Message: n.Message,
+		Link:    config.URL(link(n)),
+		Time:    formatTime(createdAt, user),
+	}
+}
+
+// link return path of the notified object
+func link(n models.Notification) string {
+	if n.ProjectID != nil && n.ModuleID != nil && n.ObjectID != nil {
+		if path, ok := modulePaths[*n.ModuleID]; ok {
+			return fmt.Sprintf("/project/%d/%s/%d", *n.ProjectID, path, *n.ObjectID)
+		}
+	}
+
+	return "/notifications"
+}
+
+func formatTime(t time.Time, user models.User) string {
+	return calendar.FormatDateTime(t.In(preference.Location(user.NotificationSettings)), calendarOf(user),
+		stringOf(user.DateFormat), stringOf(user.TimeFormat))
+}
+
+func calendarOf(user models.User) uint {
+	if user.CalendarSystemID == nil {
+		return calendar.Gregorian
+	}
+
+	return *user.CalendarSystemID
+}
+
+func stringOf(s *string) string {
+	if s == nil {
+		return ""
+	}
+
+	return *s
+}
+
+// DigestMessage build the digest email of the user from the queued items
+func DigestMessage(user models.User, rows []models.NotificationDelivery, now time.Time, config mailer.Config) (msg mailer.Message, e error) {
+	if user.Email == "" {
+		e = ErrNoRecipient
+		return
+	}
+
+	data := mailer.DigestData{
+		Username: user.Username,
+		Date: calendar.FormatDate(now.In(preference.Location(user.NotificationSettings)),
+			calendarOf(user), stringOf(user.DateFormat)),
+	}
+	for _, row := range rows {
+		var n models.Notification
+		if json.Unmarshal([]byte(row.Payload), &n) != nil {
+			continue
+		}
+		data.Items = append(data.Items, Item(n, row.CreatedAt, user, config))
+	}
+
+	msg, e = mailer.DigestMessage(user.Email, data)
+	msg.From = config.From
+
+	return
+}
@@ -0,0 +1,151 @@
+package worker
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"time"
+)
+
+// ErrNonPublicAddress returned when a webhook URL resolves to a loopback, private or link-local address
+var ErrNonPublicAddress = errors.New("Webhook URL must resolve to a public address")
+
+// maxRedirects is the number of redirects followed by webhook requests
+const maxRedirects = 3
+
+// nonPublicNetworks are networks which webhooks can't be sent to, so users can't reach
+// services inside the server network, e.g cloud metadata on 169.254.169.254
+var nonPublicNetworks = parseCIDRs(
+	"0.0.0.0/8",
+	"10.0.0.0/8",
+	"100.64.0.0/10",
+	"127.0.0.0/8",
+	"169.254.0.0/16",
+	"172.16.0.0/12",
+	"192.0.0.0/24",
+	"192.168.0.0/16",
+	"198.18.0.0/15",
+	"224.0.0.0/4",
+	"240.0.0.0/4",
+	"::/128",
+	"::1/128",
+	"fc00::/7",
+	"fe80::/10",
+	"ff00::/8",
+)
+
+func parseCIDRs(cidrs ...string) (networks []*net.IPNet) {
+	for _, cidr := range cidrs {
+		_, network, e := net.ParseCIDR(cidr)
+		if e != nil {
+			panic(e)
+		}
+		networks = append(networks, network)
+	}
+
+	return
+}
+
+// IsPublicIP check the ip not to be loopback, private, link-local, multicast or reserved
+func IsPublicIP(ip net.IP) bool {
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+	}
+	for _, network := range nonPublicNetworks {
+		if network.Contains(ip) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// dialPublic resolve the host of addr and dial the first public address of it.
+// Addresses are checked at connection time, so DNS changes and redirects can't reach non-public addresses.
+func dialPublic(dialer *net.Dialer) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		host, port, e := net.SplitHostPort(addr)
+		if e != nil {
+			return nil, e
+		}
+
+		ips, e := net.DefaultResolver.LookupIPAddr(ctx, host)
+		if e != nil {
+			return nil, e
+		}
+		for _, ip := range ips {
+			if IsPublicIP(ip.IP) == false {
+				return nil, ErrNonPublicAddress
+			}
+		}
+		if len(ips) == 0 {
+			return nil, ErrNonPublicAddress
+		}
+
+		return dialer.DialContext(ctx, network, net.JoinHostPort(ips[0].IP.String(), port))
+	}
+}
+
+// WebhookSender post notifications to webhook URLs of users
+type WebhookSender interface {
+	Send(url, event string, payload []byte) error
+}
+
+// HTTPWebhook post json payloads using an http client
+type HTTPWebhook struct {
+	Client *http.Client
+}
+
+// NewHTTPWebhook create a webhook sender with a short timeout which only connects to public addresses.
+// Proxies of environment are not used, since they would connect on behalf of the sender.
+func NewHTTPWebhook() HTTPWebhook {
+	dialer := &net.Dialer{Timeout: 5 * time.Second}
+	transport := &http.Transport{
+		DialContext:         dialPublic(dialer),
+		TLSHandshakeTimeout: 5 * time.Second,
+		MaxIdleConns:        10,
+		IdleConnTimeout:     time.Minute,
+	}
+
+	return HTTPWebhook{Client: &http.Client{
+		Timeout:   10 * time.Second,
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxRedirects {
+				return errors.New("Too many redirects")
+			}
+			if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+				return errors.New("Webhook redirected to an unsupported scheme")
+			}
+			return nil
+		},
+	}}
+}
+
+// Send post payload to url. Responses other than 2xx are errors.
+func (h HTTPWebhook) Send(url, event string, payload []byte) error {
+	req, e := http.NewRequest(http.MethodPost, url, bytes.NewReader(payload))
+	if e != nil {
+		return e
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Devin-Event", event)
+
+	res, e := h.Client.Do(req)
+	if e != nil {
+		return e
+	}
+	defer res.Body.Close()
+	io.Copy(ioutil.Discard, io.LimitReader(res.Body, 1<<16))
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("Webhook responded %s", res.Status)
+	}
+
+	return nil
+}
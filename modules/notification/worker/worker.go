@@ -0,0 +1,282 @@
+// Package worker send queued email and webhook notifications.
+// Instant deliveries are claimed with SELECT ... FOR UPDATE SKIP LOCKED in a short transaction
+// like the mail outbox, so several workers can run at the same time, and are sent without
+// holding locks. Emails are handed to the mail outbox which retries them; webhooks are retried
+// here with the outbox backoff.
+// Digest items of a user are sent in a single email once a day, at the digest time
+// of the user, with dates formatted in the user's calendar and time zone.
+package worker
+
+import (
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/jinzhu/gorm"
+
+	"devin/database"
+	"devin/mailer"
+	"devin/mailer/outbox"
+	"devin/models"
+	"devin/modules/notification/preference"
+	notification_repo "devin/modules/notification/repository"
+)
+
+// Worker send pending notification deliveries
+type Worker struct {
+	Webhook WebhookSender
+
+	// Sender and base URL of links in emails
+	MailConfig mailer.Config
+
+	// Number of deliveries claimed in each transaction
+	BatchSize int
+
+	// Claimed deliveries are retried after this time if the worker dies, it must be longer than sending a batch
+	ClaimTimeout time.Duration
+
+	// Failed webhooks are retried until MaxAttempts is reached
+	MaxAttempts uint
+
+	// Time between runs, used by Run
+	Interval time.Duration
+
+	// NewDB return a new database instance, default is database.NewGORMInstance
+	NewDB func() *gorm.DB
+}
+
+// New create a worker with default settings
+func New(config mailer.Config) *Worker {
+	return &Worker{
+		Webhook:      NewHTTPWebhook(),
+		MailConfig:   config,
+		BatchSize:    50,
+		ClaimTimeout: 15 * time.Minute,
+		MaxAttempts:  8,
+		Interval:     time.Minute,
+		NewDB:        database.NewGORMInstance,
+	}
+}
+
+// Run call RunOnce every Interval until stop is closed
+func (wr *Worker) Run(stop <-chan struct{}) {
+	ticker := time.NewTicker(wr.Interval)
+	defer ticker.Stop()
+
+	for {
+		sent, e := wr.RunOnce(time.Now())
+		if e != nil {
+			log.Println("Notifications worker:", e)
+		} else if sent > 0 {
+			log.Printf("Notifications worker: %d notifications sent", sent)
+		}
+
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce send instant deliveries and digests due until now and return the number of sent notifications
+func (wr *Worker) RunOnce(now time.Time) (sent int, e error) {
+	db := wr.NewDB()
+	defer db.Close()
+
+	for {
+		var claimed, count int
+		claimed, count, e = wr.runBatch(db, now)
+		sent += count
+		if e != nil {
+			return
+		}
+		if claimed < wr.BatchSize {
+			break
+		}
+	}
+
+	users, e := notification_repo.GetDigestUsers(db)
+	if e != nil {
+		return
+	}
+
+	for _, user := range users {
+		if preference.IsDigestDue(user.NotificationSettings, user.OldestItem, now) == false {
+			continue
+		}
+
+		var count int
+		count, e = wr.sendDigest(db, user, now)
+		sent += count
+		if e != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// runBatch claim a batch of instant deliveries in a short transaction and send them.
+// The state of each delivery is stored right after sending it, so a failure doesn't undo sent deliveries.
+// Failed deliveries are rescheduled after now, so they are not claimed again by this run.
+func (wr *Worker) runBatch(db *gorm.DB, now time.Time) (claimed int, sent int, e error) {
+	tx := db.Begin()
+	rows, e := notification_repo.ClaimInstantDeliveries(tx, now, wr.BatchSize, wr.MaxAttempts, now.Add(wr.ClaimTimeout))
+	if e == nil {
+		e = tx.Commit().Error
+	} else {
+		tx.Rollback()
+	}
+	if e != nil {
+		return
+	}
+	claimed = len(rows)
+
+	for i := range rows {
+		var user models.User
+		db.Model(&user).Where("id=?", rows[i].UserID).First(&user)
+
+		var ok bool
+		ok, e = wr.deliverAndSave(db, user, &rows[i], now)
+		if e != nil {
+			return
+		}
+		if ok {
+			sent++
+		}
+	}
+
+	return
+}
+
+// deliverAndSave send a claimed delivery and store its state. Emails are queued in the mail outbox
+// in the same transaction as the state, webhooks are posted out of any transaction.
+func (wr *Worker) deliverAndSave(db *gorm.DB, user models.User, row *models.NotificationDelivery, now time.Time) (sent bool, e error) {
+	if row.Channel != models.NOTIFICATION_CHANNEL_EMAIL {
+		sent = wr.deliver(db, user, row, now)
+		return sent, notification_repo.SaveDeliveryState(db, *row)
+	}
+
+	tx := db.Begin()
+	sent = wr.deliver(tx, user, row, now)
+	e = notification_repo.SaveDeliveryState(tx, *row)
+	if e == nil {
+		e = tx.Commit().Error
+	} else {
+		tx.Rollback()
+	}
+	if e != nil {
+		sent = false
+	}
+
+	return
+}
+
+// deliver send an instant delivery and update its state, Attempts of the row is increased
+// like the claim did in the database. It returns true if it is sent.
+func (wr *Worker) deliver(db *gorm.DB, user models.User, row *models.NotificationDelivery, now time.Time) bool {
+	row.Attempts++
+
+	var n models.Notification
+	e := json.Unmarshal([]byte(row.Payload), &n)
+	if e == nil {
+		switch row.Channel {
+		case models.NOTIFICATION_CHANNEL_EMAIL:
+			e = wr.sendEmail(db, user, n, row.CreatedAt)
+		case models.NOTIFICATION_CHANNEL_WEBHOOK:
+			e = wr.sendWebhook(user, row)
+		default:
+			e = ErrUnknownChannel
+		}
+	}
+
+	if e == nil {
+		sentAt := now
+		row.SentAt = &sentAt
+		row.LastError = nil
+		return true
+	}
+
+	message := e.Error()
+	row.LastError = &message
+	row.NextAttemptAt = now.Add(outbox.Backoff(row.Attempts))
+	if e == ErrNoRecipient || e == ErrUnknownChannel {
+		// Retrying doesn't help
+		row.Attempts = wr.MaxAttempts
+	}
+
+	return false
+}
+
+// sendEmail queue the email of a notification in the mail outbox
+func (wr *Worker) sendEmail(db *gorm.DB, user models.User, n models.Notification, createdAt time.Time) error {
+	if user.ID == 0 || user.Email == "" {
+		return ErrNoRecipient
+	}
+
+	msg, e := mailer.NotificationMessage(user.Email, mailer.NotificationData{
+		Username: user.Username,
+		Item:     Item(n, createdAt, user, wr.MailConfig),
+	})
+	if e != nil {
+		return e
+	}
+	msg.From = wr.MailConfig.From
+
+	return outbox.Enqueue(db, msg)
+}
+
+// sendWebhook post payload of the delivery to webhook URL of the user
+func (wr *Worker) sendWebhook(user models.User, row *models.NotificationDelivery) error {
+	if user.WebhookURL == nil || *user.WebhookURL == "" {
+		return ErrNoRecipient
+	}
+
+	return wr.Webhook.Send(*user.WebhookURL, row.Event, []byte(row.Payload))
+}
+
+// sendDigest send pending digest items of the user, which are queued before the digest time, in one email
+func (wr *Worker) sendDigest(db *gorm.DB, digestUser notification_repo.DigestUser, now time.Time) (sent int, e error) {
+	tx := db.Begin()
+	defer func() {
+		if e != nil {
+			tx.Rollback()
+		}
+	}()
+
+	schedule := preference.LastDigestSchedule(digestUser.NotificationSettings, now)
+	user, rows, e := notification_repo.ClaimDigest(tx, digestUser.UserID, schedule)
+	if e != nil {
+		return
+	}
+	if user.ID == 0 || len(rows) == 0 {
+		// Sent by another worker
+		tx.Rollback()
+		return
+	}
+
+	msg, e := DigestMessage(user, rows, now, wr.MailConfig)
+	if e != nil {
+		return
+	}
+
+	e = outbox.Enqueue(tx, msg)
+	if e != nil {
+		return
+	}
+
+	var ids []uint64
+	for _, row := range rows {
+		ids = append(ids, row.ID)
+	}
+	e = notification_repo.MarkDigestSent(tx, user.ID, ids, now)
+	if e != nil {
+		return
+	}
+
+	e = tx.Commit().Error
+	sent = len(rows)
+
+	return
+}
@@ -0,0 +1,164 @@
+package worker
+
+import (
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"devin/calendar"
+	"devin/mailer"
+	"devin/models"
+)
+
+var config = mailer.Config{AppURL: "http://localhost", From: "no-reply@devin.local"}
+
+func TestItem(t *testing.T) {
+	projectID, objectID, moduleID := uint64(3), uint64(7), uint(models.MODULE_TASK)
+	n := models.Notification{
+		Type:      models.NOTIFICATION_TYPE_MENTION,
+		ProjectID: &projectID,
+		ModuleID:  &moduleID,
+		ObjectID:  &objectID,
+		Message:   "@alice please review",
+	}
+
+	calendarID := calendar.Jalali
+	dateFormat := "2006/01/02"
+	user := models.User{}
+	user.CalendarSystemID = &calendarID
+	user.DateFormat = &dateFormat
+
+	item := Item(n, time.Date(2018, time.June, 18, 9, 15, 0, 0, time.UTC), user, config)
+	if item.Title != "You are mentioned in a comment" || item.Message != n.Message {
+		t.Fatal("Unexpected item", item)
+	}
+	if item.Link != "http://localhost/project/3/task/7" {
+		t.Fatal("Unexpected link", item.Link)
+	}
+	if item.Time != "1397/03/28 09:15" {
+		t.Fatal("Time must be formatted in calendar of the user", item.Time)
+	}
+
+	invitation := Item(models.Notification{Type: models.NOTIFICATION_TYPE_INVITATION}, time.Now(), models.User{}, config)
+	if invitation.Link != "http://localhost/notifications" {
+		t.Fatal("Unexpected link", invitation.Link)
+	}
+}
+
+func TestDigestMessage(t *testing.T) {
+	user := models.User{Username: "alice", Email: "alice@devin.local"}
+	rows := []models.NotificationDelivery{
+		{Payload: `{"Type":"comment","Message":"first"}`, CreatedAt: time.Now()},
+		{Payload: `{"Type":"assignment","Message":"second"}`, CreatedAt: time.Now()},
+		{Payload: `broken`, CreatedAt: time.Now()},
+	}
+
+	msg, e := DigestMessage(user, rows, time.Date(2018, time.June, 18, 8, 0, 0, 0, time.UTC), config)
+	if e != nil {
+		t.Fatal(e)
+	}
+	if msg.Subject != "Your daily digest of 2018-06-18: 2 notifications" || msg.From != config.From {
+		t.Fatal("Unexpected digest", msg.Subject, msg.From)
+	}
+	if strings.Contains(msg.Text, "first") == false || strings.Contains(msg.Text, "You are assigned") == false {
+		t.Fatal("Digest must contain the items", msg.Text)
+	}
+
+	if _, e = DigestMessage(models.User{}, rows, time.Now(), config); e != ErrNoRecipient {
+		t.Fatal("Users without email can't receive digest")
+	}
+}
+
+func TestHTTPWebhook(t *testing.T) {
+	var event, body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		event, body = r.Header.Get("X-Devin-Event"), string(b)
+		if strings.Contains(body, "fail") {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer server.Close()
+
+	webhook := HTTPWebhook{Client: server.Client()}
+	if e := webhook.Send(server.URL, models.NOTIFICATION_EVENT_MENTION, []byte(`{"ID":1}`)); e != nil {
+		t.Fatal(e)
+	}
+	if event != "mention" || body != `{"ID":1}` {
+		t.Fatal("Unexpected request", event, body)
+	}
+
+	if e := webhook.Send(server.URL, models.NOTIFICATION_EVENT_MENTION, []byte(`"fail"`)); e == nil {
+		t.Fatal("Failed responses must be errors")
+	}
+}
+
+func TestHTTPWebhookRejectsNonPublicAddresses(t *testing.T) {
+	called := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer server.Close()
+
+	e := NewHTTPWebhook().Send(server.URL, models.NOTIFICATION_EVENT_MENTION, []byte(`{}`))
+	if e == nil || strings.Contains(e.Error(), ErrNonPublicAddress.Error()) == false || called {
+		t.Fatal("Webhooks to loopback must be rejected", e)
+	}
+
+	for ip, public := range map[string]bool{
+		"8.8.8.8":         true,
+		"2606:4700::1111": true,
+		"127.0.0.1":       false,
+		"10.1.2.3":        false,
+		"172.20.0.1":      false,
+		"192.168.1.1":     false,
+		"169.254.169.254": false,
+		"::1":             false,
+		"fe80::1":         false,
+		"fd00::1":         false,
+		"::ffff:10.0.0.1": false,
+	} {
+		if IsPublicIP(net.ParseIP(ip)) != public {
+			t.Fatal("Unexpected result for", ip)
+		}
+	}
+}
+
+type webhookFunc func(url, event string, payload []byte) error
+
+func (f webhookFunc) Send(url, event string, payload []byte) error {
+	return f(url, event, payload)
+}
+
+func TestDeliverWebhook(t *testing.T) {
+	now := time.Now()
+	wr := New(config)
+	wr.Webhook = webhookFunc(func(string, string, []byte) error { return errors.New("Connection refused") })
+
+	url := "http://hooks.local/devin"
+	user := models.User{ID: 1}
+	user.WebhookURL = &url
+	row := models.NotificationDelivery{Channel: models.NOTIFICATION_CHANNEL_WEBHOOK, Payload: `{"ID":1}`}
+
+	if wr.deliver(nil, user, &row, now) {
+		t.Fatal("Failed webhook must not be sent")
+	}
+	if row.Attempts != 1 || row.SentAt != nil || row.NextAttemptAt.Equal(now.Add(time.Minute)) == false {
+		t.Fatal("Failed webhook must be rescheduled", row)
+	}
+
+	wr.Webhook = webhookFunc(func(string, string, []byte) error { return nil })
+	if wr.deliver(nil, user, &row, now) == false || row.SentAt == nil || row.LastError != nil {
+		t.Fatal("Webhook must be sent", row)
+	}
+
+	row = models.NotificationDelivery{Channel: models.NOTIFICATION_CHANNEL_WEBHOOK, Payload: `{"ID":1}`}
+	if wr.deliver(nil, models.User{ID: 2}, &row, now) || row.Attempts != wr.MaxAttempts {
+		t.Fatal("Deliveries without webhook URL must not be retried", row)
+	}
+}
@@ -6,6 +6,10 @@ import (
 
 	"devin/database"
 	"devin/helpers"
+	"devin/models"
+	"devin/modules/audit"
+	audit_repo "devin/modules/audit/repository"
+	notification_repo "devin/modules/notification/repository"
 	"devin/modules/organization/repository"
 	"devin/modules/rw_helpers"
 )
@@ -66,7 +70,7 @@ func InviteUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if rw_helpers.SaveInvitation(w, db, targetUser, OrganizationID, authUser.ID) != nil {
+	if rw_helpers.SaveInvitation(w, r, db, targetUser, organization, authUser) != nil {
 		return
 	}
 
@@ -103,7 +107,29 @@ func AcceptOrRejectInvitation(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	e = repository.SetAcceptanceStatusOfInvitaion(db, invitationID, acceptanceStatus)
+	action := models.AUDIT_ACTION_INVITATION_REJECT
+	if acceptanceStatus == true {
+		action = models.AUDIT_ACTION_INVITATION_ACCEPT
+	}
+	entry := audit.New(r, authUser, action, models.MODULE_ORGANIZATION, invitationID)
+	entry.OrganizationID = &invitation.OrganizationID
+
+	tx := db.Begin()
+	e = repository.SetAcceptanceStatusOfInvitaion(tx, invitationID, acceptanceStatus)
+	if e == nil {
+		e = notification_repo.MarkInvitationRead(tx, invitationID)
+	}
+	if e == nil {
+		e = audit.SetChanges(&entry, struct{ Accepted *bool }{invitation.Accepted}, struct{ Accepted bool }{acceptanceStatus})
+	}
+	if e == nil {
+		e = audit_repo.SaveAudit(tx, &entry)
+	}
+	if e == nil {
+		e = tx.Commit().Error
+	} else {
+		tx.Rollback()
+	}
 	if e != nil {
 		err := helpers.ErrorResponse{
 			ErrorCode: http.StatusInternalServerError,
@@ -69,7 +69,7 @@ func UpdateUserPermissionsOnOrganization(w http.ResponseWriter, r *http.Request)
 	reqModel.UserID = userID
 	reqModel.OrganizationID = organizationID
 
-	e = rw_helpers.UpdateOrganizationPermissions(w, db, reqModel)
+	e = rw_helpers.UpdateOrganizationPermissions(w, r, db, authUser, reqModel)
 	if e != nil {
 		return
 	}
@@ -0,0 +1,369 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/jinzhu/gorm"
+
+	"devin/database"
+	"devin/helpers"
+	"devin/models"
+	"devin/modules/audit"
+	audit_repo "devin/modules/audit/repository"
+	"devin/modules/authorizer"
+	project_repo "devin/modules/project/repository"
+	"devin/modules/rw_helpers"
+)
+
+// MemberController handle members of projects and their permission flags
+type MemberController struct{}
+
+// setFlags copy permission flags of the request to the member
+func setFlags(member *models.ProjectUser, reqModel models.ProjectUser) {
+	member.IsAdmin = reqModel.IsAdmin
+	member.CanUpdateProjectProfile = reqModel.CanUpdateProjectProfile
+	member.CanAddUserToProject = reqModel.CanAddUserToProject
+	member.CanCreateMilestone = reqModel.CanCreateMilestone
+	member.CanCreateTaskList = reqModel.CanCreateTaskList
+	member.CanCreateTask = reqModel.CanCreateTask
+	member.CanCreateIssue = reqModel.CanCreateIssue
+	member.CanCreateRepository = reqModel.CanCreateRepository
+	member.CanCreateTag = reqModel.CanCreateTag
+	member.CanCreateBoard = reqModel.CanCreateBoard
+	member.CanCreateReminder = reqModel.CanCreateReminder
+	member.CanCreateTimeLog = reqModel.CanCreateTimeLog
+	member.CanListAllTimeLogs = reqModel.CanListAllTimeLogs
+	member.CanCreateWiki = reqModel.CanCreateWiki
+}
+
+// canChangeFlags check authenticated user to have the permission key of each flag which the request
+// turns on or off, like role assignments, and handle http errors. Admin flag needs permission
+// to manage project admins.
+func canChangeFlags(w http.ResponseWriter, db *gorm.DB, authUser models.User, project models.Project, member, reqModel models.ProjectUser) bool {
+	before := project_repo.MemberKeys(member)
+	for key, assigned := range project_repo.MemberKeys(reqModel) {
+		if assigned == before[key] {
+			continue
+		}
+		if key == authorizer.KeyProjectAdmin {
+			if rw_helpers.CanManageProjectAdmins(w, db, authUser, project) == false {
+				return false
+			}
+			continue
+		}
+
+		permission := authorizer.Permission{Action: authorizer.ActionManage, ModuleID: models.MODULE_PROJECT, Key: key}
+		if authorizer.Authorize(db, authUser, project, permission) == false {
+			err := helpers.ErrorResponse{
+				ErrorCode: http.StatusForbidden,
+				Message:   "You can't give or take back permissions which you don't have.",
+			}
+			helpers.NewErrorResponse(w, &err)
+			return false
+		}
+	}
+
+	return true
+}
+
+// loadProjectToManageMembers extract project ID from URL, load the project and check
+// the authenticated user to manage its members
+func loadProjectToManageMembers(w http.ResponseWriter, r *http.Request, db *gorm.DB, authUser models.User) (project models.Project, e error) {
+	projectID, e := rw_helpers.ExtractProjectIDFromURL(w, r, "project_id")
+	if e != nil {
+		return
+	}
+
+	project, e = rw_helpers.GetProjectByID(w, db, projectID)
+	if e != nil {
+		return
+	}
+
+	if rw_helpers.CanManageProjectMembers(w, db, authUser, project) == false {
+		e = errors.New("Access denied")
+	}
+
+	return
+}
+
+// decodeMember decode the request body to permission flags of a member
+func decodeMember(w http.ResponseWriter, r *http.Request) (reqModel models.ProjectUser, e error) {
+	if helpers.IsRequestBodyNil(w, r) {
+		e = errors.New("Request body is nil")
+		return
+	}
+
+	e = json.NewDecoder(r.Body).Decode(&reqModel)
+	if e != nil {
+		err := helpers.ErrorResponse{
+			Message:   "Invalid request body",
+			ErrorCode: http.StatusBadRequest,
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+	defer r.Body.Close()
+
+	return
+}
+
+// saveMember save the member and its audit record in a transaction and handle http errors
+func saveMember(w http.ResponseWriter, r *http.Request, db *gorm.DB, authUser models.User, project models.Project, action string, member *models.ProjectUser, before interface{}) error {
+	tx := db.Begin()
+	e := project_repo.SaveProjectMember(tx, member, authUser.ID)
+	if e == nil {
+		entry := audit.New(r, authUser, action, models.MODULE_PROJECT, project.ID)
+		entry.OrganizationID = project.OwnerOrganizationID
+		e = audit.SetChanges(&entry, before, member)
+		if e == nil {
+			e = audit_repo.SaveAudit(tx, &entry)
+		}
+	}
+	if e == nil {
+		e = tx.Commit().Error
+	} else {
+		tx.Rollback()
+	}
+	if e != nil {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusInternalServerError,
+			Message:   "Fail to save project member",
+		}
+		helpers.NewErrorResponse(w, &err)
+	}
+
+	return e
+}
+
+// MembersIndex return members of the project with their permission flags
+// @Route: /api/project/{project_id}/members
+// @Method: GET
+func (MemberController) MembersIndex(w http.ResponseWriter, r *http.Request) {
+	authUser, e := rw_helpers.GetAuthenticatedUser(w, r)
+	if e != nil {
+		return
+	}
+
+	projectID, e := rw_helpers.ExtractProjectIDFromURL(w, r, "project_id")
+	if e != nil {
+		return
+	}
+
+	db := database.NewGORMInstance()
+	defer db.Close()
+
+	project, e := rw_helpers.GetProjectByID(w, db, projectID)
+	if e != nil {
+		return
+	}
+
+	if rw_helpers.CanViewProject(w, db, authUser, project) == false {
+		return
+	}
+
+	data, e := project_repo.GetProjectMembers(db, project.ID)
+	if e != nil {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusInternalServerError,
+			Message:   "Fail to load project members",
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&data)
+}
+
+// AddMember add a user of the owner organization to the project with the given permission flags
+// @Route: /api/project/{project_id}/members/add
+// @Method: POST
+// @Content-Type: application/json
+func (MemberController) AddMember(w http.ResponseWriter, r *http.Request) {
+	if rw_helpers.IsJSONRequest(w, r) == false {
+		return
+	}
+
+	authUser, e := rw_helpers.GetAuthenticatedUser(w, r)
+	if e != nil {
+		return
+	}
+
+	reqModel, e := decodeMember(w, r)
+	if e != nil {
+		return
+	}
+
+	db := database.NewGORMInstance()
+	defer db.Close()
+
+	project, e := loadProjectToManageMembers(w, r, db, authUser)
+	if e != nil {
+		return
+	}
+
+	if rw_helpers.IsUserExists(w, db, reqModel.UserID) != nil {
+		return
+	}
+
+	errs := make(map[string][]string)
+	if project_repo.CanJoinProject(db, project, reqModel.UserID) == false {
+		errs["UserID"] = []string{"The user is not a member of the owner organization of the project"}
+	} else if project_repo.IsProjectMember(db, project, reqModel.UserID) {
+		errs["UserID"] = []string{"The user is already a member of the project"}
+	}
+	if len(errs) > 0 {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusUnprocessableEntity,
+			Message:   "Invalid project member",
+			Errors:    errs,
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+
+	if canChangeFlags(w, db, authUser, project, models.ProjectUser{}, reqModel) == false {
+		return
+	}
+
+	member := models.ProjectUser{
+		UserID:      reqModel.UserID,
+		ProjectID:   project.ID,
+		CreatedByID: authUser.ID,
+	}
+	setFlags(&member, reqModel)
+
+	if saveMember(w, r, db, authUser, project, models.AUDIT_ACTION_MEMBER_ADD, &member, nil) != nil {
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&member)
+}
+
+// UpdateMember replace permission flags of a member of the project
+// @Route: /api/project/{project_id}/member/{id}/update
+// @Method: POST
+// @Content-Type: application/json
+func (MemberController) UpdateMember(w http.ResponseWriter, r *http.Request) {
+	if rw_helpers.IsJSONRequest(w, r) == false {
+		return
+	}
+
+	authUser, e := rw_helpers.GetAuthenticatedUser(w, r)
+	if e != nil {
+		return
+	}
+
+	memberID, e := rw_helpers.ExtractIDFromURL(w, r)
+	if e != nil {
+		return
+	}
+
+	reqModel, e := decodeMember(w, r)
+	if e != nil {
+		return
+	}
+
+	db := database.NewGORMInstance()
+	defer db.Close()
+
+	project, e := loadProjectToManageMembers(w, r, db, authUser)
+	if e != nil {
+		return
+	}
+
+	member, e := project_repo.GetProjectMemberByID(db, project.ID, memberID)
+	if e != nil {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusNotFound,
+			Message:   "No matching project member found!",
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+
+	// Members can't change their own flags, unless they can manage admins of the project
+	if member.UserID == authUser.ID && rw_helpers.CanManageProjectAdmins(w, db, authUser, project) == false {
+		return
+	}
+
+	if canChangeFlags(w, db, authUser, project, member, reqModel) == false {
+		return
+	}
+
+	before := member
+	setFlags(&member, reqModel)
+
+	if saveMember(w, r, db, authUser, project, models.AUDIT_ACTION_MEMBER_UPDATE, &member, before) != nil {
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&member)
+}
+
+// RemoveMember remove a member from the project with all of its roles on the project
+// @Route: /api/project/{project_id}/member/{id}/remove
+// @Method: POST
+func (MemberController) RemoveMember(w http.ResponseWriter, r *http.Request) {
+	authUser, e := rw_helpers.GetAuthenticatedUser(w, r)
+	if e != nil {
+		return
+	}
+
+	memberID, e := rw_helpers.ExtractIDFromURL(w, r)
+	if e != nil {
+		return
+	}
+
+	db := database.NewGORMInstance()
+	defer db.Close()
+
+	project, e := loadProjectToManageMembers(w, r, db, authUser)
+	if e != nil {
+		return
+	}
+
+	member, e := project_repo.GetProjectMemberByID(db, project.ID, memberID)
+	if e != nil {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusNotFound,
+			Message:   "No matching project member found!",
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+
+	if member.IsAdmin && rw_helpers.CanManageProjectAdmins(w, db, authUser, project) == false {
+		return
+	}
+
+	tx := db.Begin()
+	e = project_repo.RemoveProjectMember(tx, member)
+	if e == nil {
+		entry := audit.New(r, authUser, models.AUDIT_ACTION_MEMBER_REMOVE, models.MODULE_PROJECT, project.ID)
+		entry.OrganizationID = project.OwnerOrganizationID
+		e = audit.SetChanges(&entry, member, nil)
+		if e == nil {
+			e = audit_repo.SaveAudit(tx, &entry)
+		}
+	}
+	if e == nil {
+		e = tx.Commit().Error
+	} else {
+		tx.Rollback()
+	}
+	if e != nil {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusInternalServerError,
+			Message:   "Fail to remove project member",
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+
+	helpers.NewSuccessResponse(w, "Member removed.")
+}
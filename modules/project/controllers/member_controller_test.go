@@ -0,0 +1,206 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+
+	"devin/database"
+	"devin/middlewares"
+	"devin/models"
+	project_repo "devin/modules/project/repository"
+)
+
+func getValidUser(id uint64) (user models.User, tokenString string) {
+	db := database.NewGORMInstance()
+	defer db.Close()
+	db.Exec(`delete from users where id=?;`, id)
+	e := db.Exec(`insert into users (id, username, email, is_root_user, user_type) values (?, ?, ?, false, 1)`, id, fmt.Sprintf("mgh%v", id), fmt.Sprintf("m6devin%v@gmail.com", id)).Error
+	if e != nil {
+		panic(e.Error())
+	}
+	db.Where("id=?", id).First(&user)
+	claim := user.GenerateNewTokenClaim()
+	tokenString, _ = user.GenerateNewTokenString(claim)
+
+	return user, tokenString
+}
+
+func getValidOrganization(id uint64, ownerID uint64) {
+	db := database.NewGORMInstance()
+	defer db.Close()
+	db.Exec(`delete from users where id=?;`, id)
+	e := db.Exec(`insert into users (id, username, email, user_type, owner_id) values (?, ?, ?, 2, ?)`, id, fmt.Sprintf("org%v", id), fmt.Sprintf("org%v@gmail.com", id), ownerID).Error
+	if e != nil {
+		panic(e.Error())
+	}
+}
+
+func addUserToOrganization(userID, orgID uint64) {
+	db := database.NewGORMInstance()
+	defer db.Close()
+	e := db.Exec(`insert into user_organization (user_id, organization_id, created_by_id) values (?, ?, ?)`, userID, orgID, userID).Error
+	if e != nil {
+		panic(e)
+	}
+}
+
+func getValidProject(id, ownerID, orgID uint64) models.Project {
+	db := database.NewGORMInstance()
+	defer db.Close()
+	db.Exec(`delete from projects where id=?;`, id)
+	e := db.Exec(`insert into projects (id, name, owner_user_id, owner_organization_id, created_by_id) values (?, ?, ?, ?, ?)`, id, fmt.Sprintf("project%v", id), ownerID, orgID, ownerID).Error
+	if e != nil {
+		panic(e)
+	}
+
+	var project models.Project
+	db.Where("id=?", id).First(&project)
+
+	return project
+}
+
+func addUserToProject(project models.Project, member models.ProjectUser) models.ProjectUser {
+	db := database.NewGORMInstance()
+	defer db.Close()
+	member.ProjectID = project.ID
+	member.CreatedByID = project.OwnerUserID
+	e := project_repo.SaveProjectMember(db, &member, project.OwnerUserID)
+	if e != nil {
+		panic(e)
+	}
+
+	return member
+}
+
+func deleteTestData(projectID uint64, userIDs ...uint64) {
+	db := database.NewGORMInstance()
+	defer db.Close()
+	db.Exec(`delete from projects where id=?;`, projectID)
+	for _, id := range userIDs {
+		db.Exec(`delete from users where id=?;`, id)
+	}
+}
+
+func postJSON(route *mux.Router, path, tokenString string, body interface{}) (*http.Response, string) {
+	bts, _ := json.Marshal(body)
+	req, _ := http.NewRequest(http.MethodPost, path, bytes.NewReader(bts))
+	req.Header.Add("Authorization", tokenString)
+	req.Header.Add("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+	route.ServeHTTP(rr, req)
+
+	res := rr.Result()
+	defer res.Body.Close()
+	resBody, _ := ioutil.ReadAll(res.Body)
+
+	return res, string(resBody)
+}
+
+func TestManageMembers(t *testing.T) {
+	owner, ownerToken := getValidUser(31001)
+	getValidOrganization(31002, owner.ID)
+	manager, managerToken := getValidUser(31003)
+	plain, plainToken := getValidUser(31004)
+	outsider, _ := getValidUser(31005)
+	candidate, _ := getValidUser(31006)
+	project := getValidProject(31010, owner.ID, 31002)
+	defer deleteTestData(project.ID, 31001, 31002, 31003, 31004, 31005, 31006)
+
+	for _, id := range []uint64{manager.ID, plain.ID, candidate.ID} {
+		addUserToOrganization(id, 31002)
+	}
+	managerMember := addUserToProject(project, models.ProjectUser{UserID: manager.ID, CanAddUserToProject: true})
+	plainMember := addUserToProject(project, models.ProjectUser{UserID: plain.ID})
+
+	route := mux.NewRouter()
+	route.Use(middlewares.Authenticate)
+	route.HandleFunc("/api/project/{project_id:[0-9]+}/members/add", MemberController{}.AddMember)
+	route.HandleFunc("/api/project/{project_id:[0-9]+}/member/{id:[0-9]+}/update", MemberController{}.UpdateMember)
+	addPath := fmt.Sprintf("/api/project/%d/members/add", project.ID)
+	updatePath := fmt.Sprintf("/api/project/%d/member/%d/update", project.ID, plainMember.ID)
+
+	t.Run("User not in the organization", func(t *testing.T) {
+		res, body := postJSON(route, addPath, managerToken, models.ProjectUser{UserID: outsider.ID})
+		if res.StatusCode != http.StatusUnprocessableEntity {
+			t.Fatal("Status code not matched. Response is", res.StatusCode, body)
+		}
+		if !strings.Contains(body, "not a member of the owner organization") {
+			t.Fatal("Invalid response message", body)
+		}
+	})
+
+	t.Run("Plain member", func(t *testing.T) {
+		res, body := postJSON(route, addPath, plainToken, models.ProjectUser{UserID: candidate.ID})
+		if res.StatusCode != http.StatusForbidden {
+			t.Fatal("Plain members can't add members. Response is", res.StatusCode, body)
+		}
+
+		res, body = postJSON(route, updatePath, plainToken, models.ProjectUser{CanCreateTask: true})
+		if res.StatusCode != http.StatusForbidden {
+			t.Fatal("Plain members can't update members. Response is", res.StatusCode, body)
+		}
+	})
+
+	t.Run("Promotion to admin", func(t *testing.T) {
+		res, body := postJSON(route, updatePath, managerToken, models.ProjectUser{IsAdmin: true})
+		if res.StatusCode != http.StatusForbidden {
+			t.Fatal("Only admins can promote members. Response is", res.StatusCode, body)
+		}
+
+		res, body = postJSON(route, addPath, managerToken, models.ProjectUser{UserID: candidate.ID, IsAdmin: true})
+		if res.StatusCode != http.StatusForbidden {
+			t.Fatal("Only admins can add admins. Response is", res.StatusCode, body)
+		}
+
+		res, body = postJSON(route, updatePath, ownerToken, models.ProjectUser{IsAdmin: true})
+		if res.StatusCode != http.StatusOK {
+			t.Fatal("Status code not matched. Response is", res.StatusCode, body)
+		}
+
+		db := database.NewGORMInstance()
+		defer db.Close()
+		var member models.ProjectUser
+		db.Where("id=?", plainMember.ID).First(&member)
+		if member.IsAdmin == false {
+			t.Fatal("Member must be promoted to admin")
+		}
+	})
+
+	t.Run("Member manager", func(t *testing.T) {
+		res, body := postJSON(route, addPath, managerToken, models.ProjectUser{UserID: candidate.ID, CanCreateTask: true})
+		if res.StatusCode != http.StatusForbidden {
+			t.Fatal("Managers can't give permissions which they don't have. Response is", res.StatusCode, body)
+		}
+
+		selfPath := fmt.Sprintf("/api/project/%d/member/%d/update", project.ID, managerMember.ID)
+		res, body = postJSON(route, selfPath, managerToken, models.ProjectUser{CanAddUserToProject: true, CanCreateTask: true})
+		if res.StatusCode != http.StatusForbidden {
+			t.Fatal("Managers can't change their own permissions. Response is", res.StatusCode, body)
+		}
+
+		res, body = postJSON(route, addPath, managerToken, models.ProjectUser{UserID: candidate.ID, CanAddUserToProject: true})
+		if res.StatusCode != http.StatusOK {
+			t.Fatal("Status code not matched. Response is", res.StatusCode, body)
+		}
+	})
+}
+
+func TestSetFlags(t *testing.T) {
+	member := models.ProjectUser{ID: 1, UserID: 2, ProjectID: 3, CreatedByID: 4, CanCreateTask: true}
+	setFlags(&member, models.ProjectUser{ID: 10, UserID: 20, ProjectID: 30, CreatedByID: 40, IsAdmin: true, CanCreateWiki: true})
+
+	if member.ID != 1 || member.UserID != 2 || member.ProjectID != 3 || member.CreatedByID != 4 {
+		t.Error("Only permission flags must be copied", member)
+	}
+	if member.IsAdmin == false || member.CanCreateWiki == false || member.CanCreateTask == true {
+		t.Error("Permission flags must be replaced", member)
+	}
+}
@@ -6,7 +6,11 @@ import (
 	"net/http"
 
 	"devin/database"
+	"devin/helpers"
 	"devin/models"
+	"devin/modules/audit"
+	audit_repo "devin/modules/audit/repository"
+	"devin/modules/authorizer"
 	project_repo "devin/modules/project/repository"
 	"devin/modules/rw_helpers"
 )
@@ -14,9 +18,16 @@ import (
 // ProjectController handle functionalities of Project
 type ProjectController struct{}
 
-// ProjectsIndex return list of projects owned by given user or this user is a member of that
+// ProjectsIndex return list of projects owned by given user or this user is a member of that.
+// Without UserID filter, all projects which are visible to the authenticated user are listed.
+// This route is accessible for anonymous users to list public projects.
+// @Route: /api/projects?q={ProjectSearch}
+// @Method: GET
 func (ProjectController) ProjectsIndex(w http.ResponseWriter, r *http.Request) {
-	authUser, _, _ := models.User{}.ExtractUserFromRequestContext(r)
+	authUser, e := rw_helpers.GetOptionalAuthenticatedUser(w, r)
+	if e != nil {
+		return
+	}
 
 	// Decode search data
 	searchModel, e := rw_helpers.DecodeProjectSearchFilters(w, r)
@@ -32,7 +43,16 @@ func (ProjectController) ProjectsIndex(w http.ResponseWriter, r *http.Request) {
 	db := database.NewGORMInstance()
 	defer db.Close()
 
-	data, total, _ := project_repo.SearchProjects(db, authUser, searchModel)
+	data, total, e := project_repo.SearchProjects(db, authUser, searchModel)
+	if e != nil {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusInternalServerError,
+			Message:   "Fail to load projects",
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+
 	var pgn models.Pagination
 	pgn.Make(data, total, searchModel.CurrentPage, searchModel.PerPage)
 
@@ -61,14 +81,14 @@ func (ProjectController) Save(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	db := database.NewGORMInstance()
+	defer db.Close()
+
 	// validate input data
-	if rw_helpers.ValidateProjectRequestModel(w, projectReqModel) != nil {
+	if rw_helpers.ValidateProjectRequestModel(w, db, projectReqModel) != nil {
 		return
 	}
 
-	db := database.NewGORMInstance()
-	defer db.Close()
-
 	e = rw_helpers.CheckOwnerOrganizationIDOfProject(w, db, projectReqModel.OwnerOrganizationID)
 	if e != nil {
 		return
@@ -80,10 +100,47 @@ func (ProjectController) Save(w http.ResponseWriter, r *http.Request) {
 
 	}
 	log.Println(projectReqModel)
+
+	// Load the old project to record changes in audit log
+	action := models.AUDIT_ACTION_PROJECT_CREATE
+	var before interface{}
+	if projectReqModel.ID != 0 {
+		var oldProject models.Project
+		db.Where("id=?", projectReqModel.ID).First(&oldProject)
+		action = models.AUDIT_ACTION_PROJECT_UPDATE
+		before = oldProject
+	}
+
+	var after models.Project
+	tx := db.Begin()
 	if projectReqModel.ID != 0 {
-		db.Model(&projectReqModel).Where("id=?", projectReqModel.ID).Update(&projectReqModel)
+		e = tx.Model(&projectReqModel).Where("id=?", projectReqModel.ID).Update(&projectReqModel).Error
+	} else {
+		e = tx.Model(&projectReqModel).Create(&projectReqModel).Error
+	}
+	if e == nil {
+		e = tx.Where("id=?", projectReqModel.ID).First(&after).Error
+	}
+	if e == nil {
+		entry := audit.New(r, authUser, action, models.MODULE_PROJECT, after.ID)
+		entry.OrganizationID = after.OwnerOrganizationID
+		e = audit.SetChanges(&entry, before, after)
+		if e == nil {
+			e = audit_repo.SaveAudit(tx, &entry)
+		}
+	}
+	if e == nil {
+		e = tx.Commit().Error
 	} else {
-		db.Model(&projectReqModel).Create(&projectReqModel)
+		tx.Rollback()
+	}
+	if e != nil {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusInternalServerError,
+			Message:   "Fail to save project",
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
 	}
 
 	w.Header().Add("Content-Type", "application/json")
@@ -115,11 +172,13 @@ func (ProjectController) BasicInfo(w http.ResponseWriter, r *http.Request) {
 	union
 select u.* from users u
 	where u.id in (
-			select organization_id 
-			from user_organization uo 
-			where uo.user_id = ? 
-			and (uo.is_admin_of_organization=true or uo.can_create_project=true or uo.can_update_project=true ) 
-	)`, user.ID, user.ID).
+			select ra.organization_id
+			from role_assignments ra
+			inner join role_permissions rp on rp.role_id = ra.role_id
+			inner join user_organization uo on uo.user_id = ra.user_id and uo.organization_id = ra.organization_id
+			where ra.user_id = ? and uo.deleted_at is null
+			and rp.key in (?)
+	)`, user.ID, user.ID, []string{authorizer.KeyOrganizationAdmin, authorizer.KeyCreateOrganizationProject, authorizer.KeyUpdateOrganizationProjects}).
 		Scan(&basicInfo.Organizations)
 	w.Header().Add("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(&basicInfo)
@@ -1,8 +1,9 @@
 package repository
 
 import (
+	"devin/helpers"
 	"devin/models"
-	"sync"
+	"devin/modules/authorizer"
 
 	"github.com/jinzhu/gorm"
 )
@@ -12,55 +13,76 @@ import (
 // @param authenticatedUser Logged in user
 // @param searchModel search filters
 func SearchProjects(db *gorm.DB, authenticatedUser models.User, searchModel models.ProjectSearch) (data []models.Project, total uint64, e error) {
-	db = db.Debug().Model(&models.Project{})
+	db = db.Model(&models.Project{})
 	db = searchModel.GetWhereClause(db)
-	if authenticatedUser.ID == 0 {
-		// search as anonymouse
-		// TODO
-		return
-	} else if authenticatedUser.ID == *searchModel.UserID {
+	if helpers.IsNilUint64(searchModel.UserID) {
+		// search all projects which the searcher can view,
+		// just public projects for anonymous users and all projects for root users
+		db = visibleProjects(db, authenticatedUser)
+	} else if authenticatedUser.ID != 0 && authenticatedUser.ID == *searchModel.UserID {
 		// search on his (authenticatedUser) projects
 		db = allMyProjects(db, authenticatedUser.ID)
-	} else if authenticatedUser.IsRootUser == true {
-		// seach all projects
-		// TODO
-		return
-	} else if searchModel.UserID == nil || *searchModel.UserID == 0 {
-		// seach all public projects + searcher user's projects
-		// TODO
-		return
 	} else {
-		// authenticatedUser is searching on searchModel.UserID's projects
-		// just public projects of this user can be shown
-		// TODO
-		return
+		// authenticatedUser is searching on searchModel.UserID's projects,
+		// just projects of that user which are visible to authenticatedUser can be shown
+		db = projectsOfUser(db, *searchModel.UserID)
+		db = visibleProjects(db, authenticatedUser)
 	}
 
-	var wg sync.WaitGroup
-	wg.Add(2)
-
-	go func() {
-		defer wg.Done()
-		db.Count(&total)
-	}()
-
-	go func() {
-		defer wg.Done()
-		if searchModel.PerPage > 0 {
-			db = db.Limit(searchModel.PerPage)
-		}
-		db = db.Offset((searchModel.CurrentPage - 1) * searchModel.PerPage).Find(&data)
-	}()
+	e = db.Count(&total).Error
+	if e != nil {
+		return
+	}
 
-	wg.Wait()
+	if searchModel.PerPage > 0 {
+		db = db.Limit(searchModel.PerPage)
+	}
+	if searchModel.CurrentPage > 0 {
+		db = db.Offset((searchModel.CurrentPage - 1) * searchModel.PerPage)
+	}
+	e = db.Find(&data).Error
 
 	return
 }
 
 // allMyProjects limit search on the given authUserID,
-// Logged in user searching on his projects
+// Logged in user searching on his projects and projects which he has read permission on them
 func allMyProjects(db *gorm.DB, authUserID uint64) *gorm.DB {
-	db = db.Where(`owner_user_id=? OR 
-		id IN (SELECT project_id FROM project_users WHERE user_id=?)`, authUserID, authUserID)
+	db = db.Where(`owner_user_id=? OR project_manager_id=? OR
+		id IN (SELECT project_id FROM project_users WHERE user_id=? AND deleted_at IS NULL) OR
+		id IN (`+authorizer.ReadableObjectsSQL+`)`, authUserID, authUserID, authUserID, authUserID, models.MODULE_PROJECT)
 	return db
 }
+
+// projectsOfUser limit search on projects which the user is their owner, manager or member
+func projectsOfUser(db *gorm.DB, userID uint64) *gorm.DB {
+	return db.Where(`owner_user_id=? OR project_manager_id=? OR
+		id IN (SELECT project_id FROM project_users WHERE user_id=? AND deleted_at IS NULL)`, userID, userID, userID)
+}
+
+// visibleProjects limit search on projects which the user can view based on VisibilityTypeID of projects.
+// Public projects are visible to everyone, projects which are public in organization are visible to
+// organization members and private projects to their owner, manager, members and users with read permission.
+// Owner and admins of an organization view all projects of the organization. Root users view all projects.
+func visibleProjects(db *gorm.DB, authUser models.User) *gorm.DB {
+	if authUser.IsRootUser {
+		return db
+	}
+
+	if authUser.ID == 0 {
+		return db.Where("visibility_type_id=3")
+	}
+
+	return db.Where(`visibility_type_id=3 OR
+		owner_user_id=? OR project_manager_id=? OR
+		id IN (SELECT project_id FROM project_users WHERE user_id=? AND deleted_at IS NULL) OR
+		id IN (`+authorizer.ReadableObjectsSQL+`) OR
+		(visibility_type_id=2 AND owner_organization_id IN (SELECT organization_id FROM user_organization WHERE user_id=? AND deleted_at IS NULL)) OR
+		owner_organization_id IN (SELECT id FROM users WHERE owner_id=? AND user_type=2) OR
+		owner_organization_id IN (`+authorizer.OrganizationsWithKeySQL+`)`,
+		authUser.ID, authUser.ID, authUser.ID,
+		authUser.ID, models.MODULE_PROJECT,
+		authUser.ID,
+		authUser.ID,
+		authUser.ID, authorizer.KeyOrganizationAdmin)
+}
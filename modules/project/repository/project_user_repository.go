@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/jinzhu/gorm"
+
+	"devin/models"
+	"devin/modules/authorizer"
+	role_repo "devin/modules/role/repository"
+)
+
+// ErrProjectMemberNotFound returned when the member is not found in the project
+var ErrProjectMemberNotFound = errors.New("Project member not found")
+
+// IsProjectMember check user to be a member of the project.
+// Owner and manager of the project are members even without a project_users record.
+func IsProjectMember(db *gorm.DB, project models.Project, userID uint64) bool {
+	if userID == 0 {
+		return false
+	}
+
+	if userID == project.OwnerUserID || userID == project.ProjectManagerID {
+		return true
+	}
+
+	var count uint64
+	db.Model(&models.ProjectUser{}).Where("user_id=? AND project_id=?", userID, project.ID).Count(&count)
+
+	return count > 0
+}
+
+// CanJoinProject check the user to belong to the owner organization of the project.
+// Any user can join personal projects.
+func CanJoinProject(db *gorm.DB, project models.Project, userID uint64) bool {
+	if project.OwnerOrganizationID == nil {
+		return true
+	}
+
+	var count uint64
+	db.Model(&models.User{}).
+		Where("id=? AND owner_id=? AND user_type=2", *project.OwnerOrganizationID, userID).
+		Count(&count)
+	if count > 0 {
+		return true
+	}
+
+	db.Model(&models.UserOrganization{}).
+		Where("user_id=? AND organization_id=?", userID, *project.OwnerOrganizationID).
+		Count(&count)
+
+	return count > 0
+}
+
+// GetProjectMembers load members of the project
+func GetProjectMembers(db *gorm.DB, projectID uint64) (data []models.ProjectUser, e error) {
+	e = db.Where("project_id=?", projectID).
+		Preload("User").
+		Order("id ASC").
+		Find(&data).
+		Error
+
+	return
+}
+
+// GetProjectMemberByID load a member of the project
+func GetProjectMemberByID(db *gorm.DB, projectID, ID uint64) (member models.ProjectUser, e error) {
+	db.Where("id=? AND project_id=?", ID, projectID).First(&member)
+	if member.ID == 0 {
+		e = ErrProjectMemberNotFound
+	}
+
+	return
+}
+
+// MemberKeys map permission keys to flags of the membership
+func MemberKeys(member models.ProjectUser) map[string]bool {
+	return map[string]bool{
+		authorizer.KeyProjectAdmin:         member.IsAdmin,
+		authorizer.KeyUpdateProjectProfile: member.CanUpdateProjectProfile,
+		authorizer.KeyAddUserToProject:     member.CanAddUserToProject,
+		authorizer.KeyCreateMilestone:      member.CanCreateMilestone,
+		authorizer.KeyCreateTaskList:       member.CanCreateTaskList,
+		authorizer.KeyCreateTask:           member.CanCreateTask,
+		authorizer.KeyCreateIssue:          member.CanCreateIssue,
+		authorizer.KeyCreateRepository:     member.CanCreateRepository,
+		authorizer.KeyCreateTag:            member.CanCreateTag,
+		authorizer.KeyCreateBoard:          member.CanCreateBoard,
+		authorizer.KeyCreateReminder:       member.CanCreateReminder,
+		authorizer.KeyCreateTimeLog:        member.CanCreateTimeLog,
+		authorizer.KeyListAllTimeLogs:      member.CanListAllTimeLogs,
+		authorizer.KeyCreateWiki:           member.CanCreateWiki,
+	}
+}
+
+// SaveProjectMember insert or update the membership and assign or unassign
+// the built-in role of each of its flags on the project
+func SaveProjectMember(db *gorm.DB, member *models.ProjectUser, createdByID uint64) error {
+	e := db.Set("gorm:save_associations", false).Save(member).Error
+	if e != nil {
+		return e
+	}
+
+	for key, assigned := range MemberKeys(*member) {
+		e = role_repo.SetBuiltInRole(db, member.UserID, nil, &member.ProjectID, key, assigned, createdByID)
+		if e != nil {
+			return e
+		}
+	}
+
+	return nil
+}
+
+// RemoveProjectMember delete the membership and all roles of the user on the project
+func RemoveProjectMember(db *gorm.DB, member models.ProjectUser) error {
+	e := db.Where("id=?", member.ID).Delete(&models.ProjectUser{}).Error
+	if e != nil {
+		return e
+	}
+
+	return db.Where("user_id=? AND project_id=?", member.UserID, member.ProjectID).
+		Delete(&models.RoleAssignment{}).
+		Error
+}
@@ -0,0 +1,150 @@
+// Package notifier deliver task reminders to their receivers through different channels
+package notifier
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+
+	"devin/models"
+)
+
+// Notification types of reminder receivers
+const (
+	TypeSMS      = "sms"
+	TypeEmail    = "email"
+	TypeInApp    = "in-app"
+	TypeTelegram = "telegram"
+)
+
+// Notifier send a reminder to a single receiver
+type Notifier interface {
+	Notify(reminder models.TaskReminder, receiver models.TaskReminderReceiver) error
+}
+
+// NotifierFunc is an adapter to use ordinary functions as Notifier
+type NotifierFunc func(reminder models.TaskReminder, receiver models.TaskReminderReceiver) error
+
+// Notify call f(reminder, receiver)
+func (f NotifierFunc) Notify(reminder models.TaskReminder, receiver models.TaskReminderReceiver) error {
+	return f(reminder, receiver)
+}
+
+// DeliveryError is returned by Dispatcher when some notification types of the receiver fail.
+// Delivered types must be stored in DeliveredTypes of the receiver, so retries skip them.
+type DeliveryError struct {
+	Delivered []string
+	Failed    []string
+}
+
+// Error return a summary of failed types
+func (e *DeliveryError) Error() string {
+	return fmt.Sprintf("Fail to notify: %s", strings.Join(e.Failed, "; "))
+}
+
+// Dispatcher send reminder to every notification type of the receiver
+// using the notifier registered for that type.
+// Types without registered notifier are sent through Fallback, if set.
+type Dispatcher struct {
+	Notifiers map[string]Notifier
+	Fallback  Notifier
+}
+
+// NewDispatcher create a dispatcher with no registered notifier
+func NewDispatcher(fallback Notifier) *Dispatcher {
+	return &Dispatcher{
+		Notifiers: make(map[string]Notifier),
+		Fallback:  fallback,
+	}
+}
+
+// Register set the notifier of a notification type
+func (d *Dispatcher) Register(notificationType string, n Notifier) {
+	d.Notifiers[notificationType] = n
+}
+
+// Notify send reminder through all notification types of the receiver which are not delivered yet.
+// All types are tried, a *DeliveryError is returned if any of them fails.
+func (d *Dispatcher) Notify(reminder models.TaskReminder, receiver models.TaskReminderReceiver) error {
+	delivered := make(map[string]bool)
+	if receiver.DeliveredTypes != nil {
+		for _, t := range parseList(*receiver.DeliveredTypes) {
+			delivered[t] = true
+		}
+	}
+
+	var result DeliveryError
+	for _, t := range ParseTypes(receiver.NotificationTypes) {
+		if delivered[t] {
+			continue
+		}
+
+		n, ok := d.Notifiers[t]
+		if ok == false {
+			n = d.Fallback
+		}
+		if n == nil {
+			result.Failed = append(result.Failed, t+": no notifier registered")
+			continue
+		}
+
+		if e := n.Notify(reminder, receiver); e != nil {
+			result.Failed = append(result.Failed, t+": "+e.Error())
+		} else {
+			result.Delivered = append(result.Delivered, t)
+		}
+	}
+
+	if len(result.Failed) > 0 {
+		return &result
+	}
+
+	return nil
+}
+
+// ParseTypes decode the jsonb array of notification types of a receiver.
+// Empty or invalid values default to in-app notifications.
+func ParseTypes(notificationTypes string) []string {
+	types := parseList(notificationTypes)
+	if len(types) == 0 {
+		return []string{TypeInApp}
+	}
+
+	return types
+}
+
+// AddDeliveredTypes add the types to the jsonb array of delivered types of a receiver
+func AddDeliveredTypes(deliveredTypes *string, types []string) *string {
+	var all []string
+	if deliveredTypes != nil {
+		all = parseList(*deliveredTypes)
+	}
+	all = append(all, types...)
+
+	bts, _ := json.Marshal(all)
+	value := string(bts)
+
+	return &value
+}
+
+// parseList decode a jsonb array of strings, invalid values are empty
+func parseList(value string) []string {
+	var list []string
+	if json.Unmarshal([]byte(value), &list) != nil {
+		return nil
+	}
+
+	return list
+}
+
+// LogNotifier write reminders to the standard logger.
+// It is useful in development or when no other channel is configured.
+type LogNotifier struct{}
+
+// Notify log the reminder
+func (LogNotifier) Notify(reminder models.TaskReminder, receiver models.TaskReminderReceiver) error {
+	log.Printf("Reminder #%d of task #%d to user #%d: %s", reminder.ID, reminder.TaskID, receiver.UserID, reminder.Title)
+
+	return nil
+}
@@ -0,0 +1,54 @@
+package notifier
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"devin/models"
+)
+
+func TestParseTypes(t *testing.T) {
+	if types := ParseTypes(`["email","sms"]`); !reflect.DeepEqual(types, []string{"email", "sms"}) {
+		t.Fatal("Invalid types", types)
+	}
+	if types := ParseTypes(""); !reflect.DeepEqual(types, []string{TypeInApp}) {
+		t.Fatal("Empty types must default to in-app", types)
+	}
+}
+
+func TestDispatcher(t *testing.T) {
+	var sent []string
+	record := func(name string, e error) Notifier {
+		return NotifierFunc(func(models.TaskReminder, models.TaskReminderReceiver) error {
+			sent = append(sent, name)
+			return e
+		})
+	}
+
+	d := NewDispatcher(record("fallback", nil))
+	d.Register(TypeEmail, record("email", nil))
+	d.Register(TypeSMS, record("sms", errors.New("no credit")))
+
+	e := d.Notify(models.TaskReminder{}, models.TaskReminderReceiver{NotificationTypes: `["email","sms","telegram"]`})
+	if e == nil {
+		t.Fatal("Failure of sms must be returned")
+	}
+	if !reflect.DeepEqual(sent, []string{"email", "sms", "fallback"}) {
+		t.Fatal("All types must be tried", sent)
+	}
+	de, ok := e.(*DeliveryError)
+	if ok == false || !reflect.DeepEqual(de.Delivered, []string{"email", "telegram"}) || len(de.Failed) != 1 {
+		t.Fatal("Delivered and failed types must be returned", e)
+	}
+
+	// Retry only the failed type
+	sent = nil
+	e = d.Notify(models.TaskReminder{}, models.TaskReminderReceiver{
+		NotificationTypes: `["email","sms","telegram"]`,
+		DeliveredTypes:    AddDeliveredTypes(nil, de.Delivered),
+	})
+	if e == nil || !reflect.DeepEqual(sent, []string{"sms"}) {
+		t.Fatal("Delivered types must be skipped", sent)
+	}
+}
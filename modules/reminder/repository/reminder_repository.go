@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+
+	"devin/models"
+)
+
+// ClaimDueReminders lock, load and claim due reminders which are neither processed nor claimed.
+// Rows locked by other instances are skipped and claimed reminders are skipped until claimedUntil,
+// so each reminder is handled by a single instance at a time. db must be a short transaction,
+// the claim outlives it and lets the caller deliver reminders without holding locks.
+// Reminders with ID in excludeIDs are not loaded.
+func ClaimDueReminders(db *gorm.DB, now time.Time, limit int, excludeIDs []uint64, claimedUntil time.Time) (reminders []models.TaskReminder, e error) {
+	query := `SELECT id FROM task_reminders
+        WHERE processed_at IS NULL AND reminde_on <= ? AND (claimed_until IS NULL OR claimed_until <= ?)`
+	args := []interface{}{now, now}
+	if len(excludeIDs) > 0 {
+		query += " AND id NOT IN (?)"
+		args = append(args, excludeIDs)
+	}
+	query += " ORDER BY reminde_on ASC, id ASC LIMIT ? FOR UPDATE SKIP LOCKED"
+	args = append(args, limit)
+
+	rows, e := db.Raw(query, args...).Rows()
+	if e != nil {
+		return
+	}
+	defer rows.Close()
+
+	var ids []uint64
+	for rows.Next() {
+		var id uint64
+		e = rows.Scan(&id)
+		if e != nil {
+			return
+		}
+		ids = append(ids, id)
+	}
+	if len(ids) == 0 {
+		return
+	}
+
+	e = db.Model(&models.TaskReminder{}).Where("id IN (?)", ids).UpdateColumn("claimed_until", claimedUntil).Error
+	if e != nil {
+		return
+	}
+
+	e = db.Model(&models.TaskReminder{}).
+		Preload("Task").
+		Where("id IN (?)", ids).
+		Order("reminde_on ASC, id ASC").
+		Find(&reminders).
+		Error
+
+	return
+}
+
+// GetPendingReceivers load receivers of the reminder which are not notified yet
+// and have not reached maxAttempts
+func GetPendingReceivers(db *gorm.DB, reminderID uint64, maxAttempts uint) (receivers []models.TaskReminderReceiver, e error) {
+	e = db.Model(&models.TaskReminderReceiver{}).
+		Where("reminder_id=? AND delivered_at IS NULL AND attempts < ?", reminderID, maxAttempts).
+		Order("id ASC").
+		Find(&receivers).
+		Error
+
+	return
+}
+
+// SaveDeliveryState store delivery result of a receiver
+func SaveDeliveryState(db *gorm.DB, receiver models.TaskReminderReceiver) error {
+	return db.Model(&models.TaskReminderReceiver{}).
+		Where("id=?", receiver.ID).
+		Updates(map[string]interface{}{
+			"delivered_at":    receiver.DeliveredAt,
+			"delivered_types": receiver.DeliveredTypes,
+			"attempts":        receiver.Attempts,
+			"last_error":      receiver.LastError,
+		}).
+		Error
+}
+
+// MarkProcessed set the reminder as processed, so it is never claimed again
+func MarkProcessed(db *gorm.DB, reminderID uint64, now time.Time) error {
+	return db.Model(&models.TaskReminder{}).
+		Where("id=?", reminderID).
+		UpdateColumns(map[string]interface{}{"processed_at": now, "claimed_until": nil}).
+		Error
+}
+
+// ReleaseReminder remove the claim of a reminder which has receivers to retry, so the next run claims it
+func ReleaseReminder(db *gorm.DB, reminderID uint64) error {
+	return db.Model(&models.TaskReminder{}).
+		Where("id=?", reminderID).
+		UpdateColumn("claimed_until", nil).
+		Error
+}
@@ -0,0 +1,173 @@
+// Package scheduler fire due task reminders.
+// It is safe to run the scheduler in several instances at the same time: due reminders
+// are claimed for ClaimTimeout with SELECT ... FOR UPDATE SKIP LOCKED in a short transaction,
+// then delivered without holding locks. The delivery state of each receiver is stored as soon
+// as it is notified, so a receiver is never notified twice unless the process dies or
+// the database fails between sending and storing its state.
+package scheduler
+
+import (
+	"log"
+	"time"
+
+	"github.com/jinzhu/gorm"
+
+	"devin/database"
+	"devin/models"
+	"devin/modules/reminder/notifier"
+	reminder_repo "devin/modules/reminder/repository"
+)
+
+// Scheduler deliver due reminders through Notifier
+type Scheduler struct {
+	Notifier notifier.Notifier
+
+	// Number of reminders claimed in each transaction
+	BatchSize int
+
+	// Claimed reminders are skipped by other instances for this time, it must be longer than delivery of a batch
+	ClaimTimeout time.Duration
+
+	// Failed receivers are retried in next runs until MaxAttempts is reached
+	MaxAttempts uint
+
+	// Time between runs, used by Run
+	Interval time.Duration
+
+	// NewDB return a new database instance, default is database.NewGORMInstance
+	NewDB func() *gorm.DB
+}
+
+// New create a scheduler with default settings
+func New(n notifier.Notifier) *Scheduler {
+	return &Scheduler{
+		Notifier:     n,
+		BatchSize:    100,
+		ClaimTimeout: 10 * time.Minute,
+		MaxAttempts:  5,
+		Interval:     time.Minute,
+		NewDB:        database.NewGORMInstance,
+	}
+}
+
+// Run call RunOnce every Interval until stop is closed
+func (s *Scheduler) Run(stop <-chan struct{}) {
+	ticker := time.NewTicker(s.Interval)
+	defer ticker.Stop()
+
+	for {
+		processed, e := s.RunOnce(time.Now())
+		if e != nil {
+			log.Println("Reminders scheduler:", e)
+		} else if processed > 0 {
+			log.Printf("Reminders scheduler: %d reminders processed", processed)
+		}
+
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce deliver all reminders due until now and return the number of handled reminders
+func (s *Scheduler) RunOnce(now time.Time) (processed int, e error) {
+	db := s.NewDB()
+	defer db.Close()
+
+	var seen []uint64
+	for {
+		var count int
+		count, e = s.runBatch(db, now, &seen)
+		processed += count
+		if e != nil || count < s.BatchSize {
+			return
+		}
+	}
+}
+
+// runBatch claim a batch of due reminders in a short transaction and deliver them.
+// Each reminder is delivered and stored on its own, a failure doesn't undo delivered reminders.
+func (s *Scheduler) runBatch(db *gorm.DB, now time.Time, seen *[]uint64) (count int, e error) {
+	tx := db.Begin()
+	reminders, e := reminder_repo.ClaimDueReminders(tx, now, s.BatchSize, *seen, now.Add(s.ClaimTimeout))
+	if e == nil {
+		e = tx.Commit().Error
+	} else {
+		tx.Rollback()
+	}
+	if e != nil {
+		return
+	}
+
+	for _, reminder := range reminders {
+		*seen = append(*seen, reminder.ID)
+
+		e = s.deliverReminder(db, reminder, now)
+		if e != nil {
+			return
+		}
+	}
+	count = len(reminders)
+
+	return
+}
+
+// deliverReminder notify pending receivers of a claimed reminder, then mark it processed
+// or release it to retry failed receivers in next runs
+func (s *Scheduler) deliverReminder(db *gorm.DB, reminder models.TaskReminder, now time.Time) error {
+	receivers, e := reminder_repo.GetPendingReceivers(db, reminder.ID, s.MaxAttempts)
+	if e != nil {
+		return e
+	}
+
+	save := func(receiver models.TaskReminderReceiver) error {
+		return reminder_repo.SaveDeliveryState(db, receiver)
+	}
+	done, e := deliver(s.Notifier, reminder, receivers, now, s.MaxAttempts, save)
+	if e != nil {
+		return e
+	}
+
+	if done {
+		return reminder_repo.MarkProcessed(db, reminder.ID, now)
+	}
+
+	return reminder_repo.ReleaseReminder(db, reminder.ID)
+}
+
+// deliver notify pending receivers of the reminder and store the delivery state of each one
+// with save right after notifying it. It stops on the first save error.
+// It returns true when nothing is left to do for the reminder: every receiver
+// is notified or has reached maxAttempts.
+func deliver(n notifier.Notifier, reminder models.TaskReminder, receivers []models.TaskReminderReceiver, now time.Time, maxAttempts uint, save func(models.TaskReminderReceiver) error) (done bool, e error) {
+	done = true
+	for i := range receivers {
+		receivers[i].Attempts++
+
+		notifyErr := n.Notify(reminder, receivers[i])
+		if notifyErr == nil {
+			delivered := now
+			receivers[i].DeliveredAt = &delivered
+			receivers[i].LastError = nil
+		} else {
+			// Delivered types of a partial failure are not sent again on retries
+			if de, ok := notifyErr.(*notifier.DeliveryError); ok && len(de.Delivered) > 0 {
+				receivers[i].DeliveredTypes = notifier.AddDeliveredTypes(receivers[i].DeliveredTypes, de.Delivered)
+			}
+			message := notifyErr.Error()
+			receivers[i].LastError = &message
+			if receivers[i].Attempts < maxAttempts {
+				done = false
+			}
+		}
+
+		e = save(receivers[i])
+		if e != nil {
+			return false, e
+		}
+	}
+
+	return
+}
@@ -0,0 +1,119 @@
+package scheduler
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"devin/models"
+	"devin/modules/reminder/notifier"
+)
+
+func saveNothing(models.TaskReminderReceiver) error {
+	return nil
+}
+
+func TestDeliver(t *testing.T) {
+	now := time.Now()
+	calls := 0
+	n := notifier.NotifierFunc(func(reminder models.TaskReminder, receiver models.TaskReminderReceiver) error {
+		calls++
+		if receiver.UserID == 2 {
+			return errors.New("SMTP is down")
+		}
+		return nil
+	})
+
+	var saved []models.TaskReminderReceiver
+	save := func(receiver models.TaskReminderReceiver) error {
+		saved = append(saved, receiver)
+		return nil
+	}
+
+	receivers := []models.TaskReminderReceiver{{UserID: 1}, {UserID: 2}}
+	done, e := deliver(n, models.TaskReminder{ID: 1}, receivers, now, 3, save)
+
+	if e != nil {
+		t.Fatal(e)
+	}
+	if done {
+		t.Fatal("Reminder must not be done while a receiver can be retried")
+	}
+	if calls != 2 {
+		t.Fatal("Every receiver must be notified once, got", calls)
+	}
+	if receivers[0].DeliveredAt == nil || receivers[0].Attempts != 1 {
+		t.Fatal("First receiver must be delivered", receivers[0])
+	}
+	if receivers[1].DeliveredAt != nil || receivers[1].LastError == nil || *receivers[1].LastError != "SMTP is down" {
+		t.Fatal("Second receiver must be failed", receivers[1])
+	}
+	if len(saved) != 2 || saved[0].DeliveredAt == nil || saved[1].LastError == nil {
+		t.Fatal("State of each receiver must be saved after notifying it", saved)
+	}
+}
+
+func TestDeliverPartialFailure(t *testing.T) {
+	var sent []string
+	d := notifier.NewDispatcher(nil)
+	d.Register(notifier.TypeInApp, notifier.NotifierFunc(func(models.TaskReminder, models.TaskReminderReceiver) error {
+		sent = append(sent, notifier.TypeInApp)
+		return nil
+	}))
+	d.Register(notifier.TypeSMS, notifier.NotifierFunc(func(models.TaskReminder, models.TaskReminderReceiver) error {
+		sent = append(sent, notifier.TypeSMS)
+		return errors.New("no credit")
+	}))
+
+	receivers := []models.TaskReminderReceiver{{UserID: 1, NotificationTypes: `["in-app","sms"]`}}
+	for i := 0; i < 2; i++ {
+		deliver(d, models.TaskReminder{}, receivers, time.Now(), 3, saveNothing)
+	}
+
+	if len(sent) != 3 || sent[0] != notifier.TypeInApp || sent[1] != notifier.TypeSMS || sent[2] != notifier.TypeSMS {
+		t.Fatal("Only failed types must be retried", sent)
+	}
+	if receivers[0].DeliveredTypes == nil || *receivers[0].DeliveredTypes != `["in-app"]` {
+		t.Fatal("Delivered types must be stored", receivers[0].DeliveredTypes)
+	}
+}
+
+func TestDeliverStopsOnSaveError(t *testing.T) {
+	calls := 0
+	n := notifier.NotifierFunc(func(reminder models.TaskReminder, receiver models.TaskReminderReceiver) error {
+		calls++
+		return nil
+	})
+	save := func(receiver models.TaskReminderReceiver) error {
+		return errors.New("database is down")
+	}
+
+	receivers := []models.TaskReminderReceiver{{UserID: 1}, {UserID: 2}}
+	done, e := deliver(n, models.TaskReminder{ID: 1}, receivers, time.Now(), 3, save)
+	if e == nil || done {
+		t.Fatal("Save error must be returned")
+	}
+	if calls != 1 {
+		t.Fatal("Receivers must not be notified after a save error, got", calls)
+	}
+}
+
+func TestDeliverMaxAttempts(t *testing.T) {
+	n := notifier.NotifierFunc(func(reminder models.TaskReminder, receiver models.TaskReminderReceiver) error {
+		return errors.New("failed")
+	})
+
+	receivers := []models.TaskReminderReceiver{{UserID: 1, Attempts: 2}}
+	if done, _ := deliver(n, models.TaskReminder{}, receivers, time.Now(), 3, saveNothing); done == false {
+		t.Fatal("Reminder must be done when receivers reach max attempts")
+	}
+	if receivers[0].Attempts != 3 {
+		t.Fatal("Attempts must be increased, got", receivers[0].Attempts)
+	}
+}
+
+func TestDeliverWithoutReceivers(t *testing.T) {
+	if done, _ := deliver(notifier.LogNotifier{}, models.TaskReminder{}, nil, time.Now(), 3, saveNothing); done == false {
+		t.Fatal("Reminder without receivers must be done")
+	}
+}
@@ -0,0 +1,620 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/jinzhu/gorm"
+
+	"devin/database"
+	"devin/helpers"
+	"devin/models"
+	"devin/modules/audit"
+	audit_repo "devin/modules/audit/repository"
+	"devin/modules/authorizer"
+	"devin/modules/role/repository"
+	"devin/modules/rw_helpers"
+)
+
+// RoleController handle roles of organizations and their assignments to members
+type RoleController struct{}
+
+type roleReqModel struct {
+	ID          uint64 `doc:"Zero for a new role"`
+	Name        string
+	Description string
+	Keys        []string `doc:"From this list: authorizer.Keys"`
+}
+
+type assignmentReqModel struct {
+	RoleID    uint64
+	UserID    uint64
+	ProjectID *uint64 `doc:"Null to assign the role on the whole organization"`
+}
+
+// roleChanges is the audited part of a role
+type roleChanges struct {
+	Name        string
+	Description string
+	Keys        []string
+}
+
+// assignmentChanges is the audited part of a role assignment
+type assignmentChanges struct {
+	UserID    uint64
+	ProjectID *uint64
+}
+
+// keysOf return permission keys of the role
+func keysOf(role models.Role) (keys []string) {
+	for _, p := range role.Permissions {
+		keys = append(keys, p.Key)
+	}
+
+	return
+}
+
+// loadOrganization extract organization ID from URL and load the organization
+func loadOrganization(w http.ResponseWriter, r *http.Request, db *gorm.DB) (organization models.User, e error) {
+	organizationID, e := rw_helpers.ExtractOrganizationID(w, r, "organization_id")
+	if e != nil {
+		return
+	}
+
+	return rw_helpers.FetchOrganizationFromDB(w, db, organizationID)
+}
+
+// saveAudit record a change of roles of the organization
+func saveAudit(db *gorm.DB, r *http.Request, authUser models.User, organization models.User, action string, roleID uint64, before, after interface{}) error {
+	entry := audit.New(r, authUser, action, models.MODULE_ORGANIZATION, roleID)
+	entry.OrganizationID = &organization.ID
+
+	e := audit.SetChanges(&entry, before, after)
+	if e != nil {
+		return e
+	}
+
+	return audit_repo.SaveAudit(db, &entry)
+}
+
+// validateRoleReqModel check name and permission keys of the role
+func validateRoleReqModel(reqModel roleReqModel) map[string][]string {
+	errs := make(map[string][]string)
+	if reqModel.Name == "" {
+		errs["Name"] = []string{"Name is required"}
+	} else if len(reqModel.Name) > 100 {
+		errs["Name"] = []string{"Name can't be longer than 100 characters"}
+	}
+
+	if len(reqModel.Keys) == 0 {
+		errs["Keys"] = []string{"At least one permission key is required"}
+	}
+	seen := make(map[string]bool)
+	for _, key := range reqModel.Keys {
+		if authorizer.IsValidKey(key) == false {
+			errs["Keys"] = append(errs["Keys"], "Invalid permission key: "+key)
+		} else if seen[key] {
+			errs["Keys"] = append(errs["Keys"], "Repeated permission key: "+key)
+		}
+		seen[key] = true
+	}
+
+	return errs
+}
+
+// canAssignRoles check permission of authenticated user to assign the role on the organization,
+// or on the project of the organization when projectID is not nil, and handle http errors.
+// On projects, roles with project admin permission need permission to manage project admins
+// and other roles can only have the keys which authenticated user has on the project.
+func canAssignRoles(w http.ResponseWriter, db *gorm.DB, authUser, organization models.User, role models.Role, projectID *uint64) bool {
+	if projectID == nil {
+		return rw_helpers.CanManageRoles(w, db, authUser, organization)
+	}
+
+	project, e := rw_helpers.GetProjectByID(w, db, *projectID)
+	if e != nil {
+		return false
+	}
+	if project.OwnerOrganizationID == nil || *project.OwnerOrganizationID != organization.ID {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusUnprocessableEntity,
+			Message:   "Invalid role assignment",
+			Errors:    map[string][]string{"ProjectID": {"The project doesn't belong to the organization"}},
+		}
+		helpers.NewErrorResponse(w, &err)
+		return false
+	}
+
+	if rw_helpers.CanManageProjectMembers(w, db, authUser, project) == false {
+		return false
+	}
+
+	for _, key := range keysOf(role) {
+		if key == authorizer.KeyProjectAdmin {
+			if rw_helpers.CanManageProjectAdmins(w, db, authUser, project) == false {
+				return false
+			}
+			continue
+		}
+
+		// Organization keys are rejected by checkAssignment
+		permission := authorizer.Permission{Action: authorizer.ActionManage, ModuleID: models.MODULE_PROJECT, Key: key}
+		if authorizer.IsOrganizationKey(key) == false && authorizer.Authorize(db, authUser, project, permission) == false {
+			err := helpers.ErrorResponse{
+				ErrorCode: http.StatusForbidden,
+				Message:   "You can't assign a role with permissions which you don't have.",
+			}
+			helpers.NewErrorResponse(w, &err)
+			return false
+		}
+	}
+
+	return true
+}
+
+// checkAssignment validate the assignment and handle http errors. Roles are assigned to members
+// of organization on the organization, or to members of a project of the organization on the project.
+// Roles with organization permissions can't be assigned on projects.
+func checkAssignment(w http.ResponseWriter, db *gorm.DB, organization models.User, role models.Role, assignment models.RoleAssignment) error {
+	errs := make(map[string][]string)
+	var cnt uint64
+	if assignment.ProjectID == nil {
+		db.Model(&models.UserOrganization{}).
+			Where("user_id=? AND organization_id=?", assignment.UserID, organization.ID).
+			Count(&cnt)
+		if cnt == 0 {
+			errs["UserID"] = []string{"The user is not a member of the organization"}
+		}
+	} else {
+		db.Model(&models.ProjectUser{}).
+			Where("user_id=? AND project_id=?", assignment.UserID, *assignment.ProjectID).
+			Count(&cnt)
+		if cnt == 0 {
+			errs["UserID"] = []string{"The user is not a member of the project"}
+		}
+		for _, key := range keysOf(role) {
+			if authorizer.IsOrganizationKey(key) {
+				errs["RoleID"] = []string{"Roles with organization permissions can only be assigned on the organization"}
+				break
+			}
+		}
+	}
+	if len(errs) > 0 {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusUnprocessableEntity,
+			Message:   "Invalid role assignment",
+			Errors:    errs,
+		}
+		helpers.NewErrorResponse(w, &err)
+		return errors.New(err.Message)
+	}
+
+	return nil
+}
+
+// RolesIndex return built-in roles and custom roles of the organization with their permission keys
+// @Route: /api/organization/{organization_id}/roles
+// @Method: GET
+func (RoleController) RolesIndex(w http.ResponseWriter, r *http.Request) {
+	authUser, e := rw_helpers.GetAuthenticatedUser(w, r)
+	if e != nil {
+		return
+	}
+
+	db := database.NewGORMInstance()
+	defer db.Close()
+
+	organization, e := loadOrganization(w, r, db)
+	if e != nil {
+		return
+	}
+
+	if rw_helpers.CanViewRoles(w, db, authUser, organization) == false {
+		return
+	}
+
+	data, e := repository.GetRolesOfOrganization(db, organization.ID)
+	if e != nil {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusInternalServerError,
+			Message:   "Fail to load roles",
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&data)
+}
+
+// Save create a custom role of the organization or update it.
+// Permissions of the role are replaced by the given keys.
+// @Route: /api/organization/{organization_id}/roles/save
+// @Method: POST
+// @Content-Type: application/json
+func (RoleController) Save(w http.ResponseWriter, r *http.Request) {
+	if rw_helpers.IsJSONRequest(w, r) == false {
+		return
+	}
+
+	authUser, e := rw_helpers.GetAuthenticatedUser(w, r)
+	if e != nil {
+		return
+	}
+
+	if helpers.IsRequestBodyNil(w, r) {
+		return
+	}
+
+	var reqModel roleReqModel
+	e = json.NewDecoder(r.Body).Decode(&reqModel)
+	if e != nil {
+		err := helpers.ErrorResponse{
+			Message:   "Invalid request body",
+			ErrorCode: http.StatusBadRequest,
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+	defer r.Body.Close()
+
+	db := database.NewGORMInstance()
+	defer db.Close()
+
+	organization, e := loadOrganization(w, r, db)
+	if e != nil {
+		return
+	}
+
+	if rw_helpers.CanManageRoles(w, db, authUser, organization) == false {
+		return
+	}
+
+	role := models.Role{OrganizationID: &organization.ID, CreatedByID: &authUser.ID}
+	action := models.AUDIT_ACTION_ROLE_CREATE
+	var before interface{}
+	if reqModel.ID != 0 {
+		role, e = repository.GetRoleByID(db, organization.ID, reqModel.ID)
+		if e != nil {
+			err := helpers.ErrorResponse{
+				ErrorCode: http.StatusNotFound,
+				Message:   "No matching role found!",
+			}
+			helpers.NewErrorResponse(w, &err)
+			return
+		}
+		if role.IsBuiltIn {
+			err := helpers.ErrorResponse{
+				ErrorCode: http.StatusForbidden,
+				Message:   "Built-in roles can't be changed.",
+			}
+			helpers.NewErrorResponse(w, &err)
+			return
+		}
+		action = models.AUDIT_ACTION_ROLE_UPDATE
+		before = roleChanges{role.Name, role.Description, keysOf(role)}
+	}
+
+	errs := validateRoleReqModel(reqModel)
+	if _, ok := errs["Name"]; ok == false && repository.IsRoleNameTaken(db, organization.ID, reqModel.Name, role.ID) {
+		errs["Name"] = []string{"A role with this name already exists"}
+	}
+	if len(errs) > 0 {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusUnprocessableEntity,
+			Message:   "Invalid role",
+			Errors:    errs,
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+
+	role.Name = reqModel.Name
+	role.Description = reqModel.Description
+
+	tx := db.Begin()
+	e = repository.SaveRole(tx, &role, reqModel.Keys)
+	if e == nil {
+		e = saveAudit(tx, r, authUser, organization, action, role.ID, before, roleChanges{role.Name, role.Description, reqModel.Keys})
+	}
+	if e == nil {
+		e = tx.Commit().Error
+	} else {
+		tx.Rollback()
+	}
+	if e != nil {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusInternalServerError,
+			Message:   "Fail to save role",
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&role)
+}
+
+// Delete remove a custom role of the organization and all of its assignments
+// @Route: /api/organization/{organization_id}/role/{id}/delete
+// @Method: POST
+func (RoleController) Delete(w http.ResponseWriter, r *http.Request) {
+	authUser, e := rw_helpers.GetAuthenticatedUser(w, r)
+	if e != nil {
+		return
+	}
+
+	roleID, e := rw_helpers.ExtractIDFromURL(w, r)
+	if e != nil {
+		return
+	}
+
+	db := database.NewGORMInstance()
+	defer db.Close()
+
+	organization, e := loadOrganization(w, r, db)
+	if e != nil {
+		return
+	}
+
+	if rw_helpers.CanManageRoles(w, db, authUser, organization) == false {
+		return
+	}
+
+	role, e := repository.GetRoleByID(db, organization.ID, roleID)
+	if e != nil {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusNotFound,
+			Message:   "No matching role found!",
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+	if role.IsBuiltIn {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusForbidden,
+			Message:   "Built-in roles can't be deleted.",
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+
+	tx := db.Begin()
+	e = repository.DeleteRole(tx, role)
+	if e == nil {
+		e = saveAudit(tx, r, authUser, organization, models.AUDIT_ACTION_ROLE_DELETE, role.ID, roleChanges{role.Name, role.Description, keysOf(role)}, nil)
+	}
+	if e == nil {
+		e = tx.Commit().Error
+	} else {
+		tx.Rollback()
+	}
+	if e != nil {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusInternalServerError,
+			Message:   "Fail to delete role",
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+
+	helpers.NewSuccessResponse(w, "Role deleted.")
+}
+
+// AssignmentsIndex return assignments of roles on the organization and its projects.
+// List can be limited to a user or a project.
+// @Route: /api/organization/{organization_id}/role_assignments?user_id={user_id}&project_id={project_id}
+// @Method: GET
+func (RoleController) AssignmentsIndex(w http.ResponseWriter, r *http.Request) {
+	authUser, e := rw_helpers.GetAuthenticatedUser(w, r)
+	if e != nil {
+		return
+	}
+
+	var userID, projectID *uint64
+	for param, target := range map[string]**uint64{"user_id": &userID, "project_id": &projectID} {
+		value := r.URL.Query().Get(param)
+		if value == "" {
+			continue
+		}
+		ID, e := strconv.ParseUint(value, 10, 64)
+		if e != nil {
+			err := helpers.ErrorResponse{
+				Message:   "Invalid " + param + ". Just integer values accepted",
+				ErrorCode: http.StatusUnprocessableEntity,
+			}
+			helpers.NewErrorResponse(w, &err)
+			return
+		}
+		*target = &ID
+	}
+
+	db := database.NewGORMInstance()
+	defer db.Close()
+
+	organization, e := loadOrganization(w, r, db)
+	if e != nil {
+		return
+	}
+
+	if rw_helpers.CanViewRoles(w, db, authUser, organization) == false {
+		return
+	}
+
+	data, e := repository.GetRoleAssignments(db, organization.ID, userID, projectID)
+	if e != nil {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusInternalServerError,
+			Message:   "Fail to load role assignments",
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&data)
+}
+
+// Assign give a role to a member of the organization, on the whole organization or on one of its projects
+// @Route: /api/organization/{organization_id}/role_assignments/assign
+// @Method: POST
+// @Content-Type: application/json
+func (RoleController) Assign(w http.ResponseWriter, r *http.Request) {
+	if rw_helpers.IsJSONRequest(w, r) == false {
+		return
+	}
+
+	authUser, e := rw_helpers.GetAuthenticatedUser(w, r)
+	if e != nil {
+		return
+	}
+
+	if helpers.IsRequestBodyNil(w, r) {
+		return
+	}
+
+	var reqModel assignmentReqModel
+	e = json.NewDecoder(r.Body).Decode(&reqModel)
+	if e != nil {
+		err := helpers.ErrorResponse{
+			Message:   "Invalid request body",
+			ErrorCode: http.StatusBadRequest,
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+	defer r.Body.Close()
+
+	db := database.NewGORMInstance()
+	defer db.Close()
+
+	organization, e := loadOrganization(w, r, db)
+	if e != nil {
+		return
+	}
+
+	role, e := repository.GetRoleByID(db, organization.ID, reqModel.RoleID)
+	if e != nil {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusNotFound,
+			Message:   "No matching role found!",
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+
+	assignment := models.RoleAssignment{
+		RoleID:      role.ID,
+		UserID:      reqModel.UserID,
+		ProjectID:   reqModel.ProjectID,
+		CreatedByID: &authUser.ID,
+	}
+	if assignment.ProjectID == nil {
+		assignment.OrganizationID = &organization.ID
+	}
+
+	if canAssignRoles(w, db, authUser, organization, role, assignment.ProjectID) == false {
+		return
+	}
+
+	if checkAssignment(w, db, organization, role, assignment) != nil {
+		return
+	}
+
+	if repository.IsRoleAssigned(db, assignment) {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusUnprocessableEntity,
+			Message:   "Role already assigned",
+			Errors:    map[string][]string{"RoleID": {"The role is already assigned to the user"}},
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+
+	tx := db.Begin()
+	e = repository.AssignRole(tx, &assignment)
+	if e == nil {
+		e = saveAudit(tx, r, authUser, organization, models.AUDIT_ACTION_ROLE_ASSIGN, role.ID, nil, assignmentChanges{assignment.UserID, assignment.ProjectID})
+	}
+	if e == nil {
+		e = tx.Commit().Error
+	} else {
+		tx.Rollback()
+	}
+	if e != nil {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusInternalServerError,
+			Message:   "Fail to assign role",
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+
+	assignment.Role = &role
+	w.Header().Add("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&assignment)
+}
+
+// Unassign take back a role from a member of the organization
+// @Route: /api/organization/{organization_id}/role_assignment/{id}/unassign
+// @Method: POST
+func (RoleController) Unassign(w http.ResponseWriter, r *http.Request) {
+	authUser, e := rw_helpers.GetAuthenticatedUser(w, r)
+	if e != nil {
+		return
+	}
+
+	assignmentID, e := rw_helpers.ExtractIDFromURL(w, r)
+	if e != nil {
+		return
+	}
+
+	db := database.NewGORMInstance()
+	defer db.Close()
+
+	organization, e := loadOrganization(w, r, db)
+	if e != nil {
+		return
+	}
+
+	assignment, e := repository.GetRoleAssignmentByID(db, organization.ID, assignmentID)
+	if e != nil {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusNotFound,
+			Message:   "No matching role assignment found!",
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+
+	var role models.Role
+	if assignment.Role != nil {
+		role = *assignment.Role
+	}
+	if canAssignRoles(w, db, authUser, organization, role, assignment.ProjectID) == false {
+		return
+	}
+
+	tx := db.Begin()
+	e = repository.UnassignRole(tx, assignment)
+	if e == nil {
+		e = saveAudit(tx, r, authUser, organization, models.AUDIT_ACTION_ROLE_UNASSIGN, assignment.RoleID, assignmentChanges{assignment.UserID, assignment.ProjectID}, nil)
+	}
+	if e == nil {
+		e = tx.Commit().Error
+	} else {
+		tx.Rollback()
+	}
+	if e != nil {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusInternalServerError,
+			Message:   "Fail to unassign role",
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+
+	helpers.NewSuccessResponse(w, "Role unassigned.")
+}
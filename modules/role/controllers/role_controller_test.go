@@ -0,0 +1,33 @@
+package controllers
+
+import (
+	"strings"
+	"testing"
+
+	"devin/modules/authorizer"
+)
+
+func TestValidateRoleReqModel(t *testing.T) {
+	errs := validateRoleReqModel(roleReqModel{Name: "Developer", Keys: []string{authorizer.KeyCreateTask, authorizer.KeyCreateIssue}})
+	if len(errs) > 0 {
+		t.Fatal("Valid role must be accepted", errs)
+	}
+
+	errs = validateRoleReqModel(roleReqModel{})
+	if _, ok := errs["Name"]; ok == false {
+		t.Error("Name is required")
+	}
+	if _, ok := errs["Keys"]; ok == false {
+		t.Error("Keys are required")
+	}
+
+	errs = validateRoleReqModel(roleReqModel{Name: strings.Repeat("a", 101), Keys: []string{authorizer.KeyCreateTask}})
+	if _, ok := errs["Name"]; ok == false {
+		t.Error("Long names must be rejected")
+	}
+
+	errs = validateRoleReqModel(roleReqModel{Name: "Developer", Keys: []string{authorizer.KeyCreateTask, authorizer.KeyCreateTask, "task.fly"}})
+	if len(errs["Keys"]) != 2 {
+		t.Error("Repeated and unknown keys must be rejected", errs)
+	}
+}
@@ -0,0 +1,190 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/jinzhu/gorm"
+
+	"devin/models"
+)
+
+// ErrRoleNotFound returned when the role is not found in the organization or built-in roles
+var ErrRoleNotFound = errors.New("Role not found")
+
+// ErrRoleAssignmentNotFound returned when the role assignment is not found in the organization
+var ErrRoleAssignmentNotFound = errors.New("Role assignment not found")
+
+// assignmentsOfOrganizationSQL limit role assignments to the organization and its projects
+const assignmentsOfOrganizationSQL = "(organization_id=? OR project_id IN (SELECT id FROM projects WHERE owner_organization_id=?))"
+
+// GetRolesOfOrganization load built-in roles and custom roles of the organization with their permissions
+func GetRolesOfOrganization(db *gorm.DB, organizationID uint64) (roles []models.Role, e error) {
+	e = db.Where("organization_id IS NULL OR organization_id=?", organizationID).
+		Preload("Permissions").
+		Order("is_built_in DESC, name ASC").
+		Find(&roles).
+		Error
+
+	return
+}
+
+// GetRoleByID load a built-in role or a custom role of the organization with its permissions
+func GetRoleByID(db *gorm.DB, organizationID, ID uint64) (role models.Role, e error) {
+	db.Where("id=? AND (organization_id IS NULL OR organization_id=?)", ID, organizationID).
+		Preload("Permissions").
+		First(&role)
+	if role.ID == 0 {
+		e = ErrRoleNotFound
+	}
+
+	return
+}
+
+// GetBuiltInRoleByKey load the built-in role of the permission key
+func GetBuiltInRoleByKey(db *gorm.DB, key string) (role models.Role, e error) {
+	db.Where("is_built_in=true AND id IN (SELECT role_id FROM role_permissions WHERE key=?)", key).
+		First(&role)
+	if role.ID == 0 {
+		e = ErrRoleNotFound
+	}
+
+	return
+}
+
+// IsRoleNameTaken check another role of the organization or a built-in role to have the name
+func IsRoleNameTaken(db *gorm.DB, organizationID uint64, name string, exceptID uint64) bool {
+	var cnt uint64
+	db.Model(&models.Role{}).
+		Where("(organization_id IS NULL OR organization_id=?) AND name=? AND id<>?", organizationID, name, exceptID).
+		Count(&cnt)
+
+	return cnt > 0
+}
+
+// SaveRole insert or update the role and replace its permissions with the keys
+func SaveRole(db *gorm.DB, role *models.Role, keys []string) error {
+	e := db.Set("gorm:save_associations", false).Save(role).Error
+	if e != nil {
+		return e
+	}
+
+	e = db.Where("role_id=?", role.ID).Delete(&models.RolePermission{}).Error
+	if e != nil {
+		return e
+	}
+
+	role.Permissions = nil
+	for _, key := range keys {
+		permission := models.RolePermission{RoleID: role.ID, Key: key}
+		e = db.Create(&permission).Error
+		if e != nil {
+			return e
+		}
+		role.Permissions = append(role.Permissions, permission)
+	}
+
+	return nil
+}
+
+// DeleteRole delete the role, its permissions and assignments are deleted by the DB
+func DeleteRole(db *gorm.DB, role models.Role) error {
+	return db.Where("id=?", role.ID).Delete(&models.Role{}).Error
+}
+
+// GetRoleAssignments load assignments of roles on the organization and its projects,
+// optionally limited to a user or a project
+func GetRoleAssignments(db *gorm.DB, organizationID uint64, userID, projectID *uint64) (data []models.RoleAssignment, e error) {
+	db = db.Where(assignmentsOfOrganizationSQL, organizationID, organizationID)
+	if userID != nil {
+		db = db.Where("user_id=?", *userID)
+	}
+	if projectID != nil {
+		db = db.Where("project_id=?", *projectID)
+	}
+
+	e = db.Preload("Role").
+		Preload("User").
+		Order("user_id ASC, id ASC").
+		Find(&data).
+		Error
+
+	return
+}
+
+// GetRoleAssignmentByID load a role assignment on the organization or one of its projects
+func GetRoleAssignmentByID(db *gorm.DB, organizationID, ID uint64) (assignment models.RoleAssignment, e error) {
+	db.Where("id=?", ID).
+		Where(assignmentsOfOrganizationSQL, organizationID, organizationID).
+		Preload("Role.Permissions").
+		First(&assignment)
+	if assignment.ID == 0 {
+		e = ErrRoleAssignmentNotFound
+	}
+
+	return
+}
+
+// IsRoleAssigned check the role to be assigned to the user with the same scope
+func IsRoleAssigned(db *gorm.DB, assignment models.RoleAssignment) bool {
+	db = db.Model(&models.RoleAssignment{}).
+		Where("role_id=? AND user_id=?", assignment.RoleID, assignment.UserID)
+	if assignment.ProjectID != nil {
+		db = db.Where("project_id=?", *assignment.ProjectID)
+	} else {
+		db = db.Where("organization_id=?", assignment.OrganizationID)
+	}
+
+	var cnt uint64
+	db.Count(&cnt)
+
+	return cnt > 0
+}
+
+// AssignRole insert the role assignment
+func AssignRole(db *gorm.DB, assignment *models.RoleAssignment) error {
+	return db.Set("gorm:save_associations", false).Create(assignment).Error
+}
+
+// UnassignRole delete the role assignment
+func UnassignRole(db *gorm.DB, assignment models.RoleAssignment) error {
+	return db.Where("id=?", assignment.ID).Delete(&models.RoleAssignment{}).Error
+}
+
+// SetBuiltInRole assign or unassign the built-in role of the key to the user,
+// on the organization when projectID is nil or on the project otherwise.
+// It is used where permissions are still sent as boolean flags.
+func SetBuiltInRole(db *gorm.DB, userID uint64, organizationID, projectID *uint64, key string, assigned bool, createdByID uint64) error {
+	role, e := GetBuiltInRoleByKey(db, key)
+	if e != nil {
+		return e
+	}
+
+	assignment := models.RoleAssignment{
+		RoleID:      role.ID,
+		UserID:      userID,
+		CreatedByID: &createdByID,
+	}
+	if projectID != nil {
+		assignment.ProjectID = projectID
+	} else {
+		assignment.OrganizationID = organizationID
+	}
+
+	exists := IsRoleAssigned(db, assignment)
+	if assigned == exists {
+		return nil
+	}
+
+	if assigned {
+		return AssignRole(db, &assignment)
+	}
+
+	db = db.Where("role_id=? AND user_id=?", role.ID, userID)
+	if projectID != nil {
+		db = db.Where("project_id=?", *projectID)
+	} else {
+		db = db.Where("organization_id=?", organizationID)
+	}
+
+	return db.Delete(&models.RoleAssignment{}).Error
+}
@@ -0,0 +1,48 @@
+package rw_helpers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/jinzhu/gorm"
+
+	"devin/helpers"
+	"devin/models"
+	"devin/policies"
+)
+
+// DecodeAuditSearchFilters get 'q' parameter of query string, decode from json to AuditSearch
+// Handle request erros
+func DecodeAuditSearchFilters(w http.ResponseWriter, r *http.Request) (searchModel models.AuditSearch, e error) {
+	q := r.URL.Query().Get("q")
+	if strings.EqualFold(q, "") {
+		q = `{}`
+	}
+
+	e = json.Unmarshal([]byte(q), &searchModel)
+	if e != nil {
+		err := helpers.ErrorResponse{Message: "Invalid search filters", ErrorCode: http.StatusUnprocessableEntity}
+		err.Errors = make(map[string][]string)
+		err.Errors["dev"] = []string{e.Error()}
+		helpers.NewErrorResponse(w, &err)
+
+		return
+	}
+
+	return
+}
+
+// CanViewAudits check permission of authenticated user to view audit log of the organization
+func CanViewAudits(w http.ResponseWriter, db *gorm.DB, authUser models.User, organizationID *uint64) bool {
+	if policies.CanViewAudits(db, authUser, organizationID) == false {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusForbidden,
+			Message:   "This action is not allowed for you.",
+		}
+		helpers.NewErrorResponse(w, &err)
+		return false
+	}
+
+	return true
+}
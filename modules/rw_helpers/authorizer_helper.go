@@ -0,0 +1,55 @@
+package rw_helpers
+
+import (
+	"net/http"
+
+	"github.com/jinzhu/gorm"
+
+	"devin/helpers"
+	"devin/models"
+	"devin/modules/authorizer"
+)
+
+// Authorize check the permission of authenticated user on the project and handle http errors
+func Authorize(w http.ResponseWriter, db *gorm.DB, authUser models.User, project models.Project, permission authorizer.Permission) bool {
+	if authorizer.Authorize(db, authUser, project, permission) == false {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusForbidden,
+			Message:   "This action is not allowed for you.",
+		}
+		helpers.NewErrorResponse(w, &err)
+		return false
+	}
+
+	return true
+}
+
+// CanManageObjectPermissions check permission of authenticated user to grant and revoke
+// object permissions in the project and handle http errors
+func CanManageObjectPermissions(w http.ResponseWriter, db *gorm.DB, authUser models.User, project models.Project) bool {
+	return Authorize(w, db, authUser, project, authorizer.Permission{
+		Action:   authorizer.ActionManage,
+		ModuleID: models.MODULE_PROJECT,
+		Key:      authorizer.KeyAddUserToProject,
+	})
+}
+
+// CanManageProjectMembers check permission of authenticated user to add, update and remove
+// members of the project and handle http errors
+func CanManageProjectMembers(w http.ResponseWriter, db *gorm.DB, authUser models.User, project models.Project) bool {
+	return Authorize(w, db, authUser, project, authorizer.Permission{
+		Action:   authorizer.ActionManage,
+		ModuleID: models.MODULE_PROJECT,
+		Key:      authorizer.KeyAddUserToProject,
+	})
+}
+
+// CanManageProjectAdmins check permission of authenticated user to give or take back
+// admin flag of members of the project and handle http errors
+func CanManageProjectAdmins(w http.ResponseWriter, db *gorm.DB, authUser models.User, project models.Project) bool {
+	return Authorize(w, db, authUser, project, authorizer.Permission{
+		Action:   authorizer.ActionManage,
+		ModuleID: models.MODULE_PROJECT,
+		Key:      authorizer.KeyProjectAdmin,
+	})
+}
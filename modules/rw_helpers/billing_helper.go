@@ -0,0 +1,58 @@
+package rw_helpers
+
+import (
+	"net/http"
+
+	"github.com/jinzhu/gorm"
+
+	"devin/helpers"
+	"devin/models"
+	billing_repo "devin/modules/billing/repository"
+	"devin/policies"
+)
+
+// IsBillingModuleEnabled check billing module of the project to be enabled
+func IsBillingModuleEnabled(w http.ResponseWriter, project models.Project) bool {
+	if project.EnableBillingModule == false {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusForbidden,
+			Message:   "Billing module is disabled for this project!",
+		}
+		helpers.NewErrorResponse(w, &err)
+
+		return false
+	}
+
+	return true
+}
+
+// CanManageBilling check permission of authenticated user to access billing of the project
+func CanManageBilling(w http.ResponseWriter, db *gorm.DB, authUser models.User, project models.Project) bool {
+	if policies.CanManageBilling(db, authUser, project) == false {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusForbidden,
+			Message:   "You don't have permission to manage billing of this project!",
+		}
+		helpers.NewErrorResponse(w, &err)
+
+		return false
+	}
+
+	return true
+}
+
+// GetInvoiceByID try to load invoice of the project from DB. If no item found, returns an error.
+// This function handle http response errors
+func GetInvoiceByID(w http.ResponseWriter, db *gorm.DB, projectID, invoiceID uint64) (inv models.Invoice, e error) {
+	inv, e = billing_repo.GetInvoiceByID(db, projectID, invoiceID)
+	if e != nil {
+		err := helpers.ErrorResponse{}
+		err.ErrorCode = http.StatusNotFound
+		err.Message = "No matching invoice found!"
+		helpers.NewErrorResponse(w, &err)
+
+		return
+	}
+
+	return
+}
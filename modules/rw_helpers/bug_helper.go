@@ -0,0 +1,142 @@
+package rw_helpers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/jinzhu/gorm"
+
+	"devin/helpers"
+	"devin/models"
+	bug_repo "devin/modules/bug/repository"
+	"devin/policies"
+)
+
+// IsBugTrackerModuleEnabled check bug tracker module of the project to be enabled
+func IsBugTrackerModuleEnabled(w http.ResponseWriter, project models.Project) bool {
+	if project.EnableBugTrackerModule == false {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusForbidden,
+			Message:   "Bug tracker module is disabled for this project!",
+		}
+		helpers.NewErrorResponse(w, &err)
+
+		return false
+	}
+
+	return true
+}
+
+// CanViewBugs check permission of user to read bugs of the project and handle http errors
+func CanViewBugs(w http.ResponseWriter, db *gorm.DB, authUser models.User, project models.Project) bool {
+	if policies.CanViewBugs(db, authUser, project) == false {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusForbidden,
+			Message:   "You don't have permission to access bugs of this project!",
+		}
+		if authUser.ID == 0 {
+			err.ErrorCode = http.StatusUnauthorized
+		}
+		helpers.NewErrorResponse(w, &err)
+
+		return false
+	}
+
+	return true
+}
+
+// CanUpdateBug check permission of user to update bug fields of the issue
+func CanUpdateBug(w http.ResponseWriter, db *gorm.DB, authUser models.User, project models.Project, issue models.Issue) bool {
+	if policies.CanUpdateBug(db, authUser, project, issue) == false {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusForbidden,
+			Message:   "You don't have permission to update this bug!",
+		}
+		helpers.NewErrorResponse(w, &err)
+
+		return false
+	}
+
+	return true
+}
+
+// DecodeBugSearchFilters get 'q' parameter of query string, decode from json to BugSearch
+// Handle request erros
+func DecodeBugSearchFilters(w http.ResponseWriter, r *http.Request) (searchModel models.BugSearch, e error) {
+	q := r.URL.Query().Get("q")
+	if strings.EqualFold(q, "") {
+		q = `{}`
+	}
+	e = json.Unmarshal([]byte(q), &searchModel)
+	if e != nil {
+		err := helpers.ErrorResponse{Message: "Invalid search filters", ErrorCode: http.StatusUnprocessableEntity}
+		err.Errors = make(map[string][]string)
+		err.Errors["dev"] = []string{e.Error()}
+		helpers.NewErrorResponse(w, &err)
+
+		return
+	}
+
+	return
+}
+
+// DecodeBugDetailRequestModel check request body data and try to decode it to a bug detail object
+func DecodeBugDetailRequestModel(w http.ResponseWriter, r *http.Request) (detail models.IssueBugDetail, e error) {
+	if helpers.IsRequestBodyNil(w, r) {
+		e = errors.New("Request body is nil!")
+		return
+	}
+	e = json.NewDecoder(r.Body).Decode(&detail)
+
+	if e != nil {
+		err := helpers.ErrorResponse{}
+		err.ErrorCode = http.StatusBadRequest
+		err.Message = "Invalid request!"
+		helpers.NewErrorResponse(w, &err)
+
+		return
+	}
+
+	return
+}
+
+// ValidateBugDetailRequestModel will check request data for updating bug fields of an issue
+func ValidateBugDetailRequestModel(w http.ResponseWriter, reqModel models.IssueBugDetail) (err error) {
+	resErr := helpers.ErrorResponse{}
+	resErr.Errors = make(map[string][]string)
+
+	if reqModel.Severity < models.BUG_SEVERITY_LOW || reqModel.Severity > models.BUG_SEVERITY_CRITICAL {
+		resErr.Errors["Severity"] = append(resErr.Errors["Severity"], "Severity must be between 1 (low) and 4 (critical)!")
+	}
+
+	if reqModel.AffectedVersion != nil && len(*reqModel.AffectedVersion) > 100 {
+		resErr.Errors["AffectedVersion"] = append(resErr.Errors["AffectedVersion"], "Affected version can't be longer than 100 characters!")
+	}
+
+	if len(resErr.Errors) == 0 {
+		return nil
+	}
+	resErr.ErrorCode = http.StatusUnprocessableEntity
+	resErr.Message = "Invalid data!"
+	helpers.NewErrorResponse(w, &resErr)
+
+	return errors.New(resErr.Message)
+}
+
+// GetBugByID try to load a bug of the project from DB. If no item found, returns an error.
+// This function handle http response errors
+func GetBugByID(w http.ResponseWriter, db *gorm.DB, projectID, issueID uint64) (issue models.Issue, e error) {
+	issue, e = bug_repo.GetBugByID(db, projectID, issueID)
+	if e != nil {
+		err := helpers.ErrorResponse{}
+		err.ErrorCode = http.StatusNotFound
+		err.Message = "No matching bug found!"
+		helpers.NewErrorResponse(w, &err)
+
+		return
+	}
+
+	return
+}
@@ -1,8 +1,10 @@
 package rw_helpers
 
 import (
+	"devin/database"
 	"devin/helpers"
 	"devin/models"
+	"devin/modules/access_token"
 	"errors"
 	"net/http"
 	"strconv"
@@ -14,6 +16,17 @@ import (
 
 // GetAuthenticatedUser get user who is now logged into the application
 func GetAuthenticatedUser(w http.ResponseWriter, r *http.Request) (authUser models.User, e error) {
+	// Out of the Authenticate middleware, e.g on routes open to anonymous users, personal access tokens
+	// and JWTs sent as `Authorization: Bearer <token>` are resolved like the middleware does
+	if r.Context().Value("Authorization") == nil {
+		if token := access_token.BearerToken(r); token != "" {
+			if access_token.IsAccessToken(token) {
+				return authenticateAccessToken(w, r, token)
+			}
+			r.Header.Set("Authorization", token)
+		}
+	}
+
 	authUser, _, e = models.User{}.ExtractUserFromRequestContext(r)
 	if e != nil {
 		err := helpers.ErrorResponse{
@@ -28,6 +41,34 @@ func GetAuthenticatedUser(w http.ResponseWriter, r *http.Request) (authUser mode
 	return
 }
 
+// authenticateAccessToken get the user of the personal access token, tokens without the scope of the route are rejected
+func authenticateAccessToken(w http.ResponseWriter, r *http.Request, token string) (authUser models.User, e error) {
+	db := database.NewGORMInstance()
+	defer db.Close()
+
+	authUser, _, err := access_token.Authenticate(db, r, token)
+	if err != nil {
+		helpers.NewErrorResponse(w, err)
+		return authUser, errors.New(err.Message)
+	}
+
+	return authUser, nil
+}
+
+// GetOptionalAuthenticatedUser get the logged in user on routes which are open to anonymous users.
+// If the request has no authorization header or cookie, an empty user is returned.
+// Invalid tokens are rejected like on authenticated routes, as well as personal access tokens
+// without the scope of the route.
+func GetOptionalAuthenticatedUser(w http.ResponseWriter, r *http.Request) (authUser models.User, e error) {
+	if _, ok := r.Header["Authorization"]; ok == false {
+		if _, e = r.Cookie("Authorization"); e != nil {
+			return models.User{}, nil
+		}
+	}
+
+	return GetAuthenticatedUser(w, r)
+}
+
 // IsValidEmail check validation of email
 func IsValidEmail(w http.ResponseWriter, reqModel models.User) bool {
 	// email validator
@@ -0,0 +1,283 @@
+package rw_helpers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/jinzhu/gorm"
+
+	"devin/helpers"
+	"devin/markdown"
+	"devin/models"
+	issue_repo "devin/modules/issue/repository"
+	"devin/policies"
+)
+
+// IsIssueTrackerModuleEnabled check issue tracker module of the project to be enabled
+func IsIssueTrackerModuleEnabled(w http.ResponseWriter, project models.Project) bool {
+	if project.EnableIssueTrackerModule == false {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusForbidden,
+			Message:   "Issue tracker module is disabled for this project!",
+		}
+		helpers.NewErrorResponse(w, &err)
+
+		return false
+	}
+
+	return true
+}
+
+// CanViewIssues check permission of user to read issues of the project and handle http errors
+func CanViewIssues(w http.ResponseWriter, db *gorm.DB, authUser models.User, project models.Project) bool {
+	if policies.CanViewIssues(db, authUser, project) == false {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusForbidden,
+			Message:   "You don't have permission to access issues of this project!",
+		}
+		if authUser.ID == 0 {
+			err.ErrorCode = http.StatusUnauthorized
+		}
+		helpers.NewErrorResponse(w, &err)
+
+		return false
+	}
+
+	return true
+}
+
+// CanCreateIssue check permission of user to open issues in the project
+func CanCreateIssue(w http.ResponseWriter, db *gorm.DB, authUser models.User, project models.Project) bool {
+	if policies.CanCreateIssue(db, authUser, project) == false {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusForbidden,
+			Message:   "You don't have permission to open issues in this project!",
+		}
+		helpers.NewErrorResponse(w, &err)
+
+		return false
+	}
+
+	return true
+}
+
+// CanManageIssues check permission of user to label, assign and delete issues of the project
+func CanManageIssues(w http.ResponseWriter, db *gorm.DB, authUser models.User, project models.Project) bool {
+	if policies.CanManageIssues(db, authUser, project) == false {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusForbidden,
+			Message:   "You don't have permission to manage issues of this project!",
+		}
+		helpers.NewErrorResponse(w, &err)
+
+		return false
+	}
+
+	return true
+}
+
+// CanChangeIssueStatus check permission of user to change status of the issue
+func CanChangeIssueStatus(w http.ResponseWriter, db *gorm.DB, authUser models.User, project models.Project, issue models.Issue) bool {
+	if policies.CanChangeIssueStatus(db, authUser, project, issue) == false {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusForbidden,
+			Message:   "You don't have permission to change status of this issue!",
+		}
+		helpers.NewErrorResponse(w, &err)
+
+		return false
+	}
+
+	return true
+}
+
+// CanDeleteIssueComment check permission of authenticated user to delete the comment
+func CanDeleteIssueComment(w http.ResponseWriter, db *gorm.DB, authUser models.User, project models.Project, comment models.IssueComment) bool {
+	if policies.CanDeleteIssueComment(db, authUser, project, comment) == false {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusForbidden,
+			Message:   "You don't have permission to delete this comment!",
+		}
+		helpers.NewErrorResponse(w, &err)
+
+		return false
+	}
+
+	return true
+}
+
+// DecodeIssueSearchFilters get 'q' parameter of query string, decode from json to IssueSearch
+// Handle request erros
+func DecodeIssueSearchFilters(w http.ResponseWriter, r *http.Request) (searchModel models.IssueSearch, e error) {
+	q := r.URL.Query().Get("q")
+	if strings.EqualFold(q, "") {
+		q = `{}`
+	}
+	e = json.Unmarshal([]byte(q), &searchModel)
+	if e != nil {
+		err := helpers.ErrorResponse{Message: "Invalid search filters", ErrorCode: http.StatusUnprocessableEntity}
+		err.Errors = make(map[string][]string)
+		err.Errors["dev"] = []string{e.Error()}
+		helpers.NewErrorResponse(w, &err)
+
+		return
+	}
+
+	return
+}
+
+// DecodeIssueRequestModel check request body data and try to decode it to an issue object
+func DecodeIssueRequestModel(w http.ResponseWriter, r *http.Request) (issue models.Issue, e error) {
+	if helpers.IsRequestBodyNil(w, r) {
+		e = errors.New("Request body is nil!")
+		return
+	}
+	e = json.NewDecoder(r.Body).Decode(&issue)
+
+	if e != nil {
+		err := helpers.ErrorResponse{}
+		err.ErrorCode = http.StatusBadRequest
+		err.Message = "Invalid request!"
+		helpers.NewErrorResponse(w, &err)
+
+		return
+	}
+
+	return
+}
+
+// ValidateIssueRequestModel will check request data for opening or updating of an issue.
+// Anonymous users must give their email address.
+func ValidateIssueRequestModel(w http.ResponseWriter, db *gorm.DB, project models.Project, authUser models.User, reqModel models.Issue) (err error) {
+	resErr := helpers.ErrorResponse{}
+	resErr.Errors = make(map[string][]string)
+
+	if strings.EqualFold(strings.TrimSpace(reqModel.Title), "") {
+		resErr.Errors["Title"] = append(resErr.Errors["Title"], "Title can't be empty!")
+	} else if len(reqModel.Title) > 255 {
+		resErr.Errors["Title"] = append(resErr.Errors["Title"], "Title can't be longer than 255 characters!")
+	}
+
+	if authUser.ID == 0 && (reqModel.ReporterEmail == nil || helpers.Validator{}.IsValidEmailFormat(*reqModel.ReporterEmail) == false) {
+		resErr.Errors["ReporterEmail"] = append(resErr.Errors["ReporterEmail"], "A valid email address is required!")
+	}
+
+	if helpers.IsNilUint64(reqModel.RepositoryID) == false {
+		var cnt uint64
+		db.Model(&models.Repository{}).Where("id=? AND project_id=?", *reqModel.RepositoryID, project.ID).Count(&cnt)
+		if cnt == 0 {
+			resErr.Errors["RepositoryID"] = append(resErr.Errors["RepositoryID"], "Selected repository doesn't belong to this project!")
+		}
+	}
+
+	if len(resErr.Errors) == 0 {
+		return nil
+	}
+	resErr.ErrorCode = http.StatusUnprocessableEntity
+	resErr.Message = "Invalid data!"
+	helpers.NewErrorResponse(w, &resErr)
+
+	return errors.New(resErr.Message)
+}
+
+// GetIssueByID try to load issue of the project from DB. If no item found, returns an error.
+// This function handle http response errors
+func GetIssueByID(w http.ResponseWriter, db *gorm.DB, projectID, issueID uint64) (issue models.Issue, e error) {
+	issue, e = issue_repo.GetIssueByID(db, projectID, issueID)
+	if e != nil {
+		err := helpers.ErrorResponse{}
+		err.ErrorCode = http.StatusNotFound
+		err.Message = "No matching issue found!"
+		helpers.NewErrorResponse(w, &err)
+
+		return
+	}
+
+	return
+}
+
+// HideReporterEmails remove email address of anonymous reporters of issues and bugs
+// for users who can't manage issues of the project
+func HideReporterEmails(db *gorm.DB, authUser models.User, project models.Project, issues []models.Issue) {
+	if policies.CanManageIssues(db, authUser, project) {
+		return
+	}
+
+	for i := range issues {
+		issues[i].ReporterEmail = nil
+	}
+}
+
+// RenderIssueMessages render markdown messages of issues and bugs to html
+func RenderIssueMessages(db *gorm.DB, project models.Project, issues []models.Issue) {
+	resolver := markdown.NewProjectResolver(db, project)
+	for i := range issues {
+		issues[i].MessageHTML = markdown.Render(issues[i].Message, resolver)
+	}
+}
+
+// DecodeIssueLabelRequestModel check request body data and try to decode it to an issue label object
+func DecodeIssueLabelRequestModel(w http.ResponseWriter, r *http.Request) (label models.IssueLabel, e error) {
+	if helpers.IsRequestBodyNil(w, r) {
+		e = errors.New("Request body is nil!")
+		return
+	}
+	e = json.NewDecoder(r.Body).Decode(&label)
+
+	if e != nil {
+		err := helpers.ErrorResponse{}
+		err.ErrorCode = http.StatusBadRequest
+		err.Message = "Invalid request!"
+		helpers.NewErrorResponse(w, &err)
+
+		return
+	}
+
+	return
+}
+
+// ValidateIssueLabelRequestModel will check request data for creating or updating of an issue label.
+// ProjectID of reqModel must be set.
+func ValidateIssueLabelRequestModel(w http.ResponseWriter, db *gorm.DB, reqModel models.IssueLabel) (err error) {
+	resErr := helpers.ErrorResponse{}
+	resErr.Errors = make(map[string][]string)
+
+	if strings.EqualFold(strings.TrimSpace(reqModel.Label), "") {
+		resErr.Errors["Label"] = append(resErr.Errors["Label"], "Label can't be empty!")
+	} else if len(reqModel.Label) > 50 {
+		resErr.Errors["Label"] = append(resErr.Errors["Label"], "Label can't be longer than 50 characters!")
+	} else if issue_repo.IsUniqueLabel(db, reqModel) == false {
+		resErr.Errors["Label"] = append(resErr.Errors["Label"], "This label already exists in the project!")
+	}
+
+	if len(reqModel.Color) > 25 {
+		resErr.Errors["Color"] = append(resErr.Errors["Color"], "Invalid color!")
+	}
+
+	if len(resErr.Errors) == 0 {
+		return nil
+	}
+	resErr.ErrorCode = http.StatusUnprocessableEntity
+	resErr.Message = "Invalid data!"
+	helpers.NewErrorResponse(w, &resErr)
+
+	return errors.New(resErr.Message)
+}
+
+// GetIssueLabelByID try to load a label of the project from DB. If no item found, returns an error.
+// This function handle http response errors
+func GetIssueLabelByID(w http.ResponseWriter, db *gorm.DB, projectID, labelID uint64) (label models.IssueLabel, e error) {
+	label, e = issue_repo.GetLabelByID(db, projectID, labelID)
+	if e != nil {
+		err := helpers.ErrorResponse{}
+		err.ErrorCode = http.StatusNotFound
+		err.Message = "No matching label found!"
+		helpers.NewErrorResponse(w, &err)
+
+		return
+	}
+
+	return
+}
@@ -0,0 +1,147 @@
+package rw_helpers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/jinzhu/gorm"
+
+	"devin/helpers"
+	"devin/models"
+	milestone_repo "devin/modules/milestone/repository"
+	"devin/policies"
+)
+
+// IsMilestonesModuleEnabled check milestones module of the project to be enabled
+func IsMilestonesModuleEnabled(w http.ResponseWriter, project models.Project) bool {
+	if project.EnableMilestonesModule == false {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusForbidden,
+			Message:   "Milestones module is disabled for this project!",
+		}
+		helpers.NewErrorResponse(w, &err)
+
+		return false
+	}
+
+	return true
+}
+
+// CanCreateMilestone check permission of authenticated user to create or update milestones of the project
+func CanCreateMilestone(w http.ResponseWriter, db *gorm.DB, authUser models.User, project models.Project) bool {
+	if policies.CanCreateMilestone(db, authUser, project) == false {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusForbidden,
+			Message:   "You don't have permission to save milestones of this project!",
+		}
+		helpers.NewErrorResponse(w, &err)
+
+		return false
+	}
+
+	return true
+}
+
+// CanDeleteMilestoneComment check permission of authenticated user to delete the comment
+func CanDeleteMilestoneComment(w http.ResponseWriter, db *gorm.DB, authUser models.User, project models.Project, comment models.MilestoneComment) bool {
+	if policies.CanDeleteMilestoneComment(db, authUser, project, comment) == false {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusForbidden,
+			Message:   "You don't have permission to delete this comment!",
+		}
+		helpers.NewErrorResponse(w, &err)
+
+		return false
+	}
+
+	return true
+}
+
+// DecodeMilestoneRequestModel check request body data and try to decode it to a milestone object
+func DecodeMilestoneRequestModel(w http.ResponseWriter, r *http.Request) (milestone models.Milestone, e error) {
+	if helpers.IsRequestBodyNil(w, r) {
+		e = errors.New("Request body is nil!")
+		return
+	}
+	e = json.NewDecoder(r.Body).Decode(&milestone)
+
+	if e != nil {
+		err := helpers.ErrorResponse{}
+		err.ErrorCode = http.StatusBadRequest
+		err.Message = "Invalid request!"
+		helpers.NewErrorResponse(w, &err)
+
+		return
+	}
+
+	return
+}
+
+// ValidateMilestoneRequestModel will check request data for creating or updating of a milestone
+func ValidateMilestoneRequestModel(w http.ResponseWriter, reqModel models.Milestone) (err error) {
+	resErr := helpers.ErrorResponse{}
+	resErr.Errors = make(map[string][]string)
+
+	if strings.EqualFold(strings.TrimSpace(reqModel.Name), "") {
+		resErr.Errors["Name"] = append(resErr.Errors["Name"], "Name can't be empty!")
+	}
+
+	if reqModel.DueDate.IsZero() {
+		resErr.Errors["DueDate"] = append(resErr.Errors["DueDate"], "Due date is required!")
+	}
+
+	if len(resErr.Errors) == 0 {
+		return nil
+	}
+	resErr.ErrorCode = http.StatusUnprocessableEntity
+	resErr.Message = "Invalid data!"
+	helpers.NewErrorResponse(w, &resErr)
+
+	return errors.New(resErr.Message)
+}
+
+// GetMilestoneByID try to load milestone of the project from DB. If no item found, returns an error.
+// This function handle http response errors
+func GetMilestoneByID(w http.ResponseWriter, db *gorm.DB, projectID, milestoneID uint64) (milestone models.Milestone, e error) {
+	milestone, e = milestone_repo.GetMilestoneByID(db, projectID, milestoneID)
+	if e != nil {
+		err := helpers.ErrorResponse{}
+		err.ErrorCode = http.StatusNotFound
+		err.Message = "No matching milestone found!"
+		helpers.NewErrorResponse(w, &err)
+
+		return
+	}
+
+	return
+}
+
+// ExtractCommentIDFromURL extract a parameter passed in URL, convert to uint64 and returns as a comment ID
+func ExtractCommentIDFromURL(w http.ResponseWriter, r *http.Request, paramName string) (ID uint64, e error) {
+	IDString, ok := mux.Vars(r)[paramName]
+	if ok == false {
+		err := helpers.ErrorResponse{
+			Message:   "Invalid Comment ID.",
+			ErrorCode: http.StatusUnprocessableEntity,
+		}
+		helpers.NewErrorResponse(w, &err)
+		e = errors.New(err.Message)
+		return
+	}
+
+	ID, e = strconv.ParseUint(IDString, 10, 64)
+	if e != nil {
+		err := helpers.ErrorResponse{
+			Message:   "Invalid Comment ID. Just integer values accepted",
+			ErrorCode: http.StatusUnprocessableEntity,
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+
+	return ID, nil
+}
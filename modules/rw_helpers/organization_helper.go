@@ -14,7 +14,13 @@ import (
 	"github.com/gorilla/mux"
 	"github.com/jinzhu/gorm"
 
+	"devin/mailer/outbox"
+	"devin/modules/audit"
+	audit_repo "devin/modules/audit/repository"
+	"devin/modules/authorizer"
+	"devin/modules/notification/notify"
 	"devin/modules/organization/repository"
+	role_repo "devin/modules/role/repository"
 )
 
 // InvitationReqModel strcuct of invitation request
@@ -166,15 +172,40 @@ func AlreadyInvited(w http.ResponseWriter, db *gorm.DB, organiztionID uint64, ta
 	return false
 }
 
-// SaveInvitation save final data of invitation into the DB.
-func SaveInvitation(w http.ResponseWriter, db *gorm.DB, targetUser models.User, OrganizationID, CreatedByID uint64) error {
+// SaveInvitation save final data of invitation and its audit record into the DB
+// and queue the invitation email of target user.
+func SaveInvitation(w http.ResponseWriter, r *http.Request, db *gorm.DB, targetUser models.User, organization models.User, invitedBy models.User) error {
 	var invitation models.UserOrganizationInvitation
 	invitation.Email = &targetUser.Email
 	invitation.UserID = &targetUser.ID
-	invitation.OrganizationID = OrganizationID
-	invitation.CreatedByID = CreatedByID
+	invitation.OrganizationID = organization.ID
+	invitation.CreatedByID = invitedBy.ID
+
+	tx := db.Begin()
+	e := tx.Save(&invitation).Error
+	if e == nil {
+		e = outbox.EnqueueInvitation(tx, targetUser, organization, invitedBy, invitation)
+	}
+	if e == nil {
+		e = notify.Invitation(tx, invitation, organization)
+	}
+	if e == nil {
+		entry := audit.New(r, invitedBy, models.AUDIT_ACTION_INVITATION_CREATE, models.MODULE_ORGANIZATION, invitation.ID)
+		entry.OrganizationID = &organization.ID
+		e = audit.SetChanges(&entry, nil, struct {
+			UserID *uint64
+			Email  *string
+		}{invitation.UserID, invitation.Email})
+		if e == nil {
+			e = audit_repo.SaveAudit(tx, &entry)
+		}
+	}
+	if e == nil {
+		e = tx.Commit().Error
+	} else {
+		tx.Rollback()
+	}
 
-	e := db.Save(&invitation).Error
 	if e != nil {
 		err := helpers.ErrorResponse{
 			ErrorCode: http.StatusInternalServerError,
@@ -385,11 +416,51 @@ func CanUpdateUserOrganizationPermissions(w http.ResponseWriter, db *gorm.DB, au
 
 }
 
-// UpdateOrganizationPermissions handle updating of permissions and http errors
-func UpdateOrganizationPermissions(w http.ResponseWriter, db *gorm.DB, reqModel OrganizationPermissionUpdatableData) (e error) {
-	e = db.Model(&models.UserOrganization{}).
+// UpdateOrganizationPermissions handle updating of permissions, its audit record and http errors.
+// Each permission is assigned or unassigned as its built-in role on the organization.
+func UpdateOrganizationPermissions(w http.ResponseWriter, r *http.Request, db *gorm.DB, authUser models.User, reqModel OrganizationPermissionUpdatableData) (e error) {
+	keys := authorizer.OrganizationKeys(db, reqModel.UserID, reqModel.OrganizationID)
+	before := OrganizationPermissionUpdatableData{
+		UserID:                   reqModel.UserID,
+		OrganizationID:           reqModel.OrganizationID,
+		IsAdminOfOrganization:    keys[authorizer.KeyOrganizationAdmin],
+		CanCreateProject:         keys[authorizer.KeyCreateOrganizationProject],
+		CanAddUserToOrganization: keys[authorizer.KeyAddUserToOrganization],
+	}
+	roles := map[string]bool{
+		authorizer.KeyOrganizationAdmin:         reqModel.IsAdminOfOrganization,
+		authorizer.KeyCreateOrganizationProject: reqModel.CanCreateProject,
+		authorizer.KeyAddUserToOrganization:     reqModel.CanAddUserToOrganization,
+	}
+	entry := audit.New(r, authUser, models.AUDIT_ACTION_PERMISSIONS_UPDATE, models.MODULE_ORGANIZATION, reqModel.UserID)
+	entry.OrganizationID = &reqModel.OrganizationID
+
+	tx := db.Begin()
+	e = tx.Model(&models.UserOrganization{}).
 		Where("user_id=? AND organization_id=?", reqModel.UserID, reqModel.OrganizationID).
-		UpdateColumns(&reqModel).Error
+		UpdateColumns(map[string]interface{}{
+			"is_admin_of_organization":     reqModel.IsAdminOfOrganization,
+			"can_create_project":           reqModel.CanCreateProject,
+			"can_add_user_to_organization": reqModel.CanAddUserToOrganization,
+		}).Error
+	for key, assigned := range roles {
+		if e != nil {
+			break
+		}
+		e = role_repo.SetBuiltInRole(tx, reqModel.UserID, &reqModel.OrganizationID, nil, key, assigned, authUser.ID)
+	}
+	if e == nil {
+		e = audit.SetChanges(&entry, before, reqModel)
+	}
+	if e == nil {
+		e = audit_repo.SaveAudit(tx, &entry)
+	}
+	if e == nil {
+		e = tx.Commit().Error
+	} else {
+		tx.Rollback()
+	}
+
 	if e != nil {
 		err := helpers.ErrorResponse{
 			Message:   "Fail to update",
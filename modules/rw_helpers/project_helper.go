@@ -4,8 +4,10 @@ import (
 	"encoding/json"
 	"errors"
 	"net/http"
+	"strconv"
 	"strings"
 
+	"github.com/gorilla/mux"
 	"github.com/jinzhu/gorm"
 
 	"devin/helpers"
@@ -154,6 +156,61 @@ func GetProjectByID(w http.ResponseWriter, db *gorm.DB, id uint64) (project mode
 	return
 }
 
+// ErrProjectAccessDenied returned when the user can't access the project or its module
+var ErrProjectAccessDenied = errors.New("Access denied")
+
+// LoadProjectForModule extract project ID from URL, load the project and check the module to be enabled
+// and the user to have access to it. For models.MODULE_PROJECT only access to the project is checked.
+// authUser may be anonymous on public routes of issue tracker, bug tracker and wiki modules.
+// This function handle http response errors
+func LoadProjectForModule(w http.ResponseWriter, r *http.Request, db *gorm.DB, authUser models.User, moduleID uint) (project models.Project, e error) {
+	projectID, e := ExtractProjectIDFromURL(w, r, "project_id")
+	if e != nil {
+		return
+	}
+
+	project, e = GetProjectByID(w, db, projectID)
+	if e != nil {
+		return
+	}
+
+	if canAccessModule(w, db, authUser, project, moduleID) == false {
+		e = ErrProjectAccessDenied
+	}
+
+	return
+}
+
+// canAccessModule check the module of the project to be enabled and the user to have access to it
+func canAccessModule(w http.ResponseWriter, db *gorm.DB, authUser models.User, project models.Project, moduleID uint) bool {
+	switch moduleID {
+	case models.MODULE_PROJECT:
+		return CanViewProject(w, db, authUser, project)
+	case models.MODULE_TASK:
+		return CanViewProject(w, db, authUser, project) && IsTasksModuleEnabled(w, project)
+	case models.MODULE_MILESTONE:
+		return CanViewProject(w, db, authUser, project) && IsMilestonesModuleEnabled(w, project)
+	case models.MODULE_SPENT_TIME:
+		return CanViewProject(w, db, authUser, project) && IsTimeLogsModuleEnabled(w, project)
+	case models.MODULE_ISSUE_TRACKER:
+		return IsIssueTrackerModuleEnabled(w, project) && CanViewIssues(w, db, authUser, project)
+	case models.MODULE_BUG_TRACKER:
+		return IsBugTrackerModuleEnabled(w, project) && CanViewBugs(w, db, authUser, project)
+	case models.MODULE_WIKI:
+		return IsWikiModuleEnabled(w, project) && CanViewWiki(w, db, authUser, project)
+	case models.MODULE_BILLING:
+		return IsBillingModuleEnabled(w, project) && CanManageBilling(w, db, authUser, project)
+	}
+
+	err := helpers.ErrorResponse{
+		ErrorCode: http.StatusInternalServerError,
+		Message:   "Unknown module of project",
+	}
+	helpers.NewErrorResponse(w, &err)
+
+	return false
+}
+
 // CheckOwnerOrganizationIDOfProject will check requested data of projet
 // If ownerOrganizationID is nil, it return with no error
 // Otherwise check selected organization existance in DB
@@ -168,3 +225,44 @@ func CheckOwnerOrganizationIDOfProject(w http.ResponseWriter, db *gorm.DB, owner
 	}
 	return
 }
+
+// ExtractProjectIDFromURL extract a parameter passed in URL, convert to uint64 and returns as a project ID
+func ExtractProjectIDFromURL(w http.ResponseWriter, r *http.Request, paramName string) (ID uint64, e error) {
+	IDString, ok := mux.Vars(r)[paramName]
+	if ok == false {
+		err := helpers.ErrorResponse{
+			Message:   "Invalid Project ID.",
+			ErrorCode: http.StatusUnprocessableEntity,
+		}
+		helpers.NewErrorResponse(w, &err)
+		e = errors.New(err.Message)
+		return
+	}
+
+	ID, e = strconv.ParseUint(IDString, 10, 64)
+	if e != nil {
+		err := helpers.ErrorResponse{
+			Message:   "Invalid Project ID. Just integer values accepted",
+			ErrorCode: http.StatusUnprocessableEntity,
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+
+	return ID, nil
+}
+
+// CanViewProject check permission of authenticated user to access the project and handle http errors
+func CanViewProject(w http.ResponseWriter, db *gorm.DB, authUser models.User, project models.Project) bool {
+	if policies.CanViewProject(db, authUser, project) == false {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusForbidden,
+			Message:   "You don't have permission to access this project!",
+		}
+		helpers.NewErrorResponse(w, &err)
+
+		return false
+	}
+
+	return true
+}
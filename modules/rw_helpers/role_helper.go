@@ -0,0 +1,41 @@
+package rw_helpers
+
+import (
+	"net/http"
+
+	"github.com/jinzhu/gorm"
+
+	"devin/helpers"
+	"devin/models"
+	"devin/policies"
+)
+
+// CanViewRoles check permission of authenticated user to view roles of the organization
+// and their assignments and handle http errors
+func CanViewRoles(w http.ResponseWriter, db *gorm.DB, authUser, organization models.User) bool {
+	if policies.CanViewMembersOfOrganization(db, authUser, organization) == false {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusForbidden,
+			Message:   "This action is not allowed for you.",
+		}
+		helpers.NewErrorResponse(w, &err)
+		return false
+	}
+
+	return true
+}
+
+// CanManageRoles check permission of authenticated user to manage roles of the organization
+// and handle http errors
+func CanManageRoles(w http.ResponseWriter, db *gorm.DB, authUser, organization models.User) bool {
+	if policies.CanManageRoles(db, authUser, organization) == false {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusForbidden,
+			Message:   "This action is not allowed for you.",
+		}
+		helpers.NewErrorResponse(w, &err)
+		return false
+	}
+
+	return true
+}
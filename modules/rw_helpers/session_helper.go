@@ -0,0 +1,68 @@
+package rw_helpers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/jinzhu/gorm"
+
+	"devin/helpers"
+	"devin/models"
+	"devin/modules/session"
+	session_repo "devin/modules/session/repository"
+)
+
+// StartSession create a session of the user signed in through the request,
+// set its access and refresh tokens and handle http errors
+func StartSession(w http.ResponseWriter, r *http.Request, db *gorm.DB, user models.User) (newSession models.Session, e error) {
+	newSession = session.New(r, user)
+
+	var refreshToken, hash string
+	tx := db.Begin()
+	e = session_repo.CreateSession(tx, &newSession)
+	if e == nil {
+		refreshToken, hash, e = session.NewRefreshToken(newSession.ID)
+	}
+	if e == nil {
+		e = session_repo.SetRefreshTokenHash(tx, &newSession, hash)
+	}
+	if e == nil {
+		e = tx.Commit().Error
+	} else {
+		tx.Rollback()
+	}
+	if e != nil {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusInternalServerError,
+			Message:   "Fail to start session, please try again!",
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+
+	e = SetSessionTokens(w, user, newSession, refreshToken)
+
+	return
+}
+
+// SetSessionTokens set a new access token of the session and its refresh token on
+// cookies and headers and handle http errors
+func SetSessionTokens(w http.ResponseWriter, user models.User, session models.Session, refreshToken string) error {
+	claim := user.GenerateNewSessionTokenClaim(session)
+	tokenString, err := user.GenerateNewTokenString(claim)
+	if err != nil {
+		helpers.NewErrorResponse(w, err)
+		return errors.New(err.Message)
+	}
+
+	user.SetAuthorizationCookieAndHeader(w, tokenString)
+	session.SetRefreshTokenCookieAndHeader(w, refreshToken)
+
+	return nil
+}
+
+// ExpireSessionCookies expire access and refresh token cookies
+func ExpireSessionCookies(w http.ResponseWriter) {
+	models.User{}.ExpireAuthorizationCookie(w)
+	models.Session{}.ExpireRefreshTokenCookie(w)
+}
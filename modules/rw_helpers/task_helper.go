@@ -0,0 +1,247 @@
+package rw_helpers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/jinzhu/gorm"
+
+	"devin/helpers"
+	"devin/models"
+	task_repo "devin/modules/task/repository"
+	"devin/policies"
+)
+
+// IsTasksModuleEnabled check tasks module of the project to be enabled
+func IsTasksModuleEnabled(w http.ResponseWriter, project models.Project) bool {
+	if project.EnableTasksModule == false {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusForbidden,
+			Message:   "Tasks module is disabled for this project!",
+		}
+		helpers.NewErrorResponse(w, &err)
+
+		return false
+	}
+
+	return true
+}
+
+// DecodeTaskSearchFilters get 'q' parameter of query string, decode from json to TaskSearch
+// Handle request erros
+func DecodeTaskSearchFilters(w http.ResponseWriter, r *http.Request) (searchModel models.TaskSearch, e error) {
+	q := r.URL.Query().Get("q")
+	if strings.EqualFold(q, "") {
+		q = `{}`
+	}
+	e = json.Unmarshal([]byte(q), &searchModel)
+	if e != nil {
+		err := helpers.ErrorResponse{Message: "Invalid search filters", ErrorCode: http.StatusUnprocessableEntity}
+		err.Errors = make(map[string][]string)
+		err.Errors["dev"] = []string{e.Error()}
+		helpers.NewErrorResponse(w, &err)
+
+		return
+	}
+
+	return
+}
+
+// DecodeTaskRequestModel check request body data and try to decode it to a task object
+func DecodeTaskRequestModel(w http.ResponseWriter, r *http.Request) (task models.Task, e error) {
+	if helpers.IsRequestBodyNil(w, r) {
+		e = errors.New("Request body is nil!")
+		return
+	}
+	e = json.NewDecoder(r.Body).Decode(&task)
+
+	if e != nil {
+		err := helpers.ErrorResponse{}
+		err.ErrorCode = http.StatusBadRequest
+		err.Message = "Invalid request!"
+		helpers.NewErrorResponse(w, &err)
+
+		return
+	}
+
+	return
+}
+
+// ValidateTaskRequestModel will check request data for creating or updating of a task
+func ValidateTaskRequestModel(w http.ResponseWriter, db *gorm.DB, reqModel models.Task) (err error) {
+	resErr := helpers.ErrorResponse{}
+	resErr.Errors = make(map[string][]string)
+
+	if strings.EqualFold(strings.TrimSpace(reqModel.Title), "") {
+		resErr.Errors["Title"] = append(resErr.Errors["Title"], "Title can't be empty!")
+	}
+
+	if reqModel.Progress != nil && (*reqModel.Progress < 0 || *reqModel.Progress > 100) {
+		resErr.Errors["Progress"] = append(resErr.Errors["Progress"], "Progress must be between 0 and 100!")
+	}
+
+	if reqModel.EstimatedTime != nil && *reqModel.EstimatedTime < 0 {
+		resErr.Errors["EstimatedTime"] = append(resErr.Errors["EstimatedTime"], "Estimated time can't be negative!")
+	}
+
+	if reqModel.ScheduledStartDate != nil && reqModel.ScheduledCompletionDate != nil &&
+		reqModel.ScheduledCompletionDate.Before(*reqModel.ScheduledStartDate) {
+		resErr.Errors["ScheduledCompletionDate"] = append(resErr.Errors["ScheduledCompletionDate"], "Scheduled completion date must be after the scheduled start date!")
+	}
+
+	if reqModel.TaskBoardID != nil && *reqModel.TaskBoardID != 0 &&
+		task_repo.IsBoardOfProject(db, *reqModel.TaskBoardID, reqModel.ProjectID) == false {
+		resErr.Errors["TaskBoardID"] = append(resErr.Errors["TaskBoardID"], "Selected board doesn't belong to this project!")
+	}
+
+	if reqModel.TaskListID != nil && *reqModel.TaskListID != 0 &&
+		task_repo.IsTaskListOfProject(db, *reqModel.TaskListID, reqModel.ProjectID) == false {
+		resErr.Errors["TaskListID"] = append(resErr.Errors["TaskListID"], "Selected task list doesn't belong to this project!")
+	}
+
+	if len(resErr.Errors) == 0 {
+		return nil
+	}
+	resErr.ErrorCode = http.StatusUnprocessableEntity
+	resErr.Message = "Invalid data!"
+	helpers.NewErrorResponse(w, &resErr)
+
+	return errors.New(resErr.Message)
+}
+
+// GetTaskByID try to load task of the project from DB. If no item found, returns an error.
+// This function handle http response errors
+func GetTaskByID(w http.ResponseWriter, db *gorm.DB, projectID, taskID uint64) (task models.Task, e error) {
+	task, e = task_repo.GetTaskByID(db, projectID, taskID)
+	if e != nil {
+		err := helpers.ErrorResponse{}
+		err.ErrorCode = http.StatusNotFound
+		err.Message = "No matching task found!"
+		helpers.NewErrorResponse(w, &err)
+
+		return
+	}
+
+	return
+}
+
+// CanCreateTask check permission of authenticated user to create or update tasks of the project
+func CanCreateTask(w http.ResponseWriter, db *gorm.DB, authUser models.User, project models.Project) bool {
+	if policies.CanCreateTask(db, authUser, project) == false {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusForbidden,
+			Message:   "You don't have permission to save tasks of this project!",
+		}
+		helpers.NewErrorResponse(w, &err)
+
+		return false
+	}
+
+	return true
+}
+
+// CanUpdateTask check permission of authenticated user to update the task
+func CanUpdateTask(w http.ResponseWriter, db *gorm.DB, authUser models.User, project models.Project, task models.Task) bool {
+	if policies.CanUpdateTask(db, authUser, project, task) == false {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusForbidden,
+			Message:   "You don't have permission to update this task!",
+		}
+		helpers.NewErrorResponse(w, &err)
+
+		return false
+	}
+
+	return true
+}
+
+// CanDeleteTask check permission of authenticated user to delete the task
+func CanDeleteTask(w http.ResponseWriter, db *gorm.DB, authUser models.User, project models.Project, task models.Task) bool {
+	if policies.CanDeleteTask(db, authUser, project, task) == false {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusForbidden,
+			Message:   "You don't have permission to delete this task!",
+		}
+		helpers.NewErrorResponse(w, &err)
+
+		return false
+	}
+
+	return true
+}
+
+// CanDeleteTaskComment check permission of authenticated user to delete the comment
+func CanDeleteTaskComment(w http.ResponseWriter, db *gorm.DB, authUser models.User, project models.Project, comment models.TaskComment) bool {
+	if policies.CanDeleteTaskComment(db, authUser, project, comment) == false {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusForbidden,
+			Message:   "You don't have permission to delete this comment!",
+		}
+		helpers.NewErrorResponse(w, &err)
+
+		return false
+	}
+
+	return true
+}
+
+// ExtractTaskIDFromURL extract a parameter passed in URL, convert to uint64 and returns as a task ID
+func ExtractTaskIDFromURL(w http.ResponseWriter, r *http.Request, paramName string) (ID uint64, e error) {
+	IDString, ok := mux.Vars(r)[paramName]
+	if ok == false {
+		err := helpers.ErrorResponse{
+			Message:   "Invalid Task ID.",
+			ErrorCode: http.StatusUnprocessableEntity,
+		}
+		helpers.NewErrorResponse(w, &err)
+		e = errors.New(err.Message)
+		return
+	}
+
+	ID, e = strconv.ParseUint(IDString, 10, 64)
+	if e != nil {
+		err := helpers.ErrorResponse{
+			Message:   "Invalid Task ID. Just integer values accepted",
+			ErrorCode: http.StatusUnprocessableEntity,
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+
+	return ID, nil
+}
+
+// CanCreateBoard check permission of authenticated user to create or update boards of the project
+func CanCreateBoard(w http.ResponseWriter, db *gorm.DB, authUser models.User, project models.Project) bool {
+	if policies.CanCreateBoard(db, authUser, project) == false {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusForbidden,
+			Message:   "You don't have permission to save boards of this project!",
+		}
+		helpers.NewErrorResponse(w, &err)
+
+		return false
+	}
+
+	return true
+}
+
+// GetBoardByID try to load board of the project with its tasks. If no item found, returns an error.
+// This function handle http response errors
+func GetBoardByID(w http.ResponseWriter, db *gorm.DB, projectID, boardID uint64) (board models.TaskBoard, e error) {
+	board, e = task_repo.GetBoardByID(db, projectID, boardID)
+	if e != nil {
+		err := helpers.ErrorResponse{}
+		err.ErrorCode = http.StatusNotFound
+		err.Message = "No matching board found!"
+		helpers.NewErrorResponse(w, &err)
+
+		return
+	}
+
+	return
+}
@@ -0,0 +1,180 @@
+package rw_helpers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/jinzhu/gorm"
+
+	"devin/helpers"
+	"devin/models"
+	"devin/modules/time_log/report"
+	time_log_repo "devin/modules/time_log/repository"
+	"devin/policies"
+)
+
+// IsTimeLogsModuleEnabled check time logs module of the project to be enabled
+func IsTimeLogsModuleEnabled(w http.ResponseWriter, project models.Project) bool {
+	if project.EnableTimeLogsModule == false {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusForbidden,
+			Message:   "Time logs module is disabled for this project!",
+		}
+		helpers.NewErrorResponse(w, &err)
+
+		return false
+	}
+
+	return true
+}
+
+// CanCreateTimeLog check permission of authenticated user to log spent times in the project
+func CanCreateTimeLog(w http.ResponseWriter, db *gorm.DB, authUser models.User, project models.Project) bool {
+	if policies.CanCreateTimeLog(db, authUser, project) == false {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusForbidden,
+			Message:   "You don't have permission to log times in this project!",
+		}
+		helpers.NewErrorResponse(w, &err)
+
+		return false
+	}
+
+	return true
+}
+
+// CanUpdateTimeLog check permission of authenticated user to update or delete the spent time
+func CanUpdateTimeLog(w http.ResponseWriter, authUser models.User, timeLog models.TaskSpentTime) bool {
+	if policies.CanUpdateTimeLog(authUser, timeLog) == false {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusForbidden,
+			Message:   "You don't have permission to change this time log!",
+		}
+		helpers.NewErrorResponse(w, &err)
+
+		return false
+	}
+
+	if timeLog.IsBilled {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusUnprocessableEntity,
+			Message:   "Billed time logs can't be changed!",
+		}
+		helpers.NewErrorResponse(w, &err)
+
+		return false
+	}
+
+	return true
+}
+
+// DecodeTimeLogRequestModel check request body data and try to decode it to a spent time object
+func DecodeTimeLogRequestModel(w http.ResponseWriter, r *http.Request) (timeLog models.TaskSpentTime, e error) {
+	if helpers.IsRequestBodyNil(w, r) {
+		e = errors.New("Request body is nil!")
+		return
+	}
+	e = json.NewDecoder(r.Body).Decode(&timeLog)
+
+	if e != nil {
+		err := helpers.ErrorResponse{}
+		err.ErrorCode = http.StatusBadRequest
+		err.Message = "Invalid request!"
+		helpers.NewErrorResponse(w, &err)
+
+		return
+	}
+
+	return
+}
+
+// ValidateTimeLogRequestModel check request data of a manually logged spent time
+func ValidateTimeLogRequestModel(w http.ResponseWriter, reqModel models.TaskSpentTime) (err error) {
+	resErr := helpers.ErrorResponse{}
+	resErr.Errors = make(map[string][]string)
+
+	if reqModel.TaskID == 0 {
+		resErr.Errors["TaskID"] = append(resErr.Errors["TaskID"], "Task is required!")
+	}
+
+	if reqModel.StartDate.IsZero() {
+		resErr.Errors["StartDate"] = append(resErr.Errors["StartDate"], "Start date is required!")
+	}
+
+	if reqModel.EndDate == nil {
+		resErr.Errors["EndDate"] = append(resErr.Errors["EndDate"], "End date is required! Use timer to log running times.")
+	} else if reqModel.EndDate.After(reqModel.StartDate) == false {
+		resErr.Errors["EndDate"] = append(resErr.Errors["EndDate"], "End date must be after the start date!")
+	} else if reqModel.EndDate.After(time.Now()) {
+		resErr.Errors["EndDate"] = append(resErr.Errors["EndDate"], "End date can't be in the future!")
+	}
+
+	if len(resErr.Errors) == 0 {
+		return nil
+	}
+	resErr.ErrorCode = http.StatusUnprocessableEntity
+	resErr.Message = "Invalid data!"
+	helpers.NewErrorResponse(w, &resErr)
+
+	return errors.New(resErr.Message)
+}
+
+// GetTimeLogByID try to load spent time of the project from DB. If no item found, returns an error.
+// This function handle http response errors
+func GetTimeLogByID(w http.ResponseWriter, db *gorm.DB, projectID, timeLogID uint64) (timeLog models.TaskSpentTime, e error) {
+	timeLog, e = time_log_repo.GetTimeLogByID(db, projectID, timeLogID)
+	if e != nil {
+		err := helpers.ErrorResponse{}
+		err.ErrorCode = http.StatusNotFound
+		err.Message = "No matching time log found!"
+		helpers.NewErrorResponse(w, &err)
+
+		return
+	}
+
+	return
+}
+
+// DecodeTimesheetFilters get 'q' parameter of query string, decode from json to TimesheetSearch
+// and validate grouping and time zone. Handle request erros
+func DecodeTimesheetFilters(w http.ResponseWriter, r *http.Request) (searchModel models.TimesheetSearch, loc *time.Location, e error) {
+	q := r.URL.Query().Get("q")
+	if strings.EqualFold(q, "") {
+		q = `{}`
+	}
+
+	err := helpers.ErrorResponse{Message: "Invalid search filters", ErrorCode: http.StatusUnprocessableEntity}
+	err.Errors = make(map[string][]string)
+
+	e = json.Unmarshal([]byte(q), &searchModel)
+	if e != nil {
+		err.Errors["dev"] = []string{e.Error()}
+		helpers.NewErrorResponse(w, &err)
+
+		return
+	}
+
+	if strings.EqualFold(searchModel.GroupBy, "") {
+		searchModel.GroupBy = report.GroupByUser
+	}
+	if report.IsValidGroupBy(searchModel.GroupBy) == false {
+		e = report.ErrInvalidGroupBy
+		err.Errors["GroupBy"] = []string{e.Error()}
+		helpers.NewErrorResponse(w, &err)
+
+		return
+	}
+
+	loc, e = time.LoadLocation(searchModel.TimeZone)
+	if e != nil {
+		err.Errors["TimeZone"] = []string{"Invalid time zone!"}
+		helpers.NewErrorResponse(w, &err)
+
+		return
+	}
+
+	return
+}
@@ -0,0 +1,102 @@
+package rw_helpers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+	"github.com/jinzhu/gorm"
+
+	"devin/crypto"
+	"devin/helpers"
+	"devin/models"
+	"devin/modules/two_factor"
+	two_factor_repo "devin/modules/two_factor/repository"
+)
+
+// GetMFAPendingUser load the user of a token of the first signin step and handle http errors
+func GetMFAPendingUser(w http.ResponseWriter, db *gorm.DB, tokenString string) (user models.User, e error) {
+	claim := models.Claim{}
+	token, e := jwt.ParseWithClaims(tokenString, &claim, crypto.GetJWTVerifyKey)
+	if e == nil && (token.Valid == false || claim.MFAPending == false) {
+		e = errors.New("Not a two-factor authentication token")
+	}
+	if e == nil {
+		user, e = models.User{}.ExtractUserFromClaimPayload(claim.Payload)
+	}
+	if e == nil {
+		userID := user.ID
+		user = models.User{}
+		db.Where("id=?", userID).First(&user)
+		if user.ID == 0 {
+			e = errors.New("User not found")
+		}
+	}
+	if e != nil {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusUnauthorized,
+			Message:   "Invalid or expired two-factor authentication token, sign in again.",
+		}
+		helpers.NewErrorResponse(w, &err)
+	}
+
+	return
+}
+
+// CheckTwoFactorCode check the TOTP code or a recovery code of the user and handle http errors.
+// Recovery codes are only accepted when two-factor authentication is enabled.
+// Step of the valid TOTP code is returned, it is saved as the last used step when the factor is enabled.
+func CheckTwoFactorCode(w http.ResponseWriter, db *gorm.DB, user models.User, code, recoveryCode string) (step int64, e error) {
+	if two_factor.IsLocked(user.TwoFactorFailures, user.TwoFactorFailedAt, time.Now()) {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusTooManyRequests,
+			Message:   "Too many invalid codes, try again later.",
+		}
+		helpers.NewErrorResponse(w, &err)
+		return 0, errors.New(err.Message)
+	}
+
+	if user.TwoFactorSecret == nil {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusUnprocessableEntity,
+			Message:   "Two-factor authentication is not enrolled",
+		}
+		helpers.NewErrorResponse(w, &err)
+		return 0, errors.New(err.Message)
+	}
+
+	ok := false
+	if recoveryCode != "" && user.IsTwoFactorEnabled() {
+		ok = two_factor_repo.UseRecoveryCode(db, user.ID, two_factor.HashRecoveryCode(recoveryCode))
+	} else {
+		secret, e := crypto.Decrypt(*user.TwoFactorSecret, crypto.PurposeTOTPSecret)
+		if e != nil {
+			err := helpers.ErrorResponse{
+				ErrorCode: http.StatusInternalServerError,
+				Message:   "Fail to check two-factor code",
+			}
+			helpers.NewErrorResponse(w, &err)
+			return 0, e
+		}
+
+		step, ok = two_factor.Validate(secret, code, time.Now(), user.TwoFactorLastStep)
+		if ok && user.IsTwoFactorEnabled() {
+			ok = two_factor_repo.UseStep(db, user.ID, step)
+		}
+	}
+
+	if ok == false {
+		two_factor_repo.RecordFailure(db, user.ID)
+
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusUnprocessableEntity,
+			Message:   "Invalid two-factor code",
+			Errors:    map[string][]string{"Code": {"Invalid or already used code"}},
+		}
+		helpers.NewErrorResponse(w, &err)
+		return 0, errors.New(err.Message)
+	}
+
+	return step, nil
+}
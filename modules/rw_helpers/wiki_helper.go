@@ -0,0 +1,249 @@
+package rw_helpers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/jinzhu/gorm"
+
+	"devin/helpers"
+	"devin/models"
+	wiki_repo "devin/modules/wiki/repository"
+	"devin/policies"
+)
+
+// IsWikiModuleEnabled check wiki module of the project to be enabled
+func IsWikiModuleEnabled(w http.ResponseWriter, project models.Project) bool {
+	if project.EnableWikiModule == false {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusForbidden,
+			Message:   "Wiki module is disabled for this project!",
+		}
+		helpers.NewErrorResponse(w, &err)
+
+		return false
+	}
+
+	return true
+}
+
+// CanViewWiki check permission of user to read wikis of the project and handle http errors
+func CanViewWiki(w http.ResponseWriter, db *gorm.DB, authUser models.User, project models.Project) bool {
+	if policies.CanViewWiki(db, authUser, project) == false {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusForbidden,
+			Message:   "You don't have permission to access wiki of this project!",
+		}
+		if authUser.ID == 0 {
+			err.ErrorCode = http.StatusUnauthorized
+		}
+		helpers.NewErrorResponse(w, &err)
+
+		return false
+	}
+
+	return true
+}
+
+// CanCreateWiki check permission of user to create and edit wikis and pages of the project
+func CanCreateWiki(w http.ResponseWriter, db *gorm.DB, authUser models.User, project models.Project) bool {
+	if policies.CanCreateWiki(db, authUser, project) == false {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusForbidden,
+			Message:   "You don't have permission to edit wiki of this project!",
+		}
+		helpers.NewErrorResponse(w, &err)
+
+		return false
+	}
+
+	return true
+}
+
+// ExtractWikiIDFromURL get ID of wiki from the given parameter of URL
+func ExtractWikiIDFromURL(w http.ResponseWriter, r *http.Request, paramName string) (ID uint64, e error) {
+	IDString, ok := mux.Vars(r)[paramName]
+	if ok == false {
+		err := helpers.ErrorResponse{
+			Message:   "Invalid Wiki ID.",
+			ErrorCode: http.StatusUnprocessableEntity,
+		}
+		helpers.NewErrorResponse(w, &err)
+		e = errors.New(err.Message)
+		return
+	}
+
+	ID, e = strconv.ParseUint(IDString, 10, 64)
+	if e != nil {
+		err := helpers.ErrorResponse{
+			Message:   "Invalid Wiki ID. Just integer values accepted",
+			ErrorCode: http.StatusUnprocessableEntity,
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+
+	return ID, nil
+}
+
+// ExtractRevisionFromURL get a revision number of wiki page from the given parameter of URL
+func ExtractRevisionFromURL(w http.ResponseWriter, r *http.Request, paramName string) (revision uint, e error) {
+	revisionString, ok := mux.Vars(r)[paramName]
+	if ok == false {
+		err := helpers.ErrorResponse{
+			Message:   "Invalid revision.",
+			ErrorCode: http.StatusUnprocessableEntity,
+		}
+		helpers.NewErrorResponse(w, &err)
+		e = errors.New(err.Message)
+		return
+	}
+
+	number, e := strconv.ParseUint(revisionString, 10, 32)
+	if e != nil {
+		err := helpers.ErrorResponse{
+			Message:   "Invalid revision. Just integer values accepted",
+			ErrorCode: http.StatusUnprocessableEntity,
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+
+	return uint(number), nil
+}
+
+// DecodeWikiRequestModel check request body data and try to decode it to a wiki object
+func DecodeWikiRequestModel(w http.ResponseWriter, r *http.Request) (wiki models.Wiki, e error) {
+	if helpers.IsRequestBodyNil(w, r) {
+		e = errors.New("Request body is nil!")
+		return
+	}
+	e = json.NewDecoder(r.Body).Decode(&wiki)
+
+	if e != nil {
+		err := helpers.ErrorResponse{}
+		err.ErrorCode = http.StatusBadRequest
+		err.Message = "Invalid request!"
+		helpers.NewErrorResponse(w, &err)
+
+		return
+	}
+
+	return
+}
+
+// ValidateWikiRequestModel will check request data for creating or updating of a wiki.
+// ProjectID of reqModel must be set.
+func ValidateWikiRequestModel(w http.ResponseWriter, db *gorm.DB, reqModel models.Wiki) (err error) {
+	resErr := helpers.ErrorResponse{}
+	resErr.Errors = make(map[string][]string)
+
+	if strings.EqualFold(strings.TrimSpace(reqModel.Name), "") {
+		resErr.Errors["Name"] = append(resErr.Errors["Name"], "Name can't be empty!")
+	} else if len(reqModel.Name) > 255 {
+		resErr.Errors["Name"] = append(resErr.Errors["Name"], "Name can't be longer than 255 characters!")
+	} else if wiki_repo.IsUniqueWikiName(db, reqModel) == false {
+		resErr.Errors["Name"] = append(resErr.Errors["Name"], "This wiki already exists in the project!")
+	}
+
+	if helpers.IsNilUint64(reqModel.RepositoryID) == false {
+		var cnt uint64
+		db.Model(&models.Repository{}).Where("id=? AND project_id=?", *reqModel.RepositoryID, reqModel.ProjectID).Count(&cnt)
+		if cnt == 0 {
+			resErr.Errors["RepositoryID"] = append(resErr.Errors["RepositoryID"], "Selected repository doesn't belong to this project!")
+		}
+	}
+
+	if len(resErr.Errors) == 0 {
+		return nil
+	}
+	resErr.ErrorCode = http.StatusUnprocessableEntity
+	resErr.Message = "Invalid data!"
+	helpers.NewErrorResponse(w, &resErr)
+
+	return errors.New(resErr.Message)
+}
+
+// GetWikiByID try to load wiki of the project from DB. If no item found, returns an error.
+// This function handle http response errors
+func GetWikiByID(w http.ResponseWriter, db *gorm.DB, projectID, wikiID uint64) (wiki models.Wiki, e error) {
+	wiki, e = wiki_repo.GetWikiByID(db, projectID, wikiID)
+	if e != nil {
+		err := helpers.ErrorResponse{}
+		err.ErrorCode = http.StatusNotFound
+		err.Message = "No matching wiki found!"
+		helpers.NewErrorResponse(w, &err)
+
+		return
+	}
+
+	return
+}
+
+// MaxWikiPageContentLength limit size of page content in bytes, revisions of pages are diffed on save
+const MaxWikiPageContentLength = 512 * 1024
+
+// ValidateWikiPageRequestModel will check request data for creating or updating of a wiki page.
+// WikiID of reqModel must be set.
+func ValidateWikiPageRequestModel(w http.ResponseWriter, db *gorm.DB, reqModel models.WikiPage) (err error) {
+	resErr := helpers.ErrorResponse{}
+	resErr.Errors = make(map[string][]string)
+
+	if strings.EqualFold(strings.TrimSpace(reqModel.Title), "") {
+		resErr.Errors["Title"] = append(resErr.Errors["Title"], "Title can't be empty!")
+	} else if len(reqModel.Title) > 255 {
+		resErr.Errors["Title"] = append(resErr.Errors["Title"], "Title can't be longer than 255 characters!")
+	} else if wiki_repo.IsUniquePageTitle(db, reqModel) == false {
+		resErr.Errors["Title"] = append(resErr.Errors["Title"], "A page with this title already exists in the wiki!")
+	}
+
+	if len(reqModel.Content) > MaxWikiPageContentLength {
+		resErr.Errors["Content"] = append(resErr.Errors["Content"], fmt.Sprintf("Content can't be longer than %d KB!", MaxWikiPageContentLength/1024))
+	}
+
+	if len(resErr.Errors) == 0 {
+		return nil
+	}
+	resErr.ErrorCode = http.StatusUnprocessableEntity
+	resErr.Message = "Invalid data!"
+	helpers.NewErrorResponse(w, &resErr)
+
+	return errors.New(resErr.Message)
+}
+
+// GetWikiPageByID try to load a page of the wiki from DB. If no item found, returns an error.
+// This function handle http response errors
+func GetWikiPageByID(w http.ResponseWriter, db *gorm.DB, wikiID, pageID uint64) (page models.WikiPage, e error) {
+	page, e = wiki_repo.GetPageByID(db, wikiID, pageID)
+	if e != nil {
+		err := helpers.ErrorResponse{}
+		err.ErrorCode = http.StatusNotFound
+		err.Message = "No matching page found!"
+		helpers.NewErrorResponse(w, &err)
+
+		return
+	}
+
+	return
+}
+
+// GetWikiPageRevision try to load a revision of the page from DB. If no item found, returns an error.
+// This function handle http response errors
+func GetWikiPageRevision(w http.ResponseWriter, db *gorm.DB, pageID uint64, revisionNumber uint) (revision models.WikiPageRevision, e error) {
+	revision, e = wiki_repo.GetRevision(db, pageID, revisionNumber)
+	if e != nil {
+		err := helpers.ErrorResponse{}
+		err.ErrorCode = http.StatusNotFound
+		err.Message = "No matching revision found!"
+		helpers.NewErrorResponse(w, &err)
+
+		return
+	}
+
+	return
+}
@@ -0,0 +1,234 @@
+package controllers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"devin/database"
+	"devin/helpers"
+	"devin/models"
+	"devin/modules/rw_helpers"
+	"devin/modules/session"
+	session_repo "devin/modules/session/repository"
+)
+
+// SessionController handle refresh of access tokens, signout and sessions of users
+type SessionController struct{}
+
+// refreshTokenFromRequest get the refresh token from `Refresh-Token` header or `RefreshToken` cookie
+func refreshTokenFromRequest(r *http.Request) string {
+	if token := r.Header.Get("Refresh-Token"); token != "" {
+		return token
+	}
+
+	cookie, e := r.Cookie("RefreshToken")
+	if e != nil {
+		return ""
+	}
+
+	return cookie.Value
+}
+
+// unauthorized expire session cookies and write 401 error response
+func unauthorized(w http.ResponseWriter, message string) {
+	rw_helpers.ExpireSessionCookies(w)
+
+	err := helpers.ErrorResponse{
+		ErrorCode: http.StatusUnauthorized,
+		Message:   message,
+	}
+	helpers.NewErrorResponse(w, &err)
+}
+
+// Refresh rotate the refresh token of the session and return a new access token.
+// Reusing an already rotated refresh token revokes the session, since one of its copies is stolen.
+// @Route: /api/token/refresh
+// @Method: POST
+// @Headers: Refresh-Token={refresh token} or RefreshToken cookie
+func (SessionController) Refresh(w http.ResponseWriter, r *http.Request) {
+	sessionID, hash, e := session.ParseRefreshToken(refreshTokenFromRequest(r))
+	if e != nil {
+		unauthorized(w, "Invalid refresh token")
+		return
+	}
+
+	db := database.NewGORMInstance()
+	defer db.Close()
+
+	userSession, e := session_repo.GetSessionByID(db, sessionID)
+	if e != nil || userSession.IsActive(time.Now()) == false {
+		unauthorized(w, models.ErrSessionRevoked.Error())
+		return
+	}
+
+	if hash != userSession.RefreshTokenHash {
+		if userSession.PreviousTokenHash != nil && hash == *userSession.PreviousTokenHash {
+			log.Println("Reused refresh token, revoking session", userSession.ID)
+			session_repo.RevokeSession(db, userSession)
+		}
+		unauthorized(w, "Invalid refresh token")
+		return
+	}
+
+	var user models.User
+	db.Where("id=?", userSession.UserID).First(&user)
+	if user.ID == 0 {
+		unauthorized(w, "Auhtentication failed (User not found).")
+		return
+	}
+
+	refreshToken, newHash, e := session.NewRefreshToken(userSession.ID)
+	if e == nil {
+		e = session_repo.RotateRefreshToken(db, &userSession, newHash)
+	}
+	if e == models.ErrSessionRevoked {
+		unauthorized(w, e.Error())
+		return
+	}
+	if e != nil {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusInternalServerError,
+			Message:   "Fail to refresh token, please try again!",
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+
+	if rw_helpers.SetSessionTokens(w, user, userSession, refreshToken) != nil {
+		return
+	}
+
+	helpers.NewSuccessResponse(w, "Token refreshed.")
+}
+
+// Signout revoke the session of the request and expire its cookies
+// @Route: /api/signout
+// @Method: POST
+func (SessionController) Signout(w http.ResponseWriter, r *http.Request) {
+	authUser, e := rw_helpers.GetAuthenticatedUser(w, r)
+	if e != nil {
+		return
+	}
+
+	db := database.NewGORMInstance()
+	defer db.Close()
+
+	// Tokens issued before sessions have no session to revoke
+	if sessionID := models.CurrentSessionID(r); sessionID != 0 {
+		e = session_repo.RevokeSession(db, models.Session{ID: sessionID, UserID: authUser.ID})
+		if e != nil {
+			err := helpers.ErrorResponse{
+				ErrorCode: http.StatusInternalServerError,
+				Message:   "Fail to sign out, please try again!",
+			}
+			helpers.NewErrorResponse(w, &err)
+			return
+		}
+	}
+
+	rw_helpers.ExpireSessionCookies(w)
+	helpers.NewSuccessResponse(w, "Signed out.")
+}
+
+// SignoutEverywhere revoke all sessions of the authenticated user, including the current one
+// @Route: /api/signout/everywhere
+// @Method: POST
+func (SessionController) SignoutEverywhere(w http.ResponseWriter, r *http.Request) {
+	authUser, e := rw_helpers.GetAuthenticatedUser(w, r)
+	if e != nil {
+		return
+	}
+
+	db := database.NewGORMInstance()
+	defer db.Close()
+
+	e = session_repo.RevokeSessionsOfUser(db, authUser.ID, 0)
+	if e != nil {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusInternalServerError,
+			Message:   "Fail to sign out, please try again!",
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+
+	rw_helpers.ExpireSessionCookies(w)
+	helpers.NewSuccessResponse(w, "Signed out of all sessions.")
+}
+
+// SessionsIndex return active sessions of the authenticated user with device, IP and last seen time
+// @Route: /api/sessions
+// @Method: GET
+func (SessionController) SessionsIndex(w http.ResponseWriter, r *http.Request) {
+	authUser, e := rw_helpers.GetAuthenticatedUser(w, r)
+	if e != nil {
+		return
+	}
+
+	db := database.NewGORMInstance()
+	defer db.Close()
+
+	data, e := session_repo.GetActiveSessionsOfUser(db, authUser.ID)
+	if e != nil {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusInternalServerError,
+			Message:   "Fail to load sessions",
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+
+	currentID := models.CurrentSessionID(r)
+	for i := range data {
+		data[i].Current = data[i].ID == currentID
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&data)
+}
+
+// Revoke revoke a session of the authenticated user, e.g a session on a lost device
+// @Route: /api/session/{id}/revoke
+// @Method: POST
+func (SessionController) Revoke(w http.ResponseWriter, r *http.Request) {
+	authUser, e := rw_helpers.GetAuthenticatedUser(w, r)
+	if e != nil {
+		return
+	}
+
+	sessionID, e := rw_helpers.ExtractIDFromURL(w, r)
+	if e != nil {
+		return
+	}
+
+	db := database.NewGORMInstance()
+	defer db.Close()
+
+	userSession, e := session_repo.GetSessionOfUser(db, authUser.ID, sessionID)
+	if e != nil {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusNotFound,
+			Message:   "No matching session found!",
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+
+	e = session_repo.RevokeSession(db, userSession)
+	if e != nil {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusInternalServerError,
+			Message:   "Fail to revoke session",
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+
+	if userSession.ID == models.CurrentSessionID(r) {
+		rw_helpers.ExpireSessionCookies(w)
+	}
+
+	helpers.NewSuccessResponse(w, "Session revoked.")
+}
@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/jinzhu/gorm"
+
+	"devin/models"
+)
+
+// ErrSessionNotFound returned when the session is not found in sessions of the user
+var ErrSessionNotFound = errors.New("Session not found")
+
+// CreateSession insert the session
+func CreateSession(db *gorm.DB, session *models.Session) error {
+	return db.Set("gorm:save_associations", false).Create(session).Error
+}
+
+// GetSessionByID load a session with any state
+func GetSessionByID(db *gorm.DB, ID uint64) (session models.Session, e error) {
+	db.Where("id=?", ID).First(&session)
+	if session.ID == 0 {
+		e = ErrSessionNotFound
+	}
+
+	return
+}
+
+// GetSessionOfUser load a session of the user with any state
+func GetSessionOfUser(db *gorm.DB, userID, ID uint64) (session models.Session, e error) {
+	db.Where("id=? AND user_id=?", ID, userID).First(&session)
+	if session.ID == 0 {
+		e = ErrSessionNotFound
+	}
+
+	return
+}
+
+// GetActiveSessionsOfUser load sessions of the user which are neither revoked nor expired, last seen first
+func GetActiveSessionsOfUser(db *gorm.DB, userID uint64) (data []models.Session, e error) {
+	e = db.Where("user_id=? AND revoked_at IS NULL AND expires_at>?", userID, time.Now()).
+		Order("last_seen_at DESC, id DESC").
+		Find(&data).
+		Error
+
+	return
+}
+
+// SetRefreshTokenHash set hash of the first refresh token of a new session
+func SetRefreshTokenHash(db *gorm.DB, session *models.Session, hash string) error {
+	session.RefreshTokenHash = hash
+	return db.Model(&models.Session{}).Where("id=?", session.ID).UpdateColumn("refresh_token_hash", hash).Error
+}
+
+// RotateRefreshToken replace the refresh token of the session and extend its expiration.
+// It fails with models.ErrSessionRevoked when the session is revoked or its token
+// is rotated by a concurrent request meanwhile.
+func RotateRefreshToken(db *gorm.DB, session *models.Session, hash string) error {
+	now := time.Now()
+	expiresAt := now.Add(session.RefreshTokenLifetime())
+	res := db.Model(&models.Session{}).
+		Where("id=? AND refresh_token_hash=? AND revoked_at IS NULL", session.ID, session.RefreshTokenHash).
+		UpdateColumns(map[string]interface{}{
+			"previous_token_hash": session.RefreshTokenHash,
+			"refresh_token_hash":  hash,
+			"expires_at":          expiresAt,
+			"last_seen_at":        now,
+			"updated_at":          now,
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return models.ErrSessionRevoked
+	}
+
+	previous := session.RefreshTokenHash
+	session.PreviousTokenHash = &previous
+	session.RefreshTokenHash = hash
+	session.ExpiresAt = expiresAt
+	session.LastSeenAt = now
+
+	return nil
+}
+
+// RevokeSession revoke the session, its access and refresh tokens are rejected afterward
+func RevokeSession(db *gorm.DB, session models.Session) error {
+	return db.Model(&models.Session{}).
+		Where("id=? AND revoked_at IS NULL", session.ID).
+		UpdateColumns(map[string]interface{}{"revoked_at": time.Now(), "updated_at": time.Now()}).
+		Error
+}
+
+// RevokeSessionsOfUser revoke all sessions of the user except the session with exceptID,
+// set exceptID to 0 to revoke all of them
+func RevokeSessionsOfUser(db *gorm.DB, userID, exceptID uint64) error {
+	return db.Model(&models.Session{}).
+		Where("user_id=? AND id<>? AND revoked_at IS NULL", userID, exceptID).
+		UpdateColumns(map[string]interface{}{"revoked_at": time.Now(), "updated_at": time.Now()}).
+		Error
+}
@@ -0,0 +1,136 @@
+// Package session create sessions of signed in users and their rotating refresh tokens
+package session
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"devin/models"
+	"devin/modules/audit"
+)
+
+// ErrInvalidRefreshToken returned when the refresh token is malformed
+var ErrInvalidRefreshToken = errors.New("Invalid refresh token")
+
+// secretLength is the number of random bytes of refresh tokens
+const secretLength = 32
+
+// maxUserAgentLength is the size of user_agent column
+const maxUserAgentLength = 255
+
+// browsers and systems are checked in order, the first match names the device
+var browsers = []struct{ token, name string }{
+	{"Edge/", "Edge"},
+	{"OPR/", "Opera"},
+	{"Firefox/", "Firefox"},
+	{"Chrome/", "Chrome"},
+	{"Safari/", "Safari"},
+	{"MSIE ", "Internet Explorer"},
+	{"Trident/", "Internet Explorer"},
+	{"curl/", "curl"},
+}
+
+var systems = []struct{ token, name string }{
+	{"Android", "Android"},
+	{"iPhone", "iOS"},
+	{"iPad", "iOS"},
+	{"Windows", "Windows"},
+	{"Mac OS X", "macOS"},
+	{"Linux", "Linux"},
+}
+
+// New create a session of the user signed in through the request with a new refresh token.
+// The session must be saved before generating the token with NewRefreshToken.
+func New(r *http.Request, user models.User) models.Session {
+	now := time.Now()
+	session := models.Session{
+		UserID:     user.ID,
+		Device:     DeviceName(r.UserAgent()),
+		UserAgent:  r.UserAgent(),
+		IP:         audit.ClientIP(r),
+		LastSeenAt: now,
+	}
+	session.ExpiresAt = now.Add(session.RefreshTokenLifetime())
+	if ua := []rune(session.UserAgent); len(ua) > maxUserAgentLength {
+		session.UserAgent = string(ua[:maxUserAgentLength])
+	}
+
+	return session
+}
+
+// NewRefreshToken generate a refresh token of the session and hash of its secret.
+// Token is "<session ID>.<secret>", only the hash of the secret is stored.
+func NewRefreshToken(sessionID uint64) (token, hash string, e error) {
+	bts := make([]byte, secretLength)
+	_, e = rand.Read(bts)
+	if e != nil {
+		return
+	}
+
+	secret := hex.EncodeToString(bts)
+	token = fmt.Sprintf("%d.%s", sessionID, secret)
+	hash = HashSecret(secret)
+
+	return
+}
+
+// ParseRefreshToken split the refresh token to ID of its session and the hash of its secret
+func ParseRefreshToken(token string) (sessionID uint64, hash string, e error) {
+	parts := strings.SplitN(strings.TrimSpace(token), ".", 2)
+	if len(parts) != 2 || len(parts[1]) != 2*secretLength {
+		e = ErrInvalidRefreshToken
+		return
+	}
+
+	sessionID, e = strconv.ParseUint(parts[0], 10, 64)
+	if e != nil || sessionID == 0 {
+		e = ErrInvalidRefreshToken
+		return
+	}
+	hash = HashSecret(parts[1])
+
+	return
+}
+
+// HashSecret return hex encoded SHA-256 hash of the secret of a refresh token
+func HashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// DeviceName return a readable name of the browser and OS of the user agent, e.g 'Firefox on Linux'
+func DeviceName(userAgent string) string {
+	browser := ""
+	for _, b := range browsers {
+		if strings.Contains(userAgent, b.token) {
+			browser = b.name
+			break
+		}
+	}
+
+	system := ""
+	for _, s := range systems {
+		if strings.Contains(userAgent, s.token) {
+			system = s.name
+			break
+		}
+	}
+
+	switch {
+	case browser != "" && system != "":
+		return browser + " on " + system
+	case browser != "":
+		return browser
+	case system != "":
+		return system
+	}
+
+	return "Unknown device"
+}
@@ -0,0 +1,73 @@
+package session
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"devin/models"
+)
+
+func TestNew(t *testing.T) {
+	r := httptest.NewRequest("POST", "/api/signin", nil)
+	r.RemoteAddr = "10.0.0.5:51234"
+	r.Header.Set("User-Agent", "Mozilla/5.0 (X11; Linux x86_64) Firefox/60.0"+strings.Repeat("a", 300))
+
+	session := New(r, models.User{ID: 7})
+	if session.UserID != 7 || session.IP != "10.0.0.5" || session.Device != "Firefox on Linux" {
+		t.Fatal("Invalid session", session)
+	}
+	if len(session.UserAgent) != maxUserAgentLength {
+		t.Fatal("User agent must be truncated", len(session.UserAgent))
+	}
+	if session.IsActive(time.Now()) {
+		t.Fatal("Unsaved session must not be active")
+	}
+	session.ID = 1
+	if session.IsActive(time.Now()) == false || session.IsActive(time.Now().Add(31*24*time.Hour)) {
+		t.Fatal("Session must be active until its refresh token expires")
+	}
+}
+
+func TestRefreshToken(t *testing.T) {
+	token, hash, e := NewRefreshToken(42)
+	if e != nil {
+		t.Fatal(e)
+	}
+	if strings.HasPrefix(token, "42.") == false || strings.Contains(token, hash) {
+		t.Fatal("Invalid token", token)
+	}
+
+	sessionID, parsedHash, e := ParseRefreshToken(token)
+	if e != nil || sessionID != 42 || parsedHash != hash {
+		t.Fatal("Fail to parse token", sessionID, parsedHash, e)
+	}
+
+	other, _, _ := NewRefreshToken(42)
+	if other == token {
+		t.Fatal("Tokens must be random")
+	}
+
+	for _, bad := range []string{"", "42", "42.abc", "x." + token[3:], "0." + token[3:]} {
+		if _, _, e := ParseRefreshToken(bad); e != ErrInvalidRefreshToken {
+			t.Fatal("Expected invalid token error for", bad)
+		}
+	}
+}
+
+func TestDeviceName(t *testing.T) {
+	cases := map[string]string{
+		"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 Chrome/67.0 Safari/537.36":            "Chrome on Windows",
+		"Mozilla/5.0 (iPhone; CPU iPhone OS 11_4 like Mac OS X) AppleWebKit/605.1.15 Safari/604.1":          "Safari on iOS",
+		"Mozilla/5.0 (Linux; Android 8.0) AppleWebKit/537.36 Chrome/67.0 Mobile Safari/537.36":              "Chrome on Android",
+		"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_13) AppleWebKit/537.36 Chrome/67.0 Safari/537.36 OPR/54": "Opera on macOS",
+		"curl/7.58.0": "curl",
+		"":            "Unknown device",
+	}
+	for ua, expected := range cases {
+		if name := DeviceName(ua); name != expected {
+			t.Fatal("Expected", expected, "got", name, "for", ua)
+		}
+	}
+}
@@ -0,0 +1,247 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"devin/database"
+	"devin/helpers"
+	"devin/models"
+	"devin/modules/rw_helpers"
+	task_repo "devin/modules/task/repository"
+)
+
+// BoardController handle functionalities of task boards (kanban view)
+type BoardController struct{}
+
+type moveTaskReqModel struct {
+	TaskBoardID *uint64 `doc:"Target board. Null moves the task out of the boards"`
+	OrderID     int     `doc:"1 based position of the task in the target board"`
+}
+
+// BoardsIndex return all boards of the project sorted by their order, each with its ordered tasks
+// @Route: /api/project/{project_id}/boards
+// @Method: GET
+func (BoardController) BoardsIndex(w http.ResponseWriter, r *http.Request) {
+	authUser, e := rw_helpers.GetAuthenticatedUser(w, r)
+	if e != nil {
+		return
+	}
+
+	db := database.NewGORMInstance()
+	defer db.Close()
+
+	project, e := rw_helpers.LoadProjectForModule(w, r, db, authUser, models.MODULE_TASK)
+	if e != nil {
+		return
+	}
+
+	boards, e := task_repo.GetBoardsOfProject(db, project.ID, true)
+	if e != nil {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusInternalServerError,
+			Message:   "Fail to load boards",
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&boards)
+}
+
+// Show return a board with its tasks sorted by OrderID
+// @Route: /api/project/{project_id}/board/{id}
+// @Method: GET
+func (BoardController) Show(w http.ResponseWriter, r *http.Request) {
+	authUser, e := rw_helpers.GetAuthenticatedUser(w, r)
+	if e != nil {
+		return
+	}
+
+	boardID, e := rw_helpers.ExtractIDFromURL(w, r)
+	if e != nil {
+		return
+	}
+
+	db := database.NewGORMInstance()
+	defer db.Close()
+
+	project, e := rw_helpers.LoadProjectForModule(w, r, db, authUser, models.MODULE_TASK)
+	if e != nil {
+		return
+	}
+
+	board, e := rw_helpers.GetBoardByID(w, db, project.ID, boardID)
+	if e != nil {
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&board)
+}
+
+// Save handle inserting and updating of a board (column)
+// If no ID present in the request model, it will insert as new board
+// otherwise the given board will be updated
+// @Route: /api/project/{project_id}/boards/save
+// @Method: POST
+// @Content-Type: application/json
+func (BoardController) Save(w http.ResponseWriter, r *http.Request) {
+	if rw_helpers.IsJSONRequest(w, r) == false {
+		return
+	}
+
+	authUser, e := rw_helpers.GetAuthenticatedUser(w, r)
+	if e != nil {
+		return
+	}
+
+	if helpers.IsRequestBodyNil(w, r) {
+		return
+	}
+
+	var reqModel models.TaskBoard
+	e = json.NewDecoder(r.Body).Decode(&reqModel)
+	if e != nil {
+		err := helpers.ErrorResponse{
+			Message:   "Invalid request!",
+			ErrorCode: http.StatusBadRequest,
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+	defer r.Body.Close()
+
+	if strings.EqualFold(strings.TrimSpace(reqModel.Name), "") {
+		err := helpers.ErrorResponse{
+			Message:   "Invalid data!",
+			ErrorCode: http.StatusUnprocessableEntity,
+		}
+		err.Errors = make(map[string][]string)
+		err.Errors["Name"] = []string{"Name can't be empty!"}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+
+	db := database.NewGORMInstance()
+	defer db.Close()
+
+	project, e := rw_helpers.LoadProjectForModule(w, r, db, authUser, models.MODULE_TASK)
+	if e != nil {
+		return
+	}
+
+	if rw_helpers.CanCreateBoard(w, db, authUser, project) == false {
+		return
+	}
+
+	board := models.TaskBoard{}
+	if reqModel.ID != 0 {
+		// Edit mode
+		board, e = rw_helpers.GetBoardByID(w, db, project.ID, reqModel.ID)
+		if e != nil {
+			return
+		}
+		board.Tasks = nil
+	} else {
+		board.ProjectID = project.ID
+		board.CreatedByID = authUser.ID
+	}
+	board.Name = reqModel.Name
+	board.Color = reqModel.Color
+	board.OrderID = reqModel.OrderID
+
+	e = task_repo.SaveBoard(db, &board)
+	if e != nil {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusInternalServerError,
+			Message:   "Fail to save board",
+		}
+		err.Errors = make(map[string][]string)
+		err.Errors["dev"] = []string{e.Error()}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&board)
+}
+
+// MoveTask move a task to a board at the given position (drag and drop).
+// Order of tasks in both source and target boards re-numbered atomically.
+// @Route: /api/project/{project_id}/task/{id}/move
+// @Method: POST
+// @Content-Type: application/json
+func (BoardController) MoveTask(w http.ResponseWriter, r *http.Request) {
+	if rw_helpers.IsJSONRequest(w, r) == false {
+		return
+	}
+
+	authUser, e := rw_helpers.GetAuthenticatedUser(w, r)
+	if e != nil {
+		return
+	}
+
+	taskID, e := rw_helpers.ExtractIDFromURL(w, r)
+	if e != nil {
+		return
+	}
+
+	if helpers.IsRequestBodyNil(w, r) {
+		return
+	}
+
+	var reqModel moveTaskReqModel
+	e = json.NewDecoder(r.Body).Decode(&reqModel)
+	if e != nil {
+		err := helpers.ErrorResponse{
+			Message:   "Invalid request!",
+			ErrorCode: http.StatusBadRequest,
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+	defer r.Body.Close()
+
+	db := database.NewGORMInstance()
+	defer db.Close()
+
+	project, e := rw_helpers.LoadProjectForModule(w, r, db, authUser, models.MODULE_TASK)
+	if e != nil {
+		return
+	}
+
+	if rw_helpers.CanCreateTask(w, db, authUser, project) == false {
+		return
+	}
+
+	_, e = rw_helpers.GetTaskByID(w, db, project.ID, taskID)
+	if e != nil {
+		return
+	}
+
+	e = task_repo.MoveTask(db, project.ID, taskID, reqModel.TaskBoardID, reqModel.OrderID)
+	if e != nil {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusInternalServerError,
+			Message:   "Fail to move task",
+		}
+		if e == task_repo.ErrBoardNotFound {
+			err.ErrorCode = http.StatusUnprocessableEntity
+			err.Errors = make(map[string][]string)
+			err.Errors["TaskBoardID"] = []string{"Selected board doesn't belong to this project!"}
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+
+	task, e := rw_helpers.GetTaskByID(w, db, project.ID, taskID)
+	if e != nil {
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&task)
+}
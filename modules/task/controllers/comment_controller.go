@@ -0,0 +1,243 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/jinzhu/gorm"
+
+	"devin/database"
+	"devin/helpers"
+	"devin/markdown"
+	"devin/models"
+	"devin/modules/notification/mention"
+	project_repo "devin/modules/project/repository"
+	"devin/modules/rw_helpers"
+	task_repo "devin/modules/task/repository"
+)
+
+type commentReqModel struct {
+	ReplyToID *uint64
+	Comment   string
+}
+
+// loadTask load project and the task given in URL
+func loadTask(w http.ResponseWriter, r *http.Request, db *gorm.DB, authUser models.User) (project models.Project, task models.Task, e error) {
+	taskID, e := rw_helpers.ExtractIDFromURL(w, r)
+	if e != nil {
+		return
+	}
+
+	project, e = rw_helpers.LoadProjectForModule(w, r, db, authUser, models.MODULE_TASK)
+	if e != nil {
+		return
+	}
+
+	task, e = rw_helpers.GetTaskByID(w, db, project.ID, taskID)
+
+	return
+}
+
+// CommentsIndex return paginated comments of the task
+// @Route: /api/project/{project_id}/task/{id}/comments
+// @Method: GET
+func (TaskController) CommentsIndex(w http.ResponseWriter, r *http.Request) {
+	authUser, e := rw_helpers.GetAuthenticatedUser(w, r)
+	if e != nil {
+		return
+	}
+
+	perPage := rw_helpers.GetPerPage(r)
+	currentPage := rw_helpers.GetCurrectpage(r)
+
+	db := database.NewGORMInstance()
+	defer db.Close()
+
+	project, task, e := loadTask(w, r, db, authUser)
+	if e != nil {
+		return
+	}
+
+	data, total, e := task_repo.GetComments(db, task.ID, currentPage, perPage)
+	if e != nil {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusInternalServerError,
+			Message:   "Fail to load comments",
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+	resolver := markdown.NewProjectResolver(db, project)
+	for i := range data {
+		data[i].CommentHTML = markdown.Render(data[i].Comment, resolver)
+	}
+
+	var pgn models.Pagination
+	pgn.Make(data, total, currentPage, perPage)
+
+	w.Header().Add("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&pgn)
+}
+
+// AddComment add a comment to the task. Only members of the project can comment.
+// Mentioned members are notified and added to followers of the task.
+// @Route: /api/project/{project_id}/task/{id}/comments/add
+// @Method: POST
+// @Content-Type: application/json
+func (TaskController) AddComment(w http.ResponseWriter, r *http.Request) {
+	if rw_helpers.IsJSONRequest(w, r) == false {
+		return
+	}
+
+	authUser, e := rw_helpers.GetAuthenticatedUser(w, r)
+	if e != nil {
+		return
+	}
+
+	if helpers.IsRequestBodyNil(w, r) {
+		return
+	}
+
+	var reqModel commentReqModel
+	e = json.NewDecoder(r.Body).Decode(&reqModel)
+	if e != nil {
+		err := helpers.ErrorResponse{
+			Message:   "Invalid request!",
+			ErrorCode: http.StatusBadRequest,
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+	defer r.Body.Close()
+
+	if strings.EqualFold(strings.TrimSpace(reqModel.Comment), "") {
+		err := helpers.ErrorResponse{
+			Message:   "Invalid data!",
+			ErrorCode: http.StatusUnprocessableEntity,
+		}
+		err.Errors = make(map[string][]string)
+		err.Errors["Comment"] = []string{"Comment can't be empty!"}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+
+	db := database.NewGORMInstance()
+	defer db.Close()
+
+	project, task, e := loadTask(w, r, db, authUser)
+	if e != nil {
+		return
+	}
+
+	if authUser.IsRootUser == false && project_repo.IsProjectMember(db, project, authUser.ID) == false {
+		err := helpers.ErrorResponse{
+			Message:   "User is not a member of this project!",
+			ErrorCode: http.StatusUnprocessableEntity,
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+
+	if helpers.IsNilUint64(reqModel.ReplyToID) {
+		reqModel.ReplyToID = nil
+	} else if _, e = task_repo.GetCommentByID(db, task.ID, *reqModel.ReplyToID); e != nil {
+		err := helpers.ErrorResponse{
+			Message:   "Invalid data!",
+			ErrorCode: http.StatusUnprocessableEntity,
+		}
+		err.Errors = make(map[string][]string)
+		err.Errors["ReplyToID"] = []string{"Replied comment not found in this task!"}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+
+	comment := models.TaskComment{
+		TaskID:      task.ID,
+		ReplyToID:   reqModel.ReplyToID,
+		Comment:     reqModel.Comment,
+		CreatedByID: authUser.ID,
+	}
+
+	// Mentions and notifications are saved with the comment, or none of them
+	tx := db.Begin()
+	e = task_repo.SaveComment(tx, &comment)
+	if e == nil {
+		e = mention.Process(tx, project, mention.Comment{
+			ModuleID:  models.MODULE_TASK,
+			ObjectID:  task.ID,
+			CommentID: comment.ID,
+			AuthorID:  authUser.ID,
+			Text:      comment.Comment,
+		})
+	}
+	if e == nil {
+		e = tx.Commit().Error
+	} else {
+		tx.Rollback()
+	}
+
+	if e != nil {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusInternalServerError,
+			Message:   "Fail to save comment",
+		}
+		err.Errors = make(map[string][]string)
+		err.Errors["dev"] = []string{e.Error()}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+	comment.CommentHTML = markdown.Render(comment.Comment, markdown.NewProjectResolver(db, project))
+
+	w.Header().Add("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&comment)
+}
+
+// DeleteComment soft delete a comment of the task
+// @Route: /api/project/{project_id}/task/{id}/comment/{comment_id}/delete
+// @Method: POST
+func (TaskController) DeleteComment(w http.ResponseWriter, r *http.Request) {
+	authUser, e := rw_helpers.GetAuthenticatedUser(w, r)
+	if e != nil {
+		return
+	}
+
+	commentID, e := rw_helpers.ExtractCommentIDFromURL(w, r, "comment_id")
+	if e != nil {
+		return
+	}
+
+	db := database.NewGORMInstance()
+	defer db.Close()
+
+	project, task, e := loadTask(w, r, db, authUser)
+	if e != nil {
+		return
+	}
+
+	comment, e := task_repo.GetCommentByID(db, task.ID, commentID)
+	if e != nil {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusNotFound,
+			Message:   "No matching comment found!",
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+
+	if rw_helpers.CanDeleteTaskComment(w, db, authUser, project, comment) == false {
+		return
+	}
+
+	e = task_repo.DeleteComment(db, comment)
+	if e != nil {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusInternalServerError,
+			Message:   "Fail to delete comment",
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+
+	helpers.NewSuccessResponse(w, "Comment deleted.")
+}
@@ -0,0 +1,214 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"devin/database"
+	"devin/helpers"
+	"devin/models"
+	"devin/modules/rw_helpers"
+	"devin/modules/task/graph"
+	task_repo "devin/modules/task/repository"
+)
+
+type prerequisiteReqModel struct {
+	PrerequisiteID uint64
+}
+
+// AddPrerequisite add a task as prerequisite of the given task.
+// Requests which make a cycle in the dependency graph are rejected.
+// @Route: /api/project/{project_id}/task/{id}/prerequisites/add
+// @Method: POST
+// @Content-Type: application/json
+func (TaskController) AddPrerequisite(w http.ResponseWriter, r *http.Request) {
+	if rw_helpers.IsJSONRequest(w, r) == false {
+		return
+	}
+
+	authUser, e := rw_helpers.GetAuthenticatedUser(w, r)
+	if e != nil {
+		return
+	}
+
+	taskID, e := rw_helpers.ExtractIDFromURL(w, r)
+	if e != nil {
+		return
+	}
+
+	if helpers.IsRequestBodyNil(w, r) {
+		return
+	}
+
+	var reqModel prerequisiteReqModel
+	e = json.NewDecoder(r.Body).Decode(&reqModel)
+	if e != nil {
+		err := helpers.ErrorResponse{
+			Message:   "Invalid request!",
+			ErrorCode: http.StatusBadRequest,
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+	defer r.Body.Close()
+
+	db := database.NewGORMInstance()
+	defer db.Close()
+
+	project, e := rw_helpers.LoadProjectForModule(w, r, db, authUser, models.MODULE_TASK)
+	if e != nil {
+		return
+	}
+
+	if rw_helpers.CanCreateTask(w, db, authUser, project) == false {
+		return
+	}
+
+	task, e := rw_helpers.GetTaskByID(w, db, project.ID, taskID)
+	if e != nil {
+		return
+	}
+
+	// Prerequisite must be a task of the same project
+	_, e = rw_helpers.GetTaskByID(w, db, project.ID, reqModel.PrerequisiteID)
+	if e != nil {
+		return
+	}
+
+	prerequisite, e := task_repo.AddPrerequisite(db, task, reqModel.PrerequisiteID, authUser.ID)
+	if e != nil {
+		err := helpers.ErrorResponse{
+			Message:   "Fail to add prerequisite",
+			ErrorCode: http.StatusUnprocessableEntity,
+		}
+		err.Errors = make(map[string][]string)
+		switch e {
+		case graph.ErrCycle:
+			err.Errors["PrerequisiteID"] = []string{"This prerequisite makes a cycle in the dependencies of tasks"}
+		case task_repo.ErrDuplicatePrerequisite:
+			err.Errors["PrerequisiteID"] = []string{e.Error()}
+		default:
+			err.ErrorCode = http.StatusInternalServerError
+			err.Errors["dev"] = []string{e.Error()}
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&prerequisite)
+}
+
+// RemovePrerequisite remove a prerequisite of the given task
+// @Route: /api/project/{project_id}/task/{id}/prerequisite/{prerequisite_id}/remove
+// @Method: POST
+func (TaskController) RemovePrerequisite(w http.ResponseWriter, r *http.Request) {
+	authUser, e := rw_helpers.GetAuthenticatedUser(w, r)
+	if e != nil {
+		return
+	}
+
+	taskID, e := rw_helpers.ExtractIDFromURL(w, r)
+	if e != nil {
+		return
+	}
+
+	prerequisiteID, e := rw_helpers.ExtractTaskIDFromURL(w, r, "prerequisite_id")
+	if e != nil {
+		return
+	}
+
+	db := database.NewGORMInstance()
+	defer db.Close()
+
+	project, e := rw_helpers.LoadProjectForModule(w, r, db, authUser, models.MODULE_TASK)
+	if e != nil {
+		return
+	}
+
+	if rw_helpers.CanCreateTask(w, db, authUser, project) == false {
+		return
+	}
+
+	task, e := rw_helpers.GetTaskByID(w, db, project.ID, taskID)
+	if e != nil {
+		return
+	}
+
+	e = task_repo.RemovePrerequisite(db, task.ID, prerequisiteID)
+	if e != nil {
+		err := helpers.ErrorResponse{
+			Message:   "Fail to remove prerequisite",
+			ErrorCode: http.StatusInternalServerError,
+		}
+		if e == task_repo.ErrPrerequisiteNotFound {
+			err.ErrorCode = http.StatusNotFound
+			err.Message = e.Error()
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+
+	helpers.NewSuccessResponse(w, "Prerequisite removed.")
+}
+
+// DependencyGraph return tasks of the project in topological order
+// with the critical path calculated from estimated times and scheduled dates
+// @Route: /api/project/{project_id}/tasks/dependency_graph
+// @Method: GET
+func (TaskController) DependencyGraph(w http.ResponseWriter, r *http.Request) {
+	authUser, e := rw_helpers.GetAuthenticatedUser(w, r)
+	if e != nil {
+		return
+	}
+
+	db := database.NewGORMInstance()
+	defer db.Close()
+
+	project, e := rw_helpers.LoadProjectForModule(w, r, db, authUser, models.MODULE_TASK)
+	if e != nil {
+		return
+	}
+
+	g, e := task_repo.BuildProjectGraph(db, project.ID)
+	if e != nil {
+		err := helpers.ErrorResponse{
+			Message:   "Fail to load tasks",
+			ErrorCode: http.StatusInternalServerError,
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+
+	schedule, e := g.CriticalPath(projectStartDate(project, g))
+	if e != nil {
+		err := helpers.ErrorResponse{
+			Message:   e.Error(),
+			ErrorCode: http.StatusConflict,
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&schedule)
+}
+
+// projectStartDate find the start point of scheduling.
+// Real start date of the project has priority over its scheduled start date.
+func projectStartDate(project models.Project, g *graph.Graph) time.Time {
+	if project.StartDate != nil {
+		return *project.StartDate
+	}
+
+	if project.ScheduledStartDate != nil {
+		return *project.ScheduledStartDate
+	}
+
+	if earliest := g.EarliestScheduledStart(); earliest != nil {
+		return *earliest
+	}
+
+	return time.Now()
+}
@@ -0,0 +1,273 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/jinzhu/gorm"
+
+	"devin/database"
+	"devin/helpers"
+	"devin/markdown"
+	"devin/models"
+	"devin/modules/authorizer"
+	"devin/modules/rw_helpers"
+	task_repo "devin/modules/task/repository"
+)
+
+// TaskController handle functionalities of Task
+type TaskController struct{}
+
+// loadProjectOfTask extract project ID from URL, load the project and check the authenticated user
+// to read the given task, users with read permission on the task don't need access to the project.
+// If taskID is zero, access to tasks module of the project is checked.
+func loadProjectOfTask(w http.ResponseWriter, r *http.Request, db *gorm.DB, authUser models.User, taskID uint64) (project models.Project, e error) {
+	if taskID == 0 {
+		return rw_helpers.LoadProjectForModule(w, r, db, authUser, models.MODULE_TASK)
+	}
+
+	projectID, e := rw_helpers.ExtractProjectIDFromURL(w, r, "project_id")
+	if e != nil {
+		return
+	}
+
+	project, e = rw_helpers.GetProjectByID(w, db, projectID)
+	if e != nil {
+		return
+	}
+
+	permission := authorizer.Permission{Action: authorizer.ActionRead, ModuleID: models.MODULE_TASK, ObjectID: taskID}
+	if rw_helpers.Authorize(w, db, authUser, project, permission) == false {
+		e = rw_helpers.ErrProjectAccessDenied
+		return
+	}
+
+	if rw_helpers.IsTasksModuleEnabled(w, project) == false {
+		e = rw_helpers.ErrProjectAccessDenied
+		return
+	}
+
+	return
+}
+
+// renderTask render markdown description and comments of the task to html
+func renderTask(db *gorm.DB, project models.Project, task *models.Task) {
+	resolver := markdown.NewProjectResolver(db, project)
+	task.DescriptionHTML = markdown.RenderPtr(task.Description, resolver)
+	for i := range task.Comments {
+		task.Comments[i].CommentHTML = markdown.Render(task.Comments[i].Comment, resolver)
+	}
+}
+
+// TasksIndex return paginated list of tasks of the project
+// @Route: /api/project/{project_id}/tasks?q={json encoded models.TaskSearch}
+// @Method: GET
+func (TaskController) TasksIndex(w http.ResponseWriter, r *http.Request) {
+	authUser, e := rw_helpers.GetAuthenticatedUser(w, r)
+	if e != nil {
+		return
+	}
+
+	searchModel, e := rw_helpers.DecodeTaskSearchFilters(w, r)
+	if e != nil {
+		return
+	}
+
+	searchModel.PerPage = rw_helpers.GetPerPage(r)
+	searchModel.CurrentPage = rw_helpers.GetCurrectpage(r)
+
+	db := database.NewGORMInstance()
+	defer db.Close()
+
+	project, e := rw_helpers.LoadProjectForModule(w, r, db, authUser, models.MODULE_TASK)
+	if e != nil {
+		return
+	}
+	searchModel.ProjectID = project.ID
+
+	data, total, e := task_repo.SearchTasks(db, searchModel)
+	if e != nil {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusInternalServerError,
+			Message:   "Fail to load tasks",
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+
+	var pgn models.Pagination
+	pgn.Make(data, total, searchModel.CurrentPage, searchModel.PerPage)
+
+	w.Header().Add("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&pgn)
+}
+
+// Show return details of a single task
+// @Route: /api/project/{project_id}/task/{id}
+// @Method: GET
+func (TaskController) Show(w http.ResponseWriter, r *http.Request) {
+	authUser, e := rw_helpers.GetAuthenticatedUser(w, r)
+	if e != nil {
+		return
+	}
+
+	taskID, e := rw_helpers.ExtractIDFromURL(w, r)
+	if e != nil {
+		return
+	}
+
+	db := database.NewGORMInstance()
+	defer db.Close()
+
+	project, e := loadProjectOfTask(w, r, db, authUser, taskID)
+	if e != nil {
+		return
+	}
+
+	task, e := rw_helpers.GetTaskByID(w, db, project.ID, taskID)
+	if e != nil {
+		return
+	}
+	renderTask(db, project, &task)
+
+	w.Header().Add("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&task)
+}
+
+// Save handle inserting and updating of task
+// Request body is json encoded of task model
+// If no ID present in the request model, it will insert as new task
+// otherwise the given task will be updated
+// @Route: /api/project/{project_id}/tasks/save
+// @Method: POST
+// @Content-Type: application/json
+func (TaskController) Save(w http.ResponseWriter, r *http.Request) {
+	if rw_helpers.IsJSONRequest(w, r) == false {
+		return
+	}
+
+	authUser, e := rw_helpers.GetAuthenticatedUser(w, r)
+	if e != nil {
+		return
+	}
+
+	reqModel, e := rw_helpers.DecodeTaskRequestModel(w, r)
+	if e != nil {
+		return
+	}
+	defer r.Body.Close()
+
+	db := database.NewGORMInstance()
+	defer db.Close()
+
+	project, e := loadProjectOfTask(w, r, db, authUser, reqModel.ID)
+	if e != nil {
+		return
+	}
+
+	if reqModel.ID == 0 && rw_helpers.CanCreateTask(w, db, authUser, project) == false {
+		return
+	}
+
+	reqModel.ProjectID = project.ID
+	if rw_helpers.ValidateTaskRequestModel(w, db, reqModel) != nil {
+		return
+	}
+
+	task := models.Task{}
+	if reqModel.ID != 0 {
+		// Edit mode
+		task, e = rw_helpers.GetTaskByID(w, db, project.ID, reqModel.ID)
+		if e != nil {
+			return
+		}
+
+		if rw_helpers.CanUpdateTask(w, db, authUser, project, task) == false {
+			return
+		}
+	} else {
+		task.ProjectID = project.ID
+		task.CreatedByID = authUser.ID
+	}
+	fillTaskEditableFields(&task, reqModel)
+
+	e = task_repo.SaveTask(db, &task)
+	if e != nil {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusInternalServerError,
+			Message:   "Fail to save task",
+		}
+		err.Errors = make(map[string][]string)
+		err.Errors["dev"] = []string{e.Error()}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+	renderTask(db, project, &task)
+
+	w.Header().Add("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&task)
+}
+
+// Delete soft delete the given task
+// @Route: /api/project/{project_id}/task/{id}/delete
+// @Method: POST
+func (TaskController) Delete(w http.ResponseWriter, r *http.Request) {
+	authUser, e := rw_helpers.GetAuthenticatedUser(w, r)
+	if e != nil {
+		return
+	}
+
+	taskID, e := rw_helpers.ExtractIDFromURL(w, r)
+	if e != nil {
+		return
+	}
+
+	db := database.NewGORMInstance()
+	defer db.Close()
+
+	project, e := loadProjectOfTask(w, r, db, authUser, taskID)
+	if e != nil {
+		return
+	}
+
+	task, e := rw_helpers.GetTaskByID(w, db, project.ID, taskID)
+	if e != nil {
+		return
+	}
+
+	if rw_helpers.CanDeleteTask(w, db, authUser, project, task) == false {
+		return
+	}
+
+	e = task_repo.DeleteTask(db, task)
+	if e != nil {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusInternalServerError,
+			Message:   "Fail to delete task",
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+
+	helpers.NewSuccessResponse(w, "Task deleted.")
+}
+
+// fillTaskEditableFields copy user editable fields of request model to the task.
+// Ownership fields like ProjectID and CreatedByID never come from the request.
+func fillTaskEditableFields(task *models.Task, reqModel models.Task) {
+	task.Title = reqModel.Title
+	task.OrderID = reqModel.OrderID
+	task.Description = reqModel.Description
+	task.ScheduledStartDate = reqModel.ScheduledStartDate
+	task.ScheduledCompletionDate = reqModel.ScheduledCompletionDate
+	task.StartDate = reqModel.StartDate
+	task.CompletionDate = reqModel.CompletionDate
+	task.CompletedSuccessfully = reqModel.CompletedSuccessfully
+	task.PriorityID = reqModel.PriorityID
+	task.FontColor = reqModel.FontColor
+	task.BackgroundColor = reqModel.BackgroundColor
+	task.Progress = reqModel.Progress
+	task.EstimatedTime = reqModel.EstimatedTime
+	task.TaskBoardID = reqModel.TaskBoardID
+	task.TaskListID = reqModel.TaskListID
+}
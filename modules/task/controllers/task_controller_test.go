@@ -0,0 +1,29 @@
+package controllers
+
+import (
+	"testing"
+
+	"devin/models"
+)
+
+func TestFillTaskEditableFields(t *testing.T) {
+	progress := 40
+	task := models.Task{ID: 10, ProjectID: 1, CreatedByID: 2}
+	reqModel := models.Task{
+		ID:          99,
+		Title:       "New title",
+		ProjectID:   5,
+		CreatedByID: 6,
+		Progress:    &progress,
+	}
+
+	fillTaskEditableFields(&task, reqModel)
+
+	if task.Title != "New title" || task.Progress == nil || *task.Progress != 40 {
+		t.Fatal("Editable fields not copied", task)
+	}
+
+	if task.ID != 10 || task.ProjectID != 1 || task.CreatedByID != 2 {
+		t.Fatal("Ownership fields must not be changed by request model", task)
+	}
+}
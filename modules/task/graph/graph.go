@@ -0,0 +1,269 @@
+// Package graph contains algorithms on the dependency graph of tasks.
+// Edges are stored as "task depends on prerequisite", same as models.TaskPrerequisite
+package graph
+
+import (
+	"errors"
+	"sort"
+	"time"
+)
+
+// ErrCycle returned when the dependency graph contains a cycle
+var ErrCycle = errors.New("Dependency graph contains a cycle")
+
+// Node is a task inside the dependency graph
+type Node struct {
+	ID    uint64
+	Title string
+
+	// Time required to complete the task
+	Duration time.Duration
+
+	// The task can't start before this time. nil means no constraint.
+	ScheduledStartDate *time.Time
+
+	// Due date of the task. Used to detect late tasks.
+	ScheduledCompletionDate *time.Time
+}
+
+// Graph is a dependency graph of tasks
+type Graph struct {
+	nodes map[uint64]Node
+
+	// prerequisites[a] = list of tasks that must be done before a
+	prerequisites map[uint64][]uint64
+
+	// dependents[a] = list of tasks which are waiting for a
+	dependents map[uint64][]uint64
+}
+
+// ScheduledNode is the result of critical path calculation for a single task
+type ScheduledNode struct {
+	Node
+	EarliestStart  time.Time
+	EarliestFinish time.Time
+	LatestStart    time.Time
+	LatestFinish   time.Time
+
+	// Amount of time the task can be delayed without delaying the whole project
+	Slack time.Duration
+
+	IsCritical bool
+
+	// EarliestFinish is after ScheduledCompletionDate
+	IsLate bool
+}
+
+// Schedule is the result of critical path calculation
+type Schedule struct {
+	// Tasks sorted in topological order
+	Tasks []ScheduledNode
+
+	// IDs of tasks on the critical path from the first task to the last one
+	CriticalPath []uint64
+
+	ProjectedStartDate      time.Time
+	ProjectedCompletionDate time.Time
+}
+
+// New create an empty graph
+func New() *Graph {
+	return &Graph{
+		nodes:         make(map[uint64]Node),
+		prerequisites: make(map[uint64][]uint64),
+		dependents:    make(map[uint64][]uint64),
+	}
+}
+
+// AddNode add a task to the graph
+func (g *Graph) AddNode(node Node) {
+	g.nodes[node.ID] = node
+}
+
+// AddEdge add "taskID depends on prerequisiteID" to the graph.
+// Edges with unknown nodes are ignored.
+func (g *Graph) AddEdge(taskID, prerequisiteID uint64) {
+	if _, ok := g.nodes[taskID]; !ok {
+		return
+	}
+	if _, ok := g.nodes[prerequisiteID]; !ok {
+		return
+	}
+	g.prerequisites[taskID] = append(g.prerequisites[taskID], prerequisiteID)
+	g.dependents[prerequisiteID] = append(g.dependents[prerequisiteID], taskID)
+}
+
+// EarliestScheduledStart return the earliest ScheduledStartDate of the tasks or nil if no task has a scheduled start date
+func (g *Graph) EarliestScheduledStart() (earliest *time.Time) {
+	for _, node := range g.nodes {
+		if node.ScheduledStartDate == nil {
+			continue
+		}
+		if earliest == nil || node.ScheduledStartDate.Before(*earliest) {
+			t := *node.ScheduledStartDate
+			earliest = &t
+		}
+	}
+
+	return
+}
+
+// WouldCreateCycle check adding "taskID depends on prerequisiteID" makes a cycle.
+// It happens when taskID is already a (direct or indirect) prerequisite of prerequisiteID.
+func (g *Graph) WouldCreateCycle(taskID, prerequisiteID uint64) bool {
+	if taskID == prerequisiteID {
+		return true
+	}
+
+	visited := make(map[uint64]bool)
+	stack := []uint64{prerequisiteID}
+	for len(stack) > 0 {
+		current := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if current == taskID {
+			return true
+		}
+		if visited[current] {
+			continue
+		}
+		visited[current] = true
+		stack = append(stack, g.prerequisites[current]...)
+	}
+
+	return false
+}
+
+// TopologicalOrder return IDs of tasks, every task comes after all of its prerequisites.
+// Independent tasks are sorted by ID to make the result stable.
+func (g *Graph) TopologicalOrder() ([]uint64, error) {
+	inDegree := make(map[uint64]int, len(g.nodes))
+	for id := range g.nodes {
+		inDegree[id] = len(g.prerequisites[id])
+	}
+
+	var ready []uint64
+	for id, degree := range inDegree {
+		if degree == 0 {
+			ready = append(ready, id)
+		}
+	}
+	sortIDs(ready)
+
+	order := make([]uint64, 0, len(g.nodes))
+	for len(ready) > 0 {
+		current := ready[0]
+		ready = ready[1:]
+		order = append(order, current)
+
+		var released []uint64
+		for _, dependent := range g.dependents[current] {
+			inDegree[dependent]--
+			if inDegree[dependent] == 0 {
+				released = append(released, dependent)
+			}
+		}
+		sortIDs(released)
+		ready = append(ready, released...)
+		sortIDs(ready)
+	}
+
+	if len(order) != len(g.nodes) {
+		return nil, ErrCycle
+	}
+
+	return order, nil
+}
+
+// CriticalPath calculate earliest and latest start/finish of all tasks.
+// Tasks without ScheduledStartDate and prerequisites start at projectStart.
+func (g *Graph) CriticalPath(projectStart time.Time) (schedule Schedule, e error) {
+	order, e := g.TopologicalOrder()
+	if e != nil {
+		return
+	}
+
+	scheduled := make(map[uint64]*ScheduledNode, len(order))
+	schedule.ProjectedStartDate = projectStart
+	schedule.ProjectedCompletionDate = projectStart
+
+	// Forward pass
+	for _, id := range order {
+		node := g.nodes[id]
+		sn := &ScheduledNode{Node: node}
+
+		sn.EarliestStart = projectStart
+		if node.ScheduledStartDate != nil && node.ScheduledStartDate.After(sn.EarliestStart) {
+			sn.EarliestStart = *node.ScheduledStartDate
+		}
+		for _, pre := range g.prerequisites[id] {
+			if scheduled[pre].EarliestFinish.After(sn.EarliestStart) {
+				sn.EarliestStart = scheduled[pre].EarliestFinish
+			}
+		}
+		sn.EarliestFinish = sn.EarliestStart.Add(node.Duration)
+
+		if sn.EarliestFinish.After(schedule.ProjectedCompletionDate) {
+			schedule.ProjectedCompletionDate = sn.EarliestFinish
+		}
+		scheduled[id] = sn
+	}
+
+	// Backward pass
+	for i := len(order) - 1; i >= 0; i-- {
+		sn := scheduled[order[i]]
+		sn.LatestFinish = schedule.ProjectedCompletionDate
+		for _, dep := range g.dependents[sn.ID] {
+			if scheduled[dep].LatestStart.Before(sn.LatestFinish) {
+				sn.LatestFinish = scheduled[dep].LatestStart
+			}
+		}
+		sn.LatestStart = sn.LatestFinish.Add(-sn.Duration)
+		sn.Slack = sn.LatestStart.Sub(sn.EarliestStart)
+		sn.IsCritical = sn.Slack <= 0
+		if sn.ScheduledCompletionDate != nil && sn.EarliestFinish.After(*sn.ScheduledCompletionDate) {
+			sn.IsLate = true
+		}
+	}
+
+	for _, id := range order {
+		schedule.Tasks = append(schedule.Tasks, *scheduled[id])
+	}
+	schedule.CriticalPath = g.criticalChain(order, scheduled, schedule.ProjectedCompletionDate)
+
+	return
+}
+
+// criticalChain walk back from the last critical task through critical prerequisites
+func (g *Graph) criticalChain(order []uint64, scheduled map[uint64]*ScheduledNode, completion time.Time) []uint64 {
+	var current *ScheduledNode
+	for _, id := range order {
+		sn := scheduled[id]
+		if sn.IsCritical && sn.EarliestFinish.Equal(completion) {
+			current = sn
+			break
+		}
+	}
+
+	var path []uint64
+	for current != nil {
+		path = append([]uint64{current.ID}, path...)
+
+		var next *ScheduledNode
+		pres := append([]uint64{}, g.prerequisites[current.ID]...)
+		sortIDs(pres)
+		for _, pre := range pres {
+			sn := scheduled[pre]
+			if sn.IsCritical && sn.EarliestFinish.Equal(current.EarliestStart) {
+				next = sn
+				break
+			}
+		}
+		current = next
+	}
+
+	return path
+}
+
+func sortIDs(ids []uint64) {
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+}
@@ -0,0 +1,106 @@
+package graph
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func newTestGraph() *Graph {
+	//   1 --> 2 --> 4
+	//   1 --> 3 --> 4
+	// 2 takes longer than 3, so 1-2-4 is the critical path
+	g := New()
+	g.AddNode(Node{ID: 1, Duration: 2 * time.Hour})
+	g.AddNode(Node{ID: 2, Duration: 5 * time.Hour})
+	g.AddNode(Node{ID: 3, Duration: 1 * time.Hour})
+	g.AddNode(Node{ID: 4, Duration: 1 * time.Hour})
+	g.AddEdge(2, 1)
+	g.AddEdge(3, 1)
+	g.AddEdge(4, 2)
+	g.AddEdge(4, 3)
+
+	return g
+}
+
+func TestTopologicalOrder(t *testing.T) {
+	order, e := newTestGraph().TopologicalOrder()
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	if !reflect.DeepEqual(order, []uint64{1, 2, 3, 4}) {
+		t.Fatal("Wrong order", order)
+	}
+
+	t.Run("cycle", func(t *testing.T) {
+		g := newTestGraph()
+		g.AddEdge(1, 4)
+		_, e := g.TopologicalOrder()
+		if e != ErrCycle {
+			t.Fatal("Cycle not detected")
+		}
+	})
+}
+
+func TestWouldCreateCycle(t *testing.T) {
+	g := newTestGraph()
+	tests := []struct {
+		name           string
+		taskID         uint64
+		prerequisiteID uint64
+		want           bool
+	}{
+		{name: "self", taskID: 2, prerequisiteID: 2, want: true},
+		{name: "direct", taskID: 1, prerequisiteID: 2, want: true},
+		{name: "indirect", taskID: 1, prerequisiteID: 4, want: true},
+		{name: "sibling", taskID: 3, prerequisiteID: 2, want: false},
+		{name: "already implied", taskID: 4, prerequisiteID: 1, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := g.WouldCreateCycle(tt.taskID, tt.prerequisiteID); got != tt.want {
+				t.Errorf("WouldCreateCycle(%v, %v) = %v, want %v", tt.taskID, tt.prerequisiteID, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCriticalPath(t *testing.T) {
+	start := time.Date(2018, 6, 1, 8, 0, 0, 0, time.UTC)
+	schedule, e := newTestGraph().CriticalPath(start)
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	if !reflect.DeepEqual(schedule.CriticalPath, []uint64{1, 2, 4}) {
+		t.Fatal("Wrong critical path", schedule.CriticalPath)
+	}
+
+	if !schedule.ProjectedCompletionDate.Equal(start.Add(8 * time.Hour)) {
+		t.Fatal("Wrong completion date", schedule.ProjectedCompletionDate)
+	}
+
+	for _, task := range schedule.Tasks {
+		if task.ID == 3 && task.Slack != 4*time.Hour {
+			t.Fatal("Wrong slack of task 3", task.Slack)
+		}
+	}
+
+	t.Run("late task", func(t *testing.T) {
+		due := start.Add(5 * time.Hour)
+		g := newTestGraph()
+		g.AddNode(Node{ID: 5, Duration: time.Hour, ScheduledCompletionDate: &due})
+		g.AddEdge(5, 2)
+
+		schedule, e := g.CriticalPath(start)
+		if e != nil {
+			t.Fatal(e)
+		}
+		for _, task := range schedule.Tasks {
+			if task.ID == 5 && task.IsLate == false {
+				t.Fatal("Task 5 must be late")
+			}
+		}
+	})
+}
@@ -0,0 +1,218 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/jinzhu/gorm"
+
+	"devin/models"
+)
+
+// ErrBoardNotFound returned when the board is not found in the project
+var ErrBoardNotFound = errors.New("Board not found")
+
+// GetBoardsOfProject load all boards (columns) of the project sorted by their order.
+// If withTasks is true, tasks of every column loaded and sorted by OrderID.
+func GetBoardsOfProject(db *gorm.DB, projectID uint64, withTasks bool) (boards []models.TaskBoard, e error) {
+	e = db.Model(&models.TaskBoard{}).
+		Where("project_id=?", projectID).
+		Order("order_id ASC, id ASC").
+		Find(&boards).
+		Error
+	if e != nil || withTasks == false {
+		return
+	}
+
+	for i := range boards {
+		boards[i].Tasks, e = getTasksOfBoard(db, projectID, &boards[i].ID)
+		if e != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// GetBoardByID load a board of the project with its ordered tasks
+func GetBoardByID(db *gorm.DB, projectID, boardID uint64) (board models.TaskBoard, e error) {
+	db.Model(&board).Where("id=? AND project_id=?", boardID, projectID).First(&board)
+	if board.ID == 0 {
+		e = ErrBoardNotFound
+		return
+	}
+
+	board.Tasks, e = getTasksOfBoard(db, projectID, &board.ID)
+
+	return
+}
+
+// getTasksOfBoard load tasks of a column sorted by OrderID.
+// A nil boardID means tasks which are not placed on any board.
+func getTasksOfBoard(db *gorm.DB, projectID uint64, boardID *uint64) (tasks []models.Task, e error) {
+	db = db.Model(&models.Task{}).
+		Preload("Assignments").
+		Preload("Priority").
+		Where("project_id=?", projectID)
+	if boardID == nil {
+		db = db.Where("task_board_id IS NULL")
+	} else {
+		db = db.Where("task_board_id=?", *boardID)
+	}
+
+	e = db.Order("order_id ASC, id ASC").Find(&tasks).Error
+
+	return
+}
+
+// SaveBoard insert new board or update the existing one
+func SaveBoard(db *gorm.DB, board *models.TaskBoard) error {
+	if board.ID == 0 {
+		if board.OrderID == 0 {
+			var max struct {
+				MaxOrder uint
+			}
+			db.Raw("SELECT coalesce(max(order_id), 0) AS max_order FROM task_boards WHERE project_id=? AND deleted_at IS NULL", board.ProjectID).Scan(&max)
+			board.OrderID = max.MaxOrder + 1
+		}
+		return db.Model(&models.TaskBoard{}).Create(board).Error
+	}
+
+	return db.Model(&models.TaskBoard{}).
+		Where("id=? AND project_id=?", board.ID, board.ProjectID).
+		Save(board).
+		Error
+}
+
+// MoveTask move the task to the given board at the given position (1 based) and
+// re-number OrderID of tasks in both source and target columns.
+// All changes are done in one transaction and the project row is locked,
+// so concurrent moves in the same project are serialized and can't corrupt the order.
+// A nil targetBoardID moves the task out of the boards.
+func MoveTask(db *gorm.DB, projectID, taskID uint64, targetBoardID *uint64, position int) (e error) {
+	tx := db.Begin()
+	defer func() {
+		if e != nil {
+			tx.Rollback()
+		}
+	}()
+
+	e = tx.Exec("SELECT id FROM projects WHERE id=? FOR UPDATE", projectID).Error
+	if e != nil {
+		return
+	}
+
+	var task models.Task
+	tx.Model(&task).Where("id=? AND project_id=?", taskID, projectID).First(&task)
+	if task.ID == 0 {
+		e = errors.New("Task not found")
+		return
+	}
+
+	if targetBoardID != nil && IsBoardOfProject(tx, *targetBoardID, projectID) == false {
+		e = ErrBoardNotFound
+		return
+	}
+
+	sourceIDs, e := getOrderedTaskIDs(tx, projectID, task.TaskBoardID)
+	if e != nil {
+		return
+	}
+	sourceIDs = removeID(sourceIDs, task.ID)
+
+	if sameBoard(task.TaskBoardID, targetBoardID) {
+		e = renumber(tx, insertAt(sourceIDs, task.ID, position))
+		if e != nil {
+			return
+		}
+	} else {
+		var targetIDs []uint64
+		targetIDs, e = getOrderedTaskIDs(tx, projectID, targetBoardID)
+		if e != nil {
+			return
+		}
+
+		e = tx.Model(&models.Task{}).Where("id=?", task.ID).UpdateColumn("task_board_id", targetBoardID).Error
+		if e != nil {
+			return
+		}
+
+		e = renumber(tx, sourceIDs)
+		if e != nil {
+			return
+		}
+
+		e = renumber(tx, insertAt(targetIDs, task.ID, position))
+		if e != nil {
+			return
+		}
+	}
+
+	e = tx.Commit().Error
+
+	return
+}
+
+// getOrderedTaskIDs load IDs of tasks in a column sorted by OrderID
+func getOrderedTaskIDs(db *gorm.DB, projectID uint64, boardID *uint64) (ids []uint64, e error) {
+	tasks, e := getTasksOfBoard(db, projectID, boardID)
+	if e != nil {
+		return
+	}
+
+	for _, task := range tasks {
+		ids = append(ids, task.ID)
+	}
+
+	return
+}
+
+// renumber set OrderID of tasks by their position in ids, starting from 1
+func renumber(db *gorm.DB, ids []uint64) error {
+	for i, id := range ids {
+		e := db.Model(&models.Task{}).Where("id=?", id).UpdateColumn("order_id", i+1).Error
+		if e != nil {
+			return e
+		}
+	}
+
+	return nil
+}
+
+// removeID remove id from ids if exists
+func removeID(ids []uint64, id uint64) []uint64 {
+	result := make([]uint64, 0, len(ids))
+	for _, v := range ids {
+		if v != id {
+			result = append(result, v)
+		}
+	}
+
+	return result
+}
+
+// insertAt insert id at the given 1 based position of ids.
+// Positions out of range are clamped to the start or end of the list.
+func insertAt(ids []uint64, id uint64, position int) []uint64 {
+	index := position - 1
+	if index < 0 {
+		index = 0
+	}
+	if index > len(ids) {
+		index = len(ids)
+	}
+
+	result := make([]uint64, 0, len(ids)+1)
+	result = append(result, ids[:index]...)
+	result = append(result, id)
+	result = append(result, ids[index:]...)
+
+	return result
+}
+
+func sameBoard(a, b *uint64) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+
+	return *a == *b
+}
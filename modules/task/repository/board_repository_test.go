@@ -0,0 +1,46 @@
+package repository
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestInsertAt(t *testing.T) {
+	tests := []struct {
+		name     string
+		ids      []uint64
+		position int
+		want     []uint64
+	}{
+		{name: "first", ids: []uint64{1, 2, 3}, position: 1, want: []uint64{9, 1, 2, 3}},
+		{name: "middle", ids: []uint64{1, 2, 3}, position: 2, want: []uint64{1, 9, 2, 3}},
+		{name: "last", ids: []uint64{1, 2, 3}, position: 4, want: []uint64{1, 2, 3, 9}},
+		{name: "after end", ids: []uint64{1, 2, 3}, position: 100, want: []uint64{1, 2, 3, 9}},
+		{name: "zero", ids: []uint64{1, 2, 3}, position: 0, want: []uint64{9, 1, 2, 3}},
+		{name: "empty column", ids: nil, position: 3, want: []uint64{9}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := insertAt(tt.ids, 9, tt.position); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("insertAt() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRemoveID(t *testing.T) {
+	got := removeID([]uint64{4, 5, 6}, 5)
+	if !reflect.DeepEqual(got, []uint64{4, 6}) {
+		t.Fatal("removeID() =", got)
+	}
+}
+
+func TestSameBoard(t *testing.T) {
+	one, other, alsoOne := uint64(1), uint64(2), uint64(1)
+	if sameBoard(nil, nil) == false || sameBoard(&one, &alsoOne) == false {
+		t.Fatal("Boards must be same")
+	}
+	if sameBoard(&one, nil) || sameBoard(nil, &one) || sameBoard(&one, &other) {
+		t.Fatal("Boards must be different")
+	}
+}
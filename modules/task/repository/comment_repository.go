@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/jinzhu/gorm"
+
+	"devin/models"
+)
+
+// ErrCommentNotFound returned when the comment is not found in the task
+var ErrCommentNotFound = errors.New("Comment not found")
+
+// GetComments load paginated comments of the task, oldest first
+func GetComments(db *gorm.DB, taskID, currentPage, perPage uint64) (data []models.TaskComment, total uint64, e error) {
+	db = db.Model(&models.TaskComment{}).Where("task_id=?", taskID)
+
+	e = db.Count(&total).Error
+	if e != nil {
+		return
+	}
+
+	if perPage > 0 {
+		db = db.Limit(perPage)
+	}
+	if currentPage > 0 {
+		db = db.Offset((currentPage - 1) * perPage)
+	}
+
+	e = db.Preload("CreatedBy").
+		Order("created_at ASC, id ASC").
+		Find(&data).
+		Error
+
+	return
+}
+
+// GetCommentByID load a comment of the task
+func GetCommentByID(db *gorm.DB, taskID, commentID uint64) (comment models.TaskComment, e error) {
+	db.Model(&comment).Where("id=? AND task_id=?", commentID, taskID).First(&comment)
+	if comment.ID == 0 {
+		e = ErrCommentNotFound
+	}
+
+	return
+}
+
+// SaveComment insert a new comment
+func SaveComment(db *gorm.DB, comment *models.TaskComment) error {
+	return db.Set("gorm:save_associations", false).Create(comment).Error
+}
+
+// DeleteComment soft delete the comment
+func DeleteComment(db *gorm.DB, comment models.TaskComment) error {
+	return db.Delete(&comment).Error
+}
@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/jinzhu/gorm"
+
+	"devin/models"
+	"devin/modules/task/graph"
+)
+
+// ErrDuplicatePrerequisite returned when the prerequisite already added to the task
+var ErrDuplicatePrerequisite = errors.New("This prerequisite already added to the task")
+
+// ErrPrerequisiteNotFound returned when no prerequisite record found to remove
+var ErrPrerequisiteNotFound = errors.New("Prerequisite not found")
+
+// BuildProjectGraph load all tasks of the project and their prerequisites as a dependency graph
+func BuildProjectGraph(db *gorm.DB, projectID uint64) (g *graph.Graph, e error) {
+	var tasks []models.Task
+	e = db.Model(&models.Task{}).Where("project_id=?", projectID).Find(&tasks).Error
+	if e != nil {
+		return
+	}
+
+	var prerequisites []models.TaskPrerequisite
+	e = db.Model(&models.TaskPrerequisite{}).
+		Where("task_id IN (SELECT id FROM tasks WHERE project_id=? AND deleted_at IS NULL)", projectID).
+		Find(&prerequisites).
+		Error
+	if e != nil {
+		return
+	}
+
+	g = graph.New()
+	for _, task := range tasks {
+		g.AddNode(graph.Node{
+			ID:                      task.ID,
+			Title:                   task.Title,
+			Duration:                taskDuration(task),
+			ScheduledStartDate:      task.ScheduledStartDate,
+			ScheduledCompletionDate: task.ScheduledCompletionDate,
+		})
+	}
+	for _, p := range prerequisites {
+		g.AddEdge(p.TaskID, p.PrerequisiteID)
+	}
+
+	return
+}
+
+// taskDuration use EstimatedTime of the task. If it is not set,
+// the scheduled start and completion dates are used.
+func taskDuration(task models.Task) time.Duration {
+	if task.EstimatedTime != nil {
+		return *task.EstimatedTime
+	}
+
+	if task.ScheduledStartDate != nil && task.ScheduledCompletionDate != nil {
+		return task.ScheduledCompletionDate.Sub(*task.ScheduledStartDate)
+	}
+
+	return 0
+}
+
+// AddPrerequisite add prerequisiteID as a prerequisite of the task inside a transaction.
+// The project row is locked, so concurrent changes of the graph can't create a cycle together.
+// Both tasks must be belong to the same project.
+func AddPrerequisite(db *gorm.DB, task models.Task, prerequisiteID, createdByID uint64) (prerequisite models.TaskPrerequisite, e error) {
+	tx := db.Begin()
+	defer func() {
+		if e != nil {
+			tx.Rollback()
+		}
+	}()
+
+	e = tx.Exec("SELECT id FROM projects WHERE id=? FOR UPDATE", task.ProjectID).Error
+	if e != nil {
+		return
+	}
+
+	var cnt uint64
+	tx.Model(&models.TaskPrerequisite{}).
+		Where("task_id=? AND prerequisite_id=?", task.ID, prerequisiteID).
+		Count(&cnt)
+	if cnt > 0 {
+		e = ErrDuplicatePrerequisite
+		return
+	}
+
+	g, e := BuildProjectGraph(tx, task.ProjectID)
+	if e != nil {
+		return
+	}
+
+	if g.WouldCreateCycle(task.ID, prerequisiteID) {
+		e = graph.ErrCycle
+		return
+	}
+
+	prerequisite.TaskID = task.ID
+	prerequisite.PrerequisiteID = prerequisiteID
+	prerequisite.CreatedByID = createdByID
+	e = tx.Model(&models.TaskPrerequisite{}).Create(&prerequisite).Error
+	if e != nil {
+		return
+	}
+
+	e = tx.Commit().Error
+
+	return
+}
+
+// RemovePrerequisite remove prerequisiteID from prerequisites of the task
+func RemovePrerequisite(db *gorm.DB, taskID, prerequisiteID uint64) error {
+	res := db.Where("task_id=? AND prerequisite_id=?", taskID, prerequisiteID).Delete(&models.TaskPrerequisite{})
+	if res.Error != nil {
+		return res.Error
+	}
+
+	if res.RowsAffected == 0 {
+		return ErrPrerequisiteNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/jinzhu/gorm"
+
+	"devin/models"
+)
+
+// SearchTasks search on tasks of a project by given filters
+// @param db A new instance of database
+// @param searchModel search filters, searchModel.ProjectID is required
+func SearchTasks(db *gorm.DB, searchModel models.TaskSearch) (data []models.Task, total uint64, e error) {
+	db = db.Model(&models.Task{})
+	db = searchModel.GetWhereClause(db)
+
+	e = db.Count(&total).Error
+	if e != nil {
+		return
+	}
+
+	if searchModel.PerPage > 0 {
+		db = db.Limit(searchModel.PerPage)
+	}
+	if searchModel.CurrentPage > 0 {
+		db = db.Offset((searchModel.CurrentPage - 1) * searchModel.PerPage)
+	}
+
+	e = db.Preload("Priority").
+		Preload("TaskBoard").
+		Preload("Assignments").
+		Order("order_id ASC, id ASC").
+		Find(&data).
+		Error
+
+	return
+}
+
+// GetTaskByID load a task of the project with its relations
+func GetTaskByID(db *gorm.DB, projectID, taskID uint64) (task models.Task, e error) {
+	db.Model(&task).
+		Preload("Priority").
+		Preload("TaskBoard").
+		Preload("Assignments").
+		Preload("Assignments.User").
+		Preload("Followers").
+		Preload("Followers.User").
+		Preload("Attachments").
+		Preload("PrerequisiteTasks").
+		Preload("Comments").
+		Where("id=? AND project_id=?", taskID, projectID).
+		First(&task)
+
+	if task.ID == 0 {
+		e = errors.New("Task not found")
+		return
+	}
+
+	return
+}
+
+// SaveTask insert new task or update the existing one.
+// The task must be loaded and filled before calling this function
+func SaveTask(db *gorm.DB, task *models.Task) error {
+	if task.ID == 0 {
+		return db.Model(&models.Task{}).Create(task).Error
+	}
+
+	return db.Model(&models.Task{}).
+		Where("id=? AND project_id=?", task.ID, task.ProjectID).
+		Save(task).
+		Error
+}
+
+// DeleteTask soft delete the given task
+func DeleteTask(db *gorm.DB, task models.Task) error {
+	return db.Where("id=? AND project_id=?", task.ID, task.ProjectID).Delete(&models.Task{}).Error
+}
+
+// IsBoardOfProject check the given board to be belong to the project
+func IsBoardOfProject(db *gorm.DB, boardID, projectID uint64) bool {
+	var cnt uint64
+	db.Model(&models.TaskBoard{}).Where("id=? AND project_id=?", boardID, projectID).Count(&cnt)
+
+	return cnt > 0
+}
+
+// IsTaskListOfProject check the given task list to be belong to the project
+func IsTaskListOfProject(db *gorm.DB, taskListID, projectID uint64) bool {
+	var cnt uint64
+	db.Model(&models.TaskList{}).Where("id=? AND project_id=?", taskListID, projectID).Count(&cnt)
+
+	return cnt > 0
+}
+
+// Follow add a user to followers of the task, if not added before
+func Follow(db *gorm.DB, taskID, userID, createdByID uint64) error {
+	return db.Exec(`INSERT INTO task_followers (task_id, user_id, created_by_id)
+        VALUES (?, ?, ?) ON CONFLICT (task_id, user_id) DO NOTHING`, taskID, userID, createdByID).Error
+}
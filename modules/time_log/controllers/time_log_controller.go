@@ -0,0 +1,336 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"devin/database"
+	"devin/helpers"
+	"devin/models"
+	"devin/modules/rw_helpers"
+	"devin/modules/time_log/report"
+	time_log_repo "devin/modules/time_log/repository"
+	"devin/policies"
+)
+
+// TimeLogController handle functionalities of spent times of tasks
+type TimeLogController struct{}
+
+type startTimerReqModel struct {
+	TaskID      uint64
+	IsBillable  bool
+	Description string
+}
+
+type startTimerResModel struct {
+	Started models.TaskSpentTime
+	Stopped *models.TaskSpentTime `doc:"Previous running timer of the user which is stopped by this request"`
+}
+
+// RunningTimer return the running timer of authenticated user
+// @Route: /api/time_logs/timer
+// @Method: GET
+func (TimeLogController) RunningTimer(w http.ResponseWriter, r *http.Request) {
+	authUser, e := rw_helpers.GetAuthenticatedUser(w, r)
+	if e != nil {
+		return
+	}
+
+	db := database.NewGORMInstance()
+	defer db.Close()
+
+	timeLog, e := time_log_repo.GetRunningTimer(db, authUser.ID)
+	if e != nil {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusNotFound,
+			Message:   e.Error(),
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&timeLog)
+}
+
+// StartTimer start a timer on a task of the project.
+// Running timer of the user, if any, is stopped.
+// @Route: /api/project/{project_id}/time_logs/timer/start
+// @Method: POST
+// @Content-Type: application/json
+func (TimeLogController) StartTimer(w http.ResponseWriter, r *http.Request) {
+	if rw_helpers.IsJSONRequest(w, r) == false {
+		return
+	}
+
+	authUser, e := rw_helpers.GetAuthenticatedUser(w, r)
+	if e != nil {
+		return
+	}
+
+	if helpers.IsRequestBodyNil(w, r) {
+		return
+	}
+
+	var reqModel startTimerReqModel
+	e = json.NewDecoder(r.Body).Decode(&reqModel)
+	if e != nil {
+		err := helpers.ErrorResponse{
+			Message:   "Invalid request!",
+			ErrorCode: http.StatusBadRequest,
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+	defer r.Body.Close()
+
+	db := database.NewGORMInstance()
+	defer db.Close()
+
+	project, e := rw_helpers.LoadProjectForModule(w, r, db, authUser, models.MODULE_SPENT_TIME)
+	if e != nil {
+		return
+	}
+
+	if rw_helpers.CanCreateTimeLog(w, db, authUser, project) == false {
+		return
+	}
+
+	task, e := rw_helpers.GetTaskByID(w, db, project.ID, reqModel.TaskID)
+	if e != nil {
+		return
+	}
+
+	res := startTimerResModel{}
+	res.Started = models.TaskSpentTime{
+		SpentByID:   authUser.ID,
+		TaskID:      task.ID,
+		IsBillable:  reqModel.IsBillable,
+		Description: reqModel.Description,
+		CreatedByID: authUser.ID,
+	}
+	res.Stopped, e = time_log_repo.StartTimer(db, &res.Started)
+	if e != nil {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusInternalServerError,
+			Message:   "Fail to start timer",
+		}
+		err.Errors = make(map[string][]string)
+		err.Errors["dev"] = []string{e.Error()}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&res)
+}
+
+// StopTimer stop the running timer of authenticated user
+// @Route: /api/time_logs/timer/stop
+// @Method: POST
+func (TimeLogController) StopTimer(w http.ResponseWriter, r *http.Request) {
+	authUser, e := rw_helpers.GetAuthenticatedUser(w, r)
+	if e != nil {
+		return
+	}
+
+	db := database.NewGORMInstance()
+	defer db.Close()
+
+	timeLog, e := time_log_repo.StopTimer(db, authUser.ID)
+	if e != nil {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusInternalServerError,
+			Message:   "Fail to stop timer",
+		}
+		if e == time_log_repo.ErrNoRunningTimer {
+			err.ErrorCode = http.StatusNotFound
+			err.Message = e.Error()
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&timeLog)
+}
+
+// Save handle inserting and updating of manually logged spent times
+// If no ID present in the request model, it will insert as new time log
+// otherwise the given time log will be updated
+// @Route: /api/project/{project_id}/time_logs/save
+// @Method: POST
+// @Content-Type: application/json
+func (TimeLogController) Save(w http.ResponseWriter, r *http.Request) {
+	if rw_helpers.IsJSONRequest(w, r) == false {
+		return
+	}
+
+	authUser, e := rw_helpers.GetAuthenticatedUser(w, r)
+	if e != nil {
+		return
+	}
+
+	reqModel, e := rw_helpers.DecodeTimeLogRequestModel(w, r)
+	if e != nil {
+		return
+	}
+	defer r.Body.Close()
+
+	if rw_helpers.ValidateTimeLogRequestModel(w, reqModel) != nil {
+		return
+	}
+
+	db := database.NewGORMInstance()
+	defer db.Close()
+
+	project, e := rw_helpers.LoadProjectForModule(w, r, db, authUser, models.MODULE_SPENT_TIME)
+	if e != nil {
+		return
+	}
+
+	if rw_helpers.CanCreateTimeLog(w, db, authUser, project) == false {
+		return
+	}
+
+	// Task must belong to the project
+	_, e = rw_helpers.GetTaskByID(w, db, project.ID, reqModel.TaskID)
+	if e != nil {
+		return
+	}
+
+	timeLog := models.TaskSpentTime{}
+	if reqModel.ID != 0 {
+		// Edit mode
+		timeLog, e = rw_helpers.GetTimeLogByID(w, db, project.ID, reqModel.ID)
+		if e != nil {
+			return
+		}
+
+		if rw_helpers.CanUpdateTimeLog(w, authUser, timeLog) == false {
+			return
+		}
+		timeLog.Task = nil
+	} else {
+		timeLog.SpentByID = authUser.ID
+		timeLog.CreatedByID = authUser.ID
+	}
+	timeLog.TaskID = reqModel.TaskID
+	timeLog.StartDate = reqModel.StartDate
+	timeLog.EndDate = reqModel.EndDate
+	timeLog.IsBillable = reqModel.IsBillable
+	timeLog.Description = reqModel.Description
+
+	e = time_log_repo.SaveTimeLog(db, &timeLog)
+	if e != nil {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusInternalServerError,
+			Message:   "Fail to save time log",
+		}
+		err.Errors = make(map[string][]string)
+		err.Errors["dev"] = []string{e.Error()}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&timeLog)
+}
+
+// Delete soft delete the given spent time
+// @Route: /api/project/{project_id}/time_log/{id}/delete
+// @Method: POST
+func (TimeLogController) Delete(w http.ResponseWriter, r *http.Request) {
+	authUser, e := rw_helpers.GetAuthenticatedUser(w, r)
+	if e != nil {
+		return
+	}
+
+	timeLogID, e := rw_helpers.ExtractIDFromURL(w, r)
+	if e != nil {
+		return
+	}
+
+	db := database.NewGORMInstance()
+	defer db.Close()
+
+	project, e := rw_helpers.LoadProjectForModule(w, r, db, authUser, models.MODULE_SPENT_TIME)
+	if e != nil {
+		return
+	}
+
+	timeLog, e := rw_helpers.GetTimeLogByID(w, db, project.ID, timeLogID)
+	if e != nil {
+		return
+	}
+
+	if rw_helpers.CanUpdateTimeLog(w, authUser, timeLog) == false {
+		return
+	}
+
+	e = time_log_repo.DeleteTimeLog(db, timeLog)
+	if e != nil {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusInternalServerError,
+			Message:   "Fail to delete time log",
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+
+	helpers.NewSuccessResponse(w, "Time log deleted.")
+}
+
+// Timesheet return spent times of the project grouped by user, task, day or week
+// with billable and non-billable totals.
+// Members without CanListAllTimeLogs permission only see their own entries.
+// @Route: /api/project/{project_id}/timesheet?q={json encoded models.TimesheetSearch}
+// @Method: GET
+func (TimeLogController) Timesheet(w http.ResponseWriter, r *http.Request) {
+	authUser, e := rw_helpers.GetAuthenticatedUser(w, r)
+	if e != nil {
+		return
+	}
+
+	searchModel, loc, e := rw_helpers.DecodeTimesheetFilters(w, r)
+	if e != nil {
+		return
+	}
+
+	db := database.NewGORMInstance()
+	defer db.Close()
+
+	project, e := rw_helpers.LoadProjectForModule(w, r, db, authUser, models.MODULE_SPENT_TIME)
+	if e != nil {
+		return
+	}
+	searchModel.ProjectID = project.ID
+
+	if policies.CanListAllTimeLogs(db, authUser, project) == false {
+		searchModel.UserID = &authUser.ID
+	}
+
+	entries, e := time_log_repo.GetTimesheetEntries(db, searchModel)
+	if e != nil {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusInternalServerError,
+			Message:   "Fail to load time logs",
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+
+	timesheet, e := report.Build(entries, searchModel.GroupBy, loc, time.Now())
+	if e != nil {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusUnprocessableEntity,
+			Message:   e.Error(),
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&timesheet)
+}
@@ -0,0 +1,163 @@
+// Package report aggregate spent times of tasks into timesheet rows
+package report
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"devin/models"
+)
+
+// Available grouping of timesheet rows
+const (
+	GroupByUser = "user"
+	GroupByTask = "task"
+	GroupByDay  = "day"
+	GroupByWeek = "week"
+)
+
+// ErrInvalidGroupBy returned when grouping is not one of the GroupBy constants
+var ErrInvalidGroupBy = errors.New("Invalid group by. Valid values are: user, task, day, week")
+
+// Row is total of spent times of a single group.
+// Durations are in nanoseconds, the same as Task.EstimatedTime.
+type Row struct {
+	Key          string
+	Label        string
+	UserID       *uint64    `json:",omitempty"`
+	TaskID       *uint64    `json:",omitempty"`
+	Date         *time.Time `json:",omitempty" doc:"Start of the day or week (Monday)"`
+	Billable     time.Duration
+	NonBillable  time.Duration
+	Total        time.Duration
+	EntriesCount int
+}
+
+// Timesheet is the report of spent times grouped by GroupBy
+type Timesheet struct {
+	GroupBy     string
+	Rows        []Row
+	Billable    time.Duration
+	NonBillable time.Duration
+	Total       time.Duration
+}
+
+// IsValidGroupBy check groupBy to be one of the GroupBy constants
+func IsValidGroupBy(groupBy string) bool {
+	switch groupBy {
+	case GroupByUser, GroupByTask, GroupByDay, GroupByWeek:
+		return true
+	}
+
+	return false
+}
+
+// Build aggregate entries into a timesheet.
+// Running timers are counted up to now. An entry belongs to the day or week
+// its StartDate falls in, using loc as time zone.
+func Build(entries []models.TaskSpentTime, groupBy string, loc *time.Location, now time.Time) (ts Timesheet, e error) {
+	if IsValidGroupBy(groupBy) == false {
+		e = ErrInvalidGroupBy
+		return
+	}
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	ts.GroupBy = groupBy
+	ts.Rows = []Row{}
+	indexes := make(map[string]int)
+	for _, entry := range entries {
+		duration := EntryDuration(entry, now)
+		row := newRow(entry, groupBy, loc)
+
+		i, ok := indexes[row.Key]
+		if ok == false {
+			ts.Rows = append(ts.Rows, row)
+			i = len(ts.Rows) - 1
+			indexes[row.Key] = i
+		}
+
+		if entry.IsBillable {
+			ts.Rows[i].Billable += duration
+			ts.Billable += duration
+		} else {
+			ts.Rows[i].NonBillable += duration
+			ts.NonBillable += duration
+		}
+		ts.Rows[i].Total += duration
+		ts.Rows[i].EntriesCount++
+		ts.Total += duration
+	}
+
+	sort.SliceStable(ts.Rows, func(i, j int) bool {
+		if ts.Rows[i].Date != nil && ts.Rows[j].Date != nil {
+			return ts.Rows[i].Date.Before(*ts.Rows[j].Date)
+		}
+
+		return ts.Rows[i].Label < ts.Rows[j].Label
+	})
+
+	return
+}
+
+// EntryDuration return the spent time of the entry. Running timers are counted up to now.
+func EntryDuration(entry models.TaskSpentTime, now time.Time) time.Duration {
+	end := now
+	if entry.EndDate != nil {
+		end = *entry.EndDate
+	}
+	if end.Before(entry.StartDate) {
+		return 0
+	}
+
+	return end.Sub(entry.StartDate)
+}
+
+func newRow(entry models.TaskSpentTime, groupBy string, loc *time.Location) (row Row) {
+	switch groupBy {
+	case GroupByUser:
+		userID := entry.SpentByID
+		row.UserID = &userID
+		row.Key = strconv.FormatUint(userID, 10)
+		row.Label = row.Key
+		if entry.SpentBy != nil {
+			row.Label = entry.SpentBy.Username
+		}
+	case GroupByTask:
+		taskID := entry.TaskID
+		row.TaskID = &taskID
+		row.Key = strconv.FormatUint(taskID, 10)
+		row.Label = row.Key
+		if entry.Task != nil {
+			row.Label = entry.Task.Title
+		}
+	case GroupByDay:
+		day := startOfDay(entry.StartDate.In(loc))
+		row.Date = &day
+		row.Key = day.Format("2006-01-02")
+		row.Label = row.Key
+	case GroupByWeek:
+		week := startOfWeek(entry.StartDate.In(loc))
+		year, number := week.ISOWeek()
+		row.Date = &week
+		row.Key = fmt.Sprintf("%d-W%02d", year, number)
+		row.Label = row.Key
+	}
+
+	return
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// startOfWeek return start of the ISO week (Monday) of t
+func startOfWeek(t time.Time) time.Time {
+	offset := (int(t.Weekday()) + 6) % 7
+
+	return startOfDay(t).AddDate(0, 0, -offset)
+}
@@ -0,0 +1,102 @@
+package report
+
+import (
+	"testing"
+	"time"
+
+	"devin/models"
+)
+
+func entry(userID, taskID uint64, start time.Time, duration time.Duration, billable bool) models.TaskSpentTime {
+	end := start.Add(duration)
+	return models.TaskSpentTime{
+		SpentByID:  userID,
+		TaskID:     taskID,
+		StartDate:  start,
+		EndDate:    &end,
+		IsBillable: billable,
+	}
+}
+
+func TestBuildGroupByUser(t *testing.T) {
+	start := time.Date(2018, 6, 11, 9, 0, 0, 0, time.UTC)
+	entries := []models.TaskSpentTime{
+		entry(1, 10, start, time.Hour, true),
+		entry(2, 10, start, 2*time.Hour, false),
+		entry(1, 11, start.Add(3*time.Hour), 30*time.Minute, false),
+	}
+
+	ts, e := Build(entries, GroupByUser, nil, start)
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	if len(ts.Rows) != 2 {
+		t.Fatalf("Expected 2 rows, got %d", len(ts.Rows))
+	}
+	if ts.Rows[0].Key != "1" || ts.Rows[0].Billable != time.Hour || ts.Rows[0].NonBillable != 30*time.Minute {
+		t.Fatalf("Invalid row of user 1: %+v", ts.Rows[0])
+	}
+	if ts.Billable != time.Hour || ts.NonBillable != 150*time.Minute || ts.Total != 210*time.Minute {
+		t.Fatalf("Invalid totals: %+v", ts)
+	}
+}
+
+func TestBuildGroupByDayUsesTimeZone(t *testing.T) {
+	loc := time.FixedZone("UTC+4:30", 4*3600+1800)
+	// 21:00 UTC is 01:30 of the next day in loc
+	entries := []models.TaskSpentTime{
+		entry(1, 10, time.Date(2018, 6, 11, 21, 0, 0, 0, time.UTC), time.Hour, true),
+		entry(1, 10, time.Date(2018, 6, 11, 10, 0, 0, 0, time.UTC), time.Hour, true),
+	}
+
+	ts, e := Build(entries, GroupByDay, loc, time.Now())
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	if len(ts.Rows) != 2 || ts.Rows[0].Key != "2018-06-11" || ts.Rows[1].Key != "2018-06-12" {
+		t.Fatalf("Invalid rows: %+v", ts.Rows)
+	}
+}
+
+func TestBuildGroupByWeek(t *testing.T) {
+	entries := []models.TaskSpentTime{
+		// Sunday and Monday are in different ISO weeks
+		entry(1, 10, time.Date(2018, 6, 10, 10, 0, 0, 0, time.UTC), time.Hour, true),
+		entry(1, 10, time.Date(2018, 6, 11, 10, 0, 0, 0, time.UTC), time.Hour, true),
+		entry(1, 10, time.Date(2018, 6, 17, 10, 0, 0, 0, time.UTC), time.Hour, false),
+	}
+
+	ts, e := Build(entries, GroupByWeek, time.UTC, time.Now())
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	if len(ts.Rows) != 2 {
+		t.Fatalf("Expected 2 rows, got %+v", ts.Rows)
+	}
+	if ts.Rows[1].Key != "2018-W24" || ts.Rows[1].Total != 2*time.Hour || ts.Rows[1].Date.Day() != 11 {
+		t.Fatalf("Invalid row: %+v", ts.Rows[1])
+	}
+}
+
+func TestBuildRunningTimer(t *testing.T) {
+	start := time.Date(2018, 6, 11, 9, 0, 0, 0, time.UTC)
+	entries := []models.TaskSpentTime{{SpentByID: 1, TaskID: 10, StartDate: start}}
+
+	ts, e := Build(entries, GroupByTask, time.UTC, start.Add(45*time.Minute))
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	if ts.Total != 45*time.Minute {
+		t.Fatalf("Running timer must be counted up to now, got %v", ts.Total)
+	}
+}
+
+func TestBuildInvalidGroupBy(t *testing.T) {
+	if _, e := Build(nil, "month", time.UTC, time.Now()); e != ErrInvalidGroupBy {
+		t.Fatal("Expected ErrInvalidGroupBy, got", e)
+	}
+}
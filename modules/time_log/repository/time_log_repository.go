@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/jinzhu/gorm"
+
+	"devin/models"
+)
+
+// ErrNoRunningTimer returned when user has no open spent time to stop
+var ErrNoRunningTimer = errors.New("No running timer found")
+
+// ErrTimeLogNotFound returned when the spent time is not found in the project
+var ErrTimeLogNotFound = errors.New("Time log not found")
+
+// GetRunningTimer load the open spent time of the user.
+// If user has no running timer, ErrNoRunningTimer is returned.
+func GetRunningTimer(db *gorm.DB, userID uint64) (timeLog models.TaskSpentTime, e error) {
+	db.Model(&timeLog).
+		Preload("Task").
+		Where("spent_by_id=? AND end_date IS NULL", userID).
+		First(&timeLog)
+	if timeLog.ID == 0 {
+		e = ErrNoRunningTimer
+	}
+
+	return
+}
+
+// StartTimer start a new timer for the user on the given task.
+// If user already has a running timer it is stopped in the same transaction,
+// so every user has at most one open entry. The stopped entry is returned if any.
+func StartTimer(db *gorm.DB, timeLog *models.TaskSpentTime) (stopped *models.TaskSpentTime, e error) {
+	tx := db.Begin()
+	defer func() {
+		if e != nil {
+			tx.Rollback()
+		}
+	}()
+
+	// Serialize start/stop requests of the same user
+	e = tx.Exec("SELECT id FROM users WHERE id=? FOR UPDATE", timeLog.SpentByID).Error
+	if e != nil {
+		return
+	}
+
+	now := time.Now()
+	running, e := GetRunningTimer(tx, timeLog.SpentByID)
+	if e == nil {
+		running.EndDate = &now
+		e = tx.Model(&running).UpdateColumn("end_date", now).Error
+		if e != nil {
+			return
+		}
+		stopped = &running
+	}
+
+	timeLog.StartDate = now
+	timeLog.EndDate = nil
+	e = tx.Create(timeLog).Error
+	if e != nil {
+		return
+	}
+
+	e = tx.Commit().Error
+
+	return
+}
+
+// StopTimer stop the running timer of the user
+func StopTimer(db *gorm.DB, userID uint64) (timeLog models.TaskSpentTime, e error) {
+	timeLog, e = GetRunningTimer(db, userID)
+	if e != nil {
+		return
+	}
+
+	now := time.Now()
+	e = db.Model(&timeLog).
+		Where("end_date IS NULL").
+		UpdateColumn("end_date", now).
+		Error
+	timeLog.EndDate = &now
+
+	return
+}
+
+// GetTimeLogByID load a spent time which belongs to a task of the project
+func GetTimeLogByID(db *gorm.DB, projectID, timeLogID uint64) (timeLog models.TaskSpentTime, e error) {
+	db.Model(&timeLog).
+		Preload("Task").
+		Where("id=? AND task_id IN (SELECT id FROM tasks WHERE project_id=?)", timeLogID, projectID).
+		First(&timeLog)
+	if timeLog.ID == 0 {
+		e = ErrTimeLogNotFound
+	}
+
+	return
+}
+
+// SaveTimeLog insert new spent time or update the existing one
+func SaveTimeLog(db *gorm.DB, timeLog *models.TaskSpentTime) error {
+	if timeLog.ID == 0 {
+		return db.Create(timeLog).Error
+	}
+
+	return db.Save(timeLog).Error
+}
+
+// DeleteTimeLog soft delete the given spent time
+func DeleteTimeLog(db *gorm.DB, timeLog models.TaskSpentTime) error {
+	return db.Delete(&timeLog).Error
+}
+
+// GetTimesheetEntries load all spent times matching the filters, sorted by start date
+func GetTimesheetEntries(db *gorm.DB, searchModel models.TimesheetSearch) (entries []models.TaskSpentTime, e error) {
+	db = db.Model(&models.TaskSpentTime{})
+	db = searchModel.GetWhereClause(db)
+
+	e = db.Preload("Task").
+		Preload("SpentBy").
+		Order("start_date ASC, id ASC").
+		Find(&entries).
+		Error
+
+	return
+}
@@ -0,0 +1,507 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/jinzhu/gorm"
+	"golang.org/x/crypto/bcrypt"
+
+	"devin/crypto"
+	"devin/database"
+	"devin/helpers"
+	"devin/models"
+	"devin/modules/audit"
+	audit_repo "devin/modules/audit/repository"
+	"devin/modules/rw_helpers"
+	"devin/modules/two_factor"
+	two_factor_repo "devin/modules/two_factor/repository"
+)
+
+// TwoFactorController handle TOTP enrollment, second step of signin and requirement of organizations
+type TwoFactorController struct{}
+
+// codeReqModel is the request of endpoints which verify a code.
+// Token is the token of the first signin step on signin endpoints.
+type codeReqModel struct {
+	Token        string
+	Code         string
+	RecoveryCode string
+	Password     string
+}
+
+// statusResponse is the two-factor state of the authenticated user
+type statusResponse struct {
+	Enabled           bool
+	Required          bool
+	RecoveryCodesLeft uint64
+}
+
+// enrollResponse contains the secret to add to authenticator apps
+type enrollResponse struct {
+	Secret string
+	URI    string
+}
+
+// signinResponse contains the signed in user and recovery codes when TOTP is enabled on signin
+type signinResponse struct {
+	User          models.User
+	RecoveryCodes []string `json:",omitempty"`
+}
+
+// decodeCodeRequest decode request body to codeReqModel
+func decodeCodeRequest(w http.ResponseWriter, r *http.Request) (reqModel codeReqModel, e error) {
+	if helpers.IsRequestBodyNil(w, r) {
+		e = errors.New("Request body is nil")
+		return
+	}
+
+	e = json.NewDecoder(r.Body).Decode(&reqModel)
+	if e != nil {
+		err := helpers.ErrorResponse{
+			Message:   "Invalid request body",
+			ErrorCode: http.StatusBadRequest,
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+	defer r.Body.Close()
+
+	return
+}
+
+// enroll create a pending secret of the user and write it with its otpauth URI
+func enroll(w http.ResponseWriter, db *gorm.DB, user models.User) {
+	if user.IsTwoFactorEnabled() {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusUnprocessableEntity,
+			Message:   "Two-factor authentication is already enabled",
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+
+	secret, e := two_factor.GenerateSecret()
+	var encrypted string
+	if e == nil {
+		encrypted, e = crypto.Encrypt(secret, crypto.PurposeTOTPSecret)
+	}
+	if e == nil {
+		e = two_factor_repo.SetPendingSecret(db, user.ID, encrypted)
+	}
+	if e != nil {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusInternalServerError,
+			Message:   "Fail to enroll two-factor authentication",
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+
+	res := enrollResponse{Secret: secret, URI: two_factor.URI(user.Email, secret)}
+	w.Header().Add("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&res)
+}
+
+// enable enable the enrolled secret of the user with new recovery codes and handle http errors
+func enable(w http.ResponseWriter, r *http.Request, db *gorm.DB, actor, user models.User, step int64) (codes []string, e error) {
+	codes, hashes, e := two_factor.NewRecoveryCodes()
+	if e == nil {
+		tx := db.Begin()
+		e = two_factor_repo.EnableTwoFactor(tx, user.ID, step)
+		if e == nil {
+			e = two_factor_repo.ReplaceRecoveryCodes(tx, user.ID, hashes)
+		}
+		if e == nil {
+			e = saveAudit(tx, r, actor, models.AUDIT_ACTION_TWO_FACTOR_ENABLE, user.ID, false, true)
+		}
+		if e == nil {
+			e = tx.Commit().Error
+		} else {
+			tx.Rollback()
+		}
+	}
+	if e != nil {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusInternalServerError,
+			Message:   "Fail to enable two-factor authentication",
+		}
+		helpers.NewErrorResponse(w, &err)
+	}
+
+	return
+}
+
+// saveAudit record the change of two-factor state of the user
+func saveAudit(db *gorm.DB, r *http.Request, actor models.User, action string, userID uint64, before, after bool) error {
+	entry := audit.New(r, actor, action, models.MODULE_USER, userID)
+	e := audit.SetChanges(&entry, struct{ TwoFactorEnabled bool }{before}, struct{ TwoFactorEnabled bool }{after})
+	if e != nil {
+		return e
+	}
+
+	return audit_repo.SaveAudit(db, &entry)
+}
+
+// Status return two-factor state of the authenticated user
+// @Route: /api/two_factor
+// @Method: GET
+func (TwoFactorController) Status(w http.ResponseWriter, r *http.Request) {
+	authUser, e := rw_helpers.GetAuthenticatedUser(w, r)
+	if e != nil {
+		return
+	}
+
+	db := database.NewGORMInstance()
+	defer db.Close()
+
+	res := statusResponse{
+		Enabled:  authUser.IsTwoFactorEnabled(),
+		Required: two_factor_repo.IsTwoFactorRequired(db, authUser.ID),
+	}
+	if res.Enabled {
+		res.RecoveryCodesLeft = two_factor_repo.CountUnusedRecoveryCodes(db, authUser.ID)
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&res)
+}
+
+// Enroll create a new TOTP secret of the authenticated user, it is enabled by verifying a code on /two_factor/enable
+// @Route: /api/two_factor/enroll
+// @Method: POST
+func (TwoFactorController) Enroll(w http.ResponseWriter, r *http.Request) {
+	authUser, e := rw_helpers.GetAuthenticatedUser(w, r)
+	if e != nil {
+		return
+	}
+
+	db := database.NewGORMInstance()
+	defer db.Close()
+
+	enroll(w, db, authUser)
+}
+
+// Enable verify a code of the enrolled secret, enable two-factor authentication and return recovery codes
+// @Route: /api/two_factor/enable
+// @Method: POST
+// @Content-Type: application/json
+func (TwoFactorController) Enable(w http.ResponseWriter, r *http.Request) {
+	if rw_helpers.IsJSONRequest(w, r) == false {
+		return
+	}
+
+	authUser, e := rw_helpers.GetAuthenticatedUser(w, r)
+	if e != nil {
+		return
+	}
+
+	reqModel, e := decodeCodeRequest(w, r)
+	if e != nil {
+		return
+	}
+
+	if authUser.IsTwoFactorEnabled() {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusUnprocessableEntity,
+			Message:   "Two-factor authentication is already enabled",
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+
+	db := database.NewGORMInstance()
+	defer db.Close()
+
+	step, e := rw_helpers.CheckTwoFactorCode(w, db, authUser, reqModel.Code, "")
+	if e != nil {
+		return
+	}
+
+	codes, e := enable(w, r, db, authUser, authUser, step)
+	if e != nil {
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&codes)
+}
+
+// Disable disable two-factor authentication of the authenticated user after checking password and a code.
+// It is not allowed when an organization of the user requires it.
+// @Route: /api/two_factor/disable
+// @Method: POST
+// @Content-Type: application/json
+func (TwoFactorController) Disable(w http.ResponseWriter, r *http.Request) {
+	if rw_helpers.IsJSONRequest(w, r) == false {
+		return
+	}
+
+	authUser, e := rw_helpers.GetAuthenticatedUser(w, r)
+	if e != nil {
+		return
+	}
+
+	reqModel, e := decodeCodeRequest(w, r)
+	if e != nil {
+		return
+	}
+
+	if authUser.IsTwoFactorEnabled() == false {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusUnprocessableEntity,
+			Message:   "Two-factor authentication is not enabled",
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+
+	db := database.NewGORMInstance()
+	defer db.Close()
+
+	if two_factor_repo.IsTwoFactorRequired(db, authUser.ID) {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusForbidden,
+			Message:   "Two-factor authentication is required by your organization",
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+
+	if bcrypt.CompareHashAndPassword([]byte(authUser.Password), []byte(reqModel.Password)) != nil {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusUnprocessableEntity,
+			Message:   "Invalid password",
+			Errors:    map[string][]string{"Password": {"Invalid password"}},
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+
+	if _, e = rw_helpers.CheckTwoFactorCode(w, db, authUser, reqModel.Code, reqModel.RecoveryCode); e != nil {
+		return
+	}
+
+	tx := db.Begin()
+	e = two_factor_repo.DisableTwoFactor(tx, authUser.ID)
+	if e == nil {
+		e = saveAudit(tx, r, authUser, models.AUDIT_ACTION_TWO_FACTOR_DISABLE, authUser.ID, true, false)
+	}
+	if e == nil {
+		e = tx.Commit().Error
+	} else {
+		tx.Rollback()
+	}
+	if e != nil {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusInternalServerError,
+			Message:   "Fail to disable two-factor authentication",
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+
+	helpers.NewSuccessResponse(w, "Two-factor authentication disabled.")
+}
+
+// RegenerateRecoveryCodes replace recovery codes of the authenticated user after checking a code
+// @Route: /api/two_factor/recovery_codes/regenerate
+// @Method: POST
+// @Content-Type: application/json
+func (TwoFactorController) RegenerateRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	if rw_helpers.IsJSONRequest(w, r) == false {
+		return
+	}
+
+	authUser, e := rw_helpers.GetAuthenticatedUser(w, r)
+	if e != nil {
+		return
+	}
+
+	reqModel, e := decodeCodeRequest(w, r)
+	if e != nil {
+		return
+	}
+
+	if authUser.IsTwoFactorEnabled() == false {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusUnprocessableEntity,
+			Message:   "Two-factor authentication is not enabled",
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+
+	db := database.NewGORMInstance()
+	defer db.Close()
+
+	if _, e = rw_helpers.CheckTwoFactorCode(w, db, authUser, reqModel.Code, ""); e != nil {
+		return
+	}
+
+	codes, hashes, e := two_factor.NewRecoveryCodes()
+	if e == nil {
+		e = two_factor_repo.ReplaceRecoveryCodes(db, authUser.ID, hashes)
+	}
+	if e != nil {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusInternalServerError,
+			Message:   "Fail to generate recovery codes",
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&codes)
+}
+
+// SigninEnroll create a TOTP secret for a user whose organization requires two-factor authentication,
+// with the token of the first signin step
+// @Route: /api/signin/two_factor/enroll
+// @Method: POST
+// @Content-Type: application/json
+func (TwoFactorController) SigninEnroll(w http.ResponseWriter, r *http.Request) {
+	if rw_helpers.IsJSONRequest(w, r) == false {
+		return
+	}
+
+	reqModel, e := decodeCodeRequest(w, r)
+	if e != nil {
+		return
+	}
+
+	db := database.NewGORMInstance()
+	defer db.Close()
+
+	user, e := rw_helpers.GetMFAPendingUser(w, db, reqModel.Token)
+	if e != nil {
+		return
+	}
+
+	enroll(w, db, user)
+}
+
+// Signin verify the second factor with the token of the first signin step and start a session.
+// A code of an enrolled secret also enables two-factor authentication and returns recovery codes.
+// @Route: /api/signin/two_factor
+// @Method: POST
+// @Content-Type: application/json
+func (TwoFactorController) Signin(w http.ResponseWriter, r *http.Request) {
+	if rw_helpers.IsJSONRequest(w, r) == false {
+		return
+	}
+
+	reqModel, e := decodeCodeRequest(w, r)
+	if e != nil {
+		return
+	}
+
+	db := database.NewGORMInstance()
+	defer db.Close()
+
+	user, e := rw_helpers.GetMFAPendingUser(w, db, reqModel.Token)
+	if e != nil {
+		return
+	}
+
+	step, e := rw_helpers.CheckTwoFactorCode(w, db, user, reqModel.Code, reqModel.RecoveryCode)
+	if e != nil {
+		return
+	}
+
+	res := signinResponse{User: user}
+	if user.IsTwoFactorEnabled() == false {
+		res.RecoveryCodes, e = enable(w, r, db, user, user, step)
+		if e != nil {
+			return
+		}
+	}
+
+	if _, e = rw_helpers.StartSession(w, r, db, user); e != nil {
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&res)
+}
+
+// RequireForOrganization set the requirement of two-factor authentication for members of the organization.
+// Only the owner of the organization can change it.
+// @Route: /api/organization/{organization_id}/require_two_factor
+// @Method: POST
+// @Content-Type: application/json
+func (TwoFactorController) RequireForOrganization(w http.ResponseWriter, r *http.Request) {
+	if rw_helpers.IsJSONRequest(w, r) == false {
+		return
+	}
+
+	authUser, e := rw_helpers.GetAuthenticatedUser(w, r)
+	if e != nil {
+		return
+	}
+
+	organizationID, e := rw_helpers.ExtractOrganizationID(w, r, "organization_id")
+	if e != nil {
+		return
+	}
+
+	var reqModel struct{ Required bool }
+	if helpers.IsRequestBodyNil(w, r) {
+		return
+	}
+	e = json.NewDecoder(r.Body).Decode(&reqModel)
+	if e != nil {
+		err := helpers.ErrorResponse{
+			Message:   "Invalid request body",
+			ErrorCode: http.StatusBadRequest,
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+	defer r.Body.Close()
+
+	db := database.NewGORMInstance()
+	defer db.Close()
+
+	organization, e := rw_helpers.FetchOrganizationFromDB(w, db, organizationID)
+	if e != nil {
+		return
+	}
+
+	if organization.OwnerID == nil || *organization.OwnerID != authUser.ID {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusForbidden,
+			Message:   "This action is not allowed for you.",
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+
+	tx := db.Begin()
+	e = two_factor_repo.SetTwoFactorRequirement(tx, organization.ID, reqModel.Required)
+	if e == nil {
+		entry := audit.New(r, authUser, models.AUDIT_ACTION_TWO_FACTOR_REQUIRE, models.MODULE_ORGANIZATION, organization.ID)
+		entry.OrganizationID = &organization.ID
+		e = audit.SetChanges(&entry, struct{ RequireTwoFactor bool }{organization.RequireTwoFactor}, struct{ RequireTwoFactor bool }{reqModel.Required})
+		if e == nil {
+			e = audit_repo.SaveAudit(tx, &entry)
+		}
+	}
+	if e == nil {
+		e = tx.Commit().Error
+	} else {
+		tx.Rollback()
+	}
+	if e != nil {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusInternalServerError,
+			Message:   "Fail to update two-factor requirement",
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+
+	helpers.NewSuccessResponse(w, "Two-factor requirement updated.")
+}
@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+
+	"devin/models"
+	"devin/modules/two_factor"
+)
+
+// membersOfOrganizationSQL select the owner and members of the organization
+const membersOfOrganizationSQL = `SELECT owner_id FROM users WHERE id=? AND owner_id IS NOT NULL
+    UNION SELECT user_id FROM user_organization WHERE organization_id=? AND deleted_at IS NULL`
+
+// SetPendingSecret save the encrypted secret of a new enrollment, it is enabled after verifying a code
+func SetPendingSecret(db *gorm.DB, userID uint64, secret string) error {
+	return db.Model(&models.User{}).Where("id=?", userID).UpdateColumns(map[string]interface{}{
+		"two_factor_secret":     secret,
+		"two_factor_enabled_at": nil,
+		"two_factor_last_step":  0,
+		"two_factor_failures":   0,
+	}).Error
+}
+
+// EnableTwoFactor enable the enrolled secret and save the step of the verified code
+func EnableTwoFactor(db *gorm.DB, userID uint64, step int64) error {
+	return db.Model(&models.User{}).Where("id=? AND two_factor_secret IS NOT NULL", userID).UpdateColumns(map[string]interface{}{
+		"two_factor_enabled_at": time.Now(),
+		"two_factor_last_step":  step,
+		"two_factor_failures":   0,
+	}).Error
+}
+
+// DisableTwoFactor remove the secret and recovery codes of the user
+func DisableTwoFactor(db *gorm.DB, userID uint64) error {
+	e := db.Model(&models.User{}).Where("id=?", userID).UpdateColumns(map[string]interface{}{
+		"two_factor_secret":     nil,
+		"two_factor_enabled_at": nil,
+		"two_factor_last_step":  0,
+		"two_factor_failures":   0,
+	}).Error
+	if e != nil {
+		return e
+	}
+
+	return db.Where("user_id=?", userID).Delete(&models.RecoveryCode{}).Error
+}
+
+// UseStep save the step of a valid code and reset failures.
+// It returns false when a code of the step or a later step is already used by a concurrent request.
+func UseStep(db *gorm.DB, userID uint64, step int64) bool {
+	res := db.Model(&models.User{}).
+		Where("id=? AND two_factor_last_step<?", userID, step).
+		UpdateColumns(map[string]interface{}{"two_factor_last_step": step, "two_factor_failures": 0})
+
+	return res.Error == nil && res.RowsAffected == 1
+}
+
+// RecordFailure count a failed code, failures older than the lock duration are forgotten
+func RecordFailure(db *gorm.DB, userID uint64) error {
+	now := time.Now()
+	return db.Exec(`UPDATE users SET
+        two_factor_failures = CASE WHEN two_factor_failed_at < ? THEN 1 ELSE two_factor_failures + 1 END,
+        two_factor_failed_at = ?
+        WHERE id=?`, now.Add(-two_factor.LockDuration), now, userID).Error
+}
+
+// ReplaceRecoveryCodes delete recovery codes of the user and insert the new hashes
+func ReplaceRecoveryCodes(db *gorm.DB, userID uint64, hashes []string) error {
+	e := db.Where("user_id=?", userID).Delete(&models.RecoveryCode{}).Error
+	for _, hash := range hashes {
+		if e != nil {
+			break
+		}
+		e = db.Create(&models.RecoveryCode{UserID: userID, CodeHash: hash}).Error
+	}
+
+	return e
+}
+
+// UseRecoveryCode mark the unused recovery code of the hash as used, it returns false if there is no such code
+func UseRecoveryCode(db *gorm.DB, userID uint64, hash string) bool {
+	res := db.Model(&models.RecoveryCode{}).
+		Where("user_id=? AND code_hash=? AND used_at IS NULL", userID, hash).
+		UpdateColumn("used_at", time.Now())
+	if res.Error != nil || res.RowsAffected != 1 {
+		return false
+	}
+
+	db.Model(&models.User{}).Where("id=?", userID).UpdateColumn("two_factor_failures", 0)
+
+	return true
+}
+
+// CountUnusedRecoveryCodes count recovery codes of the user which are not used
+func CountUnusedRecoveryCodes(db *gorm.DB, userID uint64) (cnt uint64) {
+	db.Model(&models.RecoveryCode{}).Where("user_id=? AND used_at IS NULL", userID).Count(&cnt)
+	return
+}
+
+// IsTwoFactorRequired check the user to own or be member of an organization which requires two-factor authentication
+func IsTwoFactorRequired(db *gorm.DB, userID uint64) bool {
+	var cnt uint64
+	db.Model(&models.User{}).
+		Where("user_type=2 AND require_two_factor=true").
+		Where(`(owner_id=? OR id IN (SELECT organization_id FROM user_organization WHERE user_id=? AND deleted_at IS NULL))`, userID, userID).
+		Count(&cnt)
+
+	return cnt > 0
+}
+
+// SetTwoFactorRequirement set the requirement of two-factor authentication for members of the organization.
+// When it is required, sessions of members without two-factor authentication are revoked,
+// so they enroll on their next signin.
+func SetTwoFactorRequirement(db *gorm.DB, organizationID uint64, required bool) error {
+	e := db.Model(&models.User{}).Where("id=?", organizationID).UpdateColumn("require_two_factor", required).Error
+	if e != nil || required == false {
+		return e
+	}
+
+	return db.Exec(`UPDATE sessions SET revoked_at=now(), updated_at=now()
+        WHERE revoked_at IS NULL
+        AND user_id IN (`+membersOfOrganizationSQL+`)
+        AND user_id IN (SELECT id FROM users WHERE two_factor_enabled_at IS NULL)`, organizationID, organizationID).Error
+}
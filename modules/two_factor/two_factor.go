@@ -0,0 +1,154 @@
+// Package two_factor implement TOTP second factor (RFC 6238) and one-time recovery codes
+package two_factor
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// Issuer is shown by authenticator apps beside account name
+	Issuer = "Devin"
+
+	// Period is the time step of codes
+	Period = 30 * time.Second
+
+	// Digits of codes
+	Digits = 6
+
+	// skew is the number of accepted time steps before and after now, for clock drift of devices
+	skew = 1
+
+	// secretLength is the number of random bytes of secrets, as recommended by RFC 4226
+	secretLength = 20
+
+	// RecoveryCodesCount is the number of generated recovery codes
+	RecoveryCodesCount = 10
+
+	// MaxFailures is the number of failed codes in a row before codes are rejected for LockDuration
+	MaxFailures = 5
+
+	// LockDuration is the time codes are rejected after MaxFailures
+	LockDuration = 15 * time.Minute
+)
+
+var encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateSecret create a random base32 encoded secret
+func GenerateSecret() (string, error) {
+	bts := make([]byte, secretLength)
+	_, e := rand.Read(bts)
+	if e != nil {
+		return "", e
+	}
+
+	return encoding.EncodeToString(bts), nil
+}
+
+// URI return otpauth URI of the secret, shown as QR code to enroll authenticator apps
+func URI(account, secret string) string {
+	label := url.PathEscape(Issuer + ":" + account)
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", Issuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprint(Digits))
+	query.Set("period", fmt.Sprint(int(Period.Seconds())))
+
+	return "otpauth://totp/" + label + "?" + query.Encode()
+}
+
+// Step return the time step of t
+func Step(t time.Time) int64 {
+	return t.Unix() / int64(Period.Seconds())
+}
+
+// Code return the code of secret at time step
+func Code(secret string, step int64) (string, error) {
+	key, e := encoding.DecodeString(strings.ToUpper(strings.TrimRight(secret, "=")))
+	if e != nil {
+		return "", e
+	}
+
+	msg := make([]byte, 8)
+	binary.BigEndian.PutUint64(msg, uint64(step))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg)
+	sum := mac.Sum(nil)
+
+	// Dynamic truncation of RFC 4226
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	mod := uint32(1)
+	for i := 0; i < Digits; i++ {
+		mod *= 10
+	}
+
+	return fmt.Sprintf("%0*d", Digits, value%mod), nil
+}
+
+// Validate check the code against steps around now which are after lastStep.
+// The step of a valid code must be saved as the last step, so the code can not be used again.
+func Validate(secret, code string, now time.Time, lastStep int64) (step int64, ok bool) {
+	code = strings.Replace(strings.TrimSpace(code), " ", "", -1)
+	if len(code) != Digits {
+		return 0, false
+	}
+
+	current := Step(now)
+	for step = current - skew; step <= current+skew; step++ {
+		if step <= lastStep {
+			continue
+		}
+		expected, e := Code(secret, step)
+		if e != nil {
+			return 0, false
+		}
+		if hmac.Equal([]byte(expected), []byte(code)) {
+			return step, true
+		}
+	}
+
+	return 0, false
+}
+
+// IsLocked check codes to be rejected after too many failures
+func IsLocked(failures uint, failedAt *time.Time, now time.Time) bool {
+	return failures >= MaxFailures && failedAt != nil && now.Sub(*failedAt) < LockDuration
+}
+
+// NewRecoveryCodes generate recovery codes in xxxxx-xxxxx format and hashes of them
+func NewRecoveryCodes() (codes, hashes []string, e error) {
+	for i := 0; i < RecoveryCodesCount; i++ {
+		bts := make([]byte, 5)
+		_, e = rand.Read(bts)
+		if e != nil {
+			return nil, nil, e
+		}
+
+		code := hex.EncodeToString(bts)
+		code = code[:5] + "-" + code[5:]
+		codes = append(codes, code)
+		hashes = append(hashes, HashRecoveryCode(code))
+	}
+
+	return
+}
+
+// HashRecoveryCode return hex encoded SHA-256 hash of the code ignoring case, spaces and dashes
+func HashRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	code = strings.NewReplacer("-", "", " ", "").Replace(code)
+	sum := sha256.Sum256([]byte(code))
+
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,93 @@
+package two_factor
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfcSecret is base32 of the SHA1 secret of RFC 6238 test vectors
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestCode(t *testing.T) {
+	// Last 6 digits of RFC 6238 Appendix B codes
+	cases := map[int64]string{
+		59:         "287082",
+		1111111109: "081804",
+		1234567890: "005924",
+		2000000000: "279037",
+	}
+	for unix, expected := range cases {
+		code, e := Code(rfcSecret, Step(time.Unix(unix, 0)))
+		if e != nil || code != expected {
+			t.Fatal("Expected", expected, "got", code, e)
+		}
+	}
+
+	if _, e := Code("not base32!", 1); e == nil {
+		t.Fatal("Invalid secret must fail")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Unix(1111111109, 0)
+	current := Step(now)
+	code, _ := Code(rfcSecret, current)
+
+	step, ok := Validate(rfcSecret, code, now, 0)
+	if ok == false || step != current {
+		t.Fatal("Current code must be valid")
+	}
+	if _, ok = Validate(rfcSecret, code, now, current); ok {
+		t.Fatal("Used code must be rejected")
+	}
+	if _, ok = Validate(rfcSecret, code[:3]+" "+code[3:], now.Add(Period), 0); ok == false {
+		t.Fatal("Code of previous step must be accepted with spaces")
+	}
+	if _, ok = Validate(rfcSecret, code, now.Add(2*Period), 0); ok {
+		t.Fatal("Old code must be rejected")
+	}
+	if _, ok = Validate(rfcSecret, "12345", now, 0); ok {
+		t.Fatal("Short code must be rejected")
+	}
+}
+
+func TestGenerateSecret(t *testing.T) {
+	secret, e := GenerateSecret()
+	if e != nil || len(secret) != 32 {
+		t.Fatal("Invalid secret", secret, e)
+	}
+	if _, e = Code(secret, 1); e != nil {
+		t.Fatal(e)
+	}
+
+	uri := URI("ali@example.com", secret)
+	if strings.HasPrefix(uri, "otpauth://totp/Devin:ali@example.com?") == false || strings.Contains(uri, "secret="+secret) == false {
+		t.Fatal("Invalid URI", uri)
+	}
+}
+
+func TestIsLocked(t *testing.T) {
+	now := time.Now()
+	recent := now.Add(-time.Minute)
+	old := now.Add(-LockDuration)
+	if IsLocked(MaxFailures-1, &recent, now) || IsLocked(MaxFailures, &old, now) || IsLocked(MaxFailures, nil, now) {
+		t.Fatal("Codes must not be locked")
+	}
+	if IsLocked(MaxFailures, &recent, now) == false {
+		t.Fatal("Codes must be locked after too many failures")
+	}
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	codes, hashes, e := NewRecoveryCodes()
+	if e != nil || len(codes) != RecoveryCodesCount || len(hashes) != RecoveryCodesCount {
+		t.Fatal("Invalid recovery codes", codes, e)
+	}
+	if len(codes[0]) != 11 || codes[0][5] != '-' || codes[0] == codes[1] {
+		t.Fatal("Invalid recovery code", codes[0], codes[1])
+	}
+	if HashRecoveryCode(" "+strings.ToUpper(strings.Replace(codes[0], "-", "", 1))) != hashes[0] {
+		t.Fatal("Hash must ignore case, spaces and dashes")
+	}
+}
@@ -11,6 +11,8 @@ import (
 	"devin/database"
 	"devin/helpers"
 	"devin/models"
+	"devin/modules/rw_helpers"
+	two_factor_repo "devin/modules/two_factor/repository"
 )
 
 type SigninReq struct {
@@ -18,6 +20,18 @@ type SigninReq struct {
 	Password string
 }
 
+// twoFactorSigninResponse is returned by Signin when the second factor is needed
+type twoFactorSigninResponse struct {
+	TwoFactorRequired bool
+
+	// TOTP is not enrolled, but an organization of the user requires it.
+	// It is enrolled with the token on /signin/two_factor/enroll before the second step.
+	EnrollmentRequired bool
+
+	// Short-lived token of the first step, sent to /signin/two_factor endpoints
+	Token string
+}
+
 // Signin handle user login.
 // Method: POST
 // Content-Type: application/json
@@ -102,14 +116,28 @@ func Signin(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	claim := user.GenerateNewTokenClaim()
+	// Session starts after the second step on /signin/two_factor
+	if user.IsTwoFactorEnabled() || two_factor_repo.IsTwoFactorRequired(db, user.ID) {
+		tokenString, err := user.GenerateNewTokenString(user.GenerateMFAPendingTokenClaim())
+		if err != nil {
+			helpers.NewErrorResponse(w, err)
+			return
+		}
+
+		res := twoFactorSigninResponse{
+			TwoFactorRequired:  true,
+			EnrollmentRequired: user.IsTwoFactorEnabled() == false,
+			Token:              tokenString,
+		}
+		w.Header().Add("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(&res)
+		return
+	}
 
-	tokenString, err := user.GenerateNewTokenString(claim)
-	if err != nil {
-		helpers.NewErrorResponse(w, err)
+	// Access token is short-lived, the refresh token of the session gets new ones
+	if _, e = rw_helpers.StartSession(w, r, db, user); e != nil {
 		return
 	}
-	user.SetAuthorizationCookieAndHeader(w, tokenString)
 
 	w.Header().Add("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(&user)
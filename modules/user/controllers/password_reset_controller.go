@@ -12,7 +12,9 @@ import (
 
 	"devin/database"
 	"devin/helpers"
+	"devin/mailer/outbox"
 	"devin/models"
+	session_repo "devin/modules/session/repository"
 	"devin/modules/user/repository"
 )
 
@@ -49,7 +51,27 @@ func RequestPasswordReset(w http.ResponseWriter, r *http.Request) {
 	reset.Token = helpers.RandomString(64)
 	reset.ExpiresAt = time.Now().Add(24 * time.Hour)
 
-	db.Model(&models.PasswordReset{}).Create(&reset)
+	tx := db.Begin()
+	e = tx.Model(&models.PasswordReset{}).Create(&reset).Error
+	if e == nil {
+		e = outbox.EnqueuePasswordReset(tx, user, reset)
+	}
+	if e == nil {
+		e = tx.Commit().Error
+	} else {
+		tx.Rollback()
+	}
+
+	if e != nil {
+		err := helpers.ErrorResponse{
+			Message:   "Fail to send password reset link, please try again!",
+			ErrorCode: http.StatusInternalServerError,
+		}
+		err.Errors = make(map[string][]string)
+		err.Errors["dev"] = []string{e.Error()}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
 
 	helpers.NewSuccessResponse(w, "Password reset link sent to your email, please click to reset your new password!")
 }
@@ -183,8 +205,34 @@ func ResetPassword(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	before := struct{ Password string }{user.Password}
 	user.SetEncryptedPassword(reqModel.Password)
-	db.Model(&user).UpdateColumn("password", user.Password)
+	after := struct{ Password string }{user.Password}
+
+	// User is not authenticated, but owner of the reset token is the actor
+	tx := db.Begin()
+	e = tx.Model(&user).UpdateColumn("password", user.Password).Error
+	if e == nil {
+		e = saveUserAudit(tx, r, user, models.AUDIT_ACTION_PASSWORD_RESET, user, before, after)
+	}
+	// Whoever knew the old password is signed out
+	if e == nil {
+		e = session_repo.RevokeSessionsOfUser(tx, user.ID, 0)
+	}
+	if e == nil {
+		e = tx.Commit().Error
+	} else {
+		tx.Rollback()
+	}
+	if e != nil {
+		err := helpers.ErrorResponse{
+			Message:   "Fail to update password, please try again!",
+			ErrorCode: http.StatusInternalServerError,
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+
 	expireResetPasswordToken(db, reqModel.Token)
 	helpers.NewSuccessResponse(w, "Your password updated successfully!")
 	return
@@ -9,6 +9,7 @@ import (
 
 	"devin/database"
 	"devin/helpers"
+	"devin/mailer/outbox"
 	"devin/models"
 
 	"github.com/jinzhu/gorm"
@@ -97,8 +98,18 @@ func Signup(w http.ResponseWriter, r *http.Request) {
 	user.SetEncryptedPassword(user.PlainPassword)
 	user.SetNewEmailVerificationToken()
 
-	// Saving data
-	e = db.Save(&user).Error
+	// Saving data and queue the verification email in the same transaction,
+	// so a user is never registered without a verification link
+	tx := db.Begin()
+	e = tx.Save(&user).Error
+	if e == nil {
+		e = outbox.EnqueueVerification(tx, user)
+	}
+	if e == nil {
+		e = tx.Commit().Error
+	} else {
+		tx.Rollback()
+	}
 
 	if e != nil {
 		err := helpers.ErrorResponse{
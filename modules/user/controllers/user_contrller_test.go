@@ -59,7 +59,7 @@ func TestHandleProfileSharedErrors(t *testing.T) {
 	route.Handle(path, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		db := database.NewGORMInstance()
 		defer db.Close()
-		_, err := handleProfileSharedErrors(r, db)
+		_, _, err := handleProfileSharedErrors(r, db)
 		if err == nil {
 			return
 		}
@@ -17,10 +17,29 @@ import (
 	"devin/database"
 	"devin/helpers"
 	"devin/models"
+	"devin/modules/audit"
+	audit_repo "devin/modules/audit/repository"
+	session_repo "devin/modules/session/repository"
 	"devin/policies"
 )
 
-func handleProfileSharedErrors(r *http.Request, db *gorm.DB) (user models.User, err *helpers.ErrorResponse) {
+// saveUserAudit record changes of user made by the authenticated user.
+// Changes of an organization are shown in audit log of that organization.
+func saveUserAudit(db *gorm.DB, r *http.Request, authUser models.User, action string, user models.User, before, after interface{}) error {
+	entry := audit.New(r, authUser, action, models.MODULE_USER, user.ID)
+	if user.UserType == 2 {
+		entry.OrganizationID = &user.ID
+	}
+
+	e := audit.SetChanges(&entry, before, after)
+	if e != nil {
+		return e
+	}
+
+	return audit_repo.SaveAudit(db, &entry)
+}
+
+func handleProfileSharedErrors(r *http.Request, db *gorm.DB) (user, authUser models.User, err *helpers.ErrorResponse) {
 
 	// Check content type
 	if !helpers.HasJSONRequest(r) {
@@ -60,7 +79,7 @@ func handleProfileSharedErrors(r *http.Request, db *gorm.DB) (user models.User,
 		return
 	}
 
-	authUser, _, e := user.ExtractUserFromRequestContext(r)
+	authUser, _, e = user.ExtractUserFromRequestContext(r)
 	if e != nil {
 		err = &helpers.ErrorResponse{
 			ErrorCode: http.StatusUnauthorized,
@@ -100,7 +119,7 @@ func handleProfileSharedErrors(r *http.Request, db *gorm.DB) (user models.User,
 func UpdateProfile(w http.ResponseWriter, r *http.Request) {
 	db := database.NewGORMInstance()
 	defer db.Close()
-	user, err := handleProfileSharedErrors(r, db)
+	user, authUser, err := handleProfileSharedErrors(r, db)
 	if err != nil {
 		helpers.NewErrorResponse(w, err)
 		return
@@ -119,8 +138,20 @@ func UpdateProfile(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user.PublicProfile = profile
-	e = db.Model(&user).Where("id=?", user.ID).Update(&profile).Error
+	before := user.PublicProfile
+	tx := db.Begin()
+	e = tx.Model(&user).Where("id=?", user.ID).Update(&profile).Error
+	if e == nil {
+		e = tx.Where("id=?", user.ID).First(&user).Error
+	}
+	if e == nil {
+		e = saveUserAudit(tx, r, authUser, models.AUDIT_ACTION_PROFILE_UPDATE, user, before, user.PublicProfile)
+	}
+	if e == nil {
+		e = tx.Commit().Error
+	} else {
+		tx.Rollback()
+	}
 	if e != nil {
 		err := helpers.ErrorResponse{
 			ErrorCode: http.StatusInternalServerError,
@@ -139,7 +170,7 @@ func UpdateProfile(w http.ResponseWriter, r *http.Request) {
 func UpdateUsername(w http.ResponseWriter, r *http.Request) {
 	db := database.NewGORMInstance()
 	defer db.Close()
-	user, err := handleProfileSharedErrors(r, db)
+	user, authUser, err := handleProfileSharedErrors(r, db)
 	if err != nil {
 		helpers.NewErrorResponse(w, err)
 		return
@@ -194,8 +225,18 @@ func UpdateUsername(w http.ResponseWriter, r *http.Request) {
 	}
 
 	//Update
+	before := struct{ Username string }{user.Username}
 	user.Username = reqModel.Username
-	e = db.Model(&user).Update(&user).Error
+	tx := db.Begin()
+	e = tx.Model(&user).Update(&user).Error
+	if e == nil {
+		e = saveUserAudit(tx, r, authUser, models.AUDIT_ACTION_USERNAME_UPDATE, user, before, reqModel)
+	}
+	if e == nil {
+		e = tx.Commit().Error
+	} else {
+		tx.Rollback()
+	}
 	if e != nil {
 		err := helpers.ErrorResponse{
 			ErrorCode: http.StatusInternalServerError,
@@ -216,7 +257,7 @@ func UpdateUsername(w http.ResponseWriter, r *http.Request) {
 func UpdateEmail(w http.ResponseWriter, r *http.Request) {
 	db := database.NewGORMInstance()
 	defer db.Close()
-	user, err := handleProfileSharedErrors(r, db)
+	user, authUser, err := handleProfileSharedErrors(r, db)
 	if err != nil {
 		helpers.NewErrorResponse(w, err)
 		return
@@ -271,8 +312,18 @@ func UpdateEmail(w http.ResponseWriter, r *http.Request) {
 	}
 
 	//Update
+	before := struct{ Email string }{user.Email}
 	user.Email = reqModel.Email
-	e = db.Model(&user).UpdateColumn(&reqModel).Error
+	tx := db.Begin()
+	e = tx.Model(&user).UpdateColumn(&reqModel).Error
+	if e == nil {
+		e = saveUserAudit(tx, r, authUser, models.AUDIT_ACTION_EMAIL_UPDATE, user, before, reqModel)
+	}
+	if e == nil {
+		e = tx.Commit().Error
+	} else {
+		tx.Rollback()
+	}
 	if e != nil {
 		err := helpers.ErrorResponse{
 			ErrorCode: http.StatusInternalServerError,
@@ -386,7 +437,7 @@ func Whois(w http.ResponseWriter, r *http.Request) {
 
 	user.SetFullName()
 
-	if !policies.CanViewProfile(authUser, user) {
+	if !policies.CanViewProfile(db, authUser, user) {
 		err := helpers.ErrorResponse{
 			ErrorCode: http.StatusForbidden,
 			Message:   "This action is not allowed for you.",
@@ -433,7 +484,7 @@ func ProfileBasicInfo(w http.ResponseWriter, r *http.Request) {
 func UpdatePassword(w http.ResponseWriter, r *http.Request) {
 	db := database.NewGORMInstance()
 	defer db.Close()
-	user, err := handleProfileSharedErrors(r, db)
+	user, authUser, err := handleProfileSharedErrors(r, db)
 	if err != nil {
 		helpers.NewErrorResponse(w, err)
 		return
@@ -480,8 +531,27 @@ func UpdatePassword(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	//Change password
+	before := struct{ Password string }{user.Password}
 	user.SetEncryptedPassword(reqModel.Password)
-	e = db.Model(&user).Where("id=?", user.ID).Update(&user).Error
+	after := struct{ Password string }{user.Password}
+	tx := db.Begin()
+	e = tx.Model(&user).Where("id=?", user.ID).Update(&user).Error
+	if e == nil {
+		e = saveUserAudit(tx, r, authUser, models.AUDIT_ACTION_PASSWORD_UPDATE, user, before, after)
+	}
+	// Other sessions of the user are signed out, the session of the request is kept
+	if e == nil {
+		exceptID := uint64(0)
+		if authUser.ID == user.ID {
+			exceptID = models.CurrentSessionID(r)
+		}
+		e = session_repo.RevokeSessionsOfUser(tx, user.ID, exceptID)
+	}
+	if e == nil {
+		e = tx.Commit().Error
+	} else {
+		tx.Rollback()
+	}
 	if e != nil {
 		err := helpers.ErrorResponse{
 			ErrorCode: http.StatusInternalServerError,
@@ -614,13 +684,34 @@ func UpdateAvatar(w http.ResponseWriter, r *http.Request) {
 
 		return
 	}
-	if user.Avatar != nil {
-		oldFile := user.Avatar
-		os.Remove(*oldFile)
-	}
+	before := struct{ Avatar *string }{user.Avatar}
+	after := struct{ Avatar *string }{&fileName}
 	user.Avatar = &fileName
 
-	db.Model(&user).Save(&user)
+	tx := db.Begin()
+	e = tx.Model(&user).Save(&user).Error
+	if e == nil {
+		e = saveUserAudit(tx, r, authUser, models.AUDIT_ACTION_AVATAR_UPDATE, user, before, after)
+	}
+	if e == nil {
+		e = tx.Commit().Error
+	} else {
+		tx.Rollback()
+	}
+	if e != nil {
+		os.Remove(fileName)
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusInternalServerError,
+			Message:   "Error on updating avatar.",
+		}
+		log.Println("Error on updating avatar,", e)
+		helpers.NewErrorResponse(w, &err)
+
+		return
+	}
+	if before.Avatar != nil {
+		os.Remove(*before.Avatar)
+	}
 
 	var response struct {
 		Avatar string
@@ -0,0 +1,197 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/jinzhu/gorm"
+
+	"devin/database"
+	"devin/helpers"
+	"devin/markdown"
+	"devin/models"
+	"devin/modules/rw_helpers"
+	wiki_repo "devin/modules/wiki/repository"
+)
+
+type pageReqModel struct {
+	ID      uint64
+	Title   string
+	Content string
+	Summary *string
+
+	// Revision which the editor started from. When it's given and the page
+	// has a newer revision, saving fails instead of overwriting the changes.
+	BaseRevision *uint
+}
+
+// loadPage load project, wiki and the page given in URL
+func loadPage(w http.ResponseWriter, r *http.Request, db *gorm.DB, authUser models.User) (project models.Project, page models.WikiPage, e error) {
+	pageID, e := rw_helpers.ExtractIDFromURL(w, r)
+	if e != nil {
+		return
+	}
+
+	project, wiki, e := loadWiki(w, r, db, authUser, "wiki_id")
+	if e != nil {
+		return
+	}
+
+	page, e = rw_helpers.GetWikiPageByID(w, db, wiki.ID, pageID)
+
+	return
+}
+
+// writeSavePageError write http error of saving a page or restoring a revision
+func writeSavePageError(w http.ResponseWriter, e error) {
+	err := helpers.ErrorResponse{
+		ErrorCode: http.StatusInternalServerError,
+		Message:   "Fail to save page",
+	}
+	switch e {
+	case wiki_repo.ErrRevisionConflict:
+		err.ErrorCode = http.StatusConflict
+		err.Message = "Page is changed by someone else, reload it and apply your changes again!"
+	case wiki_repo.ErrNothingChanged:
+		err.ErrorCode = http.StatusUnprocessableEntity
+		err.Message = "Nothing changed!"
+	default:
+		err.Errors = make(map[string][]string)
+		err.Errors["dev"] = []string{e.Error()}
+	}
+	helpers.NewErrorResponse(w, &err)
+}
+
+// ShowPage return the page with its latest content, raw and rendered.
+// This route is accessible for anonymous users when public wiki is allowed.
+// @Route: /api/project/{project_id}/wiki/{wiki_id}/page/{id}
+// @Method: GET
+func (WikiController) ShowPage(w http.ResponseWriter, r *http.Request) {
+	authUser, e := rw_helpers.GetOptionalAuthenticatedUser(w, r)
+	if e != nil {
+		return
+	}
+
+	db := database.NewGORMInstance()
+	defer db.Close()
+
+	project, page, e := loadPage(w, r, db, authUser)
+	if e != nil {
+		return
+	}
+	page.ContentHTML = markdown.Render(page.Content, markdown.NewProjectResolver(db, project))
+
+	w.Header().Add("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&page)
+}
+
+// SavePage handle inserting and updating of wiki pages.
+// If no ID present in the request model, it will insert as new page
+// otherwise the given page will be updated. Each save creates a new revision of the page.
+// @Route: /api/project/{project_id}/wiki/{wiki_id}/pages/save
+// @Method: POST
+// @Content-Type: application/json
+func (WikiController) SavePage(w http.ResponseWriter, r *http.Request) {
+	if rw_helpers.IsJSONRequest(w, r) == false {
+		return
+	}
+
+	authUser, e := rw_helpers.GetAuthenticatedUser(w, r)
+	if e != nil {
+		return
+	}
+
+	var reqModel pageReqModel
+	if decodeJSONBody(w, r, &reqModel) != nil {
+		return
+	}
+
+	if reqModel.Summary != nil && len(*reqModel.Summary) > 255 {
+		err := helpers.ErrorResponse{
+			Message:   "Invalid data!",
+			ErrorCode: http.StatusUnprocessableEntity,
+		}
+		err.Errors = make(map[string][]string)
+		err.Errors["Summary"] = []string{"Summary can't be longer than 255 characters!"}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+
+	db := database.NewGORMInstance()
+	defer db.Close()
+
+	project, wiki, e := loadWiki(w, r, db, authUser, "wiki_id")
+	if e != nil {
+		return
+	}
+
+	if rw_helpers.CanCreateWiki(w, db, authUser, project) == false {
+		return
+	}
+
+	page := models.WikiPage{}
+	if reqModel.ID != 0 {
+		// Edit mode
+		page, e = rw_helpers.GetWikiPageByID(w, db, wiki.ID, reqModel.ID)
+		if e != nil {
+			return
+		}
+	} else {
+		page.WikiID = wiki.ID
+	}
+	page.Title = reqModel.Title
+	page.Content = reqModel.Content
+
+	if rw_helpers.ValidateWikiPageRequestModel(w, db, page) != nil {
+		return
+	}
+
+	_, e = wiki_repo.SavePage(db, &page, authUser.ID, reqModel.Summary, reqModel.BaseRevision)
+	if e != nil {
+		writeSavePageError(w, e)
+		return
+	}
+
+	page, e = rw_helpers.GetWikiPageByID(w, db, wiki.ID, page.ID)
+	if e != nil {
+		return
+	}
+	page.ContentHTML = markdown.Render(page.Content, markdown.NewProjectResolver(db, project))
+
+	w.Header().Add("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&page)
+}
+
+// DeletePage soft delete the page. Its revisions are kept.
+// @Route: /api/project/{project_id}/wiki/{wiki_id}/page/{id}/delete
+// @Method: POST
+func (WikiController) DeletePage(w http.ResponseWriter, r *http.Request) {
+	authUser, e := rw_helpers.GetAuthenticatedUser(w, r)
+	if e != nil {
+		return
+	}
+
+	db := database.NewGORMInstance()
+	defer db.Close()
+
+	project, page, e := loadPage(w, r, db, authUser)
+	if e != nil {
+		return
+	}
+
+	if rw_helpers.CanCreateWiki(w, db, authUser, project) == false {
+		return
+	}
+
+	e = wiki_repo.DeletePage(db, page)
+	if e != nil {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusInternalServerError,
+			Message:   "Fail to delete page",
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+
+	helpers.NewSuccessResponse(w, "Page deleted.")
+}
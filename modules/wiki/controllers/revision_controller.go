@@ -0,0 +1,206 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"devin/database"
+	"devin/helpers"
+	"devin/markdown"
+	"devin/models"
+	"devin/modules/rw_helpers"
+	"devin/modules/wiki/diff"
+	wiki_repo "devin/modules/wiki/repository"
+)
+
+// Number of unchanged lines shown around each change of a diff
+const diffContextLines = 3
+
+type diffResModel struct {
+	From models.WikiPageRevision
+	To   models.WikiPageRevision
+	Diff string
+}
+
+// RevisionsIndex return paginated revisions of the page with their authors, newest first.
+// This route is accessible for anonymous users when public wiki is allowed.
+// @Route: /api/project/{project_id}/wiki/{wiki_id}/page/{id}/revisions
+// @Method: GET
+func (WikiController) RevisionsIndex(w http.ResponseWriter, r *http.Request) {
+	authUser, e := rw_helpers.GetOptionalAuthenticatedUser(w, r)
+	if e != nil {
+		return
+	}
+
+	perPage := rw_helpers.GetPerPage(r)
+	currentPage := rw_helpers.GetCurrectpage(r)
+
+	db := database.NewGORMInstance()
+	defer db.Close()
+
+	_, page, e := loadPage(w, r, db, authUser)
+	if e != nil {
+		return
+	}
+
+	data, total, e := wiki_repo.GetRevisions(db, page.ID, currentPage, perPage)
+	if e != nil {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusInternalServerError,
+			Message:   "Fail to load revisions",
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+
+	var pgn models.Pagination
+	pgn.Make(data, total, currentPage, perPage)
+
+	w.Header().Add("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&pgn)
+}
+
+// ShowRevision return title and content of the page at the given revision.
+// This route is accessible for anonymous users when public wiki is allowed.
+// @Route: /api/project/{project_id}/wiki/{wiki_id}/page/{id}/revision/{revision}
+// @Method: GET
+func (WikiController) ShowRevision(w http.ResponseWriter, r *http.Request) {
+	authUser, e := rw_helpers.GetOptionalAuthenticatedUser(w, r)
+	if e != nil {
+		return
+	}
+
+	revisionNumber, e := rw_helpers.ExtractRevisionFromURL(w, r, "revision")
+	if e != nil {
+		return
+	}
+
+	db := database.NewGORMInstance()
+	defer db.Close()
+
+	project, page, e := loadPage(w, r, db, authUser)
+	if e != nil {
+		return
+	}
+
+	revision, e := rw_helpers.GetWikiPageRevision(w, db, page.ID, revisionNumber)
+	if e != nil {
+		return
+	}
+	revision.ContentHTML = markdown.Render(revision.Content, markdown.NewProjectResolver(db, project))
+
+	w.Header().Add("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&revision)
+}
+
+// Diff return unified diff of the page between two revisions.
+// From revision may be newer than to revision, the diff is reversed then.
+// This route is accessible for anonymous users when public wiki is allowed.
+// @Route: /api/project/{project_id}/wiki/{wiki_id}/page/{id}/diff/{from}/{to}
+// @Method: GET
+func (WikiController) Diff(w http.ResponseWriter, r *http.Request) {
+	authUser, e := rw_helpers.GetOptionalAuthenticatedUser(w, r)
+	if e != nil {
+		return
+	}
+
+	fromNumber, e := rw_helpers.ExtractRevisionFromURL(w, r, "from")
+	if e != nil {
+		return
+	}
+
+	toNumber, e := rw_helpers.ExtractRevisionFromURL(w, r, "to")
+	if e != nil {
+		return
+	}
+
+	db := database.NewGORMInstance()
+	defer db.Close()
+
+	_, page, e := loadPage(w, r, db, authUser)
+	if e != nil {
+		return
+	}
+
+	from, e := rw_helpers.GetWikiPageRevision(w, db, page.ID, fromNumber)
+	if e != nil {
+		return
+	}
+
+	to, e := rw_helpers.GetWikiPageRevision(w, db, page.ID, toNumber)
+	if e != nil {
+		return
+	}
+
+	res := diffResModel{
+		From: from,
+		To:   to,
+		Diff: diff.Unified(
+			fmt.Sprintf("%s\trevision %d", from.Title, from.Revision),
+			fmt.Sprintf("%s\trevision %d", to.Title, to.Revision),
+			from.Content,
+			to.Content,
+			diffContextLines,
+		),
+	}
+	res.From.Content = ""
+	res.To.Content = ""
+
+	w.Header().Add("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&res)
+}
+
+// Restore copy title and content of an old revision to the page as a new revision
+// @Route: /api/project/{project_id}/wiki/{wiki_id}/page/{id}/restore/{revision}
+// @Method: POST
+func (WikiController) Restore(w http.ResponseWriter, r *http.Request) {
+	authUser, e := rw_helpers.GetAuthenticatedUser(w, r)
+	if e != nil {
+		return
+	}
+
+	revisionNumber, e := rw_helpers.ExtractRevisionFromURL(w, r, "revision")
+	if e != nil {
+		return
+	}
+
+	db := database.NewGORMInstance()
+	defer db.Close()
+
+	project, page, e := loadPage(w, r, db, authUser)
+	if e != nil {
+		return
+	}
+
+	if rw_helpers.CanCreateWiki(w, db, authUser, project) == false {
+		return
+	}
+
+	old, e := rw_helpers.GetWikiPageRevision(w, db, page.ID, revisionNumber)
+	if e != nil {
+		return
+	}
+
+	// Title of the old revision may be taken by another page since then
+	restored := page
+	restored.Title = old.Title
+	if rw_helpers.ValidateWikiPageRequestModel(w, db, restored) != nil {
+		return
+	}
+
+	_, e = wiki_repo.RestoreRevision(db, &page, revisionNumber, authUser.ID)
+	if e != nil {
+		writeSavePageError(w, e)
+		return
+	}
+
+	page, e = rw_helpers.GetWikiPageByID(w, db, page.WikiID, page.ID)
+	if e != nil {
+		return
+	}
+	page.ContentHTML = markdown.Render(page.Content, markdown.NewProjectResolver(db, project))
+
+	w.Header().Add("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&page)
+}
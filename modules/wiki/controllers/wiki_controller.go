@@ -0,0 +1,227 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/jinzhu/gorm"
+
+	"devin/database"
+	"devin/helpers"
+	"devin/models"
+	"devin/modules/rw_helpers"
+	wiki_repo "devin/modules/wiki/repository"
+)
+
+// WikiController handle wikis of projects and their pages.
+// Every save of a page is stored as an immutable revision, so history of pages
+// can be listed, compared and restored.
+type WikiController struct{}
+
+// loadWiki load project and the wiki given in URL
+func loadWiki(w http.ResponseWriter, r *http.Request, db *gorm.DB, authUser models.User, paramName string) (project models.Project, wiki models.Wiki, e error) {
+	wikiID, e := rw_helpers.ExtractWikiIDFromURL(w, r, paramName)
+	if e != nil {
+		return
+	}
+
+	project, e = rw_helpers.LoadProjectForModule(w, r, db, authUser, models.MODULE_WIKI)
+	if e != nil {
+		return
+	}
+
+	wiki, e = rw_helpers.GetWikiByID(w, db, project.ID, wikiID)
+
+	return
+}
+
+// decodeJSONBody decode request body to v. Handle request errors
+func decodeJSONBody(w http.ResponseWriter, r *http.Request, v interface{}) (e error) {
+	if helpers.IsRequestBodyNil(w, r) {
+		return errors.New("Request body is nil!")
+	}
+	defer r.Body.Close()
+
+	e = json.NewDecoder(r.Body).Decode(v)
+	if e != nil {
+		err := helpers.ErrorResponse{
+			Message:   "Invalid request!",
+			ErrorCode: http.StatusBadRequest,
+		}
+		helpers.NewErrorResponse(w, &err)
+	}
+
+	return
+}
+
+// WikisIndex return all wikis of the project.
+// This route is accessible for anonymous users when public wiki is allowed.
+// @Route: /api/project/{project_id}/wikis
+// @Method: GET
+func (WikiController) WikisIndex(w http.ResponseWriter, r *http.Request) {
+	authUser, e := rw_helpers.GetOptionalAuthenticatedUser(w, r)
+	if e != nil {
+		return
+	}
+
+	db := database.NewGORMInstance()
+	defer db.Close()
+
+	project, e := rw_helpers.LoadProjectForModule(w, r, db, authUser, models.MODULE_WIKI)
+	if e != nil {
+		return
+	}
+
+	wikis, e := wiki_repo.GetWikisOfProject(db, project.ID)
+	if e != nil {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusInternalServerError,
+			Message:   "Fail to load wikis",
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&wikis)
+}
+
+// Show return the wiki with titles of its pages.
+// This route is accessible for anonymous users when public wiki is allowed.
+// @Route: /api/project/{project_id}/wiki/{id}
+// @Method: GET
+func (WikiController) Show(w http.ResponseWriter, r *http.Request) {
+	authUser, e := rw_helpers.GetOptionalAuthenticatedUser(w, r)
+	if e != nil {
+		return
+	}
+
+	db := database.NewGORMInstance()
+	defer db.Close()
+
+	_, wiki, e := loadWiki(w, r, db, authUser, "id")
+	if e != nil {
+		return
+	}
+
+	pages, e := wiki_repo.GetPagesOfWiki(db, wiki.ID)
+	if e != nil {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusInternalServerError,
+			Message:   "Fail to load pages",
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+	wiki.Pages = make([]*models.WikiPage, len(pages))
+	for i := range pages {
+		wiki.Pages[i] = &pages[i]
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&wiki)
+}
+
+// Save handle inserting and updating of wikis
+// If no ID present in the request model, it will insert as new wiki
+// otherwise the given wiki will be renamed
+// @Route: /api/project/{project_id}/wikis/save
+// @Method: POST
+// @Content-Type: application/json
+func (WikiController) Save(w http.ResponseWriter, r *http.Request) {
+	if rw_helpers.IsJSONRequest(w, r) == false {
+		return
+	}
+
+	authUser, e := rw_helpers.GetAuthenticatedUser(w, r)
+	if e != nil {
+		return
+	}
+
+	reqModel, e := rw_helpers.DecodeWikiRequestModel(w, r)
+	if e != nil {
+		return
+	}
+	defer r.Body.Close()
+
+	db := database.NewGORMInstance()
+	defer db.Close()
+
+	project, e := rw_helpers.LoadProjectForModule(w, r, db, authUser, models.MODULE_WIKI)
+	if e != nil {
+		return
+	}
+
+	if rw_helpers.CanCreateWiki(w, db, authUser, project) == false {
+		return
+	}
+
+	wiki := models.Wiki{}
+	if reqModel.ID != 0 {
+		// Edit mode
+		wiki, e = rw_helpers.GetWikiByID(w, db, project.ID, reqModel.ID)
+		if e != nil {
+			return
+		}
+		wiki.CreatedBy = nil
+	} else {
+		wiki.ProjectID = project.ID
+		wiki.CreatedByID = authUser.ID
+	}
+	wiki.Name = reqModel.Name
+	wiki.RepositoryID = reqModel.RepositoryID
+
+	if rw_helpers.ValidateWikiRequestModel(w, db, wiki) != nil {
+		return
+	}
+
+	e = wiki_repo.SaveWiki(db, &wiki)
+	if e != nil {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusInternalServerError,
+			Message:   "Fail to save wiki",
+		}
+		err.Errors = make(map[string][]string)
+		err.Errors["dev"] = []string{e.Error()}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&wiki)
+}
+
+// Delete soft delete the wiki and its pages
+// @Route: /api/project/{project_id}/wiki/{id}/delete
+// @Method: POST
+func (WikiController) Delete(w http.ResponseWriter, r *http.Request) {
+	authUser, e := rw_helpers.GetAuthenticatedUser(w, r)
+	if e != nil {
+		return
+	}
+
+	db := database.NewGORMInstance()
+	defer db.Close()
+
+	project, wiki, e := loadWiki(w, r, db, authUser, "id")
+	if e != nil {
+		return
+	}
+
+	if rw_helpers.CanCreateWiki(w, db, authUser, project) == false {
+		return
+	}
+
+	e = wiki_repo.DeleteWiki(db, wiki)
+	if e != nil {
+		err := helpers.ErrorResponse{
+			ErrorCode: http.StatusInternalServerError,
+			Message:   "Fail to delete wiki",
+		}
+		helpers.NewErrorResponse(w, &err)
+		return
+	}
+
+	helpers.NewSuccessResponse(w, "Wiki deleted.")
+}
@@ -0,0 +1,289 @@
+// Package diff compare texts line by line and format the result as a unified diff.
+// Differences are found with the Myers algorithm after trimming the common
+// prefix and suffix of both texts, which keeps typical page edits cheap.
+package diff
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// Operations of a diff line
+const (
+	OpEqual = iota
+	OpDelete
+	OpInsert
+)
+
+// MaxEditDistance limit steps of the Myers algorithm, as its trace needs O(D²) memory.
+// Texts which need more edits are compared as replacing all of their changed lines.
+const MaxEditDistance = 1000
+
+// Line is a single line of the edit script
+type Line struct {
+	Op   int
+	Text string
+}
+
+// SplitLines split text to lines. A trailing new line doesn't produce an empty line.
+func SplitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	lines := strings.Split(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	return lines
+}
+
+// Lines return the shortest edit script which converts a to b
+func Lines(a, b []string) []Line {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	script := make([]Line, 0, len(a)+len(b)-prefix-suffix)
+	for _, text := range a[:prefix] {
+		script = append(script, Line{OpEqual, text})
+	}
+	script = append(script, myers(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for _, text := range a[len(a)-suffix:] {
+		script = append(script, Line{OpEqual, text})
+	}
+
+	return script
+}
+
+// myers find the shortest edit script using the greedy algorithm of
+// E. Myers, "An O(ND) Difference Algorithm and Its Variations".
+// Only the reachable diagonals of each step are kept for backtracking.
+// After MaxEditDistance steps it gives up and returns the script of replace.
+func myers(a, b []string) []Line {
+	n, m := len(a), len(b)
+	max := n + m
+	if max == 0 {
+		return nil
+	}
+	offset := max + 1
+	v := make([]int, 2*max+3)
+
+	// trace[d] keeps v[-d..d] at the beginning of step d
+	var trace [][]int
+	for d := 0; d <= max && d <= MaxEditDistance; d++ {
+		snapshot := make([]int, 2*d+1)
+		copy(snapshot, v[offset-d:offset+d+1])
+		trace = append(trace, snapshot)
+
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+
+			if x >= n && y >= m {
+				return backtrack(trace, a, b)
+			}
+		}
+	}
+
+	return replace(a, b)
+}
+
+// replace return the script which deletes all lines of a and inserts all lines of b
+func replace(a, b []string) []Line {
+	script := make([]Line, 0, len(a)+len(b))
+	for _, text := range a {
+		script = append(script, Line{OpDelete, text})
+	}
+	for _, text := range b {
+		script = append(script, Line{OpInsert, text})
+	}
+
+	return script
+}
+
+// backtrack walk the trace from the end of both texts to their beginning and build the script
+func backtrack(trace [][]int, a, b []string) []Line {
+	var reversed []Line
+	x, y := len(a), len(b)
+
+	for d := len(trace) - 1; d > 0; d-- {
+		v := trace[d]
+		k := x - y
+
+		var prevK int
+		if k == -d || (k != d && v[k-1+d] < v[k+1+d]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := v[prevK+d]
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			reversed = append(reversed, Line{OpEqual, a[x-1]})
+			x--
+			y--
+		}
+		if x == prevX {
+			reversed = append(reversed, Line{OpInsert, b[y-1]})
+			y--
+		} else {
+			reversed = append(reversed, Line{OpDelete, a[x-1]})
+			x--
+		}
+	}
+	for x > 0 && y > 0 {
+		reversed = append(reversed, Line{OpEqual, a[x-1]})
+		x--
+		y--
+	}
+
+	script := make([]Line, len(reversed))
+	for i := range reversed {
+		script[len(reversed)-1-i] = reversed[i]
+	}
+
+	return script
+}
+
+// Stats count inserted and deleted lines of the script
+func Stats(script []Line) (inserted, deleted int) {
+	for _, l := range script {
+		switch l.Op {
+		case OpInsert:
+			inserted++
+		case OpDelete:
+			deleted++
+		}
+	}
+
+	return
+}
+
+// Unified format differences of from and to texts as a unified diff
+// with the given number of context lines. Empty string is returned for equal texts.
+func Unified(fromName, toName, from, to string, context int) string {
+	script := Lines(SplitLines(from), SplitLines(to))
+
+	var buf bytes.Buffer
+	for _, h := range hunks(script, context) {
+		if buf.Len() == 0 {
+			fmt.Fprintf(&buf, "--- %s\n+++ %s\n", fromName, toName)
+		}
+		fmt.Fprintf(&buf, "@@ -%s +%s @@\n", hunkRange(h.fromStart, h.fromLen), hunkRange(h.toStart, h.toLen))
+		for _, l := range script[h.first:h.last] {
+			switch l.Op {
+			case OpEqual:
+				buf.WriteString(" ")
+			case OpDelete:
+				buf.WriteString("-")
+			case OpInsert:
+				buf.WriteString("+")
+			}
+			buf.WriteString(l.Text)
+			buf.WriteString("\n")
+		}
+	}
+
+	return buf.String()
+}
+
+type hunk struct {
+	// Range of the hunk in the script
+	first, last int
+
+	// Zero based start line and length of the hunk in both texts
+	fromStart, fromLen int
+	toStart, toLen     int
+}
+
+// hunks group changed lines of the script with their context.
+// Changes separated by at most 2*context equal lines are kept in the same hunk.
+func hunks(script []Line, context int) (result []hunk) {
+	var changes []int
+	for i, l := range script {
+		if l.Op != OpEqual {
+			changes = append(changes, i)
+		}
+	}
+	if len(changes) == 0 {
+		return
+	}
+
+	for i := 0; i < len(changes); {
+		first := changes[i] - context
+		if first < 0 {
+			first = 0
+		}
+
+		j := i
+		for j+1 < len(changes) && changes[j+1]-changes[j]-1 <= 2*context {
+			j++
+		}
+
+		last := changes[j] + context + 1
+		if last > len(script) {
+			last = len(script)
+		}
+
+		result = append(result, hunk{first: first, last: last})
+		i = j + 1
+	}
+
+	// Calculate line numbers of hunks in both texts
+	fromLine, toLine, h := 0, 0, 0
+	for i, l := range script {
+		if h < len(result) && i == result[h].first {
+			result[h].fromStart, result[h].toStart = fromLine, toLine
+		}
+		if h < len(result) && i >= result[h].first && i < result[h].last {
+			if l.Op != OpInsert {
+				result[h].fromLen++
+			}
+			if l.Op != OpDelete {
+				result[h].toLen++
+			}
+		}
+		if l.Op != OpInsert {
+			fromLine++
+		}
+		if l.Op != OpDelete {
+			toLine++
+		}
+		if h < len(result) && i == result[h].last-1 {
+			h++
+		}
+	}
+
+	return
+}
+
+// hunkRange format start and length of a hunk like GNU diff.
+// Empty ranges point to the line before them.
+func hunkRange(start, length int) string {
+	if length == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	if length == 1 {
+		return fmt.Sprintf("%d", start+1)
+	}
+
+	return fmt.Sprintf("%d,%d", start+1, length)
+}
@@ -0,0 +1,132 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// apply rebuild both texts from the script
+func apply(script []Line) (from, to []string) {
+	for _, l := range script {
+		if l.Op != OpInsert {
+			from = append(from, l.Text)
+		}
+		if l.Op != OpDelete {
+			to = append(to, l.Text)
+		}
+	}
+
+	return
+}
+
+func TestLines(t *testing.T) {
+	cases := [][2]string{
+		{"", ""},
+		{"", "a\nb\n"},
+		{"a\nb\n", ""},
+		{"a\nb\nc\n", "a\nb\nc\n"},
+		{"a\nb\nc\na\nb\nb\na\n", "c\nb\na\nb\na\nc\n"},
+		{"one\ntwo\nthree\n", "zero\none\nthree\nfour\n"},
+	}
+
+	for _, c := range cases {
+		a, b := SplitLines(c[0]), SplitLines(c[1])
+		script := Lines(a, b)
+
+		from, to := apply(script)
+		if strings.Join(from, "\n") != strings.Join(a, "\n") || strings.Join(to, "\n") != strings.Join(b, "\n") {
+			t.Fatalf("Script of %q -> %q doesn't rebuild texts: %v", c[0], c[1], script)
+		}
+	}
+
+	// Example of the Myers paper has an edit script of length 5
+	inserted, deleted := Stats(Lines(SplitLines("a\nb\nc\na\nb\nb\na"), SplitLines("c\nb\na\nb\na\nc")))
+	if inserted+deleted != 5 {
+		t.Fatal("Edit script is not the shortest one", inserted, deleted)
+	}
+}
+
+func TestLinesMaxEditDistance(t *testing.T) {
+	var a, b []string
+	for i := 0; i < MaxEditDistance; i++ {
+		a = append(a, fmt.Sprintf("a%d", i))
+		b = append(b, fmt.Sprintf("b%d", i))
+	}
+	a = append([]string{"first"}, append(a, "last")...)
+	b = append([]string{"first"}, append(b, "last")...)
+
+	script := Lines(a, b)
+	from, to := apply(script)
+	if strings.Join(from, "\n") != strings.Join(a, "\n") || strings.Join(to, "\n") != strings.Join(b, "\n") {
+		t.Fatal("Script of a large edit doesn't rebuild texts")
+	}
+
+	inserted, deleted := Stats(script)
+	if inserted != MaxEditDistance || deleted != MaxEditDistance {
+		t.Fatal("Changed lines must be replaced", inserted, deleted)
+	}
+	if script[0] != (Line{OpEqual, "first"}) || script[len(script)-1] != (Line{OpEqual, "last"}) {
+		t.Fatal("Common prefix and suffix must be kept")
+	}
+}
+
+func TestUnified(t *testing.T) {
+	if Unified("a", "b", "same\n", "same\n", 3) != "" {
+		t.Fatal("Diff of equal texts must be empty")
+	}
+
+	from := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n13\n14\n15\n"
+	to := "1\n2\nthree\n4\n5\n6\n7\n8\n9\n10\n11\n12\n13\n15\n16\n"
+	expected := `--- old
++++ new
+@@ -1,6 +1,6 @@
+ 1
+ 2
+-3
++three
+ 4
+ 5
+ 6
+@@ -11,5 +11,5 @@
+ 11
+ 12
+ 13
+-14
+ 15
++16
+`
+	if got := Unified("old", "new", from, to, 3); got != expected {
+		t.Fatalf("Unexpected diff:\n%s", got)
+	}
+
+	// Close changes are merged into one hunk
+	expected = `--- old
++++ new
+@@ -2,7 +2,7 @@
+ 2
+ 3
+ 4
+-5
++five
+ 6
+ 7
+-8
++eight
+`
+	if got := Unified("old", "new", "1\n2\n3\n4\n5\n6\n7\n8", "1\n2\n3\n4\nfive\n6\n7\neight", 3); got != expected {
+		t.Fatalf("Unexpected diff:\n%s", got)
+	}
+}
+
+func TestUnifiedEmptyTexts(t *testing.T) {
+	expected := "--- old\n+++ new\n@@ -0,0 +1,2 @@\n+a\n+b\n"
+	if got := Unified("old", "new", "", "a\nb\n", 3); got != expected {
+		t.Fatalf("Unexpected diff:\n%s", got)
+	}
+
+	expected = "--- old\n+++ new\n@@ -1 +0,0 @@\n-a\n"
+	if got := Unified("old", "new", "a", "", 3); got != expected {
+		t.Fatalf("Unexpected diff:\n%s", got)
+	}
+}
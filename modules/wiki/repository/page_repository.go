@@ -0,0 +1,188 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/jinzhu/gorm"
+
+	"devin/models"
+	"devin/modules/wiki/diff"
+)
+
+// ErrNothingChanged returned when a page is saved without changing its title or content
+var ErrNothingChanged = errors.New("Nothing changed")
+
+// GetPagesOfWiki load pages of the wiki sorted by title, without their content
+func GetPagesOfWiki(db *gorm.DB, wikiID uint64) (pages []models.WikiPage, e error) {
+	e = db.Model(&models.WikiPage{}).
+		Select("id, wiki_id, title, revision, created_by_id, updated_by_id, created_at, updated_at").
+		Preload("UpdatedBy").
+		Where("wiki_id=?", wikiID).
+		Order("lower(title) ASC").
+		Find(&pages).
+		Error
+
+	return
+}
+
+// GetPageByID load a page of the wiki with its creator and last editor
+func GetPageByID(db *gorm.DB, wikiID, pageID uint64) (page models.WikiPage, e error) {
+	db.Model(&page).
+		Preload("CreatedBy").
+		Preload("UpdatedBy").
+		Where("id=? AND wiki_id=?", pageID, wikiID).
+		First(&page)
+	if page.ID == 0 {
+		e = ErrPageNotFound
+	}
+
+	return
+}
+
+// IsUniquePageTitle check title of the page to be unique in its wiki
+func IsUniquePageTitle(db *gorm.DB, page models.WikiPage) bool {
+	var count uint64
+	db.Model(&models.WikiPage{}).
+		Where("wiki_id=? AND lower(title)=lower(?) AND id<>?", page.WikiID, page.Title, page.ID).
+		Count(&count)
+
+	return count == 0
+}
+
+// SavePage insert new page or update the existing one and store the result as a new revision.
+// When baseRevision is given, saving fails with ErrRevisionConflict if the page
+// has a newer revision, so concurrent edits don't overwrite each other silently.
+func SavePage(db *gorm.DB, page *models.WikiPage, authorID uint64, summary *string, baseRevision *uint) (models.WikiPageRevision, error) {
+	return savePage(db, page, authorID, summary, baseRevision, nil)
+}
+
+// RestoreRevision copy title and content of an old revision to the page as a new revision
+func RestoreRevision(db *gorm.DB, page *models.WikiPage, revisionNumber uint, authorID uint64) (revision models.WikiPageRevision, e error) {
+	old, e := GetRevision(db, page.ID, revisionNumber)
+	if e != nil {
+		return
+	}
+
+	page.Title = old.Title
+	page.Content = old.Content
+	summary := fmt.Sprintf("Restored revision %d", revisionNumber)
+
+	return savePage(db, page, authorID, &summary, nil, &revisionNumber)
+}
+
+// savePage lock the page, write its new state and append the revision in one transaction
+func savePage(db *gorm.DB, page *models.WikiPage, authorID uint64, summary *string, baseRevision, restoredFrom *uint) (revision models.WikiPageRevision, e error) {
+	tx := db.Set("gorm:save_associations", false).Begin()
+	defer func() {
+		if e != nil {
+			tx.Rollback()
+		}
+	}()
+
+	previousContent := ""
+	page.UpdatedByID = &authorID
+	if page.ID == 0 {
+		page.Revision = 1
+		page.CreatedByID = authorID
+		e = tx.Create(page).Error
+	} else {
+		var current models.WikiPage
+		tx.Set("gorm:query_option", "FOR UPDATE").
+			Where("id=?", page.ID).
+			First(&current)
+		if current.ID == 0 {
+			e = ErrPageNotFound
+			return
+		}
+		if baseRevision != nil && *baseRevision != current.Revision {
+			e = ErrRevisionConflict
+			return
+		}
+		if current.Title == page.Title && current.Content == page.Content {
+			e = ErrNothingChanged
+			return
+		}
+
+		previousContent = current.Content
+		page.Revision = current.Revision + 1
+		e = tx.Model(&models.WikiPage{}).
+			Where("id=?", page.ID).
+			Updates(map[string]interface{}{
+				"title":         page.Title,
+				"content":       page.Content,
+				"revision":      page.Revision,
+				"updated_by_id": authorID,
+			}).
+			Error
+	}
+	if e != nil {
+		return
+	}
+
+	script := diff.Lines(diff.SplitLines(previousContent), diff.SplitLines(page.Content))
+	added, deleted := diff.Stats(script)
+	revision = models.WikiPageRevision{
+		WikiPageID:           page.ID,
+		Revision:             page.Revision,
+		Title:                page.Title,
+		Content:              page.Content,
+		Summary:              summary,
+		RestoredFromRevision: restoredFrom,
+		LinesAdded:           added,
+		LinesDeleted:         deleted,
+		CreatedByID:          authorID,
+	}
+	e = tx.Create(&revision).Error
+	if e != nil {
+		return
+	}
+
+	e = tx.Commit().Error
+
+	return
+}
+
+// DeletePage soft delete the page. Revisions are kept.
+func DeletePage(db *gorm.DB, page models.WikiPage) error {
+	return db.Delete(&page).Error
+}
+
+// GetRevisions load paginated revisions of the page with their authors, newest first.
+// Content of revisions is not loaded.
+func GetRevisions(db *gorm.DB, pageID, currentPage, perPage uint64) (data []models.WikiPageRevision, total uint64, e error) {
+	db = db.Model(&models.WikiPageRevision{}).Where("wiki_page_id=?", pageID)
+
+	e = db.Count(&total).Error
+	if e != nil {
+		return
+	}
+
+	if perPage > 0 {
+		db = db.Limit(perPage)
+	}
+	if currentPage > 0 {
+		db = db.Offset((currentPage - 1) * perPage)
+	}
+
+	e = db.Select("id, wiki_page_id, revision, title, summary, restored_from_revision, lines_added, lines_deleted, created_by_id, created_at").
+		Preload("CreatedBy").
+		Order("revision DESC").
+		Find(&data).
+		Error
+
+	return
+}
+
+// GetRevision load a revision of the page by its number
+func GetRevision(db *gorm.DB, pageID uint64, revisionNumber uint) (revision models.WikiPageRevision, e error) {
+	db.Model(&revision).
+		Preload("CreatedBy").
+		Where("wiki_page_id=? AND revision=?", pageID, revisionNumber).
+		First(&revision)
+	if revision.ID == 0 {
+		e = ErrRevisionNotFound
+	}
+
+	return
+}
@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/jinzhu/gorm"
+
+	"devin/models"
+)
+
+// ErrWikiNotFound returned when the wiki is not found in the project
+var ErrWikiNotFound = errors.New("Wiki not found")
+
+// ErrPageNotFound returned when the page is not found in the wiki
+var ErrPageNotFound = errors.New("Page not found")
+
+// ErrRevisionNotFound returned when the revision is not found in the page
+var ErrRevisionNotFound = errors.New("Revision not found")
+
+// ErrRevisionConflict returned when the page is changed by someone else
+// after the revision which the editor started from
+var ErrRevisionConflict = errors.New("Page is changed after the given base revision")
+
+// GetWikisOfProject load all wikis of the project sorted by name
+func GetWikisOfProject(db *gorm.DB, projectID uint64) (wikis []models.Wiki, e error) {
+	e = db.Model(&models.Wiki{}).
+		Where("project_id=?", projectID).
+		Order("name ASC").
+		Find(&wikis).
+		Error
+
+	return
+}
+
+// GetWikiByID load a wiki of the project
+func GetWikiByID(db *gorm.DB, projectID, wikiID uint64) (wiki models.Wiki, e error) {
+	db.Model(&wiki).
+		Preload("CreatedBy").
+		Where("id=? AND project_id=?", wikiID, projectID).
+		First(&wiki)
+	if wiki.ID == 0 {
+		e = ErrWikiNotFound
+	}
+
+	return
+}
+
+// IsUniqueWikiName check name of the wiki to be unique in its project
+func IsUniqueWikiName(db *gorm.DB, wiki models.Wiki) bool {
+	var count uint64
+	db.Model(&models.Wiki{}).
+		Where("project_id=? AND lower(name)=lower(?) AND id<>?", wiki.ProjectID, wiki.Name, wiki.ID).
+		Count(&count)
+
+	return count == 0
+}
+
+// SaveWiki insert new wiki or update the existing one
+func SaveWiki(db *gorm.DB, wiki *models.Wiki) error {
+	db = db.Set("gorm:save_associations", false)
+	if wiki.ID == 0 {
+		return db.Create(wiki).Error
+	}
+
+	return db.Save(wiki).Error
+}
+
+// DeleteWiki soft delete the wiki and its pages. Revisions are kept.
+func DeleteWiki(db *gorm.DB, wiki models.Wiki) (e error) {
+	tx := db.Begin()
+	defer func() {
+		if e != nil {
+			tx.Rollback()
+		}
+	}()
+
+	e = tx.Where("wiki_id=?", wiki.ID).Delete(&models.WikiPage{}).Error
+	if e != nil {
+		return
+	}
+
+	e = tx.Delete(&wiki).Error
+	if e != nil {
+		return
+	}
+
+	return tx.Commit().Error
+}
@@ -0,0 +1,33 @@
+package policies
+
+import (
+	"github.com/jinzhu/gorm"
+
+	"devin/helpers"
+	"devin/models"
+	"devin/modules/authorizer"
+)
+
+// CanViewAudits check permission of authenticated user to view audit log.
+// Root users view all records, other users view records of organizations which they own or are admin of.
+func CanViewAudits(db *gorm.DB, authUser models.User, organizationID *uint64) bool {
+	if authUser.IsRootUser {
+		return true
+	}
+
+	if helpers.IsNilUint64(organizationID) {
+		return false
+	}
+
+	var organization models.User
+	db.Where("id=? AND user_type=2", *organizationID).First(&organization)
+	if organization.ID == 0 {
+		return false
+	}
+
+	if organization.OwnerID != nil && *organization.OwnerID == authUser.ID {
+		return true
+	}
+
+	return authorizer.HasOrganizationKey(db, authUser.ID, organization.ID, authorizer.KeyOrganizationAdmin)
+}
@@ -0,0 +1,17 @@
+package policies
+
+import (
+	"github.com/jinzhu/gorm"
+
+	"devin/models"
+	"devin/modules/authorizer"
+)
+
+// CanManageBilling check permission of user to set hourly rates and create invoices of the project.
+// Only project manager, project admins and admins of the owner organization can access billing.
+func CanManageBilling(db *gorm.DB, authUser models.User, project models.Project) bool {
+	return authorizer.Authorize(db, authUser, project, authorizer.Permission{
+		Action:   authorizer.ActionManage,
+		ModuleID: models.MODULE_SPENT_TIME,
+	})
+}
@@ -0,0 +1,28 @@
+package policies
+
+import (
+	"github.com/jinzhu/gorm"
+
+	"devin/models"
+	"devin/modules/authorizer"
+)
+
+// CanViewBugs check permission of user to read bugs of the project.
+// Members of the project can always read bugs, other users (even anonymous ones)
+// only when public bugs are allowed.
+func CanViewBugs(db *gorm.DB, authUser models.User, project models.Project) bool {
+	return authorizer.Authorize(db, authUser, project, authorizer.Permission{
+		Action:   authorizer.ActionRead,
+		ModuleID: models.MODULE_BUG_TRACKER,
+	})
+}
+
+// CanUpdateBug check permission of user to update severity, reproduction steps
+// and affected version of a bug. Users who can change status of the issue can update it.
+func CanUpdateBug(db *gorm.DB, authUser models.User, project models.Project, issue models.Issue) bool {
+	if issue.CreatedByID != nil && *issue.CreatedByID == authUser.ID && authUser.ID != 0 {
+		return true
+	}
+
+	return CanChangeIssueStatus(db, authUser, project, issue)
+}
@@ -0,0 +1,73 @@
+package policies
+
+import (
+	"github.com/jinzhu/gorm"
+
+	"devin/models"
+	"devin/modules/authorizer"
+)
+
+// CanViewIssues check permission of user to read issues of the project.
+// Members of the project can always read issues, other users (even anonymous ones)
+// only when public issues are allowed.
+func CanViewIssues(db *gorm.DB, authUser models.User, project models.Project) bool {
+	return authorizer.Authorize(db, authUser, project, authorizer.Permission{
+		Action:   authorizer.ActionRead,
+		ModuleID: models.MODULE_ISSUE_TRACKER,
+	})
+}
+
+// CanCreateIssue check permission of user to open an issue in the project.
+// When public issues are allowed everyone, including anonymous users, can open issues.
+func CanCreateIssue(db *gorm.DB, authUser models.User, project models.Project) bool {
+	if project.AllowPublicIssues == true {
+		return true
+	}
+
+	return authorizer.Authorize(db, authUser, project, authorizer.Permission{
+		Action:   authorizer.ActionCreate,
+		ModuleID: models.MODULE_ISSUE_TRACKER,
+		Key:      authorizer.KeyCreateIssue,
+	})
+}
+
+// CanManageIssues check permission of user to label, assign, transit and delete issues
+// and to manage issue labels of the project
+func CanManageIssues(db *gorm.DB, authUser models.User, project models.Project) bool {
+	return authorizer.Authorize(db, authUser, project, authorizer.Permission{
+		Action:   authorizer.ActionManage,
+		ModuleID: models.MODULE_ISSUE_TRACKER,
+	})
+}
+
+// CanChangeIssueStatus check permission of user to transit the issue.
+// Users assigned to the issue and users with update permission on the issue can change its status too.
+func CanChangeIssueStatus(db *gorm.DB, authUser models.User, project models.Project, issue models.Issue) bool {
+	if CanManageIssues(db, authUser, project) {
+		return true
+	}
+
+	var cnt uint64
+	db.Model(&models.IssueAssignment{}).
+		Where("issue_id=? AND user_id=?", issue.ID, authUser.ID).
+		Count(&cnt)
+	if cnt > 0 {
+		return true
+	}
+
+	return authorizer.Authorize(db, authUser, project, authorizer.Permission{
+		Action:   authorizer.ActionUpdate,
+		ModuleID: models.MODULE_ISSUE_TRACKER,
+		ObjectID: issue.ID,
+	})
+}
+
+// CanDeleteIssueComment check permission of user to delete a comment of issue.
+// Writer of the comment can delete it too.
+func CanDeleteIssueComment(db *gorm.DB, authUser models.User, project models.Project, comment models.IssueComment) bool {
+	if comment.CreatedByID == authUser.ID && authUser.ID != 0 {
+		return true
+	}
+
+	return CanManageIssues(db, authUser, project)
+}
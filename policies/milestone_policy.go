@@ -0,0 +1,27 @@
+package policies
+
+import (
+	"github.com/jinzhu/gorm"
+
+	"devin/models"
+	"devin/modules/authorizer"
+)
+
+// CanCreateMilestone check permission of user to create, update or delete milestones of the project
+func CanCreateMilestone(db *gorm.DB, authUser models.User, project models.Project) bool {
+	return authorizer.Authorize(db, authUser, project, authorizer.Permission{
+		Action:   authorizer.ActionCreate,
+		ModuleID: models.MODULE_MILESTONE,
+		Key:      authorizer.KeyCreateMilestone,
+	})
+}
+
+// CanDeleteMilestoneComment check permission of user to delete a comment of milestone.
+// Writer of the comment can delete it too.
+func CanDeleteMilestoneComment(db *gorm.DB, authUser models.User, project models.Project, comment models.MilestoneComment) bool {
+	if comment.CreatedByID == authUser.ID && authUser.ID != 0 {
+		return true
+	}
+
+	return CanCreateMilestone(db, authUser, project)
+}
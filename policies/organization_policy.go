@@ -3,6 +3,7 @@ package policies
 import (
 	"devin/database"
 	"devin/models"
+	"devin/modules/authorizer"
 
 	"github.com/jinzhu/gorm"
 )
@@ -24,17 +25,8 @@ func CanInviteUserToOrganization(authenticatedUser models.User, requestedOrganiz
 
 	db := database.NewGORMInstance()
 	defer db.Close()
-	var orgUser models.UserOrganization
-	db.Model(&orgUser).Where("user_id=? and organization_id=?", authenticatedUser.ID, requestedOrganization.ID).First(&orgUser)
-	if orgUser.ID == 0 {
-		return false
-	}
-
-	if orgUser.IsAdminOfOrganization == true || orgUser.CanAddUserToOrganization == true {
-		return true
-	}
 
-	return false
+	return authorizer.HasOrganizationKey(db, authenticatedUser.ID, requestedOrganization.ID, authorizer.KeyAddUserToOrganization)
 }
 
 //CanViewOrganizationsOfUser check permission of authenticatedUser to access organizations list of userID
@@ -94,18 +86,5 @@ func CanUpdateUserOrganizationPermissions(db *gorm.DB, authenticatedUser, organi
 		return true
 	}
 
-	var orgUser models.UserOrganization
-	db.Model(&models.UserOrganization{}).
-		Where("user_id=? and organization_id=?", authenticatedUser.ID, organization.ID).
-		First(&orgUser)
-
-	if orgUser.ID == 0 {
-		return false
-	}
-
-	if orgUser.IsAdminOfOrganization == true {
-		return true
-	}
-
-	return false
+	return authorizer.HasOrganizationKey(db, authenticatedUser.ID, organization.ID, authorizer.KeyOrganizationAdmin)
 }
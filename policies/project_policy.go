@@ -4,51 +4,68 @@ import (
 	"github.com/jinzhu/gorm"
 
 	"devin/models"
+	"devin/modules/authorizer"
 )
 
-// CanSaveProject check permission of user to save project data
+// CanSaveProject check permission of user to save project data.
+// For a new project ownerOrganizationID is where the project will be created,
+// for an existing project it is checked when the project is moved to another organization.
 func CanSaveProject(db *gorm.DB, authUser models.User, ownerOrganizationID *uint64, project models.Project) bool {
 	// The root user can do anyting
 	if authUser.IsRootUser == true {
 		return true
 	}
 
-	if ownerOrganizationID == nil {
-		// Authenticated user is trying to create a personnal project
-		if authUser.ID == project.OwnerUserID {
-			return true
+	if project.ID > 0 {
+		// User is editing an exiting project
+		can := authorizer.Authorize(db, authUser, project, authorizer.Permission{
+			Action:   authorizer.ActionUpdate,
+			ModuleID: models.MODULE_PROJECT,
+			ObjectID: project.ID,
+			Key:      authorizer.KeyUpdateProjectProfile,
+		})
+		// Moving the project to another organization needs permission of creating projects there
+		if can == false || ownerOrganizationID == nil ||
+			(project.OwnerOrganizationID != nil && *project.OwnerOrganizationID == *ownerOrganizationID) {
+			return can
 		}
 
-		return false
-	}
-
-	var userOrg models.UserOrganization
-	db.Model(&models.UserOrganization{}).Where("user_id=? AND organization_id=?", authUser.ID, *ownerOrganizationID).First(&userOrg)
-
-	// This user is not a member of organization
-	if userOrg.ID == 0 {
-		return false
-	}
-
-	// Admin of organization allowed to do anything on the organization
-	if userOrg.IsAdminOfOrganization {
-		return true
+		return canCreateProjectInOrganization(db, authUser, *ownerOrganizationID)
 	}
 
-	// User is trying to create a project
-	if project.ID > 0 {
-		if userOrg.CanCreateProject == true {
+	if ownerOrganizationID == nil {
+		// Authenticated user is trying to create a personnal project
+		if authUser.ID == project.OwnerUserID {
 			return true
 		}
 
 		return false
 	}
 
-	// User is editing an exiting project
-	if userOrg.CanUpateProject == true {
+	return canCreateProjectInOrganization(db, authUser, *ownerOrganizationID)
+}
+
+// canCreateProjectInOrganization check the user to be owner of organization, its admin
+// or a member with permission of creating projects
+func canCreateProjectInOrganization(db *gorm.DB, authUser models.User, organizationID uint64) bool {
+	var cnt uint64
+	db.Model(&models.User{}).
+		Where("id=? AND owner_id=? AND user_type=2", organizationID, authUser.ID).
+		Count(&cnt)
+	if cnt > 0 {
 		return true
 	}
 
-	return false
+	// Admin of organization allowed to do anything on the organization
+	return authorizer.HasOrganizationKey(db, authUser.ID, organizationID, authorizer.KeyCreateOrganizationProject)
+}
 
+// CanViewProject check permission of user to view the project and its contents
+// based on the VisibilityTypeID of the project
+func CanViewProject(db *gorm.DB, authUser models.User, project models.Project) bool {
+	return authorizer.Authorize(db, authUser, project, authorizer.Permission{
+		Action:   authorizer.ActionRead,
+		ModuleID: models.MODULE_PROJECT,
+		ObjectID: project.ID,
+	})
 }
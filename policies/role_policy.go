@@ -0,0 +1,22 @@
+package policies
+
+import (
+	"github.com/jinzhu/gorm"
+
+	"devin/models"
+	"devin/modules/authorizer"
+)
+
+// CanManageRoles check permission of authenticated user to create, update and delete
+// roles of the organization and to assign them on the whole organization
+func CanManageRoles(db *gorm.DB, authUser, organization models.User) bool {
+	if authUser.IsRootUser {
+		return true
+	}
+
+	if organization.OwnerID != nil && *organization.OwnerID == authUser.ID {
+		return true
+	}
+
+	return authorizer.HasOrganizationKey(db, authUser.ID, organization.ID, authorizer.KeyOrganizationAdmin)
+}
@@ -0,0 +1,62 @@
+package policies
+
+import (
+	"github.com/jinzhu/gorm"
+
+	"devin/models"
+	"devin/modules/authorizer"
+)
+
+// CanCreateTask check permission of user to create or update tasks of the project
+func CanCreateTask(db *gorm.DB, authUser models.User, project models.Project) bool {
+	return authorizer.Authorize(db, authUser, project, authorizer.Permission{
+		Action:   authorizer.ActionCreate,
+		ModuleID: models.MODULE_TASK,
+		Key:      authorizer.KeyCreateTask,
+	})
+}
+
+// CanUpdateTask check permission of user to update the task.
+// Users with update permission on the task can update it too.
+func CanUpdateTask(db *gorm.DB, authUser models.User, project models.Project, task models.Task) bool {
+	return authorizer.Authorize(db, authUser, project, authorizer.Permission{
+		Action:   authorizer.ActionUpdate,
+		ModuleID: models.MODULE_TASK,
+		ObjectID: task.ID,
+		Key:      authorizer.KeyCreateTask,
+	})
+}
+
+// CanDeleteTask check permission of user to delete a task.
+// Creator of the task and users with delete permission on the task can delete it too.
+func CanDeleteTask(db *gorm.DB, authUser models.User, project models.Project, task models.Task) bool {
+	if task.CreatedByID == authUser.ID && authUser.ID != 0 {
+		return true
+	}
+
+	return authorizer.Authorize(db, authUser, project, authorizer.Permission{
+		Action:   authorizer.ActionDelete,
+		ModuleID: models.MODULE_TASK,
+		ObjectID: task.ID,
+		Key:      authorizer.KeyCreateTask,
+	})
+}
+
+// CanDeleteTaskComment check permission of user to delete a comment of task.
+// Writer of the comment can delete it too.
+func CanDeleteTaskComment(db *gorm.DB, authUser models.User, project models.Project, comment models.TaskComment) bool {
+	if comment.CreatedByID == authUser.ID && authUser.ID != 0 {
+		return true
+	}
+
+	return CanCreateTask(db, authUser, project)
+}
+
+// CanCreateBoard check permission of user to create or update boards of the project
+func CanCreateBoard(db *gorm.DB, authUser models.User, project models.Project) bool {
+	return authorizer.Authorize(db, authUser, project, authorizer.Permission{
+		Action:   authorizer.ActionCreate,
+		ModuleID: models.MODULE_TASK,
+		Key:      authorizer.KeyCreateBoard,
+	})
+}
@@ -0,0 +1,36 @@
+package policies
+
+import (
+	"github.com/jinzhu/gorm"
+
+	"devin/models"
+	"devin/modules/authorizer"
+)
+
+// CanCreateTimeLog check permission of user to log spent times on tasks of the project
+func CanCreateTimeLog(db *gorm.DB, authUser models.User, project models.Project) bool {
+	return authorizer.Authorize(db, authUser, project, authorizer.Permission{
+		Action:   authorizer.ActionCreate,
+		ModuleID: models.MODULE_SPENT_TIME,
+		Key:      authorizer.KeyCreateTimeLog,
+	})
+}
+
+// CanListAllTimeLogs check permission of user to see spent times of other members of the project
+func CanListAllTimeLogs(db *gorm.DB, authUser models.User, project models.Project) bool {
+	return authorizer.Authorize(db, authUser, project, authorizer.Permission{
+		Action:   authorizer.ActionManage,
+		ModuleID: models.MODULE_SPENT_TIME,
+		Key:      authorizer.KeyListAllTimeLogs,
+	})
+}
+
+// CanUpdateTimeLog check permission of user to update or delete a spent time.
+// Users can only change their own entries.
+func CanUpdateTimeLog(authUser models.User, timeLog models.TaskSpentTime) bool {
+	if authUser.IsRootUser == true {
+		return true
+	}
+
+	return timeLog.SpentByID == authUser.ID && authUser.ID != 0
+}
@@ -1,7 +1,10 @@
 package policies
 
 import (
+	"github.com/jinzhu/gorm"
+
 	"devin/models"
+	"devin/modules/authorizer"
 )
 
 //CanEditUser check permission of authenticatedUser for editing of requestedUser
@@ -27,7 +30,7 @@ func CanEditUser(authenticatedUser models.User, requestedUser models.User) bool
 }
 
 //CanViewProfile check permission of authenticatedUser for viewing all details of requestedUser
-func CanViewProfile(authenticatedUser models.User, requestedUser models.User) bool {
+func CanViewProfile(db *gorm.DB, authenticatedUser models.User, requestedUser models.User) bool {
 	switch requestedUser.UserType {
 	case 1:
 		if authenticatedUser.ID == requestedUser.ID || authenticatedUser.IsRootUser {
@@ -40,13 +43,7 @@ func CanViewProfile(authenticatedUser models.User, requestedUser models.User) bo
 			return true
 		}
 
-		for _, v := range requestedUser.OrganizationUserMapping {
-
-			if *v.OrganizationID == requestedUser.ID && *v.UserID == authenticatedUser.ID && v.IsAdminOfOrganization == true {
-				return true
-			}
-		}
-		return false
+		return authorizer.HasOrganizationKey(db, authenticatedUser.ID, requestedUser.ID, authorizer.KeyOrganizationAdmin)
 	}
 	return false
 }
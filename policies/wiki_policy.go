@@ -0,0 +1,27 @@
+package policies
+
+import (
+	"github.com/jinzhu/gorm"
+
+	"devin/models"
+	"devin/modules/authorizer"
+)
+
+// CanViewWiki check permission of user to read wikis of the project and their history.
+// Members of the project can always read wikis, other users (even anonymous ones)
+// only when public wiki is allowed.
+func CanViewWiki(db *gorm.DB, authUser models.User, project models.Project) bool {
+	return authorizer.Authorize(db, authUser, project, authorizer.Permission{
+		Action:   authorizer.ActionRead,
+		ModuleID: models.MODULE_WIKI,
+	})
+}
+
+// CanCreateWiki check permission of user to create, edit, restore and delete wikis and pages of the project
+func CanCreateWiki(db *gorm.DB, authUser models.User, project models.Project) bool {
+	return authorizer.Authorize(db, authUser, project, authorizer.Permission{
+		Action:   authorizer.ActionCreate,
+		ModuleID: models.MODULE_WIKI,
+		Key:      authorizer.KeyCreateWiki,
+	})
+}
@@ -6,21 +6,69 @@ import (
 	"github.com/gorilla/mux"
 
 	"devin/middlewares"
+	access_token_ctrl "devin/modules/access_token/controllers"
+	audit_ctrl "devin/modules/audit/controllers"
+	authorizer_ctrl "devin/modules/authorizer/controllers"
+	billing_ctrl "devin/modules/billing/controllers"
+	bug_ctrl "devin/modules/bug/controllers"
+	issue_ctrl "devin/modules/issue/controllers"
+	milestone_ctrl "devin/modules/milestone/controllers"
+	notification_ctrl "devin/modules/notification/controllers"
 	org_ctrl "devin/modules/organization/controllers"
 	project_ctrl "devin/modules/project/controllers"
+	role_ctrl "devin/modules/role/controllers"
+	session_ctrl "devin/modules/session/controllers"
+	task_ctrl "devin/modules/task/controllers"
+	time_log_ctrl "devin/modules/time_log/controllers"
+	two_factor_ctrl "devin/modules/two_factor/controllers"
 	user_ctrl "devin/modules/user/controllers"
+	wiki_ctrl "devin/modules/wiki/controllers"
 )
 
 func LoadRoutes(r *mux.Router) *mux.Router {
 	r.HandleFunc("/signup", user_ctrl.Signup).Methods(http.MethodPost)
 	r.HandleFunc("/signup/verify", user_ctrl.VerifySignup).Methods(http.MethodGet)
 	r.HandleFunc("/signin", user_ctrl.Signin).Methods(http.MethodPost)
+	r.HandleFunc("/signin/two_factor", two_factor_ctrl.TwoFactorController{}.Signin).Methods(http.MethodPost)
+	r.HandleFunc("/signin/two_factor/enroll", two_factor_ctrl.TwoFactorController{}.SigninEnroll).Methods(http.MethodPost)
+	r.HandleFunc("/token/refresh", session_ctrl.SessionController{}.Refresh).Methods(http.MethodPost)
 	r.HandleFunc("/password_reset/request", user_ctrl.RequestPasswordReset).Methods(http.MethodPost)
 	r.HandleFunc("/password_reset/validate", user_ctrl.ValidatePasswordResetLink).Methods(http.MethodGet)
 	r.HandleFunc("/password_reset/do", user_ctrl.ResetPassword).Methods(http.MethodPost)
 
+	// Public projects are listed for anonymous users
+	r.HandleFunc("/projects", project_ctrl.ProjectController{}.ProjectsIndex).Methods(http.MethodGet)
+
+	// Issues, bugs and wikis are readable by anonymous users when the project allows public issues, bugs or wiki
+	r.HandleFunc("/project/{project_id:[0-9]+}/issues", issue_ctrl.IssueController{}.IssuesIndex).Methods(http.MethodGet)
+	r.HandleFunc("/project/{project_id:[0-9]+}/issues/open", issue_ctrl.IssueController{}.Open).Methods(http.MethodPost)
+	r.HandleFunc("/project/{project_id:[0-9]+}/issue/{id:[0-9]+}", issue_ctrl.IssueController{}.Show).Methods(http.MethodGet)
+	r.HandleFunc("/project/{project_id:[0-9]+}/issue/{id:[0-9]+}/comments", issue_ctrl.IssueController{}.CommentsIndex).Methods(http.MethodGet)
+	r.HandleFunc("/project/{project_id:[0-9]+}/issue_labels", issue_ctrl.IssueController{}.LabelsIndex).Methods(http.MethodGet)
+	r.HandleFunc("/project/{project_id:[0-9]+}/bugs", bug_ctrl.BugController{}.BugsIndex).Methods(http.MethodGet)
+	r.HandleFunc("/project/{project_id:[0-9]+}/bug/{id:[0-9]+}", bug_ctrl.BugController{}.Show).Methods(http.MethodGet)
+	r.HandleFunc("/project/{project_id:[0-9]+}/wikis", wiki_ctrl.WikiController{}.WikisIndex).Methods(http.MethodGet)
+	r.HandleFunc("/project/{project_id:[0-9]+}/wiki/{id:[0-9]+}", wiki_ctrl.WikiController{}.Show).Methods(http.MethodGet)
+	r.HandleFunc("/project/{project_id:[0-9]+}/wiki/{wiki_id:[0-9]+}/page/{id:[0-9]+}", wiki_ctrl.WikiController{}.ShowPage).Methods(http.MethodGet)
+	r.HandleFunc("/project/{project_id:[0-9]+}/wiki/{wiki_id:[0-9]+}/page/{id:[0-9]+}/revisions", wiki_ctrl.WikiController{}.RevisionsIndex).Methods(http.MethodGet)
+	r.HandleFunc("/project/{project_id:[0-9]+}/wiki/{wiki_id:[0-9]+}/page/{id:[0-9]+}/revision/{revision:[0-9]+}", wiki_ctrl.WikiController{}.ShowRevision).Methods(http.MethodGet)
+	r.HandleFunc("/project/{project_id:[0-9]+}/wiki/{wiki_id:[0-9]+}/page/{id:[0-9]+}/diff/{from:[0-9]+}/{to:[0-9]+}", wiki_ctrl.WikiController{}.Diff).Methods(http.MethodGet)
+
 	secureArea := r.PathPrefix("/").Subrouter().StrictSlash(true)
 	secureArea.Use(middlewares.Authenticate)
+	secureArea.HandleFunc("/signout", session_ctrl.SessionController{}.Signout).Methods(http.MethodPost)
+	secureArea.HandleFunc("/signout/everywhere", session_ctrl.SessionController{}.SignoutEverywhere).Methods(http.MethodPost)
+	secureArea.HandleFunc("/sessions", session_ctrl.SessionController{}.SessionsIndex).Methods(http.MethodGet)
+	secureArea.HandleFunc("/session/{id:[0-9]+}/revoke", session_ctrl.SessionController{}.Revoke).Methods(http.MethodPost)
+	secureArea.HandleFunc("/access_tokens", access_token_ctrl.AccessTokenController{}.AccessTokensIndex).Methods(http.MethodGet)
+	secureArea.HandleFunc("/access_tokens/create", access_token_ctrl.AccessTokenController{}.Create).Methods(http.MethodPost)
+	secureArea.HandleFunc("/access_token/{id:[0-9]+}/revoke", access_token_ctrl.AccessTokenController{}.Revoke).Methods(http.MethodPost)
+	secureArea.HandleFunc("/two_factor", two_factor_ctrl.TwoFactorController{}.Status).Methods(http.MethodGet)
+	secureArea.HandleFunc("/two_factor/enroll", two_factor_ctrl.TwoFactorController{}.Enroll).Methods(http.MethodPost)
+	secureArea.HandleFunc("/two_factor/enable", two_factor_ctrl.TwoFactorController{}.Enable).Methods(http.MethodPost)
+	secureArea.HandleFunc("/two_factor/disable", two_factor_ctrl.TwoFactorController{}.Disable).Methods(http.MethodPost)
+	secureArea.HandleFunc("/two_factor/recovery_codes/regenerate", two_factor_ctrl.TwoFactorController{}.RegenerateRecoveryCodes).Methods(http.MethodPost)
+
 	secureArea.HandleFunc("/user/{id:[0-9]+}/update", user_ctrl.UpdateProfile).Methods(http.MethodPost)
 	secureArea.HandleFunc("/user/{id:[0-9]+}/update_username", user_ctrl.UpdateUsername).Methods(http.MethodPost)
 	secureArea.HandleFunc("/user/{id:[0-9]+}/update_email", user_ctrl.UpdateEmail).Methods(http.MethodPost)
@@ -32,12 +80,101 @@ func LoadRoutes(r *mux.Router) *mux.Router {
 	secureArea.HandleFunc("/organization/list", org_ctrl.UserOrganizationsIndex).Methods(http.MethodGet)
 	secureArea.HandleFunc("/organization/{id:[0-9]+}/invite_user", org_ctrl.InviteUser).Methods(http.MethodPost)
 	secureArea.HandleFunc("/organization/{organization_id:[0-9]+}/user/{user_id:[0-9]+}/update_permissions", org_ctrl.UpdateUserPermissionsOnOrganization).Methods(http.MethodPost)
+	secureArea.HandleFunc("/organization/{organization_id:[0-9]+}/require_two_factor", two_factor_ctrl.TwoFactorController{}.RequireForOrganization).Methods(http.MethodPost)
+
+	secureArea.HandleFunc("/organization/{organization_id:[0-9]+}/roles", role_ctrl.RoleController{}.RolesIndex).Methods(http.MethodGet)
+	secureArea.HandleFunc("/organization/{organization_id:[0-9]+}/roles/save", role_ctrl.RoleController{}.Save).Methods(http.MethodPost)
+	secureArea.HandleFunc("/organization/{organization_id:[0-9]+}/role/{id:[0-9]+}/delete", role_ctrl.RoleController{}.Delete).Methods(http.MethodPost)
+	secureArea.HandleFunc("/organization/{organization_id:[0-9]+}/role_assignments", role_ctrl.RoleController{}.AssignmentsIndex).Methods(http.MethodGet)
+	secureArea.HandleFunc("/organization/{organization_id:[0-9]+}/role_assignments/assign", role_ctrl.RoleController{}.Assign).Methods(http.MethodPost)
+	secureArea.HandleFunc("/organization/{organization_id:[0-9]+}/role_assignment/{id:[0-9]+}/unassign", role_ctrl.RoleController{}.Unassign).Methods(http.MethodPost)
 
 	secureArea.HandleFunc("/invitation/{id:[0-9]+}/set_acceptance/{acceptance_status:(?:accept|reject)}", org_ctrl.AcceptOrRejectInvitation)
 
-	secureArea.HandleFunc("/projects", project_ctrl.ProjectController{}.ProjectsIndex).Methods(http.MethodGet)
+	secureArea.HandleFunc("/audits", audit_ctrl.AuditController{}.AuditsIndex).Methods(http.MethodGet)
+
+	secureArea.HandleFunc("/notifications", notification_ctrl.NotificationController{}.NotificationsIndex).Methods(http.MethodGet)
+	secureArea.HandleFunc("/notifications/unread_count", notification_ctrl.NotificationController{}.UnreadCount).Methods(http.MethodGet)
+	secureArea.HandleFunc("/notifications/stream", notification_ctrl.NotificationController{}.Stream).Methods(http.MethodGet)
+	secureArea.HandleFunc("/notifications/preferences", notification_ctrl.NotificationController{}.PreferencesIndex).Methods(http.MethodGet)
+	secureArea.HandleFunc("/notifications/preferences/save", notification_ctrl.NotificationController{}.SavePreferences).Methods(http.MethodPost)
+	secureArea.HandleFunc("/notifications/read_all", notification_ctrl.NotificationController{}.MarkAllRead).Methods(http.MethodPost)
+	secureArea.HandleFunc("/notification/{id:[0-9]+}/read", notification_ctrl.NotificationController{}.MarkRead).Methods(http.MethodPost)
+	secureArea.HandleFunc("/notification/{id:[0-9]+}/unread", notification_ctrl.NotificationController{}.MarkUnread).Methods(http.MethodPost)
+
 	secureArea.HandleFunc("/projects/basic_info", project_ctrl.ProjectController{}.BasicInfo)
 	secureArea.HandleFunc("/projects/save", project_ctrl.ProjectController{}.Save).Methods(http.MethodPost)
+	secureArea.HandleFunc("/project/{project_id:[0-9]+}/members", project_ctrl.MemberController{}.MembersIndex).Methods(http.MethodGet)
+	secureArea.HandleFunc("/project/{project_id:[0-9]+}/members/add", project_ctrl.MemberController{}.AddMember).Methods(http.MethodPost)
+	secureArea.HandleFunc("/project/{project_id:[0-9]+}/member/{id:[0-9]+}/update", project_ctrl.MemberController{}.UpdateMember).Methods(http.MethodPost)
+	secureArea.HandleFunc("/project/{project_id:[0-9]+}/member/{id:[0-9]+}/remove", project_ctrl.MemberController{}.RemoveMember).Methods(http.MethodPost)
+	secureArea.HandleFunc("/project/{project_id:[0-9]+}/object_permissions", authorizer_ctrl.ObjectPermissionController{}.ObjectPermissionsIndex).Methods(http.MethodGet)
+	secureArea.HandleFunc("/project/{project_id:[0-9]+}/object_permissions/grant", authorizer_ctrl.ObjectPermissionController{}.Grant).Methods(http.MethodPost)
+	secureArea.HandleFunc("/project/{project_id:[0-9]+}/object_permission/{id:[0-9]+}/revoke", authorizer_ctrl.ObjectPermissionController{}.Revoke).Methods(http.MethodPost)
+
+	secureArea.HandleFunc("/project/{project_id:[0-9]+}/tasks", task_ctrl.TaskController{}.TasksIndex).Methods(http.MethodGet)
+	secureArea.HandleFunc("/project/{project_id:[0-9]+}/tasks/save", task_ctrl.TaskController{}.Save).Methods(http.MethodPost)
+	secureArea.HandleFunc("/project/{project_id:[0-9]+}/task/{id:[0-9]+}", task_ctrl.TaskController{}.Show).Methods(http.MethodGet)
+	secureArea.HandleFunc("/project/{project_id:[0-9]+}/task/{id:[0-9]+}/delete", task_ctrl.TaskController{}.Delete).Methods(http.MethodPost)
+	secureArea.HandleFunc("/project/{project_id:[0-9]+}/task/{id:[0-9]+}/comments", task_ctrl.TaskController{}.CommentsIndex).Methods(http.MethodGet)
+	secureArea.HandleFunc("/project/{project_id:[0-9]+}/task/{id:[0-9]+}/comments/add", task_ctrl.TaskController{}.AddComment).Methods(http.MethodPost)
+	secureArea.HandleFunc("/project/{project_id:[0-9]+}/task/{id:[0-9]+}/comment/{comment_id:[0-9]+}/delete", task_ctrl.TaskController{}.DeleteComment).Methods(http.MethodPost)
+	secureArea.HandleFunc("/project/{project_id:[0-9]+}/task/{id:[0-9]+}/prerequisites/add", task_ctrl.TaskController{}.AddPrerequisite).Methods(http.MethodPost)
+	secureArea.HandleFunc("/project/{project_id:[0-9]+}/task/{id:[0-9]+}/prerequisite/{prerequisite_id:[0-9]+}/remove", task_ctrl.TaskController{}.RemovePrerequisite).Methods(http.MethodPost)
+	secureArea.HandleFunc("/project/{project_id:[0-9]+}/tasks/dependency_graph", task_ctrl.TaskController{}.DependencyGraph).Methods(http.MethodGet)
+	secureArea.HandleFunc("/project/{project_id:[0-9]+}/task/{id:[0-9]+}/move", task_ctrl.BoardController{}.MoveTask).Methods(http.MethodPost)
+	secureArea.HandleFunc("/project/{project_id:[0-9]+}/boards", task_ctrl.BoardController{}.BoardsIndex).Methods(http.MethodGet)
+	secureArea.HandleFunc("/project/{project_id:[0-9]+}/boards/save", task_ctrl.BoardController{}.Save).Methods(http.MethodPost)
+	secureArea.HandleFunc("/project/{project_id:[0-9]+}/board/{id:[0-9]+}", task_ctrl.BoardController{}.Show).Methods(http.MethodGet)
+
+	secureArea.HandleFunc("/time_logs/timer", time_log_ctrl.TimeLogController{}.RunningTimer).Methods(http.MethodGet)
+	secureArea.HandleFunc("/time_logs/timer/stop", time_log_ctrl.TimeLogController{}.StopTimer).Methods(http.MethodPost)
+	secureArea.HandleFunc("/project/{project_id:[0-9]+}/time_logs/timer/start", time_log_ctrl.TimeLogController{}.StartTimer).Methods(http.MethodPost)
+	secureArea.HandleFunc("/project/{project_id:[0-9]+}/time_logs/save", time_log_ctrl.TimeLogController{}.Save).Methods(http.MethodPost)
+	secureArea.HandleFunc("/project/{project_id:[0-9]+}/time_log/{id:[0-9]+}/delete", time_log_ctrl.TimeLogController{}.Delete).Methods(http.MethodPost)
+	secureArea.HandleFunc("/project/{project_id:[0-9]+}/timesheet", time_log_ctrl.TimeLogController{}.Timesheet).Methods(http.MethodGet)
+
+	secureArea.HandleFunc("/project/{project_id:[0-9]+}/billing/rates", billing_ctrl.BillingController{}.RatesIndex).Methods(http.MethodGet)
+	secureArea.HandleFunc("/project/{project_id:[0-9]+}/billing/rates/save", billing_ctrl.BillingController{}.SaveRate).Methods(http.MethodPost)
+	secureArea.HandleFunc("/project/{project_id:[0-9]+}/invoices", billing_ctrl.BillingController{}.InvoicesIndex).Methods(http.MethodGet)
+	secureArea.HandleFunc("/project/{project_id:[0-9]+}/invoices/draft", billing_ctrl.BillingController{}.CreateDraft).Methods(http.MethodPost)
+	secureArea.HandleFunc("/project/{project_id:[0-9]+}/invoice/{id:[0-9]+}", billing_ctrl.BillingController{}.Export).Methods(http.MethodGet)
+	secureArea.HandleFunc("/project/{project_id:[0-9]+}/invoice/{id:[0-9]+}/finalize", billing_ctrl.BillingController{}.Finalize).Methods(http.MethodPost)
+	secureArea.HandleFunc("/project/{project_id:[0-9]+}/invoice/{id:[0-9]+}/delete", billing_ctrl.BillingController{}.Delete).Methods(http.MethodPost)
+
+	secureArea.HandleFunc("/project/{project_id:[0-9]+}/milestones", milestone_ctrl.MilestoneController{}.MilestonesIndex).Methods(http.MethodGet)
+	secureArea.HandleFunc("/project/{project_id:[0-9]+}/milestones/save", milestone_ctrl.MilestoneController{}.Save).Methods(http.MethodPost)
+	secureArea.HandleFunc("/project/{project_id:[0-9]+}/milestone/{id:[0-9]+}", milestone_ctrl.MilestoneController{}.Show).Methods(http.MethodGet)
+	secureArea.HandleFunc("/project/{project_id:[0-9]+}/milestone/{id:[0-9]+}/delete", milestone_ctrl.MilestoneController{}.Delete).Methods(http.MethodPost)
+	secureArea.HandleFunc("/project/{project_id:[0-9]+}/milestone/{id:[0-9]+}/responsible_users/add", milestone_ctrl.MilestoneController{}.AddResponsibleUser).Methods(http.MethodPost)
+	secureArea.HandleFunc("/project/{project_id:[0-9]+}/milestone/{id:[0-9]+}/responsible_users/remove", milestone_ctrl.MilestoneController{}.RemoveResponsibleUser).Methods(http.MethodPost)
+	secureArea.HandleFunc("/project/{project_id:[0-9]+}/milestone/{id:[0-9]+}/follow", milestone_ctrl.MilestoneController{}.Follow).Methods(http.MethodPost)
+	secureArea.HandleFunc("/project/{project_id:[0-9]+}/milestone/{id:[0-9]+}/unfollow", milestone_ctrl.MilestoneController{}.Unfollow).Methods(http.MethodPost)
+	secureArea.HandleFunc("/project/{project_id:[0-9]+}/milestone/{id:[0-9]+}/task_lists/attach", milestone_ctrl.MilestoneController{}.AttachTaskList).Methods(http.MethodPost)
+	secureArea.HandleFunc("/project/{project_id:[0-9]+}/milestone/{id:[0-9]+}/task_lists/detach", milestone_ctrl.MilestoneController{}.DetachTaskList).Methods(http.MethodPost)
+	secureArea.HandleFunc("/project/{project_id:[0-9]+}/milestone/{id:[0-9]+}/comments", milestone_ctrl.MilestoneController{}.CommentsIndex).Methods(http.MethodGet)
+	secureArea.HandleFunc("/project/{project_id:[0-9]+}/milestone/{id:[0-9]+}/comments/add", milestone_ctrl.MilestoneController{}.AddComment).Methods(http.MethodPost)
+	secureArea.HandleFunc("/project/{project_id:[0-9]+}/milestone/{id:[0-9]+}/comment/{comment_id:[0-9]+}/delete", milestone_ctrl.MilestoneController{}.DeleteComment).Methods(http.MethodPost)
+	secureArea.HandleFunc("/project/{project_id:[0-9]+}/milestone/{id:[0-9]+}/burndown", milestone_ctrl.MilestoneController{}.Burndown).Methods(http.MethodGet)
+
+	secureArea.HandleFunc("/project/{project_id:[0-9]+}/issue/{id:[0-9]+}/delete", issue_ctrl.IssueController{}.Delete).Methods(http.MethodPost)
+	secureArea.HandleFunc("/project/{project_id:[0-9]+}/issue/{id:[0-9]+}/set_status/{status:(?:pending|in_progress|closed)}", issue_ctrl.IssueController{}.SetStatus).Methods(http.MethodPost)
+	secureArea.HandleFunc("/project/{project_id:[0-9]+}/issue/{id:[0-9]+}/labels/add", issue_ctrl.IssueController{}.AddLabel).Methods(http.MethodPost)
+	secureArea.HandleFunc("/project/{project_id:[0-9]+}/issue/{id:[0-9]+}/labels/remove", issue_ctrl.IssueController{}.RemoveLabel).Methods(http.MethodPost)
+	secureArea.HandleFunc("/project/{project_id:[0-9]+}/issue/{id:[0-9]+}/assign", issue_ctrl.IssueController{}.Assign).Methods(http.MethodPost)
+	secureArea.HandleFunc("/project/{project_id:[0-9]+}/issue/{id:[0-9]+}/unassign", issue_ctrl.IssueController{}.Unassign).Methods(http.MethodPost)
+	secureArea.HandleFunc("/project/{project_id:[0-9]+}/issue/{id:[0-9]+}/comments/add", issue_ctrl.IssueController{}.AddComment).Methods(http.MethodPost)
+	secureArea.HandleFunc("/project/{project_id:[0-9]+}/issue/{id:[0-9]+}/comment/{comment_id:[0-9]+}/delete", issue_ctrl.IssueController{}.DeleteComment).Methods(http.MethodPost)
+	secureArea.HandleFunc("/project/{project_id:[0-9]+}/issue_labels/save", issue_ctrl.IssueController{}.SaveLabel).Methods(http.MethodPost)
+	secureArea.HandleFunc("/project/{project_id:[0-9]+}/issue_label/{id:[0-9]+}/delete", issue_ctrl.IssueController{}.DeleteLabel).Methods(http.MethodPost)
+
+	secureArea.HandleFunc("/project/{project_id:[0-9]+}/bug/{id:[0-9]+}/update", bug_ctrl.BugController{}.UpdateDetails).Methods(http.MethodPost)
+
+	secureArea.HandleFunc("/project/{project_id:[0-9]+}/wikis/save", wiki_ctrl.WikiController{}.Save).Methods(http.MethodPost)
+	secureArea.HandleFunc("/project/{project_id:[0-9]+}/wiki/{id:[0-9]+}/delete", wiki_ctrl.WikiController{}.Delete).Methods(http.MethodPost)
+	secureArea.HandleFunc("/project/{project_id:[0-9]+}/wiki/{wiki_id:[0-9]+}/pages/save", wiki_ctrl.WikiController{}.SavePage).Methods(http.MethodPost)
+	secureArea.HandleFunc("/project/{project_id:[0-9]+}/wiki/{wiki_id:[0-9]+}/page/{id:[0-9]+}/delete", wiki_ctrl.WikiController{}.DeletePage).Methods(http.MethodPost)
+	secureArea.HandleFunc("/project/{project_id:[0-9]+}/wiki/{wiki_id:[0-9]+}/page/{id:[0-9]+}/restore/{revision:[0-9]+}", wiki_ctrl.WikiController{}.Restore).Methods(http.MethodPost)
 
 	secureArea.HandleFunc("/whoami", user_ctrl.Whoami).Methods(http.MethodGet)
 	secureArea.HandleFunc("/whois/{id:[0-9]+}", user_ctrl.Whois).Methods(http.MethodGet)